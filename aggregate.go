@@ -0,0 +1,239 @@
+package trie
+
+// Monoid defines how values are aggregated by WithAggregate, e.g., a sum, a
+// min or a max.
+//
+// Since 0.2.0
+type Monoid struct {
+	// Identity is the aggregate of no value, e.g., 0 for a sum.
+	//
+	// Since 0.2.0
+	Identity interface{}
+
+	// Lift converts a value to its aggregate. If it is nil, a value is its own
+	// aggregate. It receives values as stored, see Resolve.
+	//
+	// Since 0.2.0
+	Lift func(v interface{}) interface{}
+
+	// Combine returns the aggregate of two adjacent key ranges, the range of
+	// `a` before the range of `b`. It must be associative, but does not need
+	// to be commutative.
+	//
+	// Since 0.2.0
+	Combine func(a, b interface{}) interface{}
+}
+
+// WithAggregate makes every inner node maintain the aggregate of the values
+// in its subtree with `m`, thus Aggregate of any key range takes time
+// proportional to the depth of the trie instead of the number of keys.
+//
+// Aggregates are updated along the path of a key on every Append, Set and
+// Remove. MapValues, Filter and ReplaceSubTrie update the nodes they change.
+//
+// Since 0.2.0
+func WithAggregate(m Monoid) Option {
+	return func(o *options) {
+		o.aggregate = &m
+	}
+}
+
+// Aggregate returns the aggregate of the values of keys in [lo, hi), with
+// the Monoid set by WithAggregate. A nil `hi` means no upper bound.
+// It returns nil if the trie does not aggregate.
+//
+// Same as Search, in a squashed trie the bytes removed by squashing are not
+// compared.
+//
+// Since 0.2.0
+func (r *Node) Aggregate(lo, hi []byte) interface{} {
+
+	m := r.monoid()
+	if m == nil {
+		return nil
+	}
+
+	lo = r.normalize(lo)
+	if hi != nil {
+		hi = r.normalize(hi)
+	}
+
+	return r.rangeAggregate(m, r.byteOrder(), -1, lo, hi, true, hi != nil)
+}
+
+// rangeAggregate returns the aggregate of keys in the subtree of r that are
+// in [lo, hi). `i` is the index of the byte before r. `hasLo` and `hasHi` are
+// whether the keys of r may be out of the bounds.
+func (r *Node) rangeAggregate(m *Monoid, order byteOrder, i int, lo, hi []byte, hasLo, hasHi bool) interface{} {
+
+	if r.Children == nil {
+		return m.aggregateOf(r)
+	}
+
+	i += int(r.Step)
+
+	loBr := leafBranch
+	if hasLo {
+		if len(lo) > i {
+			loBr = int(lo[i])
+		} else {
+			// Every key of r starts with lo.
+			hasLo = false
+		}
+	}
+
+	hiBr := leafBranch
+	if hasHi {
+		if len(hi) <= i {
+			// Every key of r starts with hi.
+			return m.Identity
+		}
+		hiBr = int(hi[i])
+	}
+
+	if !hasLo && !hasHi {
+		return r.agg()
+	}
+
+	acc := m.Identity
+	for _, b := range r.Branches {
+
+		if hasLo && order.rank(b) < order.rank(loBr) {
+			continue
+		}
+		if hasHi && order.rank(b) > order.rank(hiBr) {
+			break
+		}
+
+		child := r.Children[b]
+		onLo := hasLo && b == loBr
+		onHi := hasHi && b == hiBr
+
+		var a interface{}
+		if onLo || onHi {
+			a = child.rangeAggregate(m, order, i, lo, hi, onLo, onHi)
+		} else {
+			a = m.aggregateOf(child)
+		}
+		acc = m.Combine(acc, a)
+	}
+	return acc
+}
+
+// monoid returns the Monoid of WithAggregate, or nil.
+func (r *Node) monoid() *Monoid {
+	if r.opt == nil {
+		return nil
+	}
+	return r.opt.aggregate
+}
+
+// lift returns the aggregate of value `v`.
+func (m *Monoid) lift(v interface{}) interface{} {
+	if v == removed {
+		return m.Identity
+	}
+	if m.Lift == nil {
+		return v
+	}
+	return m.Lift(v)
+}
+
+// aggregateOf returns the maintained aggregate of node `n`, or the aggregate
+// of the value of a leaf.
+func (m *Monoid) aggregateOf(n *Node) interface{} {
+	if n.Children == nil {
+		return m.lift(n.Value)
+	}
+	return n.agg()
+}
+
+// combineChildren returns the aggregate of the children of `n`.
+func (m *Monoid) combineChildren(n *Node) interface{} {
+	acc := m.Identity
+	for _, b := range n.Branches {
+		acc = m.Combine(acc, m.aggregateOf(n.Children[b]))
+	}
+	return acc
+}
+
+// reaggregatePath recalculates the aggregates and extremes of `path`, a serial
+// of nodes from r downwards, from the deepest one.
+func (r *Node) reaggregatePath(path []*Node) {
+
+	r.extremesPath(path)
+
+	m := r.monoid()
+	if m == nil {
+		return
+	}
+
+	for d := len(path) - 1; d >= 0; d-- {
+		path[d].inner().agg = m.combineChildren(path[d])
+	}
+}
+
+// reaggregateKey recalculates the aggregates and extremes along `key`, which
+// must be a path of nodes that are not squashed.
+func (r *Node) reaggregateKey(key []byte) {
+
+	if r.monoid() == nil && !r.cachedExtremes() {
+		return
+	}
+
+	path := make([]*Node, 0, len(key)+1)
+	node := r
+	path = append(path, node)
+	for _, b := range key {
+		node = node.Children[int(b)]
+		path = append(path, node)
+	}
+	r.reaggregatePath(path)
+}
+
+// reaggregate recalculates the aggregate and extremes of every node in the
+// subtree of n.
+func (r *Node) reaggregate(n *Node) {
+
+	m := r.monoid()
+	if m == nil && !r.cachedExtremes() || n.Children == nil {
+		return
+	}
+
+	for _, b := range n.Branches {
+		r.reaggregate(n.Children[b])
+	}
+	if m != nil {
+		n.inner().agg = m.combineChildren(n)
+	}
+	if r.cachedExtremes() {
+		n.setExtremes()
+	}
+}
+
+// addAggregate adds leaf value `v` of the last key `key` to the aggregates
+// along `key`, in which nodes after the first `j` bytes are just created, and
+// updates the extremes along it.
+func (r *Node) addAggregate(key []byte, j int, v interface{}) {
+
+	r.extremesKey(key)
+
+	m := r.monoid()
+	if m == nil {
+		return
+	}
+
+	a := m.lift(v)
+	node := r
+	for d := 0; ; d++ {
+		if d <= j {
+			node.inner().agg = m.Combine(node.agg(), a)
+		} else {
+			node.inner().agg = a
+		}
+		if d == len(key) {
+			return
+		}
+		node = node.Children[int(key[d])]
+	}
+}
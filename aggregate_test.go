@@ -0,0 +1,139 @@
+package trie
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var sumMonoid = Monoid{
+	Identity: 0,
+	Combine: func(a, b interface{}) interface{} {
+		return a.(int) + b.(int)
+	},
+}
+
+// concatMonoid is not commutative, to check the order of aggregation.
+var concatMonoid = Monoid{
+	Identity: "",
+	Lift: func(v interface{}) interface{} {
+		return string(rune('a' + v.(int)))
+	},
+	Combine: func(a, b interface{}) interface{} {
+		return a.(string) + b.(string)
+	},
+}
+
+// bruteAggregate aggregates the live entries of `tr` in [lo, hi) one by one.
+func bruteAggregate(tr *Node, m Monoid, lo, hi []byte) interface{} {
+	acc := m.Identity
+	for it := tr.NewIter(); it.Next(); {
+		k := it.Key()
+		if bytes.Compare(k, lo) < 0 || (hi != nil && bytes.Compare(k, hi) >= 0) {
+			continue
+		}
+		acc = m.Combine(acc, m.lift(it.Value()))
+	}
+	return acc
+}
+
+func TestNode_Aggregate(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := [][]byte{
+		[]byte("a"),
+		[]byte("ab"),
+		[]byte("abc"),
+		[]byte("abd"),
+		[]byte("b"),
+		[]byte("bcd"),
+		[]byte("c"),
+	}
+	values := []int{0, 1, 2, 3, 4, 5, 6}
+
+	bounds := [][]byte{
+		nil, {}, []byte("a"), []byte("aa"), []byte("ab"), []byte("abc"), []byte("abcd"),
+		[]byte("abd"), []byte("ac"), []byte("b"), []byte("bc"), []byte("bce"), []byte("c"), []byte("d"),
+	}
+
+	for _, m := range []Monoid{sumMonoid, concatMonoid} {
+
+		tr, err := NewTrie(keys, values, false, WithAggregate(m))
+		ta.Nil(err)
+
+		check := func() {
+			for _, lo := range bounds {
+				for _, hi := range bounds {
+					want := bruteAggregate(tr, m, lo, hi)
+					ta.Equal(want, tr.Aggregate(lo, hi), "lo: %q, hi: %q", lo, hi)
+				}
+			}
+		}
+
+		check()
+		ta.Equal(m.Identity, tr.Aggregate([]byte("b"), []byte("b")))
+
+		_, err = tr.Append([]byte("cde"), 7)
+		ta.Nil(err)
+		_, err = tr.Set([]byte("aa"), 8)
+		ta.Nil(err)
+		_, err = tr.Set([]byte("c"), 9)
+		ta.Nil(err)
+		check()
+
+		ta.True(tr.Remove([]byte("abc")))
+		ta.True(tr.Remove([]byte("bcd")))
+		check()
+
+		ta.Nil(tr.MapValues(func(key []byte, v interface{}) interface{} { return v.(int) % 5 }))
+		check()
+
+		f := tr.Filter(func(key []byte, v interface{}) bool { return v.(int) != 3 })
+		ta.Equal(bruteAggregate(f, m, nil, nil), f.Aggregate(nil, nil))
+
+		ta.Nil(tr.ReplaceSubTrie([]byte("ab"), newStrTrie(ta, false)))
+		check()
+	}
+}
+
+func TestNode_Aggregate_squash(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr, err := NewTrie(nil, nil, true, WithAggregate(sumMonoid))
+	ta.Nil(err)
+	ta.Equal(0, tr.Aggregate(nil, nil))
+
+	for i, k := range []string{"abc", "abd", "b", "bcd", "bce"} {
+		_, err = tr.Append([]byte(k), i+1)
+		ta.Nil(err)
+	}
+	tr.Squash()
+
+	ta.Equal(15, tr.Aggregate(nil, nil))
+	ta.Equal(3, tr.Aggregate(nil, []byte("b")))
+	ta.Equal(12, tr.Aggregate([]byte("b"), nil))
+	ta.Equal(2, tr.Aggregate([]byte("abd"), []byte("b")))
+
+	tr, err = NewTrie(nil, nil, false)
+	ta.Nil(err)
+	ta.Nil(tr.Aggregate(nil, nil))
+}
+
+func TestNode_Aggregate_lazyRemove(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr, err := NewTrie([][]byte{[]byte("a"), []byte("b"), []byte("c")}, []int{1, 2, 3}, false,
+		WithAggregate(sumMonoid), WithLazyRemove())
+	ta.Nil(err)
+
+	ta.True(tr.Remove([]byte("b")))
+	ta.Equal(4, tr.Aggregate(nil, nil))
+
+	_, err = tr.Append([]byte("b"), 5)
+	ta.Nil(err)
+	ta.Equal(9, tr.Aggregate(nil, nil))
+}
@@ -0,0 +1,159 @@
+package trie
+
+import (
+	"time"
+
+	"github.com/openacid/errors"
+)
+
+// OpType is the type of change an Op makes.
+//
+// Since 0.2.0
+type OpType int
+
+const (
+	// OpSet sets the value of a key, as Node.Set does.
+	//
+	// Since 0.2.0
+	OpSet OpType = iota
+
+	// OpRemove removes a key, as Node.Remove does.
+	//
+	// Since 0.2.0
+	OpRemove
+
+	// OpSetTTL sets the value of a key that expires after Op.TTL, as
+	// Node.SetTTL does.
+	//
+	// Since 0.2.0
+	OpSetTTL
+)
+
+// Op is a change of a key applied by Apply.
+//
+// Since 0.2.0
+type Op struct {
+	Type  OpType
+	Key   []byte
+	Value interface{}
+
+	// TTL is used by OpSetTTL.
+	TTL time.Duration
+}
+
+// Apply applies `ops` in order, all or nothing.
+//
+// All of `ops` are validated before any of them is applied: it returns an
+// error without modifying the trie if the trie is read only, an Op is of an
+// unknown type, a key to set is rejected by the limits of WithMaxKeyLen,
+// WithMaxTrieDepth or WithKeyValidator, a Set goes through a squashed node,
+// or a value can not be prepared, e.g., stored by the ValueStore of
+// WithValueStore.
+// With a ValueStore, values stored before a failed one are not deleted.
+//
+// Nodes left with a single branch by OpRemove are resquashed once after all
+// of `ops`, thus a Remove does not make a following Set fail.
+//
+// Since 0.2.0
+func (r *Node) Apply(ops []Op) error {
+
+	if r.readOnly() {
+		return errors.Wrapf(ErrReadOnly, "apply")
+	}
+
+	keys := make([][]byte, len(ops))
+	values := make([]interface{}, len(ops))
+
+	for i, op := range ops {
+		keys[i] = r.normalize(op.Key)
+
+		switch op.Type {
+		case OpSet, OpSetTTL:
+			if err := r.checkKey("set", op.Key, keys[i]); err != nil {
+				return errors.Wrapf(err, "apply op %d", i)
+			}
+			if !r.settable(keys[i]) {
+				return errors.Wrapf(ErrSquashed, "apply op %d: set %q", i, op.Key)
+			}
+			v, err := r.prepareValue(op.Value)
+			if err != nil {
+				return errors.Wrapf(err, "apply op %d: set %q", i, op.Key)
+			}
+			values[i] = v
+		case OpRemove:
+		default:
+			return errors.Wrapf(ErrInvalidOp, "apply op %d: type %d", i, op.Type)
+		}
+	}
+
+	touched := make(map[*Node]int)
+
+	for i, op := range ops {
+		switch op.Type {
+		case OpSet, OpSetTTL:
+			leaf, err := r.set(keys[i], values[i])
+			if err != nil {
+				// Validated above.
+				panic(err)
+			}
+			if op.Type == OpSetTTL {
+				r.expire(leaf, op.TTL)
+			}
+		case OpRemove:
+			if r.lazyRemove() {
+				r.removeLazily(keys[i : i+1])
+			} else {
+				r.removeBatch(keys[i:i+1], touched)
+			}
+		}
+	}
+
+	if r.squash {
+		r.resquash(touched)
+	}
+	return nil
+}
+
+// settable returns true if a normalized `key` can be Set, i.e., no node
+// along it is squashed.
+func (r *Node) settable(key []byte) bool {
+
+	if r.Step > 1 {
+		return false
+	}
+
+	node := r
+	for _, b := range key {
+		child := node.Children[int(b)]
+		if child == nil {
+			return true
+		}
+		if child.Step > 1 {
+			return false
+		}
+		node = child
+	}
+	return true
+}
+
+// Apply is the same as Node.Apply on a new version, which is published with
+// a single atomic store: readers see either none or all of `ops`.
+//
+// Since 0.2.0
+func (s *SyncTrie) Apply(ops []Op) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	root := s.Load()
+	for _, op := range ops {
+		root = root.copyKeyPath(root.normalize(op.Key))
+	}
+
+	if err := root.Apply(ops); err != nil {
+		return err
+	}
+
+	s.root.Store(root)
+	return nil
+}
@@ -0,0 +1,118 @@
+package trie
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openacid/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNode_Apply(t *testing.T) {
+
+	ta := require.New(t)
+
+	for _, opt := range []Option{WithLazyRemove(), WithHistory(1)} {
+
+		tr, err := NewTrie(
+			[][]byte{[]byte("a"), []byte("ab"), []byte("b")}, []int{1, 2, 3}, false, opt)
+		ta.Nil(err)
+
+		err = tr.Apply([]Op{
+			{Type: OpSet, Key: []byte("a"), Value: 10},
+			{Type: OpRemove, Key: []byte("ab")},
+			{Type: OpSetTTL, Key: []byte("c"), Value: 4, TTL: -time.Second},
+			{Type: OpRemove, Key: []byte("b")},
+			{Type: OpSet, Key: []byte("b"), Value: 30},
+			{Type: OpRemove, Key: []byte("x")},
+		})
+		ta.Nil(err)
+
+		ks, vs := iterAll(tr.NewIter())
+		ta.Equal([]string{"a", "b", "c"}, ks)
+		ta.Equal([]interface{}{10, 30, 4}, vs)
+
+		// "c" is already expired.
+		_, eq, _ := tr.Search([]byte("c"))
+		ta.Nil(eq)
+	}
+}
+
+func TestNode_Apply_invalid(t *testing.T) {
+
+	ta := require.New(t)
+
+	changes := 0
+	tr, err := NewTrie(
+		[][]byte{[]byte("abc"), []byte("abd")}, []int{1, 2}, true,
+		WithMutationHooks(MutationHooks{
+			OnUpdate: func(key []byte, old, value interface{}) { changes++ },
+			OnDelete: func(key []byte, old, value interface{}) { changes++ },
+		}),
+	)
+	ta.Nil(err)
+	tr.Squash()
+	before := tr.String()
+
+	cases := []struct {
+		ops  []Op
+		want error
+	}{
+		{[]Op{{Type: OpRemove, Key: []byte("abc")}, {Type: OpSet, Key: []byte("abe")}}, ErrSquashed},
+		{[]Op{{Type: OpRemove, Key: []byte("abc")}, {Type: OpType(100)}}, ErrInvalidOp},
+	}
+
+	for i, c := range cases {
+		err := tr.Apply(c.ops)
+		ta.Equal(c.want, errors.Cause(err), "%d-th", i+1)
+		ta.Equal(before, tr.String(), "%d-th", i+1)
+		ta.Equal(0, changes, "%d-th", i+1)
+	}
+}
+
+func TestNode_Apply_resquash(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := [][]byte{[]byte("abc"), []byte("abd"), []byte("ac"), []byte("b")}
+
+	want, err := NewTrie(keys, []int{1, 2, 3, 4}, true)
+	ta.Nil(err)
+	want.RemoveBatch([][]byte{[]byte("abc"), []byte("ac")})
+
+	tr, err := NewTrie(keys, []int{1, 2, 3, 4}, true)
+	ta.Nil(err)
+	err = tr.Apply([]Op{
+		{Type: OpRemove, Key: []byte("abc")},
+		{Type: OpRemove, Key: []byte("ac")},
+	})
+	ta.Nil(err)
+
+	ta.Equal(want.String(), tr.String())
+}
+
+func TestSyncTrie_Apply(t *testing.T) {
+
+	ta := require.New(t)
+
+	st, err := NewSyncTrie([][]byte{[]byte("a"), []byte("b")}, []int{1, 2}, false)
+	ta.Nil(err)
+	old := st.Load()
+
+	err = st.Apply([]Op{
+		{Type: OpSet, Key: []byte("a"), Value: 10},
+		{Type: OpRemove, Key: []byte("b")},
+	})
+	ta.Nil(err)
+
+	ks, vs := iterAll(st.Load().NewIter())
+	ta.Equal([]string{"a"}, ks)
+	ta.Equal([]interface{}{10}, vs)
+
+	ks, vs = iterAll(old.NewIter())
+	ta.Equal([]string{"a", "b"}, ks)
+	ta.Equal([]interface{}{1, 2}, vs)
+
+	err = st.Apply([]Op{{Type: OpType(-1)}})
+	ta.Equal(ErrInvalidOp, errors.Cause(err))
+}
@@ -0,0 +1,136 @@
+package trie
+
+import "time"
+
+// WithAutoSquash makes a trie created with squash enabled squash in batches
+// while keys are appended, instead of squashing the preceding branches on
+// every Append.
+//
+// Subtrees Append does not modify any more, i.e., those not on the right most
+// path, are collected and squashed together once more than `threshold` inner
+// nodes are added since the last time. Thus chains of single-branch nodes of
+// long keys are released while building, and each node is squashed about
+// once. NewTrie then only squashes what is left, instead of the whole trie.
+//
+// A `threshold` not greater than 0 disables it.
+// It does not affect Append to versions of a SyncTrie.
+//
+// Since 0.2.0
+func WithAutoSquash(threshold int) Option {
+	return func(o *options) {
+		if threshold > 0 {
+			o.autoSquash = &autoSquash{threshold: threshold}
+		}
+	}
+}
+
+// autoSquash collects the subtrees to squash for WithAutoSquash.
+type autoSquash struct {
+	threshold int
+
+	// root is the trie it is of.
+	root *Node
+
+	// pending are the subtrees not yet squashed, by ascending depth. None of
+	// them is in another.
+	pending []pendingSquash
+
+	// added is the number of inner nodes added since the last squash.
+	added int
+}
+
+type pendingSquash struct {
+	node  *Node
+	depth int
+}
+
+// autoSquashing returns the autoSquash of r if Append to r squashes in
+// batches.
+func (r *Node) autoSquashing() *autoSquash {
+	if r.opt == nil || r.opt.autoSquash == nil || r.opt.autoSquash.root != r {
+		return nil
+	}
+	return r.opt.autoSquash
+}
+
+// addSquash records that Append does not modify `node`, the child at `depth`
+// of a node on the right most path, any more, after adding `added` inner
+// nodes. It squashes the recorded subtrees if there are enough new nodes.
+func (r *Node) addSquash(a *autoSquash, node *Node, depth int, added int) {
+
+	// Subtrees recorded before below `node` are in it.
+	for len(a.pending) > 0 && a.pending[len(a.pending)-1].depth > depth {
+		a.pending = a.pending[:len(a.pending)-1]
+	}
+	a.pending = append(a.pending, pendingSquash{node: node, depth: depth})
+
+	a.added += added
+	if a.added > a.threshold {
+		a.flush()
+	}
+}
+
+// flush squashes the pending subtrees and returns the number of nodes
+// removed, which are discounted from the counters of the root.
+func (a *autoSquash) flush() int {
+
+	cnt := 0
+	for _, p := range a.pending {
+		cnt += p.node.squashSubtree(a.root, nil)
+	}
+	a.pending = a.pending[:0]
+	a.added = 0
+	return cnt
+}
+
+// finishSquash squashes what WithAutoSquash has not yet: the pending subtrees
+// and the right most path. It is what Squash does, if nothing else is left
+// unsquashed.
+func (r *Node) finishSquash(a *autoSquash) int {
+
+	var start time.Time
+	hook := r.opt.buildHook
+	if hook != nil {
+		start = time.Now()
+	}
+
+	cnt := a.flush()
+
+	var path []*Node
+	for n := r; len(n.Branches) > 0; {
+		path = append(path, n)
+		br := n.Branches[len(n.Branches)-1]
+		if br == leafBranch {
+			break
+		}
+		n = n.Children[br]
+	}
+
+	// From the deepest, the same as squashSubtree.
+	for d := len(path) - 1; d >= 0; d-- {
+		n := path[d]
+		if len(n.Branches) == 1 && n.Branches[0] != leafBranch && mergeable(n, n.Children[n.Branches[0]]) {
+			child := n.Children[n.Branches[0]]
+			r.InnerNodeCnt--
+			r.addCounts(0, mergeDelta(n, child))
+			n.Branches = child.Branches
+			n.Children = child.Children
+			n.Step += child.Step
+			n.shrunk = child.shrunk
+			cnt++
+		}
+	}
+
+	r.buildFront()
+
+	if hook != nil {
+		hook(BuildEvent{
+			Phase:        PhaseSquash,
+			InnerNodeCnt: r.InnerNodeCnt,
+			SquashedCnt:  cnt,
+			Elapsed:      time.Since(start),
+			Done:         true,
+		})
+	}
+	return cnt
+}
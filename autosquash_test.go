@@ -0,0 +1,107 @@
+package trie
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithAutoSquash(t *testing.T) {
+
+	ta := require.New(t)
+
+	rnd := rand.New(rand.NewSource(3))
+	uniq := map[string]bool{}
+	for i := 0; i < 300; i++ {
+		k := make([]byte, 1+rnd.Intn(12))
+		for j := range k {
+			k[j] = "abc"[rnd.Intn(3)]
+		}
+		uniq[string(k)] = true
+	}
+	strs := []string{}
+	for k := range uniq {
+		strs = append(strs, k)
+	}
+	sort.Strings(strs)
+	keys := make([][]byte, len(strs))
+	for i, k := range strs {
+		keys[i] = []byte(k)
+	}
+
+	want, err := NewTrie(keys, strs, true)
+	ta.Nil(err)
+
+	for _, threshold := range []int{0, 1, 10, 100, 10000} {
+		msg := fmt.Sprintf("threshold: %d", threshold)
+
+		var events []BuildEvent
+		tr, err := NewTrie(keys, strs, true, WithAutoSquash(threshold),
+			WithBuildHook(0, func(e BuildEvent) { events = append(events, e) }))
+		ta.Nil(err)
+
+		ta.Equal(want.String(), tr.String(), msg)
+		ta.Equal(PhaseSquash, events[len(events)-1].Phase, msg)
+
+		for _, k := range strs {
+			_, eq, _ := tr.Search([]byte(k))
+			ta.Equal(k, eq, msg)
+		}
+	}
+}
+
+func TestWithAutoSquash_append(t *testing.T) {
+
+	ta := require.New(t)
+
+	// Keys without shared prefixes make chains of single-branch nodes.
+	var keys [][]byte
+	for i := 0; i < 20; i++ {
+		keys = append(keys, []byte(fmt.Sprintf("%c-long-key-without-prefix", 'a'+i)))
+	}
+
+	tr, err := NewTrie(nil, nil, true, WithAutoSquash(50))
+	ta.Nil(err)
+
+	maxNodes := 0
+	for i, k := range keys {
+		_, err := tr.Append(k, i)
+		ta.Nil(err)
+		if n := tr.innerNodeCnt(); n > maxNodes {
+			maxNodes = n
+		}
+	}
+
+	// Unsquashed, there would be 20 chains of 26 nodes.
+	ta.True(maxNodes < 150, "max inner nodes: %d", maxNodes)
+	ta.Equal(tr.innerNodeCnt(), tr.InnerNodeCnt)
+
+	for i, k := range keys {
+		_, eq, _ := tr.Search(k)
+		ta.Equal(i, eq)
+	}
+}
+
+func TestWithAutoSquash_long(t *testing.T) {
+
+	ta := require.New(t)
+
+	// The chain on the right most path, squashed when the build finishes,
+	// is longer than a Step can be.
+	k2 := bytes.Repeat([]byte("b"), 70001)
+	k3 := append(append([]byte{}, k2[:70000]...), 'c')
+	keys := [][]byte{[]byte("a"), k2, k3}
+
+	tr, err := NewTrie(keys, []int{1, 2, 3}, true, WithAutoSquash(1))
+	ta.Nil(err)
+	ta.Nil(tr.Validate())
+
+	for i, k := range keys {
+		_, eq, _ := tr.Search(k)
+		ta.Equal(i+1, eq)
+	}
+}
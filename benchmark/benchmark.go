@@ -0,0 +1,125 @@
+// Package benchmark compares tries built from the same key set under different
+// configurations.
+//
+// For every configuration it reports the build time, the heap memory held by
+// the result and the average Search latency.
+//
+// Since 0.2.0
+package benchmark
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"text/tabwriter"
+	"time"
+
+	"github.com/openacid/trie"
+	"github.com/openacid/trie/trietest"
+)
+
+// Config is a named way to build a trie.
+//
+// Since 0.2.0
+type Config struct {
+	Name  string
+	Build trietest.Builder
+}
+
+// Result is the measurement of one Config.
+//
+// Since 0.2.0
+type Result struct {
+	Name    string
+	KeyCnt  int
+	Build   time.Duration
+	MemSize uint64
+
+	// Search is the average latency of one Search.
+	Search time.Duration
+}
+
+// DefaultConfigs returns all configurations this package supports.
+//
+// Children are always stored in a map, thus the only choice to compare is
+// whether to squash.
+//
+// Since 0.2.0
+func DefaultConfigs() []Config {
+	return []Config{
+		{Name: "map", Build: trietest.TrieBuilder(false)},
+		{Name: "map-squash", Build: trietest.TrieBuilder(true)},
+	}
+}
+
+// Run builds a trie from `keys` and `values` with every Config and measures
+// it. Every key is searched `rounds` times to measure the Search latency.
+//
+// Since 0.2.0
+func Run(keys [][]byte, values []interface{}, configs []Config, rounds int) ([]Result, error) {
+
+	rst := make([]Result, 0, len(configs))
+
+	for _, c := range configs {
+
+		var before, after runtime.MemStats
+
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+
+		start := time.Now()
+		s, err := c.Build(keys, values)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", c.Name, err)
+		}
+		buildTime := time.Since(start)
+
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+
+		var mem uint64
+		if after.HeapAlloc > before.HeapAlloc {
+			mem = after.HeapAlloc - before.HeapAlloc
+		}
+
+		var search time.Duration
+		if len(keys) > 0 && rounds > 0 {
+			start = time.Now()
+			for i := 0; i < rounds; i++ {
+				for _, k := range keys {
+					s.Search(k)
+				}
+			}
+			search = time.Since(start) / time.Duration(rounds*len(keys))
+		}
+
+		runtime.KeepAlive(s)
+
+		rst = append(rst, Result{
+			Name:    c.Name,
+			KeyCnt:  len(keys),
+			Build:   buildTime,
+			MemSize: mem,
+			Search:  search,
+		})
+	}
+
+	return rst, nil
+}
+
+// WriteTable writes results as an aligned text table.
+//
+// Since 0.2.0
+func WriteTable(w io.Writer, results []Result) error {
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "config\tkeys\tbuild\tmem(bytes)\tsearch\t")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%d\t%v\t%d\t%v\t\n",
+			r.Name, r.KeyCnt, r.Build, r.MemSize, r.Search)
+	}
+	return tw.Flush()
+}
+
+// compile time check: a trie is a Searcher.
+var _ trietest.Searcher = &trie.Node{}
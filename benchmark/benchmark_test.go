@@ -0,0 +1,135 @@
+package benchmark
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/openacid/trie"
+	"github.com/openacid/trie/trietest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+
+	ta := require.New(t)
+
+	s := trietest.Random(1, 1000, trietest.UniformLen(4, 16), []byte("abcdefgh"))
+
+	rst, err := Run(s.Keys, s.Values, DefaultConfigs(), 2)
+	ta.Nil(err)
+	ta.Equal(2, len(rst))
+
+	for _, r := range rst {
+		ta.Equal(1000, r.KeyCnt)
+		ta.True(r.MemSize > 0, "%s mem", r.Name)
+	}
+
+	buf := &bytes.Buffer{}
+	ta.Nil(WriteTable(buf, rst))
+	ta.Equal(3, strings.Count(buf.String(), "\n"))
+	ta.Contains(buf.String(), "map-squash")
+}
+
+func BenchmarkSearch(b *testing.B) {
+
+	s := trietest.Random(1, 10000, trietest.UniformLen(4, 16), []byte("abcdefgh"))
+
+	for _, c := range DefaultConfigs() {
+		tr, err := c.Build(s.Keys, s.Values)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(fmt.Sprintf("%s/n=%d", c.Name, len(s.Keys)), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				tr.Search(s.Keys[i%len(s.Keys)])
+			}
+		})
+	}
+}
+
+func BenchmarkSearchSorted(b *testing.B) {
+
+	s := trietest.Random(1, 10000, trietest.UniformLen(4, 16), []byte("abcdefgh"))
+
+	for _, c := range DefaultConfigs() {
+		tr, err := c.Build(s.Keys, s.Values)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		sr, ok := tr.(interface {
+			SearchSorted(keys [][]byte) []trie.SearchResult
+		})
+		if !ok {
+			continue
+		}
+
+		b.Run(fmt.Sprintf("%s/n=%d", c.Name, len(s.Keys)), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				sr.SearchSorted(s.Keys)
+			}
+		})
+	}
+}
+
+func BenchmarkBuild(b *testing.B) {
+
+	s := trietest.Random(1, 10000, trietest.UniformLen(4, 16), []byte("abcdefgh"))
+
+	for _, c := range DefaultConfigs() {
+		b.Run(fmt.Sprintf("%s/n=%d", c.Name, len(s.Keys)), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := c.Build(s.Keys, s.Values); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkTiny(b *testing.B) {
+
+	for _, keys := range [][][]byte{{}, {[]byte("tenant")}} {
+
+		values := make([]interface{}, len(keys))
+		for i := range values {
+			values[i] = i
+		}
+
+		b.Run(fmt.Sprintf("build/n=%d", len(keys)), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := trie.NewTrie(keys, values, false); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		tr, err := trie.NewTrie(keys, values, false)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		for _, k := range []string{"tenant", "other"} {
+			key := []byte(k)
+
+			b.Run(fmt.Sprintf("search/n=%d/%s", len(keys), k), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					tr.Search(key)
+				}
+			})
+
+			b.Run(fmt.Sprintf("get/n=%d/%s", len(keys), k), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					tr.Get(key)
+				}
+			})
+		}
+	}
+}
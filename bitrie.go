@@ -0,0 +1,113 @@
+package trie
+
+import (
+	"bytes"
+	"sort"
+)
+
+// BiTrie indexes entries by both prefix and suffix. It keeps a forward trie
+// and a ReverseTrie of the same keys, which share the values.
+//
+// Both tries are not squashed, so that keys rebuilt from them are complete
+// and every match is exact.
+//
+// Since 0.2.0
+type BiTrie struct {
+	forward *Node
+	reverse *ReverseTrie
+}
+
+// NewBiTrie creates a BiTrie from `keys` and corresponding `values`, with the
+// same `opts` as NewTrie. Same as NewTrie, `keys` must be ascending.
+//
+// Since 0.2.0
+func NewBiTrie(keys [][]byte, values interface{}, opts ...Option) (*BiTrie, error) {
+
+	forward, err := NewTrie(keys, values, false, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	reverse, err := NewReverseTrie(keys, values, false, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BiTrie{forward: forward, reverse: reverse}, nil
+}
+
+// Get returns the value of `key` and whether it is in the trie.
+//
+// Since 0.2.0
+func (t *BiTrie) Get(key []byte) (interface{}, bool) {
+	return t.forward.lookup(key)
+}
+
+// Set sets the value of `key`, adding it if it is not in the trie.
+//
+// Since 0.2.0
+func (t *BiTrie) Set(key []byte, value interface{}) error {
+
+	if _, err := t.forward.Set(key, value); err != nil {
+		return err
+	}
+	return t.reverse.Set(key, value)
+}
+
+// Remove removes `key` and returns whether it was in the trie.
+//
+// Since 0.2.0
+func (t *BiTrie) Remove(key []byte) bool {
+	t.reverse.Remove(key)
+	return t.forward.Remove(key)
+}
+
+// Match calls `fn` with every key starting with `prefix` and ending with
+// `suffix`, and its value, in ascending key order. The prefix and the suffix
+// may overlap in a key. Iteration stops if `fn` returns false.
+//
+// Only the keys of the smaller of the two candidate sets, the keys with the
+// prefix or the keys with the suffix, are visited.
+//
+// Since 0.2.0
+func (t *BiTrie) Match(prefix, suffix []byte, fn func(key []byte, value interface{}) bool) {
+
+	prefix = t.forward.normalize(prefix)
+	rsuffix := t.reverse.root.normalize(reverseBytes(suffix))
+
+	fsub, fkey := t.forward.subtree(prefix)
+	rsub, _ := t.reverse.root.subtree(rsuffix)
+	if fsub == nil || rsub == nil {
+		return
+	}
+
+	if fsub.leafCnt() <= rsub.leafCnt() {
+		suffix = reverseBytes(rsuffix)
+		stopped := false
+		fsub.eachLeaf(fkey, func(key []byte, leaf *Node) {
+			if !stopped && bytes.HasSuffix(key, suffix) {
+				stopped = !fn(key, leaf.Value)
+			}
+		})
+		return
+	}
+
+	var matches []kv
+	t.reverse.EachWithSuffix(suffix, func(key []byte, value interface{}) bool {
+		if bytes.HasPrefix(key, prefix) {
+			matches = append(matches, kv{key: key, value: value})
+		}
+		return true
+	})
+
+	order := t.forward.byteOrder()
+	sort.Slice(matches, func(i, j int) bool {
+		return order.compare(matches[i].key, matches[j].key) < 0
+	})
+
+	for _, m := range matches {
+		if !fn(m.key, m.value) {
+			return
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package trie
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBiTrie_Match(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := [][]byte{
+		[]byte("a.example.com"),
+		[]byte("a.example.org"),
+		[]byte("ab.com"),
+		[]byte("b.example.com"),
+		[]byte("b.test.com"),
+	}
+	values := []int{0, 1, 2, 3, 4}
+
+	tr, err := NewBiTrie(keys, values)
+	ta.Nil(err)
+
+	cases := []struct {
+		prefix, suffix string
+		want           []string
+	}{
+		{"a", ".com", []string{"a.example.com", "ab.com"}},
+		{"", ".example.com", []string{"a.example.com", "b.example.com"}},
+		{"b.", "", []string{"b.example.com", "b.test.com"}},
+		{"a.example.", ".org", []string{"a.example.org"}},
+		{"ab.com", "b.com", []string{"ab.com"}},
+		{"", "", []string{"a.example.com", "a.example.org", "ab.com", "b.example.com", "b.test.com"}},
+		{"c", ".com", nil},
+		{"a", ".net", nil},
+	}
+
+	for i, c := range cases {
+		var got []string
+		tr.Match([]byte(c.prefix), []byte(c.suffix), func(key []byte, v interface{}) bool {
+			got = append(got, string(key))
+			return true
+		})
+		ta.Equal(c.want, got, "%d-th: prefix: %q, suffix: %q", i+1, c.prefix, c.suffix)
+	}
+
+	n := 0
+	tr.Match(nil, []byte(".com"), func(key []byte, v interface{}) bool {
+		n++
+		return false
+	})
+	ta.Equal(1, n)
+}
+
+func TestBiTrie_update(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr, err := NewBiTrie(nil, nil)
+	ta.Nil(err)
+
+	ta.Nil(tr.Set([]byte("xyz"), 1))
+	ta.Nil(tr.Set([]byte("abc"), 2))
+
+	v, found := tr.Get([]byte("abc"))
+	ta.True(found)
+	ta.Equal(2, v)
+
+	ta.True(tr.Remove([]byte("abc")))
+	ta.False(tr.Remove([]byte("abc")))
+
+	_, found = tr.Get([]byte("abc"))
+	ta.False(found)
+
+	var got []string
+	tr.Match(nil, []byte("c"), func(key []byte, v interface{}) bool {
+		got = append(got, string(key))
+		return true
+	})
+	ta.Nil(got)
+}
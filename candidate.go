@@ -0,0 +1,73 @@
+package trie
+
+// Candidate is the leaf a key leads to in a squashed trie, which holds the key
+// only if the bytes removed by squashing match. Search and Get assume they do.
+//
+// Since 0.2.0
+type Candidate struct {
+	// Leaf is the leaf found.
+	//
+	// Since 0.2.0
+	Leaf *Node
+
+	// Skipped are the indexes of the bytes of the key that are not compared,
+	// in ascending order. The key of Leaf has the same length as the key
+	// searched, thus it is the key searched if it has the same bytes at these
+	// indexes.
+	//
+	// Since 0.2.0
+	Skipped []int
+}
+
+// SearchCandidate returns the Candidate of `key`, i.e., the leaf of `key` if
+// `key` is in the trie, and whether there is one.
+// In an unsquashed trie, or with WithStoreKeys, the candidate is exact and
+// Skipped is empty.
+// A key removed by WithLazyRemove or expired has no candidate.
+//
+// Since 0.2.0
+func (r *Node) SearchCandidate(key []byte) (Candidate, bool) {
+
+	key = r.normalize(key)
+
+	var skipped []int
+
+	node := r
+	for i := -1; ; {
+		prev := i
+		i += int(node.Step)
+		if len(key) < i {
+			return Candidate{}, false
+		}
+		for p := prev + 1; p < i; p++ {
+			skipped = append(skipped, p)
+		}
+
+		br := leafBranch
+		if len(key) > i {
+			br = int(key[i])
+		}
+
+		child := node.Children[br]
+		if child == nil {
+			return Candidate{}, false
+		}
+		if br == leafBranch {
+			node = child
+			break
+		}
+		node = child
+	}
+
+	if node.Value == removed || !node.isKey(key) {
+		return Candidate{}, false
+	}
+	if r.expired(node) {
+		return Candidate{}, false
+	}
+
+	if node.key() != nil {
+		skipped = nil
+	}
+	return Candidate{Leaf: node, Skipped: skipped}, true
+}
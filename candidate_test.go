@@ -0,0 +1,59 @@
+package trie
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNode_SearchCandidate(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := []string{"abcd", "abxy", "b"}
+
+	cases := []struct {
+		key     string
+		want    string
+		skipped []int
+	}{
+		{"abcd", "abcd", []int{1, 3}},
+		{"azcz", "abcd", []int{1, 3}},
+		{"abxy", "abxy", []int{1, 3}},
+		{"b", "b", nil},
+		{"ab", "", nil},
+		{"abc", "", nil},
+		{"abz", "", nil},
+		{"c", "", nil},
+	}
+
+	tr := newStrTrie(ta, true, keys...)
+	for i, c := range cases {
+		cand, found := tr.SearchCandidate([]byte(c.key))
+		ta.Equal(c.want != "", found, "%d-th: %q", i+1, c.key)
+		if !found {
+			continue
+		}
+		ta.Equal(c.want, cand.Leaf.Value, "%d-th: %q", i+1, c.key)
+		ta.Equal(c.skipped, cand.Skipped, "%d-th: %q", i+1, c.key)
+	}
+
+	// Unsquashed: nothing is skipped.
+	tr = newStrTrie(ta, false, keys...)
+	cand, found := tr.SearchCandidate([]byte("abcd"))
+	ta.True(found)
+	ta.Equal("abcd", cand.Leaf.Value)
+	ta.Nil(cand.Skipped)
+	_, found = tr.SearchCandidate([]byte("azcz"))
+	ta.False(found)
+
+	// Stored keys are exact.
+	bs := [][]byte{[]byte("abcd"), []byte("abxy"), []byte("b")}
+	tr, err := NewTrie(bs, keys, true, WithStoreKeys())
+	ta.Nil(err)
+	_, found = tr.SearchCandidate([]byte("azcz"))
+	ta.False(found)
+	cand, found = tr.SearchCandidate([]byte("abcd"))
+	ta.True(found)
+	ta.Nil(cand.Skipped)
+}
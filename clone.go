@@ -0,0 +1,80 @@
+package trie
+
+// Cloner is implemented by values that need to be deep copied when a trie is
+// copied, e.g., by Clone or WithSnapshot. A value not implementing Cloner is
+// shared between the copies.
+//
+// Since 0.2.0
+type Cloner interface {
+	// Clone returns a copy of the value that shares nothing mutable with it.
+	//
+	// Since 0.2.0
+	Clone() interface{}
+}
+
+// Clone returns a copy of the trie that shares no node, slice or map with
+// r. Values implementing Cloner are cloned, others are shared.
+//
+// It costs O(n) time and memory for a trie of n nodes.
+//
+// Since 0.2.0
+func (r *Node) Clone() *Node {
+	return r.clone(cloneValue)
+}
+
+// CloneWith is the same as Clone except that every value is copied with
+// `fn`.
+//
+// Since 0.2.0
+func (r *Node) CloneWith(fn func(v interface{}) interface{}) *Node {
+	return r.clone(func(v interface{}) interface{} {
+		if v == removed {
+			return v
+		}
+		return fn(v)
+	})
+}
+
+// cloneValue clones a Cloner and returns other values as is.
+func cloneValue(v interface{}) interface{} {
+	if c, ok := v.(Cloner); ok {
+		return c.Clone()
+	}
+	return v
+}
+
+// clone returns a copy of the subtree rooted at r, in which no slice or map
+// is shared with r. Values of leaves are copied with `cloneV`.
+func (r *Node) clone(cloneV func(interface{}) interface{}) *Node {
+
+	n := *r
+	n.counts = copyCounts(r.counts)
+	n.copyExt()
+	// History is not copied, see WithHistory.
+	if n.leafExt != nil {
+		n.leafExt.history = nil
+	}
+
+	if r.Value != nil {
+		n.Value = cloneV(r.Value)
+	}
+
+	if r.Branches != nil {
+		n.Branches = make([]int, len(r.Branches))
+		copy(n.Branches, r.Branches)
+	}
+
+	if r.Children != nil {
+		n.Children = make(map[int]*Node, len(r.Children))
+		for _, b := range r.Branches {
+			n.Children[b] = r.Children[b].clone(cloneV)
+		}
+	}
+
+	// Point to the leaves of the copy.
+	if r.first() != nil {
+		n.setExtremes()
+	}
+
+	return &n
+}
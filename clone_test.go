@@ -0,0 +1,73 @@
+package trie
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type clonedSlice []int
+
+func (s clonedSlice) Clone() interface{} {
+	return append(clonedSlice{}, s...)
+}
+
+func TestNode_Clone(t *testing.T) {
+
+	ta := require.New(t)
+
+	shared := []int{1}
+	cloned := clonedSlice{2}
+
+	tr, err := NewTrie([][]byte{[]byte("a"), []byte("b")}, []interface{}{shared, cloned}, false)
+	ta.Nil(err)
+
+	c := tr.Clone()
+
+	shared[0] = 10
+	cloned[0] = 20
+	_, err = tr.Append([]byte("c"), 3)
+	ta.Nil(err)
+
+	_, eq, _ := c.Search([]byte("a"))
+	ta.Equal([]int{10}, eq, "not a Cloner, shared")
+	_, eq, _ = c.Search([]byte("b"))
+	ta.Equal(clonedSlice{2}, eq)
+	_, eq, _ = c.Search([]byte("c"))
+	ta.Nil(eq)
+
+	// A snapshot clones values too.
+	it := tr.NewIter(WithSnapshot())
+	cloned[0] = 200
+	_, vs := iterAll(it)
+	ta.Equal([]interface{}{[]int{10}, clonedSlice{20}, 3}, vs)
+}
+
+func TestNode_CloneWith(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr := newStrTrie(ta, true, "a", "abc", "b")
+	tr.Remove([]byte("b"))
+	before := tr.String()
+
+	c := tr.CloneWith(func(v interface{}) interface{} {
+		return v.(string) + "'"
+	})
+
+	ks, vs := iterAll(c.NewIter())
+	ta.Equal([]string{"a", "ab"}, ks)
+	ta.Equal([]interface{}{"a'", "abc'"}, vs)
+	ta.Equal(before, tr.String())
+
+	lazy, err := NewTrie([][]byte{[]byte("a"), []byte("b")}, []string{"a", "b"}, false, WithLazyRemove())
+	ta.Nil(err)
+	lazy.Remove([]byte("a"))
+
+	c = lazy.CloneWith(func(v interface{}) interface{} {
+		return v.(string) + "'"
+	})
+	ks, vs = iterAll(c.NewIter())
+	ta.Equal([]string{"b"}, ks)
+	ta.Equal([]interface{}{"b'"}, vs)
+}
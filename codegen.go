@@ -0,0 +1,227 @@
+package trie
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"strconv"
+	"text/template"
+	"unicode"
+
+	"github.com/openacid/errors"
+)
+
+// GoOption configures GenerateGo.
+//
+// Since 0.2.0
+type GoOption func(*goOptions)
+
+type goOptions struct {
+	pkg  string
+	name string
+	enc  func(v interface{}) ([]byte, error)
+}
+
+// WithGoPackage sets the package of the generated file. It is "main" by
+// default.
+//
+// Since 0.2.0
+func WithGoPackage(pkg string) GoOption {
+	return func(o *goOptions) {
+		o.pkg = pkg
+	}
+}
+
+// WithGoName sets the name of the generated lookup function, from which the
+// names of the data and helpers are derived. It is "lookup" by default.
+//
+// Since 0.2.0
+func WithGoName(name string) GoOption {
+	return func(o *goOptions) {
+		o.name = name
+	}
+}
+
+// WithGoValueEncoder sets how a value is converted to the string the lookup
+// function returns. By default a string or []byte value is used as is, and
+// any other value is formatted with fmt "%v".
+//
+// Since 0.2.0
+func WithGoValueEncoder(enc func(v interface{}) ([]byte, error)) GoOption {
+	return func(o *goOptions) {
+		o.enc = enc
+	}
+}
+
+func newGoOptions(opts []GoOption) *goOptions {
+	o := &goOptions{
+		pkg:  "main",
+		name: "lookup",
+		enc: func(v interface{}) ([]byte, error) {
+			switch x := v.(type) {
+			case string:
+				return []byte(x), nil
+			case []byte:
+				return x, nil
+			}
+			return []byte(fmt.Sprintf("%v", v)), nil
+		},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// GenerateGo writes a Go source file to `w`, of the trie encoded as a
+// constant and a function `func <name>(key string) (string, bool)` returning
+// the value of a key, e.g., to embed a small fixed dictionary into a program
+// without building it at startup. The generated file depends on nothing but
+// the language, and a lookup does not allocate.
+//
+// The constant is the trie in the fixed layout of ExportFixed, thus the same
+// restrictions apply. In a squashed trie, the bytes removed by squashing are
+// not compared, the same as Search; generate from a trie that is not squashed
+// for exact lookups.
+//
+// Since 0.2.0
+func (r *Node) GenerateGo(w io.Writer, opts ...GoOption) error {
+
+	o := newGoOptions(opts)
+	for _, id := range []string{o.pkg, o.name} {
+		if !isGoIdentifier(id) {
+			return errors.Wrapf(ErrInvalidGoName, "%q", id)
+		}
+	}
+
+	data := &bytes.Buffer{}
+	if err := r.ExportFixed(data, o.enc); err != nil {
+		return err
+	}
+
+	src := &bytes.Buffer{}
+	err := goTemplate.Execute(src, map[string]interface{}{
+		"Pkg":   o.pkg,
+		"Name":  o.name,
+		"Keys":  r.KeyCnt(),
+		"Bytes": data.Len(),
+		"Data":  goStringLiteral(data.Bytes()),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "generate go")
+	}
+
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return errors.Wrapf(err, "format generated go")
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.Write(formatted)
+	return bw.Flush()
+}
+
+// isGoIdentifier returns true if `s` is a Go identifier and not a keyword.
+func isGoIdentifier(s string) bool {
+
+	if s == "" || goKeywords[s] {
+		return false
+	}
+	for i, c := range s {
+		if !unicode.IsLetter(c) && c != '_' && (i == 0 || !unicode.IsDigit(c)) {
+			return false
+		}
+	}
+	return true
+}
+
+var goKeywords = map[string]bool{
+	"break": true, "case": true, "chan": true, "const": true, "continue": true,
+	"default": true, "defer": true, "else": true, "fallthrough": true,
+	"for": true, "func": true, "go": true, "goto": true, "if": true,
+	"import": true, "interface": true, "map": true, "package": true,
+	"range": true, "return": true, "select": true, "struct": true,
+	"switch": true, "type": true, "var": true,
+}
+
+// goStringLiteral returns `data` as a concatenation of quoted strings of at
+// most 32 bytes each, one per line.
+func goStringLiteral(data []byte) string {
+
+	const width = 32
+
+	if len(data) == 0 {
+		return `""`
+	}
+
+	b := &bytes.Buffer{}
+	for i := 0; i < len(data); i += width {
+		end := i + width
+		if end > len(data) {
+			end = len(data)
+		}
+		if i > 0 {
+			b.WriteString(" +\n\t")
+		}
+		b.WriteString(strconv.Quote(string(data[i:end])))
+	}
+	return b.String()
+}
+
+var goTemplate = template.Must(template.New("go").Parse(`// Code generated by github.com/openacid/trie GenerateGo. DO NOT EDIT.
+
+package {{.Pkg}}
+
+// {{.Name}}Data is a trie of {{.Keys}} keys in {{.Bytes}} bytes, in the fixed
+// layout of github.com/openacid/trie.
+const {{.Name}}Data = {{.Data}}
+
+// {{.Name}} returns the value of key and whether key is in the trie.
+func {{.Name}}(key string) (string, bool) {
+
+	d := {{.Name}}Data
+	off := {{.Name}}U32(d, 12)
+	for i := -1; ; {
+		i += {{.Name}}U16(d, off)
+		n := {{.Name}}U16(d, off+2)
+
+		if i > len(key) {
+			return "", false
+		}
+
+		if i == len(key) {
+			if d[off+4:off+8] == "\xff\xff\xff\xff" {
+				// No value.
+				return "", false
+			}
+			v := {{.Name}}U32(d, off+4)
+			return d[v+4 : v+4+{{.Name}}U32(d, v)], true
+		}
+
+		// Labels are ascending.
+		lo, hi := 0, n
+		for lo < hi {
+			m := (lo + hi) / 2
+			if d[off+8+5*m] < key[i] {
+				lo = m + 1
+			} else {
+				hi = m
+			}
+		}
+		if lo == n || d[off+8+5*lo] != key[i] {
+			return "", false
+		}
+		off = {{.Name}}U32(d, off+9+5*lo)
+	}
+}
+
+func {{.Name}}U16(d string, off int) int {
+	return int(d[off]) | int(d[off+1])<<8
+}
+
+func {{.Name}}U32(d string, off int) int {
+	return int(d[off]) | int(d[off+1])<<8 | int(d[off+2])<<16 | int(d[off+3])<<24
+}
+`))
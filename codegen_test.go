@@ -0,0 +1,110 @@
+package trie
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/openacid/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNode_GenerateGo(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := [][]byte{[]byte("css"), []byte("htm"), []byte("html"), []byte("js"), []byte("json")}
+	values := []string{"text/css", "text/html", "text/html", "text/javascript", "application/json"}
+	tr, err := NewTrie(keys, values, false)
+	ta.Nil(err)
+
+	src := &bytes.Buffer{}
+	ta.Nil(tr.GenerateGo(src, WithGoPackage("mime"), WithGoName("mimeType")))
+
+	s := src.String()
+	ta.True(strings.HasPrefix(s, "// Code generated by github.com/openacid/trie GenerateGo. DO NOT EDIT.\n\npackage mime\n"))
+	ta.Contains(s, "func mimeType(key string) (string, bool) {")
+	ta.Contains(s, "// mimeTypeData is a trie of 5 keys in ")
+	ta.NotContains(s, "import")
+
+	// The data is what ExportFixed writes.
+	fixed := &bytes.Buffer{}
+	ta.Nil(tr.ExportFixed(fixed, func(v interface{}) ([]byte, error) { return []byte(v.(string)), nil }))
+	ta.Contains(s, fmt.Sprintf("in %d bytes", fixed.Len()))
+
+	for _, name := range []string{"", "1x", "func", "a-b"} {
+		err := tr.GenerateGo(&bytes.Buffer{}, WithGoName(name))
+		ta.Equal(ErrInvalidGoName, errors.Cause(err), "%q", name)
+	}
+	err = tr.GenerateGo(&bytes.Buffer{}, WithGoPackage("x y"))
+	ta.Equal(ErrInvalidGoName, errors.Cause(err))
+
+	err = tr.GenerateGo(&bytes.Buffer{}, WithGoValueEncoder(func(v interface{}) ([]byte, error) {
+		return nil, errors.New("unencodable")
+	}))
+	ta.Equal("unencodable", errors.Cause(err).Error())
+}
+
+func TestNode_GenerateGo_run(t *testing.T) {
+
+	ta := require.New(t)
+
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("no go tool")
+	}
+
+	strs := []string{"k3", "z"}
+	for i := 0; i < 300; i += 3 {
+		strs = append(strs, fmt.Sprintf("k%03d", i))
+	}
+	sort.Strings(strs)
+	keys := make([][]byte, len(strs))
+	values := make([]int, len(strs))
+	for i, k := range strs {
+		keys[i] = []byte(k)
+		values[i] = i * 7
+	}
+
+	tr, err := NewTrie(keys, values, false)
+	ta.Nil(err)
+
+	dir, err := ioutil.TempDir("", "trie-gen-")
+	ta.Nil(err)
+	defer os.RemoveAll(dir)
+
+	src := &bytes.Buffer{}
+	ta.Nil(tr.GenerateGo(src))
+	ta.Nil(ioutil.WriteFile(filepath.Join(dir, "lookup.go"), src.Bytes(), 0644))
+
+	queries := []string{"", "k", "k0", "k000", "k001", "k003", "k297", "k299", "k3", "k30", "z", "zz", "a"}
+	main := "package main\n\nimport \"fmt\"\n\nfunc main() {\n"
+	for _, q := range queries {
+		main += fmt.Sprintf("\tfmt.Println(lookup(%q))\n", q)
+	}
+	main += "}\n"
+	ta.Nil(ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte(main), 0644))
+
+	cmd := exec.Command(goBin, "run", "lookup.go", "main.go")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=", "GO111MODULE=off")
+	out, err := cmd.CombinedOutput()
+	ta.Nil(err, string(out))
+
+	want := ""
+	for _, q := range queries {
+		v, found := tr.Get([]byte(q))
+		s := ""
+		if found {
+			s = fmt.Sprint(v)
+		}
+		want += fmt.Sprintln(s, found)
+	}
+	ta.Equal(want, string(out))
+}
@@ -0,0 +1,127 @@
+package trie
+
+import (
+	"bytes"
+	"sort"
+)
+
+// byteOrder is the rank of every byte in a custom key order, set by
+// WithByteOrder. A nil byteOrder is the order of byte values.
+type byteOrder []int
+
+// WithByteOrder makes keys ordered by comparing bytes with `cmp` instead of
+// comparing byte values, e.g., to order "a" < "B" < "c", or to follow a
+// collation table. `cmp` returns a negative number if `a` goes before `b`, a
+// positive number if `a` goes after `b`, and 0 if they are equal. Bytes `cmp`
+// reports as equal are ordered by value, since different keys are never equal
+// in a trie. To treat them as the same key, use WithKeyNormalizer too.
+//
+// Keys are compared byte by byte in this order, and a key goes before any
+// longer key it is a prefix of.
+// It decides the order NewTrie and Append require keys to be added in, the
+// left and right neighbors Search returns, and the order Iter and MergeIter
+// yield keys in.
+//
+// Since 0.2.0
+func WithByteOrder(cmp func(a, b byte) int) Option {
+
+	bs := make([]int, 256)
+	for i := range bs {
+		bs[i] = i
+	}
+	sort.SliceStable(bs, func(i, j int) bool {
+		return cmp(byte(bs[i]), byte(bs[j])) < 0
+	})
+
+	order := make(byteOrder, 256)
+	for rank, b := range bs {
+		order[b] = rank
+	}
+
+	return func(o *options) {
+		o.byteOrder = order
+	}
+}
+
+// byteOrder returns the custom byte order of the trie, or nil.
+func (r *Node) byteOrder() byteOrder {
+	if r.opt == nil {
+		return nil
+	}
+	return r.opt.byteOrder
+}
+
+// rank returns the position of branch `br` in the order. The leaf branch goes
+// before any byte.
+func (o byteOrder) rank(br int) int {
+	if o == nil || br == leafBranch {
+		return br
+	}
+	return o[br]
+}
+
+// compare compares two keys in the order, the same way bytes.Compare does.
+func (o byteOrder) compare(a, b []byte) int {
+
+	if o == nil {
+		return bytes.Compare(a, b)
+	}
+
+	for i := 0; i < len(a) && i < len(b); i++ {
+		ra, rb := o.rank(int(a[i])), o.rank(int(b[i]))
+		if ra != rb {
+			if ra < rb {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	}
+	return 0
+}
+
+// neighborBranches is neighborBranches of `branches` sorted in the order.
+func (o byteOrder) neighborBranches(branches []int, br int) (ltIndex, rtIndex int) {
+
+	if o == nil {
+		return neighborBranches(branches, br)
+	}
+
+	rank := o.rank(br)
+	i := sort.Search(len(branches), func(i int) bool {
+		return o.rank(branches[i]) >= rank
+	})
+
+	ltIndex = i - 1
+	rtIndex = i
+	if i < len(branches) && branches[i] == br {
+		rtIndex = i + 1
+	}
+	if rtIndex == len(branches) {
+		rtIndex = -1
+	}
+	return
+}
+
+// insertBranch inserts `br` into `branches` sorted in the order.
+func (o byteOrder) insertBranch(branches []int, br int) []int {
+
+	if o == nil {
+		return insertBranch(branches, br)
+	}
+
+	rank := o.rank(br)
+	i := sort.Search(len(branches), func(i int) bool {
+		return o.rank(branches[i]) >= rank
+	})
+	branches = append(branches, 0)
+	copy(branches[i+1:], branches[i:])
+	branches[i] = br
+	return branches
+}
@@ -0,0 +1,128 @@
+package trie
+
+import (
+	"testing"
+
+	"github.com/openacid/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// foldCase orders bytes case insensitively: "A" < "a" < "B" < "b".
+func foldCase(a, b byte) int {
+	lower := func(c byte) byte {
+		if c >= 'A' && c <= 'Z' {
+			return c + 'a' - 'A'
+		}
+		return c
+	}
+	return int(lower(a)) - int(lower(b))
+}
+
+func TestWithByteOrder(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := [][]byte{
+		[]byte("A"),
+		[]byte("a"),
+		[]byte("ab"),
+		[]byte("B"),
+		[]byte("bc"),
+	}
+	values := []int{0, 1, 2, 3, 4}
+
+	for _, squash := range []bool{false, true} {
+
+		tr, err := NewTrie(keys, values, squash, WithByteOrder(foldCase))
+		ta.Nil(err)
+
+		got := []interface{}{}
+		for it := tr.NewIter(); it.Next(); {
+			got = append(got, it.Value())
+		}
+		ta.Equal([]interface{}{0, 1, 2, 3, 4}, got, "squash: %v", squash)
+
+		cases := []struct {
+			key        string
+			lt, eq, gt interface{}
+		}{
+			{"A", nil, 0, 1},
+			{"ab", 1, 2, 3},
+			{"B", 2, 3, 4},
+			{"c", 4, nil, nil},
+		}
+
+		for i, c := range cases {
+			lt, eq, gt := tr.Search([]byte(c.key))
+			ta.Equal(c.lt, lt, "%d-th: squash: %v, lt", i+1, squash)
+			ta.Equal(c.eq, eq, "%d-th: squash: %v, eq", i+1, squash)
+			ta.Equal(c.gt, gt, "%d-th: squash: %v, gt", i+1, squash)
+		}
+	}
+
+	// keys in byte value order are out of order.
+	_, err := NewTrie([][]byte{[]byte("B"), []byte("a")}, []int{0, 1}, false, WithByteOrder(foldCase))
+	ta.Equal(ErrKeyOutOfOrder, errors.Cause(err))
+}
+
+func TestWithByteOrder_set(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr, err := NewTrie(nil, nil, false, WithByteOrder(foldCase))
+	ta.Nil(err)
+
+	for i, k := range []string{"b", "C", "A", "a", "c"} {
+		_, err = tr.Set([]byte(k), i)
+		ta.Nil(err)
+	}
+
+	ta.True(tr.Remove([]byte("a")))
+
+	got := []string{}
+	for it := tr.NewIter(); it.Next(); {
+		got = append(got, string(it.Key()))
+	}
+	ta.Equal([]string{"A", "b", "C", "c"}, got)
+
+	lt, eq, gt := tr.Search([]byte("B"))
+	ta.Equal(2, lt)
+	ta.Nil(eq)
+	ta.Equal(0, gt)
+
+	other, err := NewTrie([][]byte{[]byte("a"), []byte("D")}, []int{10, 11}, false, WithByteOrder(foldCase))
+	ta.Nil(err)
+
+	got = []string{}
+	for it := MergeIter(tr, other); it.Next(); {
+		got = append(got, string(it.Key()))
+	}
+	ta.Equal([]string{"A", "a", "b", "C", "c", "D"}, got)
+}
+
+func TestByteOrder_compare(t *testing.T) {
+
+	ta := require.New(t)
+
+	o := &options{}
+	WithByteOrder(foldCase)(o)
+
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "a", -1},
+		{"a", "B", -1},
+		{"B", "a", 1},
+		{"A", "a", -1},
+		{"ab", "ab", 0},
+		{"ab", "aB", 1},
+		{"ab", "abc", -1},
+	}
+
+	for i, c := range cases {
+		ta.Equal(c.want, o.byteOrder.compare([]byte(c.a), []byte(c.b)), "%d-th: %q %q", i+1, c.a, c.b)
+		ta.Equal(-c.want, o.byteOrder.compare([]byte(c.b), []byte(c.a)), "%d-th: swapped", i+1)
+	}
+}
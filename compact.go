@@ -0,0 +1,82 @@
+package trie
+
+import "strconv"
+
+// Compact reallocates Branches and Children of nodes that have lost
+// branches, e.g., after Remove, to fit their current size. Go maps never
+// shrink, thus this is the only way to release the memory.
+//
+// With WithLazyRemove, leaves marked as removed are removed first, the same
+// way RemoveBatch does without WithLazyRemove.
+//
+// It returns an estimate of the bytes reclaimed.
+//
+// Since 0.2.0
+func (r *Node) Compact() int {
+
+	if r.lazyRemove() {
+		touched := make(map[*Node]int)
+		r.purge(r, 0, touched)
+		if r.squash {
+			r.resquash(touched)
+		}
+		r.buildFront()
+	}
+
+	return r.compact()
+}
+
+func (r *Node) compact() int {
+
+	if len(r.Branches) == 0 {
+		return r.compactNode()
+	}
+
+	reclaimed := r.compactNode()
+	for _, b := range r.Branches {
+		reclaimed += r.Children[b].compact()
+	}
+	return reclaimed
+}
+
+// compactNode compacts r if it has lost branches since it is created or
+// compacted. The estimate of the bytes reclaimed is by the capacity of
+// r.Branches, which is the max fan-out r ever had.
+func (r *Node) compactNode() int {
+
+	if !r.shrunk || r.Children == nil {
+		return 0
+	}
+	r.shrunk = false
+	l, c := len(r.Branches), cap(r.Branches)
+
+	branches := make([]int, l)
+	copy(branches, r.Branches)
+
+	children := make(map[int]*Node, l)
+	for _, b := range branches {
+		children[b] = r.Children[b]
+	}
+
+	r.Branches = branches
+	r.Children = children
+
+	return (c-l)*intSize + estimateMapSize(c) - estimateMapSize(l)
+}
+
+const intSize = strconv.IntSize / 8
+
+// estimateMapSize estimates the bytes used by a map[int]*Node with n
+// entries: in buckets of 8 entries, with a load factor of 6.5. Each bucket
+// has 8 hash bytes, 8 keys, 8 values and an overflow pointer.
+func estimateMapSize(n int) int {
+
+	const ptrSize = intSize
+	const bucketSize = 8 + 8*intSize + 8*ptrSize + ptrSize
+
+	buckets := 1
+	for float64(n) > 6.5*float64(buckets) {
+		buckets *= 2
+	}
+	return buckets * bucketSize
+}
@@ -0,0 +1,51 @@
+package trie
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNode_Compact(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := []string{}
+	for i := 0; i < 256; i++ {
+		keys = append(keys, fmt.Sprintf("%02x", i))
+	}
+
+	tr := newStrTrie(ta, false, keys...)
+	ta.Equal(0, tr.Compact(), "nothing to reclaim if no branch is removed")
+
+	rm := [][]byte{}
+	for _, k := range keys[:250] {
+		rm = append(rm, []byte(k))
+	}
+	ta.Equal(250, tr.RemoveBatch(rm))
+
+	reclaimed := tr.Compact()
+	ta.True(reclaimed > 0)
+	ta.Equal(0, tr.Compact())
+
+	ks, _ := iterAll(tr.NewIter())
+	ta.Equal(keys[250:], ks)
+
+	for _, k := range keys[250:] {
+		_, eq, _ := tr.Search([]byte(k))
+		ta.Equal(k, eq)
+	}
+
+	_, err := tr.Append([]byte("zz"), "zz")
+	ta.Nil(err)
+}
+
+func TestEstimateMapSize(t *testing.T) {
+
+	ta := require.New(t)
+
+	ta.Equal(estimateMapSize(0), estimateMapSize(6))
+	ta.True(estimateMapSize(7) > estimateMapSize(6))
+	ta.True(estimateMapSize(256) > estimateMapSize(7))
+}
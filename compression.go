@@ -0,0 +1,91 @@
+package trie
+
+// CompressionReport compares the size of a trie with what squashing, hash
+// consing and interning stored keys would make it. Nodes include inner nodes
+// and leaves, and bytes are estimated of nodes, their maps and branches, the
+// same way as SquashResult.BytesSaved.
+//
+// Since 0.2.0
+type CompressionReport struct {
+	// Nodes and Bytes are of the trie as it is.
+	//
+	// Since 0.2.0
+	Nodes int
+	Bytes int
+
+	// SquashedNodes and SquashedBytes are of the trie after Squash, the same
+	// as Nodes and Bytes if it is squashed.
+	//
+	// Since 0.2.0
+	SquashedNodes int
+	SquashedBytes int
+
+	// HashConsNodes and HashConsBytes are what WithHashConsing would save of
+	// the squashed trie, by sharing identical subtrees.
+	//
+	// Since 0.2.0
+	HashConsNodes int
+	HashConsBytes int
+
+	// StoredKeyBytes is the bytes allocated for keys of WithStoreKeys, and
+	// InternSavedBytes is what storing them in an Interner of WithKeyInterner
+	// would save: the spare capacity of every allocation and the duplicates.
+	//
+	// Since 0.2.0
+	StoredKeyBytes   int
+	InternSavedBytes int
+}
+
+// Ratio returns the bytes of the trie squashed and hash consed relative to
+// the bytes of it as it is, e.g., 0.4 if the two would save 60%.
+//
+// Since 0.2.0
+func (c CompressionReport) Ratio() float64 {
+	if c.Bytes == 0 {
+		return 1
+	}
+	return float64(c.SquashedBytes-c.HashConsBytes) / float64(c.Bytes)
+}
+
+// CompressionReport estimates what squashing, hash consing and interning
+// stored keys would save, without modifying the trie, to decide which of them
+// are worth enabling. Values are not counted.
+//
+// It takes O(n) time and copies the structure of the trie to squash it.
+//
+// Since 0.2.0
+func (r *Node) CompressionReport() CompressionReport {
+
+	var rep CompressionReport
+
+	in := NewInterner()
+	r.WalkDepth(func(n *Node, depth int) bool {
+		rep.Nodes++
+		rep.Bytes += n.size()
+		if n.key() != nil {
+			rep.StoredKeyBytes += cap(n.key())
+			in.Intern(n.key())
+		}
+		return true
+	})
+	rep.InternSavedBytes = rep.StoredKeyBytes - in.Size
+
+	rep.SquashedNodes, rep.SquashedBytes = rep.Nodes, rep.Bytes
+	squashed := r
+
+	st := &squashState{dryRun: true}
+	if r.squashSubtree(r, st) > 0 {
+		squashed = r.clone(func(v interface{}) interface{} { return v })
+		squashed.squashSubtree(squashed, nil)
+
+		rep.SquashedNodes, rep.SquashedBytes = 0, 0
+		squashed.WalkDepth(func(n *Node, depth int) bool {
+			rep.SquashedNodes++
+			rep.SquashedBytes += n.size()
+			return true
+		})
+	}
+	rep.HashConsNodes, rep.HashConsBytes = squashed.hashConsWith(true)
+
+	return rep
+}
@@ -0,0 +1,76 @@
+package trie
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNode_CompressionReport(t *testing.T) {
+
+	ta := require.New(t)
+
+	// Keys of repeated suffixes with the same values.
+	keys := [][]byte{}
+	values := []int{}
+	for _, p := range []string{"aa", "bb", "cc"} {
+		for _, s := range []string{"-xyz", "-xzz"} {
+			keys = append(keys, []byte(p+s))
+			values = append(values, len(s))
+		}
+	}
+
+	tr, err := NewTrie(keys, values, false, WithStoreKeys())
+	ta.Nil(err)
+	before := tr.String()
+
+	rep := tr.CompressionReport()
+	ta.Equal(before, tr.String(), "not modified")
+
+	c := tr.Counters()
+	ta.Equal(c.InnerNodes+c.Leaves, rep.Nodes)
+	ta.True(rep.SquashedNodes < rep.Nodes)
+	ta.True(rep.SquashedBytes < rep.Bytes)
+	ta.True(rep.HashConsNodes > 0)
+	ta.True(rep.HashConsBytes > 0)
+	ta.True(rep.Ratio() > 0 && rep.Ratio() < 1)
+	ta.True(rep.StoredKeyBytes >= 6*6)
+
+	// The estimates hold.
+	sq, err := NewTrie(keys, values, true)
+	ta.Nil(err)
+	sc := sq.Counters()
+	ta.Equal(sc.InnerNodes+sc.Leaves, rep.SquashedNodes)
+
+	hc, err := NewTrie(keys, values, true, WithHashConsing())
+	ta.Nil(err)
+	distinct := map[*Node]bool{}
+	hc.WalkDepth(func(n *Node, depth int) bool {
+		distinct[n] = true
+		return true
+	})
+	ta.Equal(rep.SquashedNodes-rep.HashConsNodes, len(distinct))
+
+	// A squashed trie is reported as it is.
+	rep = sq.CompressionReport()
+	ta.Equal(rep.Nodes, rep.SquashedNodes)
+	ta.Equal(rep.Bytes, rep.SquashedBytes)
+	ta.Equal(0, rep.StoredKeyBytes)
+	ta.Equal(0, rep.InternSavedBytes)
+
+	empty, err := NewTrie(nil, nil, false)
+	ta.Nil(err)
+	rep = empty.CompressionReport()
+	ta.Equal(1, rep.Nodes)
+	ta.Equal(0, rep.HashConsNodes)
+}
+
+func TestCompressionReport_Ratio(t *testing.T) {
+
+	ta := require.New(t)
+
+	ta.Equal(1.0, CompressionReport{}.Ratio())
+	ta.Equal(0.4, CompressionReport{Bytes: 100, SquashedBytes: 50, HashConsBytes: 10}.Ratio())
+	ta.Equal("0.40", fmt.Sprintf("%.2f", CompressionReport{Bytes: 10, SquashedBytes: 4}.Ratio()))
+}
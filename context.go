@@ -0,0 +1,101 @@
+package trie
+
+import (
+	"context"
+	"io"
+)
+
+// ctxCheckEvery is the number of keys or nodes processed between two checks
+// of a context, to keep the checks cheap.
+const ctxCheckEvery = 1024
+
+// canceler checks a context periodically. A nil canceler is never canceled.
+type canceler struct {
+	ctx context.Context
+	n   int
+	// err is the error of ctx once it is found canceled.
+	err error
+}
+
+func newCanceler(ctx context.Context) *canceler {
+	return &canceler{ctx: ctx}
+}
+
+// canceled returns true if the context is found canceled. It checks the
+// context at the first and every ctxCheckEvery calls, and keeps returning
+// true once canceled.
+func (c *canceler) canceled() bool {
+
+	if c == nil {
+		return false
+	}
+	if c.err == nil && c.n%ctxCheckEvery == 0 {
+		c.err = c.ctx.Err()
+	}
+	c.n++
+	return c.err != nil
+}
+
+// NewTrieContext is the same as NewTrie except that it returns ctx.Err() if
+// `ctx` is done before the trie is built.
+//
+// Since 0.2.0
+func NewTrieContext(ctx context.Context, keys [][]byte, values interface{}, squash bool, opts ...Option) (*Node, error) {
+
+	c := newCanceler(ctx)
+	if keys == nil {
+		return newTrie(c, nil, 0, nil, squash, opts)
+	}
+
+	valSlice, err := toSlice(values)
+	if err != nil {
+		return nil, err
+	}
+	return newTrie(c, keys, len(valSlice), func(i int) interface{} { return valSlice[i] }, squash, opts)
+}
+
+// NewTrieFromSourcesContext is the same as NewTrieFromSources except that it
+// returns ctx.Err() if `ctx` is done before all sources are merged into the
+// trie.
+//
+// Since 0.2.0
+func NewTrieFromSourcesContext(ctx context.Context, sources []KVSource, policy MergePolicy, squash bool, opts ...Option) (*Node, error) {
+	return newTrieFromSources(newCanceler(ctx), sources, policy, squash, opts)
+}
+
+// SquashContext is the same as Squash except that it stops and returns
+// ctx.Err() if `ctx` is done. A stopped Squash returns the number of nodes
+// merged so far, and leaves the trie valid but partly squashed. Another
+// Squash finishes it.
+//
+// Since 0.2.0
+func (r *Node) SquashContext(ctx context.Context) (int, error) {
+
+	c := newCanceler(ctx)
+	res := r.squashWith(&squashState{cancel: c})
+	return res.Merged, c.err
+}
+
+// WalkDepthContext is the same as WalkDepth except that it stops and returns
+// ctx.Err() if `ctx` is done.
+//
+// Since 0.2.0
+func (r *Node) WalkDepthContext(ctx context.Context, fn func(n *Node, depth int) bool) error {
+
+	c := newCanceler(ctx)
+	r.WalkDepth(func(n *Node, depth int) bool {
+		if c.canceled() {
+			return false
+		}
+		return fn(n, depth)
+	})
+	return c.err
+}
+
+// ExportFixedContext is the same as ExportFixed except that it stops and
+// returns ctx.Err() if `ctx` is done. `w` may have been written partly.
+//
+// Since 0.2.0
+func (r *Node) ExportFixedContext(ctx context.Context, w io.Writer, enc func(v interface{}) ([]byte, error)) error {
+	return r.exportFixed(newCanceler(ctx), w, enc)
+}
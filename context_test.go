@@ -0,0 +1,187 @@
+package trie
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func ctxTestKeys(n int) ([][]byte, []int) {
+	keys := make([][]byte, n)
+	values := make([]int, n)
+	for i := range keys {
+		keys[i] = []byte(fmt.Sprintf("%06d-key", i))
+		values[i] = i
+	}
+	return keys, values
+}
+
+func TestNewTrieContext(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys, values := ctxTestKeys(5000)
+
+	for _, squash := range []bool{false, true} {
+		tr, err := NewTrieContext(context.Background(), keys, values, squash)
+		ta.Nil(err)
+		want, err := NewTrie(keys, values, squash)
+		ta.Nil(err)
+		ta.Equal(want.String(), tr.String())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		tr, err = NewTrieContext(ctx, keys, values, squash)
+		ta.Equal(context.Canceled, err)
+		ta.Nil(tr)
+
+		// Canceled while appending.
+		ctx, cancel = context.WithCancel(context.Background())
+		appended := 0
+		tr, err = NewTrieContext(ctx, keys, values, squash, WithBuildHook(2000, func(e BuildEvent) {
+			if e.Phase == PhaseAppend {
+				appended = e.KeyCnt
+				cancel()
+			}
+		}))
+		ta.Equal(context.Canceled, err)
+		ta.Nil(tr)
+		ta.Equal(2000, appended)
+
+		// Canceled while squashing.
+		ctx, cancel = context.WithCancel(context.Background())
+		tr, err = NewTrieContext(ctx, keys, values, squash, WithBuildHook(2000, func(e BuildEvent) {
+			if e.Phase == PhaseSquash {
+				cancel()
+			}
+		}))
+		if squash {
+			ta.Equal(context.Canceled, err)
+			ta.Nil(tr)
+		} else {
+			ta.Nil(err)
+		}
+	}
+
+	_, err := NewTrieContext(context.Background(), keys, 1, false)
+	ta.Equal(ErrValuesNotSlice, err.(*ValuesNotSliceError).Cause())
+
+	tr, err := NewTrieContext(context.Background(), nil, nil, false)
+	ta.Nil(err)
+	ta.Equal(0, tr.KeyCnt())
+}
+
+func TestNode_SquashContext(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys, values := ctxTestKeys(5000)
+	want, err := NewTrie(keys, values, true)
+	ta.Nil(err)
+
+	tr, err := NewTrie(keys, values, false)
+	ta.Nil(err)
+	ctx, cancel := context.WithCancel(context.Background())
+	tr.opt.buildHook = func(e BuildEvent) {
+		if !e.Done {
+			cancel()
+		}
+	}
+	tr.opt.buildHookEvery = 3000
+
+	merged, err := tr.SquashContext(ctx)
+	ta.Equal(context.Canceled, err)
+	ta.True(merged > 0)
+	ta.Nil(tr.Validate())
+	for i, k := range keys {
+		_, eq, _ := tr.Search(k)
+		ta.Equal(values[i], eq)
+	}
+
+	rest, err := tr.SquashContext(context.Background())
+	ta.Nil(err)
+	ta.True(rest > 0)
+	ta.Equal(want.String(), tr.String())
+	ta.Nil(tr.Validate())
+}
+
+func TestNode_WalkDepthContext(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys, values := ctxTestKeys(3000)
+	tr, err := NewTrie(keys, values, true)
+	ta.Nil(err)
+
+	total := 0
+	ta.Nil(tr.WalkDepthContext(context.Background(), func(n *Node, depth int) bool {
+		total++
+		return true
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cnt := 0
+	err = tr.WalkDepthContext(ctx, func(n *Node, depth int) bool {
+		cnt++
+		if cnt == 10 {
+			cancel()
+		}
+		return true
+	})
+	ta.Equal(context.Canceled, err)
+	ta.True(cnt < total)
+	ta.True(cnt <= 10+ctxCheckEvery)
+}
+
+func TestNode_ExportFixedContext(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys, values := ctxTestKeys(3000)
+	tr, err := NewTrie(keys, values, true)
+	ta.Nil(err)
+
+	enc := func(v interface{}) ([]byte, error) { return []byte(fmt.Sprint(v)), nil }
+
+	want := &bytes.Buffer{}
+	ta.Nil(tr.ExportFixed(want, enc))
+	got := &bytes.Buffer{}
+	ta.Nil(tr.ExportFixedContext(context.Background(), got, enc))
+	ta.Equal(want.Bytes(), got.Bytes())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cnt := 0
+	err = tr.ExportFixedContext(ctx, &bytes.Buffer{}, func(v interface{}) ([]byte, error) {
+		cnt++
+		if cnt == 100 {
+			cancel()
+		}
+		return enc(v)
+	})
+	ta.Equal(context.Canceled, err)
+	ta.True(cnt < len(keys))
+}
+
+func TestNewTrieFromSourcesContext(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys, values := ctxTestKeys(3000)
+	a, err := NewTrie(keys[:2000], values[:2000], false)
+	ta.Nil(err)
+	b, err := NewTrie(keys[1000:], values[1000:], false)
+	ta.Nil(err)
+
+	tr, err := NewTrieFromSourcesContext(context.Background(), []KVSource{a.NewIter(), b.NewIter()}, nil, true)
+	ta.Nil(err)
+	ta.Equal(len(keys), tr.KeyCnt())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tr, err = NewTrieFromSourcesContext(ctx, []KVSource{a.NewIter(), b.NewIter()}, nil, true)
+	ta.Equal(context.Canceled, err)
+	ta.Nil(tr)
+}
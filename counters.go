@@ -0,0 +1,208 @@
+package trie
+
+import "github.com/openacid/errors"
+
+// Counters is the numbers of nodes, keys and branches of a trie.
+//
+// Since 0.2.0
+type Counters struct {
+	// InnerNodes is the number of inner nodes, including the root. It is the
+	// same as InnerNodeCnt.
+	//
+	// Since 0.2.0
+	InnerNodes int
+
+	// Leaves is the number of leaves, including those of keys removed with
+	// WithLazyRemove and not yet purged.
+	//
+	// Since 0.2.0
+	Leaves int
+
+	// Keys is the number of keys, the same as KeyCnt.
+	//
+	// Since 0.2.0
+	Keys int
+
+	// Branches is the total number of branches of inner nodes, which is
+	// InnerNodes - 1 + Leaves since every node except the root is the child of
+	// one branch.
+	//
+	// Since 0.2.0
+	Branches int
+
+	// SquashedChains is the number of inner nodes standing for a chain of
+	// nodes merged by squashing, i.e., of which Step is greater than 1.
+	//
+	// Since 0.2.0
+	SquashedChains int
+}
+
+// nodeCounts is what the root keeps for Counters besides InnerNodeCnt and
+// keyCnt.
+type nodeCounts struct {
+	leaves   int
+	squashed int
+}
+
+// Counters returns the counters of the trie, which are maintained by every
+// change to it and take O(1) time. Nodes shared by WithHashConsing are counted
+// once for every path through them.
+//
+// A trie not created by NewTrie, e.g., built by hand, has its counters
+// calculated, in O(n) time.
+//
+// Since 0.2.0
+func (r *Node) Counters() Counters {
+
+	if r.counts == nil {
+		return r.countAll()
+	}
+
+	return Counters{
+		InnerNodes:     r.InnerNodeCnt,
+		Leaves:         r.counts.leaves,
+		Keys:           r.KeyCnt(),
+		Branches:       r.InnerNodeCnt - 1 + r.counts.leaves,
+		SquashedChains: r.counts.squashed,
+	}
+}
+
+// Validate checks that the structure of the trie is consistent and that the
+// maintained counters, i.e., Counters, InnerNodeCnt and KeyCnt of every
+// node, match the trie. It returns an error wrapping ErrInvalidTrie that
+// tells the first inconsistency found.
+//
+// It takes O(n) time for a trie of n nodes.
+//
+// Since 0.2.0
+func (r *Node) Validate() error {
+
+	if r.Children == nil {
+		return errors.Wrapf(ErrInvalidTrie, "root is a leaf")
+	}
+
+	if err := r.validateNode(r, nil, -1); err != nil {
+		return err
+	}
+
+	got := r.Counters()
+	want := r.countAll()
+	if got != want {
+		return errors.Wrapf(ErrInvalidTrie, "counters: maintained %+v, actual %+v", got, want)
+	}
+
+	return nil
+}
+
+// validateNode checks the subtree of inner node `n` at `key`, the branch
+// labels from the root, of which the last one is the byte at `depth` of keys.
+//
+// A key stored WithStoreKeys is checked to end where its leaf is, by the sum
+// of Steps along the path.
+func (r *Node) validateNode(n *Node, key []byte, depth int) error {
+
+	if n.Step == 0 {
+		return errors.Wrapf(ErrInvalidTrie, "node %q: zero Step", key)
+	}
+	depth += int(n.Step)
+
+	if len(n.Children) != len(n.Branches) {
+		return errors.Wrapf(ErrInvalidTrie, "node %q: %d children but %d branches", key, len(n.Children), len(n.Branches))
+	}
+
+	order := r.byteOrder()
+	labels := r.labelStyle(LabelDecimal)
+	keyCnt := 0
+	for i, b := range n.Branches {
+		if i > 0 && order.rank(n.Branches[i-1]) >= order.rank(b) {
+			return errors.Wrapf(ErrInvalidTrie, "node %q: branches not ascending: %s", key, labels.formatLabels(n.Branches))
+		}
+
+		child := n.Children[b]
+		if child == nil {
+			return errors.Wrapf(ErrInvalidTrie, "node %q: no child of branch %s", key, labels.Format(b))
+		}
+
+		if b == leafBranch {
+			if child.Children != nil {
+				return errors.Wrapf(ErrInvalidTrie, "node %q: leaf branch to an inner node", key)
+			}
+			if child.key() != nil && len(child.key()) != depth {
+				return errors.Wrapf(ErrInvalidTrie, "node %q: leaf of %q at depth %d", key, child.key(), depth)
+			}
+		} else {
+			if child.Children == nil {
+				return errors.Wrapf(ErrInvalidTrie, "node %q: branch %s to a leaf", key, labels.Format(b))
+			}
+			if err := r.validateNode(child, append(key, byte(b)), depth); err != nil {
+				return err
+			}
+		}
+		keyCnt += child.KeyCnt()
+	}
+
+	if n.keyCnt != keyCnt {
+		return errors.Wrapf(ErrInvalidTrie, "node %q: key count %d, actual %d", key, n.keyCnt, keyCnt)
+	}
+
+	return nil
+}
+
+// countAll calculates the counters of the subtree of r.
+func (r *Node) countAll() Counters {
+
+	var c Counters
+	r.WalkDepth(func(n *Node, depth int) bool {
+		if n.Children == nil {
+			c.Leaves++
+			c.Keys += liveCnt(n.Value)
+			return false
+		}
+
+		c.InnerNodes++
+		c.Branches += len(n.Branches)
+		if n.Step > 1 {
+			c.SquashedChains++
+		}
+		return true
+	})
+	return c
+}
+
+// addCounts adds to the counters of r, if it maintains them.
+func (r *Node) addCounts(leaves, squashed int) {
+	if r.counts != nil {
+		r.counts.leaves += leaves
+		r.counts.squashed += squashed
+	}
+}
+
+// setCounts recalculates the counters of r.
+func (r *Node) setCounts() {
+	c := r.countAll()
+	r.InnerNodeCnt = c.InnerNodes
+	r.counts = &nodeCounts{leaves: c.Leaves, squashed: c.SquashedChains}
+}
+
+// copyCounts returns a copy of `c` to be kept by another root.
+func copyCounts(c *nodeCounts) *nodeCounts {
+	if c == nil {
+		return nil
+	}
+	cp := *c
+	return &cp
+}
+
+// mergeDelta returns the change of SquashedChains by merging `child`, the only
+// child of `n`, into `n`: n becomes a chain if it is not, and the child is
+// gone.
+func mergeDelta(n, child *Node) int {
+	d := 0
+	if n.Step <= 1 {
+		d++
+	}
+	if child.Step > 1 {
+		d--
+	}
+	return d
+}
@@ -0,0 +1,199 @@
+package trie
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/openacid/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNode_Counters(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr := newStrTrie(ta, false, "abc", "b")
+	ta.Equal(Counters{
+		InnerNodes:     5,
+		Leaves:         2,
+		Keys:           2,
+		Branches:       6,
+		SquashedChains: 0,
+	}, tr.Counters())
+
+	ta.Equal(2, tr.Squash())
+	ta.Equal(Counters{
+		InnerNodes:     3,
+		Leaves:         2,
+		Keys:           2,
+		Branches:       4,
+		SquashedChains: 1,
+	}, tr.Counters())
+	ta.Equal(3, tr.InnerNodeCnt)
+	ta.Nil(tr.Validate())
+
+	empty, err := NewTrie(nil, nil, false)
+	ta.Nil(err)
+	ta.Equal(Counters{InnerNodes: 1}, empty.Counters())
+	ta.Nil(empty.Validate())
+}
+
+func TestNode_Counters_mutations(t *testing.T) {
+
+	for _, lazy := range []bool{false, true} {
+		for seed := int64(0); seed < 5; seed++ {
+			testCounters(t, lazy, seed)
+		}
+	}
+}
+
+func testCounters(t *testing.T, lazy bool, seed int64) {
+
+	ta := require.New(t)
+	msg := fmt.Sprintf("lazy=%v seed=%d", lazy, seed)
+
+	rnd := rand.New(rand.NewSource(seed))
+	randKey := func() []byte {
+		k := make([]byte, rnd.Intn(6))
+		for i := range k {
+			k[i] = "abc"[rnd.Intn(3)]
+		}
+		return k
+	}
+
+	check := func(step string, tr *Node) {
+		ta.Nil(tr.Validate(), "%s %s", msg, step)
+	}
+
+	uniq := map[string]bool{}
+	for i := 0; i < 30; i++ {
+		uniq[string(randKey())] = true
+	}
+	strs := make([]string, 0, len(uniq))
+	for k := range uniq {
+		strs = append(strs, k)
+	}
+	sort.Strings(strs)
+	keys := make([][]byte, len(strs))
+	for i, k := range strs {
+		keys[i] = []byte(k)
+	}
+
+	var opts []Option
+	if lazy {
+		opts = append(opts, WithLazyRemove())
+	}
+
+	for _, squash := range []bool{false, true} {
+		tr, err := NewTrie(keys, strs, squash, opts...)
+		ta.Nil(err)
+		check(fmt.Sprintf("new squash=%v", squash), tr)
+
+		tr.RemoveBatch([][]byte{randKey(), randKey(), randKey()})
+		check("remove batch", tr)
+		tr.Compact()
+		check("compact", tr)
+	}
+
+	tr, err := NewTrie(keys, strs, false, opts...)
+	ta.Nil(err)
+	for i := 0; i < 100; i++ {
+		k := randKey()
+		if rnd.Intn(2) == 0 {
+			_, err := tr.Set(k, string(k))
+			ta.Nil(err)
+		} else {
+			tr.Remove(k)
+		}
+	}
+	check("set and remove", tr)
+
+	cl := tr.Clone()
+	check("clone", cl)
+
+	ta.Nil(tr.ReplaceSubTrie([]byte("a"), newStrTrie(ta, true, "x", "xyz", "y")))
+	check("replace", tr)
+	ta.Nil(tr.ReplaceSubTrie([]byte("b"), newStrTrie(ta, false)))
+	check("replace with empty", tr)
+	check("clone not affected", cl)
+
+	f := tr.Filter(func(key []byte, v interface{}) bool { return len(key)%2 == 0 })
+	check("filter", f)
+
+	tr.Compact()
+	check("compact", tr)
+
+	tr.Squash()
+	check("squash", tr)
+}
+
+func TestNode_Counters_autoSquash(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := [][]byte{}
+	for i := 0; i < 300; i++ {
+		keys = append(keys, []byte(fmt.Sprintf("%05d-key", i*7)))
+	}
+
+	tr, err := NewTrie(keys, make([]int, len(keys)), true, WithAutoSquash(16))
+	ta.Nil(err)
+	ta.Nil(tr.Validate())
+}
+
+func TestNode_Counters_sync(t *testing.T) {
+
+	ta := require.New(t)
+
+	s, err := NewSyncTrie([][]byte{[]byte("a"), []byte("ab")}, []string{"a", "ab"}, false)
+	ta.Nil(err)
+
+	v1 := s.Load()
+	ta.Nil(s.Append([]byte("abc"), "abc"))
+	v2 := s.Load()
+	tx := s.Begin()
+	tx.Remove([]byte("a"))
+	ta.Nil(tx.Commit())
+
+	for i, v := range []*Node{v1, v2, s.Load()} {
+		ta.Nil(v.Validate(), "%d-th version", i+1)
+	}
+	ta.Equal(2, v1.Counters().Leaves)
+	ta.Equal(3, v2.Counters().Leaves)
+	ta.Equal(2, s.Load().Counters().Leaves)
+}
+
+func TestNode_Validate(t *testing.T) {
+
+	ta := require.New(t)
+
+	cases := []struct {
+		name    string
+		corrupt func(tr *Node)
+	}{
+		{"inner node count", func(tr *Node) { tr.InnerNodeCnt++ }},
+		{"leaf count", func(tr *Node) { tr.counts.leaves-- }},
+		{"key count", func(tr *Node) { tr.Children['a'].keyCnt++ }},
+		{"branches", func(tr *Node) { tr.Branches = append(tr.Branches, 'z') }},
+		{"order", func(tr *Node) { tr.Branches[0], tr.Branches[1] = tr.Branches[1], tr.Branches[0] }},
+		{"step", func(tr *Node) { tr.Children['b'].Step = 0 }},
+	}
+
+	for _, c := range cases {
+		tr := newStrTrie(ta, false, "a", "ab", "b")
+		ta.Nil(tr.Validate(), c.name)
+
+		c.corrupt(tr)
+		err := tr.Validate()
+		ta.Equal(ErrInvalidTrie, errors.Cause(err), c.name)
+	}
+
+	// The depth of a stored key.
+	tr, err := NewTrie([][]byte{[]byte("a"), []byte("bcd")}, []int{1, 2}, true, WithStoreKeys())
+	ta.Nil(err)
+	ta.Nil(tr.Validate())
+	tr.Children['b'].Step--
+	ta.Equal(ErrInvalidTrie, errors.Cause(tr.Validate()))
+}
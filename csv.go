@@ -0,0 +1,191 @@
+package trie
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/openacid/errors"
+)
+
+// CSVOption configures ReadCSV and WriteCSV.
+//
+// Since 0.2.0
+type CSVOption func(*csvOptions)
+
+type csvOptions struct {
+	comma  rune
+	header bool
+	parse  func(string) (interface{}, error)
+	format func(interface{}) (string, error)
+	iter   []IterOption
+}
+
+// WithComma sets the field delimiter, e.g., '\t' for TSV. It is ',' by
+// default.
+//
+// Since 0.2.0
+func WithComma(comma rune) CSVOption {
+	return func(o *csvOptions) {
+		o.comma = comma
+	}
+}
+
+// WithHeader makes ReadCSV skip the first record and WriteCSV write a header
+// record of "key,value".
+//
+// Since 0.2.0
+func WithHeader() CSVOption {
+	return func(o *csvOptions) {
+		o.header = true
+	}
+}
+
+// WithValueParser sets how ReadCSV converts a field to a value. By default the
+// value is the field string.
+//
+// Since 0.2.0
+func WithValueParser(parse func(field string) (interface{}, error)) CSVOption {
+	return func(o *csvOptions) {
+		o.parse = parse
+	}
+}
+
+// WithValueFormatter sets how WriteCSV converts a value to a field. By default
+// a value is formatted with fmt "%v".
+//
+// Since 0.2.0
+func WithValueFormatter(format func(v interface{}) (string, error)) CSVOption {
+	return func(o *csvOptions) {
+		o.format = format
+	}
+}
+
+// WithIterOptions sets the options of the Iter WriteCSV writes records from,
+// e.g., WithStripPrefix to export a subtree with keys rewritten.
+//
+// Since 0.2.0
+func WithIterOptions(opts ...IterOption) CSVOption {
+	return func(o *csvOptions) {
+		o.iter = opts
+	}
+}
+
+func newCSVOptions(opts []CSVOption) *csvOptions {
+	o := &csvOptions{
+		comma: ',',
+		parse: func(s string) (interface{}, error) {
+			return s, nil
+		},
+		format: func(v interface{}) (string, error) {
+			return fmt.Sprintf("%v", v), nil
+		},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// ReadCSV creates a trie from CSV records read from `r`. The key of a record
+// is the field at `keyCol` and the value is parsed from the field at
+// `valCol`. Columns are 0-based; a negative one is an error.
+//
+// Records do not need to be sorted. A key that appears more than once is an
+// ErrDuplicateKeys. The trie is not squashed; call Squash to squash it.
+//
+// Since 0.2.0
+func ReadCSV(r io.Reader, keyCol, valCol int, opts ...CSVOption) (*Node, error) {
+
+	if keyCol < 0 || valCol < 0 {
+		return nil, errors.Errorf("negative column: key %d, value %d", keyCol, valCol)
+	}
+
+	o := newCSVOptions(opts)
+
+	cr := csv.NewReader(r)
+	cr.Comma = o.comma
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	type record struct {
+		key   []byte
+		value interface{}
+	}
+	var records []record
+
+	for lineNum := 1; ; lineNum++ {
+		fields, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if lineNum == 1 && o.header {
+			continue
+		}
+
+		if keyCol >= len(fields) || valCol >= len(fields) {
+			return nil, errors.Errorf("record %d: expect column %d and %d but there are %d fields",
+				lineNum, keyCol, valCol, len(fields))
+		}
+
+		v, err := o.parse(fields[valCol])
+		if err != nil {
+			return nil, errors.Wrapf(err, "record %d: parse value", lineNum)
+		}
+
+		records = append(records, record{[]byte(fields[keyCol]), v})
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return bytes.Compare(records[i].key, records[j].key) < 0
+	})
+
+	keys := make([][]byte, len(records))
+	values := make([]interface{}, len(records))
+	for i, rec := range records {
+		keys[i] = rec.key
+		values[i] = rec.value
+	}
+
+	return NewTrie(keys, values, false)
+}
+
+// WriteCSV writes every key and value in the trie to `w` as a CSV record of
+// two fields, in ascending key order. Fields are quoted when needed.
+//
+// Keys are rebuilt the same way as Iter does.
+//
+// Since 0.2.0
+func (r *Node) WriteCSV(w io.Writer, opts ...CSVOption) error {
+
+	o := newCSVOptions(opts)
+
+	cw := csv.NewWriter(w)
+	cw.Comma = o.comma
+
+	if o.header {
+		if err := cw.Write([]string{"key", "value"}); err != nil {
+			return err
+		}
+	}
+
+	it := r.NewIter(o.iter...)
+	for it.Next() {
+		v, err := o.format(it.Value())
+		if err != nil {
+			return errors.Wrapf(err, "format value of %q", it.Key())
+		}
+		if err := cw.Write([]string{string(it.Key()), v}); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
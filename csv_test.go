@@ -0,0 +1,86 @@
+package trie
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/openacid/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadCSV(t *testing.T) {
+
+	ta := require.New(t)
+
+	input := "id,name,score\n" +
+		"3,\"b, c\",30\n" +
+		"1,a,10\n" +
+		"2,\"say \"\"hi\"\"\",20\n"
+
+	parseInt := func(s string) (interface{}, error) {
+		return strconv.Atoi(s)
+	}
+
+	tr, err := ReadCSV(strings.NewReader(input), 1, 2, WithHeader(), WithValueParser(parseInt))
+	ta.Nil(err)
+
+	keys, values := iterAll(tr.NewIter())
+	ta.Equal([]string{"a", "b, c", "say \"hi\""}, keys)
+	ta.Equal([]interface{}{10, 30, 20}, values)
+
+	var buf bytes.Buffer
+	ta.Nil(tr.WriteCSV(&buf, WithHeader()))
+	ta.Equal("key,value\na,10\n\"b, c\",30\n\"say \"\"hi\"\"\",20\n", buf.String())
+
+	buf.Reset()
+	hex := func(v interface{}) (string, error) {
+		return strconv.FormatInt(int64(v.(int)), 16), nil
+	}
+	ta.Nil(tr.WriteCSV(&buf, WithComma('\t'), WithValueFormatter(hex)))
+	ta.Equal("a\ta\nb, c\t1e\n\"say \"\"hi\"\"\"\t14\n", buf.String())
+
+	// Round trip.
+	tr2, err := ReadCSV(&buf, 0, 1, WithComma('\t'))
+	ta.Nil(err)
+	keys, values = iterAll(tr2.NewIter())
+	ta.Equal([]string{"a", "b, c", "say \"hi\""}, keys)
+	ta.Equal([]interface{}{"a", "1e", "14"}, values)
+}
+
+func TestReadCSV_error(t *testing.T) {
+
+	ta := require.New(t)
+
+	cases := []struct {
+		input   string
+		wanterr error
+	}{
+		{"a,1\na,2\n", ErrDuplicateKeys},
+		{"a,1\nb\n", nil},
+		{"a,\"1\n", nil},
+		{"a,x\n", strconv.ErrSyntax},
+	}
+
+	parseInt := func(s string) (interface{}, error) {
+		_, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, err.(*strconv.NumError).Err
+		}
+		return s, nil
+	}
+
+	for i, c := range cases {
+		_, err := ReadCSV(strings.NewReader(c.input), 0, 1, WithValueParser(parseInt))
+		ta.NotNil(err, "%d-th", i+1)
+		if c.wanterr != nil {
+			ta.Equal(c.wanterr, errors.Cause(err), "%d-th", i+1)
+		}
+	}
+
+	for _, cols := range [][2]int{{-1, 1}, {0, -1}} {
+		_, err := ReadCSV(strings.NewReader("a,1\n"), cols[0], cols[1])
+		ta.NotNil(err, "%v", cols)
+	}
+}
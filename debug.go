@@ -0,0 +1,183 @@
+package trie
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/openacid/errors"
+)
+
+// debugHandler serves the pages of DebugHandler on the version returned by
+// load.
+type debugHandler struct {
+	load func() *Node
+}
+
+// debugEntry is a key and its value in the output of DebugHandler.
+type debugEntry struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+const (
+	debugMaxDepth    = 4
+	debugMaxChildren = 32
+	debugLimit       = 100
+)
+
+// DebugHandler returns an http.Handler to inspect the trie from a browser or
+// curl, e.g., in a live process. Like net/http/pprof, it can be mounted at any
+// path of a debug mux, and serves pages by the last element of the request
+// path:
+//
+//	stats                 Counters as JSON; with memory=1 also the
+//	                      MemoryBreakdown, which visits every node.
+//	tree?prefix=p         the subtree under p in the form of StringWith,
+//	                      limited by depth=4 and children=32 by default.
+//	get?key=k             the value of k as JSON.
+//	prefix?prefix=p       the keys starting with p and their values as JSON,
+//	                      at most limit=100 by default.
+//
+// Any other path is an index of the pages. Values are formatted with fmt
+// "%v". Unlike Get, a query never loads, expires or otherwise modifies a key.
+//
+// The handler reads r without locking, thus r must not be modified while it
+// is in use; use SyncTrie.DebugHandler for a trie being modified.
+//
+// Since 0.2.0
+func (r *Node) DebugHandler() http.Handler {
+	return &debugHandler{load: func() *Node { return r }}
+}
+
+// DebugHandler is the same as Node.DebugHandler, on the current version on
+// every request.
+//
+// Since 0.2.0
+func (s *SyncTrie) DebugHandler() http.Handler {
+	return &debugHandler{load: s.Load}
+}
+
+func (h *debugHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+
+	r := h.load()
+	q := req.URL.Query()
+
+	switch path.Base(req.URL.Path) {
+	case "stats":
+		rst := map[string]interface{}{"counters": r.Counters()}
+		if q.Get("memory") == "1" {
+			rst["memory"] = r.MemoryBreakdown()
+		}
+		writeDebugJSON(w, rst)
+
+	case "tree":
+		depth, err := debugInt(q.Get("depth"), debugMaxDepth)
+		if err != nil {
+			http.Error(w, "depth: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		children, err := debugInt(q.Get("children"), debugMaxChildren)
+		if err != nil {
+			http.Error(w, "children: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		sub, _ := r.subtree(r.normalize([]byte(q.Get("prefix"))))
+		if sub == nil {
+			http.Error(w, "no key starts with the prefix", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintln(w, sub.StringWith(
+			WithMaxDepth(depth),
+			WithMaxChildren(children),
+			WithKeyCounts(),
+			WithLabelStyle(r.labelStyle(LabelPrintable))))
+
+	case "get":
+		rst := map[string]interface{}{"found": false}
+		if len(r.Branches) > 0 {
+			leaf := r.leafOf(r.normalize([]byte(q.Get("key"))))
+			if leaf != nil && leaf.Value != removed {
+				rst["found"] = true
+				rst["value"] = fmt.Sprintf("%v", leaf.Value)
+			}
+		}
+		writeDebugJSON(w, rst)
+
+	case "prefix":
+		limit, err := debugInt(q.Get("limit"), debugLimit)
+		if err != nil {
+			http.Error(w, "limit: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeDebugJSON(w, r.debugPrefix(r.normalize([]byte(q.Get("prefix"))), limit))
+
+	default:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprint(w, "stats?memory=1\ntree?prefix=&depth=&children=\nget?key=\nprefix?prefix=&limit=\n")
+	}
+}
+
+// debugPrefix returns at most `limit` keys starting with a normalized
+// `prefix` and their values, in ascending key order.
+func (r *Node) debugPrefix(prefix []byte, limit int) []debugEntry {
+
+	rst := []debugEntry{}
+
+	sub, key := r.subtree(prefix)
+	if sub == nil {
+		return rst
+	}
+
+	var walk func(node *Node, key []byte)
+	walk = func(node *Node, key []byte) {
+		for _, b := range node.Branches {
+			if len(rst) >= limit {
+				return
+			}
+			child := node.Children[b]
+			if b == leafBranch {
+				if child.Value != removed {
+					k := key
+					if child.key() != nil {
+						k = child.key()
+					}
+					rst = append(rst, debugEntry{Key: string(k), Value: fmt.Sprintf("%v", child.Value)})
+				}
+				continue
+			}
+			walk(child, append(key, byte(b)))
+		}
+	}
+	walk(sub, key)
+
+	return rst
+}
+
+// debugInt parses a positive int query parameter, which is `dflt` if it is
+// empty.
+func debugInt(s string, dflt int) (int, error) {
+	if s == "" {
+		return dflt, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err == nil && n <= 0 {
+		return 0, errors.Errorf("%d is not positive", n)
+	}
+	return n, err
+}
+
+func writeDebugJSON(w http.ResponseWriter, v interface{}) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	// Everything is of plain types, thus encoding does not fail, except for
+	// writing.
+	_ = enc.Encode(v)
+}
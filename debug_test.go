@@ -0,0 +1,106 @@
+package trie
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNode_DebugHandler(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := [][]byte{[]byte("ab"), []byte("abc"), []byte("abd"), []byte("b")}
+	tr, err := NewTrie(keys, []int{1, 2, 3, 4}, false, WithLoader(func(key []byte) (interface{}, bool) {
+		return 0, true
+	}))
+	ta.Nil(err)
+
+	mux := http.NewServeMux()
+	mux.Handle("/debug/trie/", tr.DebugHandler())
+
+	get := func(url string) (int, string) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", url, nil))
+		body, err := ioutil.ReadAll(rec.Body)
+		ta.Nil(err)
+		return rec.Code, string(body)
+	}
+	getJSON := func(url string, v interface{}) {
+		code, body := get(url)
+		ta.Equal(http.StatusOK, code, url)
+		ta.Nil(json.Unmarshal([]byte(body), v), url)
+	}
+
+	var stats struct {
+		Counters Counters
+		Memory   *MemoryBreakdown
+	}
+	getJSON("/debug/trie/stats", &stats)
+	ta.Equal(tr.Counters(), stats.Counters)
+	ta.Nil(stats.Memory)
+	getJSON("/debug/trie/stats?memory=1", &stats)
+	ta.Equal(tr.MemoryBreakdown(), *stats.Memory)
+
+	code, body := get("/debug/trie/tree?prefix=ab")
+	ta.Equal(http.StatusOK, code)
+	ta.Equal(tr.Children['a'].Children['b'].StringWith(WithKeyCounts(), WithLabelStyle(LabelPrintable))+"\n", body)
+
+	code, body = get("/debug/trie/tree?depth=1&children=1")
+	ta.Equal(http.StatusOK, code)
+	ta.Equal("*2(4 keys)\n-a->(3 keys)\n    ...\n...(1 more)\n", body)
+
+	code, _ = get("/debug/trie/tree?prefix=x")
+	ta.Equal(http.StatusNotFound, code)
+	code, _ = get("/debug/trie/tree?depth=0")
+	ta.Equal(http.StatusBadRequest, code)
+
+	var found map[string]interface{}
+	getJSON("/debug/trie/get?key=abc", &found)
+	ta.Equal(map[string]interface{}{"found": true, "value": "2"}, found)
+
+	// Not loaded.
+	found = nil
+	getJSON("/debug/trie/get?key=x", &found)
+	ta.Equal(map[string]interface{}{"found": false}, found)
+	ta.Equal(4, tr.KeyCnt())
+
+	var entries []debugEntry
+	getJSON("/debug/trie/prefix?prefix=ab", &entries)
+	ta.Equal([]debugEntry{{"ab", "1"}, {"abc", "2"}, {"abd", "3"}}, entries)
+	getJSON("/debug/trie/prefix?limit=2", &entries)
+	ta.Equal([]debugEntry{{"ab", "1"}, {"abc", "2"}}, entries)
+	getJSON("/debug/trie/prefix?prefix=x", &entries)
+	ta.Equal([]debugEntry{}, entries)
+	code, _ = get("/debug/trie/prefix?limit=x")
+	ta.Equal(http.StatusBadRequest, code)
+
+	code, body = get("/debug/trie/")
+	ta.Equal(http.StatusOK, code)
+	ta.Contains(body, "stats")
+}
+
+func TestSyncTrie_DebugHandler(t *testing.T) {
+
+	ta := require.New(t)
+
+	s, err := NewSyncTrie(nil, nil, false)
+	ta.Nil(err)
+	h := s.DebugHandler()
+
+	count := func() int {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "/stats", nil))
+		var stats struct{ Counters Counters }
+		ta.Nil(json.Unmarshal(rec.Body.Bytes(), &stats))
+		return stats.Counters.Keys
+	}
+
+	ta.Equal(0, count())
+	ta.Nil(s.Set([]byte("a"), 1))
+	ta.Equal(1, count())
+}
@@ -0,0 +1,65 @@
+package trie
+
+// WalkDepth calls `fn` with every node of the trie, inner nodes and leaves,
+// and its depth, in depth first order, parents before children and branches
+// in order. If `fn` returns false, the children of the node are skipped.
+//
+// The depth of a node is the number of key bytes consumed from the root to it:
+// the sum of Step of the nodes from the root to it, minus one, since the
+// branches of the root are labeled with the first byte of keys. The branches
+// of a node of depth d are labeled with the byte at index d of keys, and a
+// leaf has the depth of the length of its key, including the bytes removed by
+// squashing.
+//
+// It does not recurse, thus it does not overflow the stack on deep tries.
+//
+// Since 0.2.0
+func (r *Node) WalkDepth(fn func(n *Node, depth int) bool) {
+
+	type frame struct {
+		n     *Node
+		depth int
+	}
+
+	stack := []frame{{n: r, depth: int(r.Step) - 1}}
+	for len(stack) > 0 {
+		f := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if !fn(f.n, f.depth) {
+			continue
+		}
+
+		brs := f.n.Branches
+		for i := len(brs) - 1; i >= 0; i-- {
+			child := f.n.Children[brs[i]]
+			d := f.depth
+			if brs[i] != leafBranch {
+				d += int(child.Step)
+			}
+			stack = append(stack, frame{n: child, depth: d})
+		}
+	}
+}
+
+// DepthOf returns the depth of node `n` in the trie, see WalkDepth, and
+// whether `n` is found in it, e.g., the leaf returned by Append.
+//
+// It searches the whole trie, thus takes O(n) time for a trie of n nodes.
+// To get the depth of many nodes, use WalkDepth.
+//
+// Since 0.2.0
+func (r *Node) DepthOf(n *Node) (depth int, found bool) {
+
+	r.WalkDepth(func(node *Node, d int) bool {
+		if found {
+			return false
+		}
+		if node == n {
+			depth, found = d, true
+			return false
+		}
+		return true
+	})
+	return
+}
@@ -0,0 +1,51 @@
+package trie
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNode_WalkDepth(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := []string{"a", "abcd", "abce", "b"}
+
+	for _, squash := range []bool{false, true} {
+		tr := newStrTrie(ta, squash, keys...)
+
+		// Every leaf has the depth of its key length.
+		leaves := map[string]int{}
+		inner := 0
+		tr.WalkDepth(func(n *Node, depth int) bool {
+			if n.Children == nil {
+				leaves[n.Value.(string)] = depth
+			} else {
+				inner++
+			}
+			return true
+		})
+		ta.Equal(map[string]int{"a": 1, "abcd": 4, "abce": 4, "b": 1}, leaves, "squash: %v", squash)
+		ta.Equal(tr.innerNodeCnt(), inner, "squash: %v", squash)
+
+		for _, k := range keys {
+			leaf := tr.leafOf([]byte(k))
+			d, found := tr.DepthOf(leaf)
+			ta.True(found)
+			ta.Equal(len(k), d, "squash: %v, key: %q", squash, k)
+		}
+
+		_, found := tr.DepthOf(&Node{})
+		ta.False(found)
+	}
+
+	tr := newStrTrie(ta, true, keys...)
+	var depths []int
+	tr.WalkDepth(func(n *Node, depth int) bool {
+		depths = append(depths, depth)
+		return depth < 1
+	})
+	// The root, "a" (squashed "ab" is skipped) and "b".
+	ta.Equal([]int{0, 1, 1}, depths)
+}
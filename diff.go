@@ -0,0 +1,218 @@
+package trie
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"reflect"
+
+	"github.com/openacid/errors"
+)
+
+// Change is a difference of one key between two tries.
+//
+// Since 0.2.0
+type Change struct {
+	// Key is the changed key.
+	Key []byte
+
+	// Value is the new value of Key. It is nil if Removed is true.
+	Value interface{}
+
+	// Removed is true if Key is removed.
+	Removed bool
+}
+
+// Patch is a list of changes in ascending key order, that turns one trie into
+// another.
+//
+// Since 0.2.0
+type Patch []Change
+
+// Diff returns the changes that turn `from` into `to`: keys added to or
+// updated in `to`, and keys removed from `from`. Values are compared with
+// reflect.DeepEqual.
+//
+// Keys are rebuilt the same way as Iter does, thus to diff squashed tries
+// is only meaningful if they are built from the same keys.
+//
+// Since 0.2.0
+func Diff(from, to *Node) Patch {
+
+	p := Patch{}
+
+	a, b := from.NewIter(), to.NewIter()
+	hasA, hasB := a.Next(), b.Next()
+
+	for hasA || hasB {
+		var c int
+		switch {
+		case !hasA:
+			c = 1
+		case !hasB:
+			c = -1
+		default:
+			c = from.byteOrder().compare(a.Key(), b.Key())
+		}
+
+		switch {
+		case c < 0:
+			p = append(p, Change{Key: copyBytes(a.Key()), Removed: true})
+			hasA = a.Next()
+		case c > 0:
+			p = append(p, Change{Key: copyBytes(b.Key()), Value: b.Value()})
+			hasB = b.Next()
+		default:
+			if !reflect.DeepEqual(a.Value(), b.Value()) {
+				p = append(p, Change{Key: copyBytes(b.Key()), Value: b.Value()})
+			}
+			hasA, hasB = a.Next(), b.Next()
+		}
+	}
+
+	return p
+}
+
+// ApplyPatch applies the changes in `p` to the trie with Set and Remove.
+// Removing a key not in the trie is not an error.
+//
+// It stops at the first error, e.g., ErrSquashed if a key is set into a
+// squashed branch.
+//
+// Since 0.2.0
+func (r *Node) ApplyPatch(p Patch) error {
+
+	for _, c := range p {
+		if c.Removed {
+			r.Remove(c.Key)
+			continue
+		}
+		if _, err := r.Set(c.Key, c.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// patchVersion is the first byte of an encoded Patch.
+const patchVersion = 1
+
+// Encode writes the binary form of `p` to `w`. Values are encoded with `enc`.
+//
+// The format is a version byte and a varint number of changes, followed by
+// every change: a byte of 1 if it is a removal, or 0 followed by the varint
+// length of the encoded value and the value; then the varint length of the key
+// and the key.
+// A varint is an unsigned 64-bit integer in the form of binary.PutUvarint,
+// thus the output only depends on `p` and `enc`, on any architecture.
+//
+// Since 0.2.0
+func (p Patch) Encode(w io.Writer, enc func(v interface{}) ([]byte, error)) error {
+
+	bw := bufio.NewWriter(w)
+	buf := make([]byte, binary.MaxVarintLen64)
+
+	writeUvarint := func(v uint64) {
+		n := binary.PutUvarint(buf, v)
+		bw.Write(buf[:n])
+	}
+
+	bw.WriteByte(patchVersion)
+	writeUvarint(uint64(len(p)))
+
+	for _, c := range p {
+		if c.Removed {
+			bw.WriteByte(1)
+		} else {
+			v, err := enc(c.Value)
+			if err != nil {
+				return errors.Wrapf(err, "encode value of %q", c.Key)
+			}
+			bw.WriteByte(0)
+			writeUvarint(uint64(len(v)))
+			bw.Write(v)
+		}
+		writeUvarint(uint64(len(c.Key)))
+		bw.Write(c.Key)
+	}
+
+	// bufio.Writer keeps the first write error.
+	return bw.Flush()
+}
+
+// DecodePatch reads a Patch written by Patch.Encode. Values are decoded with
+// `dec`.
+//
+// Since 0.2.0
+func DecodePatch(r io.Reader, dec func(b []byte) (interface{}, error)) (Patch, error) {
+
+	br := bufio.NewReader(r)
+
+	ver, err := br.ReadByte()
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidPatch, err.Error())
+	}
+	if ver != patchVersion {
+		return nil, errors.Wrapf(ErrInvalidPatch, "unknown version %d", ver)
+	}
+
+	readBytes := func() ([]byte, error) {
+		n, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, err
+		}
+		// Do not trust n to allocate: a malformed patch may claim a huge
+		// length.
+		var b bytes.Buffer
+		_, err = io.CopyN(&b, br, int64(n))
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return b.Bytes(), err
+	}
+
+	cnt, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidPatch, err.Error())
+	}
+
+	p := Patch{}
+	for i := uint64(0); i < cnt; i++ {
+		var c Change
+
+		flag, err := br.ReadByte()
+		if err != nil {
+			return nil, errors.Wrap(ErrInvalidPatch, err.Error())
+		}
+
+		switch flag {
+		case 1:
+			c.Removed = true
+		case 0:
+			v, err := readBytes()
+			if err != nil {
+				return nil, errors.Wrap(ErrInvalidPatch, err.Error())
+			}
+			c.Value, err = dec(v)
+			if err != nil {
+				return nil, errors.Wrapf(err, "decode value of %d-th change", i)
+			}
+		default:
+			return nil, errors.Wrapf(ErrInvalidPatch, "unknown flag %d", flag)
+		}
+
+		c.Key, err = readBytes()
+		if err != nil {
+			return nil, errors.Wrap(ErrInvalidPatch, err.Error())
+		}
+
+		p = append(p, c)
+	}
+
+	return p, nil
+}
+
+func copyBytes(b []byte) []byte {
+	return append([]byte{}, b...)
+}
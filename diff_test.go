@@ -0,0 +1,117 @@
+package trie
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/openacid/errors"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	strEnc = func(v interface{}) ([]byte, error) { return []byte(v.(string)), nil }
+	strDec = func(b []byte) (interface{}, error) { return string(b), nil }
+)
+
+func TestDiff(t *testing.T) {
+
+	ta := require.New(t)
+
+	from := newStrTrie(ta, false, "a", "ab", "b", "bc")
+	to := newStrTrie(ta, false, "", "ab", "bc", "c")
+	_, err := to.Set([]byte("ab"), "AB")
+	ta.Nil(err)
+
+	p := Diff(from, to)
+	ta.Equal(Patch{
+		{Key: []byte(""), Value: ""},
+		{Key: []byte("a"), Removed: true},
+		{Key: []byte("ab"), Value: "AB"},
+		{Key: []byte("b"), Removed: true},
+		{Key: []byte("c"), Value: "c"},
+	}, p)
+
+	ta.Equal(Patch{}, Diff(to, to))
+
+	ta.Nil(from.ApplyPatch(p))
+	ta.Equal(Patch{}, Diff(from, to))
+}
+
+func TestPatch_Encode(t *testing.T) {
+
+	ta := require.New(t)
+
+	p := Patch{
+		{Key: []byte(""), Value: "empty"},
+		{Key: []byte("a"), Removed: true},
+		{Key: []byte("abc"), Value: ""},
+	}
+
+	var buf bytes.Buffer
+	ta.Nil(p.Encode(&buf, strEnc))
+
+	got, err := DecodePatch(bytes.NewReader(buf.Bytes()), strDec)
+	ta.Nil(err)
+	ta.Equal(p, got)
+
+	// Every truncation is invalid.
+	b := buf.Bytes()
+	for i := 0; i < len(b); i++ {
+		_, err := DecodePatch(bytes.NewReader(b[:i]), strDec)
+		ta.Equal(ErrInvalidPatch, errors.Cause(err), "truncated at %d", i)
+	}
+
+	_, err = DecodePatch(strings.NewReader("\x02\x00"), strDec)
+	ta.Equal(ErrInvalidPatch, errors.Cause(err))
+
+	_, err = DecodePatch(strings.NewReader("\x01\x01\x05"), strDec)
+	ta.Equal(ErrInvalidPatch, errors.Cause(err))
+
+	// A huge length does not allocate.
+	_, err = DecodePatch(strings.NewReader("\x01\x01\x00\xff\xff\xff\xff\x0f"), strDec)
+	ta.Equal(ErrInvalidPatch, errors.Cause(err))
+}
+
+func TestPatch_Encode_golden(t *testing.T) {
+
+	ta := require.New(t)
+
+	// Lengths and counts of more than one varint byte.
+	p := Patch{
+		{Key: []byte(""), Value: "empty"},
+		{Key: []byte("a"), Removed: true},
+		{Key: bytes.Repeat([]byte{0xff}, 200), Value: strings.Repeat("v", 300)},
+	}
+	for i := 0; i < 130; i++ {
+		p = append(p, Change{Key: []byte{'k', byte(i)}, Value: ""})
+	}
+
+	var buf bytes.Buffer
+	ta.Nil(p.Encode(&buf, strEnc))
+
+	name := "testdata/patch/golden.bin"
+	if *update {
+		ta.Nil(ioutil.WriteFile(name, buf.Bytes(), 0644))
+	}
+
+	want, err := ioutil.ReadFile(name)
+	ta.Nil(err)
+	ta.Equal(want, buf.Bytes())
+
+	got, err := DecodePatch(bytes.NewReader(want), strDec)
+	ta.Nil(err)
+	ta.Equal(p, got)
+}
+
+func TestApplyPatch_squashed(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr := newStrTrie(ta, true, "abc", "abd", "xyz")
+	tr.Squash()
+
+	err := tr.ApplyPatch(Patch{{Key: []byte("ab"), Value: "ab"}})
+	ta.Equal(ErrSquashed, errors.Cause(err))
+}
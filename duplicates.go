@@ -0,0 +1,88 @@
+package trie
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WithDuplicateReport makes NewTrie and the constructors built on it go on
+// past duplicate keys, instead of stopping at the first one. Once every key
+// is scanned, it returns a *DuplicateKeysError listing every duplicated key,
+// along with the trie built from the first value of every key.
+//
+// Keys are compared after WithKeyNormalizer. With WithMultiValue there are no
+// duplicates. Keys must still be ascending: a key that duplicates one before
+// the last key added is ErrKeyOutOfOrder.
+//
+// Since 0.2.0
+func WithDuplicateReport() Option {
+	return func(o *options) {
+		o.duplicateReport = true
+	}
+}
+
+// DuplicateKey is a key given more than once to build a trie.
+//
+// Since 0.2.0
+type DuplicateKey struct {
+	// Key is the key as it is given at Indexes[1], the first duplicate. It
+	// differs from that at Indexes[0] only if they are normalized the same.
+	Key []byte
+	// Indexes are the indexes in the input of every occurrence of Key,
+	// ascending. The first one is the one in the trie.
+	Indexes []int
+}
+
+// DuplicateKeysError is returned by a build WithDuplicateReport if there are
+// duplicate keys.
+// Its Cause is ErrDuplicateKeys.
+//
+// Since 0.2.0
+type DuplicateKeysError struct {
+	// Duplicates are the duplicated keys in input order.
+	Duplicates []DuplicateKey
+}
+
+// Error implements error. It tells at most 10 duplicated keys.
+//
+// Since 0.2.0
+func (e *DuplicateKeysError) Error() string {
+
+	const max = 10
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %d keys duplicated:", ErrDuplicateKeys, len(e.Duplicates))
+	for i, d := range e.Duplicates {
+		if i == max {
+			b.WriteString(" ...")
+			break
+		}
+		fmt.Fprintf(&b, " %q at %v", d.Key, d.Indexes)
+	}
+	return b.String()
+}
+
+// Cause returns ErrDuplicateKeys.
+//
+// Since 0.2.0
+func (e *DuplicateKeysError) Cause() error {
+	return ErrDuplicateKeys
+}
+
+// add records that the key at `i` duplicates the key at `prev`, the last key
+// added. Duplicates are adjacent since keys are ascending.
+func (e *DuplicateKeysError) add(key []byte, prev, i int) {
+
+	if l := len(e.Duplicates); l > 0 {
+		d := &e.Duplicates[l-1]
+		if d.Indexes[0] == prev {
+			d.Indexes = append(d.Indexes, i)
+			return
+		}
+	}
+
+	e.Duplicates = append(e.Duplicates, DuplicateKey{
+		Key:     copyBytes(key),
+		Indexes: []int{prev, i},
+	})
+}
@@ -0,0 +1,80 @@
+package trie
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/openacid/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithDuplicateReport(t *testing.T) {
+
+	ta := require.New(t)
+
+	strs := []string{"a", "a", "b", "c", "c", "c", "d", "e", "e"}
+	keys := make([][]byte, len(strs))
+	values := make([]int, len(strs))
+	for i, s := range strs {
+		keys[i] = []byte(s)
+		values[i] = i
+	}
+
+	_, err := NewTrie(keys, values, false)
+	ta.Equal(ErrDuplicateKeys, errors.Cause(err))
+
+	for _, squash := range []bool{false, true} {
+		tr, err := NewTrie(keys, values, squash, WithDuplicateReport())
+		ta.Equal(ErrDuplicateKeys, errors.Cause(err))
+
+		de := err.(*DuplicateKeysError)
+		ta.Equal([]DuplicateKey{
+			{Key: []byte("a"), Indexes: []int{0, 1}},
+			{Key: []byte("c"), Indexes: []int{3, 4, 5}},
+			{Key: []byte("e"), Indexes: []int{7, 8}},
+		}, de.Duplicates)
+		ta.Equal(`keys can not be duplicate: 3 keys duplicated: "a" at [0 1] "c" at [3 4 5] "e" at [7 8]`, de.Error())
+
+		// The first value of every key is kept.
+		ta.Equal(5, tr.KeyCnt())
+		for _, c := range []struct {
+			key  string
+			want int
+		}{{"a", 0}, {"b", 2}, {"c", 3}, {"d", 6}, {"e", 7}} {
+			_, eq, _ := tr.Search([]byte(c.key))
+			ta.Equal(c.want, eq, "squash=%v key=%s", squash, c.key)
+		}
+		ta.Nil(tr.Validate())
+	}
+
+	// Without duplicates.
+	tr, err := NewTrie(keys[1:3], values[1:3], false, WithDuplicateReport())
+	ta.Nil(err)
+	ta.Equal(2, tr.KeyCnt())
+
+	// Other errors still stop the build.
+	_, err = NewTrie([][]byte{[]byte("b"), []byte("b"), []byte("a")}, []int{1, 2, 3}, false, WithDuplicateReport())
+	ta.Equal(ErrKeyOutOfOrder, errors.Cause(err))
+
+	// A duplicate of a key before the last one is out of order.
+	for _, squash := range []bool{false, true} {
+		_, err = NewTrie([][]byte{[]byte("a"), []byte("b"), []byte("a")}, []int{1, 2, 3}, squash, WithDuplicateReport())
+		ta.Equal(ErrKeyOutOfOrder, errors.Cause(err), "squash=%v", squash)
+	}
+
+	// Keys reusing a buffer and duplicated after normalization.
+	buf := []byte{}
+	tr, err = NewTrieFromFunc(func(i int) ([]byte, interface{}, bool) {
+		buf = append(buf[:0], fmt.Sprintf("K%02d", i/2)...)
+		if i%2 == 1 {
+			buf = bytes.ToLower(buf)
+		}
+		return buf, i, i < 30
+	}, false, WithDuplicateReport(), WithKeyNormalizer(bytes.ToUpper))
+	de := err.(*DuplicateKeysError)
+	ta.Equal(15, len(de.Duplicates))
+	ta.Equal(DuplicateKey{Key: []byte("k14"), Indexes: []int{28, 29}}, de.Duplicates[14])
+	ta.Contains(de.Error(), `"k09" at [18 19] ...`)
+	ta.Equal(15, tr.KeyCnt())
+}
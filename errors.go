@@ -12,4 +12,66 @@ var (
 
 	// ErrKeyOutOfOrder means keys to create Trie are not ascendingly ordered.
 	ErrKeyOutOfOrder = errors.New("keys not ascending sorted")
+
+	// ErrSquashed means a key can not be appended because the branch it belongs
+	// to has already been squashed, e.g., after a full Squash().
+	ErrSquashed = errors.New("can not append into a squashed branch")
+
+	// ErrInvalidPatch means an encoded Patch is malformed or of an unknown
+	// version.
+	ErrInvalidPatch = errors.New("invalid patch")
+
+	// ErrReadOnly means a trie can not be modified, e.g., its nodes are shared
+	// by WithHashConsing.
+	ErrReadOnly = errors.New("trie is read only")
+
+	// ErrValueNotFound means a ValueStore has no value of a handle.
+	ErrValueNotFound = errors.New("value not found")
+
+	// ErrInvalidGramSize means the n of an n-gram index is less than 1.
+	ErrInvalidGramSize = errors.New("n-gram size must be positive")
+
+	// ErrInvalidInterval means the lower bound of an interval is greater than
+	// the upper bound.
+	ErrInvalidInterval = errors.New("interval lower bound greater than upper bound")
+
+	// ErrInvalidFixedLayout means data is not a trie in the fixed layout of
+	// ExportFixed, or a trie can not be exported to it.
+	ErrInvalidFixedLayout = errors.New("invalid fixed layout")
+
+	// ErrInvalidOp means an Op to Apply is of an unknown type.
+	ErrInvalidOp = errors.New("invalid op type")
+
+	// ErrVersionConflict means the version of a key is not the expected one,
+	// see VersionConflictError.
+	ErrVersionConflict = errors.New("version conflict")
+
+	// ErrTxDone means a Tx is used after it is committed or rolled back.
+	ErrTxDone = errors.New("transaction already committed or rolled back")
+
+	// ErrInvalidTrie means the structure or counters of a trie are
+	// inconsistent, see Validate.
+	ErrInvalidTrie = errors.New("invalid trie")
+
+	// ErrNegativeSymbol means a key of a SymbolTrie has a negative symbol.
+	ErrNegativeSymbol = errors.New("symbol must not be negative")
+
+	// ErrValuesNotSlice means the values to create a trie are not a slice,
+	// see ValuesNotSliceError.
+	ErrValuesNotSlice = errors.New("values must be a slice")
+
+	// ErrMemoryLimit means a build exceeds WithMaxMemory, see
+	// MemoryLimitError.
+	ErrMemoryLimit = errors.New("memory limit exceeded")
+
+	// ErrKeyTooLong means a key is longer than WithMaxKeyLen, see
+	// KeyLimitError.
+	ErrKeyTooLong = errors.New("key too long")
+
+	// ErrTrieTooDeep means a key would make a trie deeper than
+	// WithMaxTrieDepth, see KeyLimitError.
+	ErrTrieTooDeep = errors.New("trie too deep")
+
+	// ErrInvalidGoName means a name for GenerateGo is not a Go identifier.
+	ErrInvalidGoName = errors.New("invalid go identifier")
 )
@@ -0,0 +1,495 @@
+package trie
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/openacid/errors"
+)
+
+// ExternalBuilder builds a trie of more keys than fit in memory into the
+// fixed layout, see ExportFixed, which is queried in place with FixedReader,
+// e.g., from a memory-mapped file.
+//
+// Keys are added in any order. Once the buffered keys and encoded values
+// reach the memory limit, they are sorted and spilled to a temporary file of
+// sorted records. Finish merges all of the files and writes the trie without
+// building it in memory: only the nodes along the current key are kept, thus
+// the memory used is bounded by the limit, and by the length of keys times
+// the fan-out while merging.
+//
+// The merged keys are read twice: once to lay out the nodes and once to write
+// them. Values are encoded when they are added, and are written to another
+// temporary file until all nodes are written. Unlike ExportFixed, children
+// are written before their parent and values in key order, which readers do
+// not rely on.
+//
+// The fixed layout stores 32-bit offsets, thus the result must be less than
+// 4GB, or Finish returns ErrInvalidFixedLayout.
+//
+// Since 0.2.0
+type ExternalBuilder struct {
+	dir      string
+	memLimit int
+	squash   bool
+	enc      func(v interface{}) ([]byte, error)
+	policy   MergePolicy
+
+	chunk []externalKV
+	mem   int
+
+	// runs are the spilled files, in the order they are spilled.
+	runs []string
+}
+
+// externalKV is a key and its encoded value.
+type externalKV struct {
+	key   []byte
+	value []byte
+}
+
+// externalKVOverhead is the memory a buffered key costs besides the bytes of
+// the key and value.
+const externalKVOverhead = 48
+
+// NewExternalBuilder creates an ExternalBuilder that spills to temporary
+// files in `dir`, or the default directory for temporary files if it is
+// empty, once the buffered keys and values reach `memLimit` bytes.
+// If `squash` is true, the trie is squashed the same way as Squash does.
+// Values are encoded with `enc`.
+//
+// Since 0.2.0
+func NewExternalBuilder(dir string, memLimit int, squash bool, enc func(v interface{}) ([]byte, error)) *ExternalBuilder {
+	return &ExternalBuilder{
+		dir:      dir,
+		memLimit: memLimit,
+		squash:   squash,
+		enc:      enc,
+		policy:   FirstWins,
+	}
+}
+
+// Policy sets the MergePolicy of a key added more than once, which is
+// FirstWins by default. Values passed to it are encoded ones, of type
+// []byte, and it must return one of type []byte.
+// It must be called before the first Add.
+//
+// Since 0.2.0
+func (b *ExternalBuilder) Policy(p MergePolicy) *ExternalBuilder {
+	b.policy = p
+	return b
+}
+
+// Add adds a key and its value. `key` is copied, and can be modified
+// afterwards.
+//
+// Since 0.2.0
+func (b *ExternalBuilder) Add(key []byte, value interface{}) error {
+
+	v, err := b.enc(value)
+	if err != nil {
+		return errors.Wrapf(err, "encode value of %q", key)
+	}
+
+	b.chunk = append(b.chunk, externalKV{key: copyBytes(key), value: v})
+	b.mem += len(key) + len(v) + externalKVOverhead
+
+	if b.mem >= b.memLimit {
+		return b.spill()
+	}
+	return nil
+}
+
+// spill writes the buffered keys to a new run file, sorted, with the values
+// of a key merged by the policy.
+func (b *ExternalBuilder) spill() error {
+
+	if len(b.chunk) == 0 {
+		return nil
+	}
+
+	chunk := b.chunk
+	sort.SliceStable(chunk, func(i, j int) bool {
+		return bytes.Compare(chunk[i].key, chunk[j].key) < 0
+	})
+
+	f, err := ioutil.TempFile(b.dir, "trie-run-")
+	if err != nil {
+		return errors.Wrapf(err, "create run file")
+	}
+	b.runs = append(b.runs, f.Name())
+
+	w := bufio.NewWriter(f)
+	var values []interface{}
+	for i := 0; i < len(chunk); {
+		j := i + 1
+		for j < len(chunk) && bytes.Equal(chunk[j].key, chunk[i].key) {
+			j++
+		}
+
+		v := chunk[i].value
+		if j-i > 1 {
+			values = values[:0]
+			for _, kv := range chunk[i:j] {
+				values = append(values, kv.value)
+			}
+			if v, err = b.merge(chunk[i].key, values); err != nil {
+				f.Close()
+				return err
+			}
+		}
+
+		writeRecord(w, chunk[i].key, v)
+		i = j
+	}
+
+	// bufio.Writer keeps the first write error.
+	err = w.Flush()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errors.Wrapf(err, "write run file")
+	}
+
+	b.chunk = b.chunk[:0]
+	b.mem = 0
+	return nil
+}
+
+// merge returns the value chosen by the policy of a key of several values.
+func (b *ExternalBuilder) merge(key []byte, values []interface{}) ([]byte, error) {
+	v, ok := b.policy(key, values).([]byte)
+	if !ok {
+		return nil, errors.Wrapf(ErrInvalidFixedLayout, "merged value of %q is not []byte", key)
+	}
+	return v, nil
+}
+
+// writeRecord writes a key and value of a run file, each prefixed with its
+// length as a uvarint.
+func writeRecord(w *bufio.Writer, key, value []byte) {
+	buf := make([]byte, binary.MaxVarintLen64)
+	w.Write(buf[:binary.PutUvarint(buf, uint64(len(key)))])
+	w.Write(key)
+	w.Write(buf[:binary.PutUvarint(buf, uint64(len(value)))])
+	w.Write(value)
+}
+
+// Finish writes the trie of all keys added in the fixed layout to `w`, and
+// removes the temporary files. The ExternalBuilder must not be used
+// afterwards.
+//
+// Since 0.2.0
+func (b *ExternalBuilder) Finish(w io.Writer) error {
+
+	defer b.Close()
+
+	if err := b.spill(); err != nil {
+		return err
+	}
+
+	// Lay out nodes to know where values start.
+	layout := &fixedStream{squash: b.squash}
+	if err := b.mergeInto(layout); err != nil {
+		return err
+	}
+
+	vf, err := ioutil.TempFile(b.dir, "trie-values-")
+	if err != nil {
+		return errors.Wrapf(err, "create value file")
+	}
+	defer os.Remove(vf.Name())
+	defer vf.Close()
+
+	bw := bufio.NewWriter(w)
+	out := &fixedStream{
+		squash:  b.squash,
+		w:       bw,
+		vals:    bufio.NewWriter(vf),
+		valBase: layout.off,
+	}
+
+	buf := make([]byte, 4)
+	bw.WriteString(fixedMagic)
+	for _, v := range []uint64{fixedVersion, layout.cnt, layout.root} {
+		binary.LittleEndian.PutUint32(buf, uint32(v))
+		bw.Write(buf)
+	}
+
+	if err := b.mergeInto(out); err != nil {
+		return err
+	}
+	if err := out.vals.Flush(); err != nil {
+		return errors.Wrapf(err, "write value file")
+	}
+
+	if _, err := vf.Seek(0, io.SeekStart); err != nil {
+		return errors.Wrapf(err, "read value file")
+	}
+	if _, err := io.Copy(bw, vf); err != nil {
+		return errors.Wrapf(err, "copy values")
+	}
+	return bw.Flush()
+}
+
+// mergeInto adds the merged keys of all runs to `s`.
+func (b *ExternalBuilder) mergeInto(s *fixedStream) error {
+
+	readers := make([]*runReader, 0, len(b.runs))
+	defer func() {
+		for _, r := range readers {
+			r.f.Close()
+		}
+	}()
+
+	sources := make([]KVSource, 0, len(b.runs))
+	for _, name := range b.runs {
+		f, err := os.Open(name)
+		if err != nil {
+			return errors.Wrapf(err, "open run file")
+		}
+		r := &runReader{f: f, r: bufio.NewReader(f)}
+		readers = append(readers, r)
+		sources = append(sources, r)
+	}
+
+	m := MergeSources(sources...).Policy(b.policy)
+
+	for m.Next() {
+		v, ok := m.Value().([]byte)
+		if !ok {
+			return errors.Wrapf(ErrInvalidFixedLayout, "merged value of %q is not []byte", m.Key())
+		}
+		if err := s.add(m.Key(), v); err != nil {
+			return err
+		}
+	}
+
+	for _, r := range readers {
+		if r.err != nil {
+			return errors.Wrapf(r.err, "read run file")
+		}
+	}
+	return s.finish()
+}
+
+// Close removes the temporary files. It is called by Finish, and is needed
+// only if Finish is not called.
+//
+// Since 0.2.0
+func (b *ExternalBuilder) Close() error {
+
+	var err error
+	for _, name := range b.runs {
+		if e := os.Remove(name); e != nil && err == nil {
+			err = e
+		}
+	}
+	b.runs = nil
+	b.chunk = nil
+	return err
+}
+
+// runReader is a KVSource of a run file.
+type runReader struct {
+	f   *os.File
+	r   *bufio.Reader
+	err error
+
+	key   []byte
+	value []byte
+}
+
+func (r *runReader) Next() bool {
+
+	if r.err != nil {
+		return false
+	}
+
+	var ok bool
+	if r.key, ok = r.read(r.key); !ok {
+		return false
+	}
+	r.value, ok = r.read(nil)
+	return ok
+}
+
+// read reads a length prefixed field into `buf`.
+func (r *runReader) read(buf []byte) ([]byte, bool) {
+
+	l, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		if err != io.EOF {
+			r.err = err
+		}
+		return nil, false
+	}
+
+	buf = append(buf[:0], make([]byte, l)...)
+	if _, err := io.ReadFull(r.r, buf); err != nil {
+		r.err = err
+		return nil, false
+	}
+	return buf, true
+}
+
+func (r *runReader) Key() []byte        { return r.key }
+func (r *runReader) Value() interface{} { return r.value }
+
+// fixedStream writes nodes of ascending keys in the fixed layout, children
+// before parents, or only lays them out if w is nil.
+type fixedStream struct {
+	squash bool
+	w      *bufio.Writer
+
+	// off is the offset of the next node, and cnt is the number of nodes.
+	off uint64
+	cnt uint64
+	// root is the offset of the root, set by finish.
+	root uint64
+
+	// vals receives values, of which the first is at valBase.
+	vals    *bufio.Writer
+	valBase uint64
+	valOff  uint64
+
+	// open are the nodes along the last key: open[d] is of the first d bytes.
+	open []*fixedOpen
+	prev []byte
+	any  bool
+}
+
+// fixedOpen is a node whose keys are not all added yet.
+type fixedOpen struct {
+	label    byte
+	value    uint32
+	children []*fixedPending
+}
+
+// fixedPending is a node whose keys are all added, to be written when its
+// parent is, since it may be squashed into the parent.
+type fixedPending struct {
+	label    byte
+	step     int
+	value    uint32
+	branches []fixedBranch
+}
+
+type fixedBranch struct {
+	label byte
+	off   uint32
+}
+
+func (s *fixedStream) add(key, value []byte) error {
+
+	if s.open == nil {
+		s.off = fixedHeaderSize
+		s.open = []*fixedOpen{{value: fixedNoValue}}
+	}
+
+	if s.any && bytes.Compare(s.prev, key) >= 0 {
+		return errors.Wrapf(ErrKeyOutOfOrder, "add %q", key)
+	}
+
+	p := 0
+	for p < len(s.prev) && p < len(key) && s.prev[p] == key[p] {
+		p++
+	}
+	for len(s.open) > p+1 {
+		s.closeTop()
+	}
+	for _, b := range key[p:] {
+		s.open = append(s.open, &fixedOpen{label: b, value: fixedNoValue})
+	}
+
+	off := s.valBase + s.valOff
+	if off > math.MaxUint32 {
+		return errors.Wrapf(ErrInvalidFixedLayout, "size exceeds 4GB")
+	}
+	s.open[len(s.open)-1].value = uint32(off)
+	s.valOff += 4 + uint64(len(value))
+
+	if s.vals != nil {
+		buf := make([]byte, 4)
+		binary.LittleEndian.PutUint32(buf, uint32(len(value)))
+		s.vals.Write(buf)
+		s.vals.Write(value)
+	}
+
+	s.prev = append(s.prev[:0], key...)
+	s.any = true
+	return nil
+}
+
+// closeTop pops the deepest open node into its parent.
+func (s *fixedStream) closeTop() {
+	top := s.open[len(s.open)-1]
+	s.open = s.open[:len(s.open)-1]
+	parent := s.open[len(s.open)-1]
+	parent.children = append(parent.children, s.close(top))
+}
+
+// close writes the children of `n`, unless n is squashed with its only child.
+// A chain longer than a step can be is split into several nodes.
+func (s *fixedStream) close(n *fixedOpen) *fixedPending {
+
+	if s.squash && n.value == fixedNoValue && len(n.children) == 1 && n.children[0].step < math.MaxUint16 {
+		c := n.children[0]
+		return &fixedPending{label: n.label, step: c.step + 1, value: c.value, branches: c.branches}
+	}
+
+	brs := make([]fixedBranch, len(n.children))
+	for i, c := range n.children {
+		brs[i] = fixedBranch{label: c.label, off: s.write(c)}
+	}
+	return &fixedPending{label: n.label, step: 1, value: n.value, branches: brs}
+}
+
+// write writes node `p` and returns its offset.
+func (s *fixedStream) write(p *fixedPending) uint32 {
+
+	off := s.off
+	s.off += 8 + 5*uint64(len(p.branches))
+	s.cnt++
+
+	if s.w != nil {
+		buf := make([]byte, 4)
+		binary.LittleEndian.PutUint16(buf, uint16(p.step))
+		binary.LittleEndian.PutUint16(buf[2:], uint16(len(p.branches)))
+		s.w.Write(buf)
+		binary.LittleEndian.PutUint32(buf, p.value)
+		s.w.Write(buf)
+		for _, b := range p.branches {
+			s.w.WriteByte(b.label)
+			binary.LittleEndian.PutUint32(buf, b.off)
+			s.w.Write(buf)
+		}
+	}
+	return uint32(off)
+}
+
+// finish writes all open nodes, the root the last.
+func (s *fixedStream) finish() error {
+
+	if s.open == nil {
+		s.off = fixedHeaderSize
+		s.open = []*fixedOpen{{value: fixedNoValue}}
+	}
+
+	for len(s.open) > 1 {
+		s.closeTop()
+	}
+	s.root = uint64(s.write(s.close(s.open[0])))
+
+	if s.off+s.valOff > math.MaxUint32 {
+		return errors.Wrapf(ErrInvalidFixedLayout, "size %d exceeds 4GB", s.off+s.valOff)
+	}
+	return nil
+}
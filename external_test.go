@@ -0,0 +1,154 @@
+package trie
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/openacid/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExternalBuilder(t *testing.T) {
+
+	for _, squash := range []bool{false, true} {
+		for _, memLimit := range []int{1, 200, 1 << 20} {
+			testExternalBuilder(t, squash, memLimit)
+		}
+	}
+}
+
+func testExternalBuilder(t *testing.T, squash bool, memLimit int) {
+
+	ta := require.New(t)
+	msg := fmt.Sprintf("squash=%v memLimit=%d", squash, memLimit)
+
+	dir, err := ioutil.TempDir("", "trie-external-")
+	ta.Nil(err)
+	defer os.RemoveAll(dir)
+
+	rnd := rand.New(rand.NewSource(int64(memLimit)))
+	randKey := func() string {
+		k := make([]byte, rnd.Intn(6))
+		for i := range k {
+			k[i] = "abc"[rnd.Intn(3)]
+		}
+		return string(k)
+	}
+
+	b := NewExternalBuilder(dir, memLimit, squash, strEnc)
+
+	// The first value of a key wins.
+	first := map[string]string{}
+	for i := 0; i < 200; i++ {
+		k := randKey()
+		v := fmt.Sprintf("%s-%d", k, i)
+		if _, ok := first[k]; !ok {
+			first[k] = v
+		}
+		ta.Nil(b.Add([]byte(k), v))
+	}
+
+	var buf bytes.Buffer
+	ta.Nil(b.Finish(&buf), msg)
+
+	files, err := ioutil.ReadDir(dir)
+	ta.Nil(err)
+	ta.Equal(0, len(files), "%s: temporary files removed", msg)
+
+	got, err := NewFixedReader(buf.Bytes())
+	ta.Nil(err, msg)
+	ta.Nil(got.Verify(), msg)
+
+	keys := []string{}
+	for k := range first {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	bs := make([][]byte, len(keys))
+	values := make([]string, len(keys))
+	for i, k := range keys {
+		bs[i] = []byte(k)
+		values[i] = first[k]
+	}
+	tr, err := NewTrie(bs, values, squash)
+	ta.Nil(err)
+	var wantBuf bytes.Buffer
+	ta.Nil(tr.ExportFixed(&wantBuf, strEnc))
+	want, err := NewFixedReader(wantBuf.Bytes())
+	ta.Nil(err)
+
+	ta.Equal(wantBuf.Len(), buf.Len(), "%s: the same nodes and values", msg)
+
+	for i := 0; i < 200; i++ {
+		q := []byte(randKey())
+		wlt, weq, wgt, err := want.Search(q)
+		ta.Nil(err)
+		lt, eq, gt, err := got.Search(q)
+		ta.Nil(err)
+		ta.Equal([][]byte{wlt, weq, wgt}, [][]byte{lt, eq, gt}, "%s: search %q", msg, q)
+	}
+}
+
+func TestExternalBuilder_long(t *testing.T) {
+
+	ta := require.New(t)
+
+	// The shared prefix is longer than a step can be.
+	k1 := bytes.Repeat([]byte("a"), 70001)
+	k2 := append(append([]byte{}, k1[:70000]...), 'b')
+
+	b := NewExternalBuilder("", 10, true, strEnc)
+	ta.Nil(b.Add(k1, "1"))
+	ta.Nil(b.Add(k2, "2"))
+
+	var buf bytes.Buffer
+	ta.Nil(b.Finish(&buf))
+
+	f, err := NewFixedReader(buf.Bytes())
+	ta.Nil(err)
+	ta.Nil(f.Verify())
+
+	lt, eq, gt, err := f.Search(k2)
+	ta.Nil(err)
+	ta.Equal([]string{"1", "2", ""}, []string{string(lt), string(eq), string(gt)})
+}
+
+func TestExternalBuilder_policy(t *testing.T) {
+
+	ta := require.New(t)
+
+	b := NewExternalBuilder("", 1, false, strEnc).Policy(LastWins)
+	for _, kv := range [][2]string{{"b", "1"}, {"a", "2"}, {"b", "3"}, {"", "4"}} {
+		ta.Nil(b.Add([]byte(kv[0]), kv[1]))
+	}
+
+	var buf bytes.Buffer
+	ta.Nil(b.Finish(&buf))
+
+	f, err := NewFixedReader(buf.Bytes())
+	ta.Nil(err)
+	ta.Nil(f.Verify())
+
+	lt, eq, gt, err := f.Search([]byte("b"))
+	ta.Nil(err)
+	ta.Equal([]string{"2", "3", ""}, []string{string(lt), string(eq), string(gt)})
+	_, eq, _, _ = f.Search([]byte(""))
+	ta.Equal("4", string(eq))
+
+	// An empty trie.
+	buf.Reset()
+	ta.Nil(NewExternalBuilder("", 10, true, strEnc).Finish(&buf))
+	f, err = NewFixedReader(buf.Bytes())
+	ta.Nil(err)
+	ta.Nil(f.Verify())
+
+	bad := NewExternalBuilder("", 1, false, strEnc).Policy(func(key []byte, values []interface{}) interface{} { return 1 })
+	ta.Nil(bad.Add([]byte("a"), "1"))
+	ta.Nil(bad.Add([]byte("a"), "2"))
+	ta.Equal(ErrInvalidFixedLayout, errors.Cause(bad.Finish(&buf)))
+}
@@ -0,0 +1,87 @@
+package trie
+
+// WithCachedExtremes makes every inner node keep pointers to the first and last
+// leaves of its subtree, thus Search finds the values of the neighbors of a
+// key in O(1) once it reaches the nodes holding them, instead of descending to
+// the left most or right most leaf of them. It pays off in deep tries, e.g.,
+// of long keys sharing few prefixes.
+//
+// The pointers are two fields of every node, which are nil without it.
+//
+// The pointers are updated along the path of a key on every change, at the
+// same places aggregates of WithAggregate are.
+//
+// Since 0.2.0
+func WithCachedExtremes() Option {
+	return func(o *options) {
+		o.cachedExtremes = true
+	}
+}
+
+func (r *Node) cachedExtremes() bool {
+	return r.opt != nil && r.opt.cachedExtremes
+}
+
+// setExtremes sets the first and last leaves of inner node `n` from its
+// children, which must be up to date.
+func (n *Node) setExtremes() {
+
+	if len(n.Branches) == 0 {
+		if n.innerExt != nil {
+			n.innerExt.first, n.innerExt.last = nil, nil
+		}
+		return
+	}
+
+	e := n.inner()
+	e.first = n.Children[n.Branches[0]].firstLeaf()
+	e.last = n.Children[n.Branches[len(n.Branches)-1]].lastLeaf()
+}
+
+// firstLeaf returns the cached first leaf of `n`, or `n` itself if it is a
+// leaf.
+func (n *Node) firstLeaf() *Node {
+	if n.Children == nil {
+		return n
+	}
+	return n.first()
+}
+
+// lastLeaf is the same as firstLeaf for the last leaf.
+func (n *Node) lastLeaf() *Node {
+	if n.Children == nil {
+		return n
+	}
+	return n.last()
+}
+
+// extremesPath updates the extremes of `path`, a serial of nodes from r
+// downwards, from the deepest one.
+func (r *Node) extremesPath(path []*Node) {
+
+	if !r.cachedExtremes() {
+		return
+	}
+
+	for d := len(path) - 1; d >= 0; d-- {
+		path[d].setExtremes()
+	}
+}
+
+// extremesKey updates the extremes along `key`, which must be a path of nodes
+// that are not squashed.
+func (r *Node) extremesKey(key []byte) {
+
+	if !r.cachedExtremes() {
+		return
+	}
+
+	path := make([]*Node, 0, len(key)+1)
+	node := r
+	path = append(path, node)
+	for _, b := range key {
+		node = node.Children[int(b)]
+		path = append(path, node)
+	}
+	r.extremesPath(path)
+}
@@ -0,0 +1,166 @@
+package trie
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// checkExtremes checks that every inner node caches its first and last leaf.
+func checkExtremes(ta *require.Assertions, n *Node, msg string) {
+
+	if n.Children == nil {
+		return
+	}
+
+	if len(n.Branches) == 0 {
+		ta.Nil(n.first(), msg)
+		ta.Nil(n.last(), msg)
+		return
+	}
+
+	first, last := n, n
+	for len(first.Branches) > 0 {
+		first = first.Children[first.Branches[0]]
+	}
+	for len(last.Branches) > 0 {
+		last = last.Children[last.Branches[len(last.Branches)-1]]
+	}
+	ta.True(first == n.first(), "%s: first of %v", msg, n.Branches)
+	ta.True(last == n.last(), "%s: last of %v", msg, n.Branches)
+
+	for _, b := range n.Branches {
+		checkExtremes(ta, n.Children[b], msg)
+	}
+}
+
+func TestWithCachedExtremes(t *testing.T) {
+
+	for _, squash := range []bool{false, true} {
+		for _, lazy := range []bool{false, true} {
+			msg := fmt.Sprintf("squash=%v lazy=%v", squash, lazy)
+			testCachedExtremes(t, squash, lazy, msg)
+		}
+	}
+}
+
+func testCachedExtremes(t *testing.T, squash, lazy bool, msg string) {
+
+	ta := require.New(t)
+
+	rnd := rand.New(rand.NewSource(7))
+	randKey := func() []byte {
+		k := make([]byte, rnd.Intn(6))
+		for i := range k {
+			k[i] = "abc"[rnd.Intn(3)]
+		}
+		return k
+	}
+
+	uniq := map[string]bool{}
+	for i := 0; i < 50; i++ {
+		uniq[string(randKey())] = true
+	}
+	strs := make([]string, 0, len(uniq))
+	for k := range uniq {
+		strs = append(strs, k)
+	}
+	sort.Strings(strs)
+
+	keys := make([][]byte, len(strs))
+	for i, k := range strs {
+		keys[i] = []byte(k)
+	}
+
+	var opts []Option
+	if lazy {
+		opts = append(opts, WithLazyRemove())
+	}
+
+	ref, err := NewTrie(keys, strs, squash, opts...)
+	ta.Nil(err)
+	tr, err := NewTrie(keys, strs, squash, append(opts, WithCachedExtremes())...)
+	ta.Nil(err)
+
+	check := func(step string, tr, ref *Node) {
+		checkExtremes(ta, tr, msg+" "+step)
+		for i := 0; i < 200; i++ {
+			q := randKey()
+			lt, eq, gt := tr.Search(q)
+			wlt, weq, wgt := ref.Search(q)
+			ta.Equal([]interface{}{wlt, weq, wgt}, []interface{}{lt, eq, gt}, "%s %s: search %q", msg, step, q)
+		}
+	}
+	check("new", tr, ref)
+
+	for i := 0; i < 20; i++ {
+		k := randKey()
+		ta.Equal(ref.Remove(k), tr.Remove(k), "%s: remove %q", msg, k)
+	}
+	check("remove", tr, ref)
+
+	tr.Compact()
+	ref.Compact()
+	check("compact", tr, ref)
+
+	pred := func(key []byte, v interface{}) bool { return len(key)%2 == 0 }
+	check("filter", tr.Filter(pred), ref.Filter(pred))
+
+	if squash {
+		return
+	}
+
+	for i := 0; i < 100; i++ {
+		k := randKey()
+		if rnd.Intn(2) == 0 {
+			_, err := ref.Set(k, string(k)+"'")
+			ta.Nil(err)
+			_, err = tr.Set(k, string(k)+"'")
+			ta.Nil(err)
+		} else {
+			ta.Equal(ref.Remove(k), tr.Remove(k), "%s: remove %q", msg, k)
+		}
+	}
+	check("set and remove", tr, ref)
+
+	cl := tr.Clone()
+	checkExtremes(ta, cl, msg+" clone")
+	_, err = tr.Set([]byte("cccccc"), "x")
+	ta.Nil(err)
+	_, _, gt := cl.Search([]byte("cccc"))
+	ta.NotEqual("x", gt, "clone is not affected")
+
+	ta.Nil(tr.ReplaceSubTrie([]byte("b"), newStrTrie(ta, false, "", "x", "xy")))
+	ta.Nil(ref.ReplaceSubTrie([]byte("b"), newStrTrie(ta, false, "", "x", "xy")))
+	ref.Set([]byte("cccccc"), "x")
+	check("replace", tr, ref)
+}
+
+func TestWithCachedExtremes_sync(t *testing.T) {
+
+	ta := require.New(t)
+
+	s, err := NewSyncTrie([][]byte{[]byte("a"), []byte("ab")}, []string{"a", "ab"}, false, WithCachedExtremes())
+	ta.Nil(err)
+
+	v1 := s.Load()
+	ta.Nil(s.Append([]byte("abc"), "abc"))
+	v2 := s.Load()
+	tx := s.Begin()
+	tx.Remove([]byte("abc"))
+	ta.Nil(tx.Commit())
+
+	checkExtremes(ta, v1, "v1")
+	checkExtremes(ta, v2, "v2")
+	checkExtremes(ta, s.Load(), "v3")
+
+	_, _, gt := v2.Search([]byte(""))
+	ta.Equal("a", gt)
+	lt, _, _ := v2.Search([]byte("b"))
+	ta.Equal("abc", lt)
+	lt, _, _ = s.Search([]byte("b"))
+	ta.Equal("ab", lt)
+}
@@ -0,0 +1,575 @@
+package trie
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sync/atomic"
+
+	"github.com/openacid/errors"
+)
+
+// The fixed layout is a flat, padding-free binary form of a trie, which a
+// reader in any language can query in place, without decoding it first.
+// It is specified in docs/fixed-layout.md:
+//
+// All integers are little-endian. A 16-byte header of the magic "OTRI", the
+// version, the number of nodes and the offset of the root is followed by all
+// nodes, then all values. A node is a u16 step, a u16 number of branches n,
+// the u32 offset of its value or 0xffffffff, and n branches, each of a
+// 1-byte label and the u32 offset of a child. A value is a u32 length
+// followed by the bytes.
+//
+// FixedReader is the reference reader.
+const (
+	fixedMagic      = "OTRI"
+	fixedVersion    = 1
+	fixedHeaderSize = 16
+	fixedNoValue    = math.MaxUint32
+)
+
+// ExportFixed writes the trie in the fixed layout to `w`. Values are encoded
+// with `enc`. Keys removed with WithLazyRemove are not written.
+// Every integer is of an explicit width and byte order, thus the output only
+// depends on the trie and `enc`, on any architecture.
+//
+// A trie WithByteOrder can not be exported, because the fixed layout orders
+// labels by byte value.
+//
+// Since 0.2.0
+func (r *Node) ExportFixed(w io.Writer, enc func(v interface{}) ([]byte, error)) error {
+	return r.exportFixed(nil, w, enc)
+}
+
+// exportFixed is ExportFixed that stops with the error of `c` once `c` is
+// canceled.
+func (r *Node) exportFixed(c *canceler, w io.Writer, enc func(v interface{}) ([]byte, error)) error {
+
+	if r.byteOrder() != nil {
+		return errors.Wrapf(ErrInvalidFixedLayout, "custom byte order")
+	}
+
+	// Assign offsets in depth-first order, then encode values.
+	var nodes []*Node
+	offsets := make(map[*Node]uint64)
+	var values [][]byte
+	valueOf := make(map[*Node]int)
+
+	end := uint64(fixedHeaderSize)
+
+	var walk func(n *Node) error
+	walk = func(n *Node) error {
+		if c.canceled() {
+			return c.err
+		}
+
+		nodes = append(nodes, n)
+		offsets[n] = end
+		end += 8 + 5*uint64(len(fixedBranches(n)))
+
+		if leaf := n.Children[leafBranch]; leaf != nil && leaf.Value != removed {
+			v, err := enc(leaf.Value)
+			if err != nil {
+				return errors.Wrapf(err, "encode value")
+			}
+			valueOf[n] = len(values)
+			values = append(values, v)
+		}
+
+		for _, b := range fixedBranches(n) {
+			if err := walk(n.Children[b]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(r); err != nil {
+		return err
+	}
+
+	valueOffsets := make([]uint64, len(values))
+	for i, v := range values {
+		valueOffsets[i] = end
+		end += 4 + uint64(len(v))
+	}
+	if end > math.MaxUint32 {
+		return errors.Wrapf(ErrInvalidFixedLayout, "size %d exceeds 4GB", end)
+	}
+
+	bw := bufio.NewWriter(w)
+	buf := make([]byte, 4)
+
+	writeU32 := func(v uint64) {
+		binary.LittleEndian.PutUint32(buf, uint32(v))
+		bw.Write(buf)
+	}
+	writeU16 := func(v int) {
+		binary.LittleEndian.PutUint16(buf, uint16(v))
+		bw.Write(buf[:2])
+	}
+
+	bw.WriteString(fixedMagic)
+	writeU32(fixedVersion)
+	writeU32(uint64(len(nodes)))
+	writeU32(offsets[r])
+
+	for _, n := range nodes {
+		if c.canceled() {
+			return c.err
+		}
+
+		brs := fixedBranches(n)
+		_, hasValue := valueOf[n]
+		if n == r && len(brs) == 0 && !hasValue {
+			// The root of a trie without any key, e.g., lazily removed
+			// from a squashed trie, is written as that of an empty trie.
+			writeU16(1)
+		} else {
+			writeU16(int(n.Step))
+		}
+		writeU16(len(brs))
+		if i, ok := valueOf[n]; ok {
+			writeU32(valueOffsets[i])
+		} else {
+			writeU32(fixedNoValue)
+		}
+		for _, b := range brs {
+			bw.WriteByte(byte(b))
+			writeU32(offsets[n.Children[b]])
+		}
+	}
+
+	for _, v := range values {
+		writeU32(uint64(len(v)))
+		bw.Write(v)
+	}
+
+	// bufio.Writer keeps the first write error.
+	return bw.Flush()
+}
+
+// fixedBranches returns the branches of `n` to inner nodes with keys.
+func fixedBranches(n *Node) []int {
+
+	brs := make([]int, 0, len(n.Branches))
+	for _, b := range n.Branches {
+		if b != leafBranch && n.Children[b].KeyCnt() > 0 {
+			brs = append(brs, b)
+		}
+	}
+	return brs
+}
+
+// FixedLayoutError describes where and why data is not in the fixed
+// layout of ExportFixed.
+// Its Cause is ErrInvalidFixedLayout.
+//
+// Since 0.2.0
+type FixedLayoutError struct {
+	// Offset is where the malformed structure is in the data.
+	Offset uint64
+	Reason string
+}
+
+// Error implements error.
+//
+// Since 0.2.0
+func (e *FixedLayoutError) Error() string {
+	return fmt.Sprintf("%s: at %d: %s", ErrInvalidFixedLayout, e.Offset, e.Reason)
+}
+
+// Cause returns ErrInvalidFixedLayout.
+//
+// Since 0.2.0
+func (e *FixedLayoutError) Cause() error {
+	return ErrInvalidFixedLayout
+}
+
+func fixedErr(off uint64, format string, args ...interface{}) error {
+	return &FixedLayoutError{Offset: off, Reason: fmt.Sprintf(format, args...)}
+}
+
+// FixedReader queries a trie in the fixed layout written by ExportFixed,
+// without decoding it.
+//
+// Since 0.2.0
+type FixedReader struct {
+	data []byte
+	root uint32
+}
+
+// NewFixedReader creates a FixedReader of `data` in the fixed layout, which
+// is used in place, e.g., a memory-mapped file. It checks the header only.
+// A malformed node is reported by the query reading it, as a
+// *FixedLayoutError, or by Verify.
+//
+// Since 0.2.0
+func NewFixedReader(data []byte) (*FixedReader, error) {
+
+	if len(data) < fixedHeaderSize {
+		return nil, fixedErr(0, "header of %d bytes", len(data))
+	}
+	if string(data[:4]) != fixedMagic {
+		return nil, fixedErr(0, "magic %q", data[:4])
+	}
+	if v := binary.LittleEndian.Uint32(data[4:]); v != fixedVersion {
+		return nil, fixedErr(4, "unknown version %d", v)
+	}
+
+	return &FixedReader{data: data, root: binary.LittleEndian.Uint32(data[12:])}, nil
+}
+
+// fixedNode is a node read from the fixed layout.
+type fixedNode struct {
+	step     int
+	n        int
+	value    uint32
+	branches []byte
+}
+
+func (f *FixedReader) node(off uint32) (fixedNode, error) {
+
+	d := f.data
+	if uint64(off)+8 > uint64(len(d)) {
+		return fixedNode{}, fixedErr(uint64(off), "node out of bounds")
+	}
+
+	n := fixedNode{
+		step:  int(binary.LittleEndian.Uint16(d[off:])),
+		n:     int(binary.LittleEndian.Uint16(d[off+2:])),
+		value: binary.LittleEndian.Uint32(d[off+4:]),
+	}
+
+	start := uint64(off) + 8
+	end := start + 5*uint64(n.n)
+	if end > uint64(len(d)) {
+		return fixedNode{}, fixedErr(uint64(off), "node out of bounds")
+	}
+	n.branches = d[start:end]
+	return n, nil
+}
+
+func (n fixedNode) label(i int) byte {
+	return n.branches[5*i]
+}
+
+func (n fixedNode) child(i int) uint32 {
+	return binary.LittleEndian.Uint32(n.branches[5*i+1:])
+}
+
+func (f *FixedReader) value(off uint32) ([]byte, error) {
+
+	d := f.data
+	if uint64(off)+4 > uint64(len(d)) {
+		return nil, fixedErr(uint64(off), "value out of bounds")
+	}
+	l := uint64(binary.LittleEndian.Uint32(d[off:]))
+	start := uint64(off) + 4
+	if start+l > uint64(len(d)) {
+		return nil, fixedErr(uint64(off), "value out of bounds")
+	}
+	return d[start : start+l], nil
+}
+
+// Search is the same as Node.Search, except that it returns encoded values,
+// which are slices of the data of f. A nil value means there is none.
+//
+// Since 0.2.0
+func (f *FixedReader) Search(key []byte) (ltValue, eqValue, gtValue []byte, err error) {
+
+	// The offsets of nodes whose right most or left most value are the
+	// neighbors, or of a value.
+	ltOff, gtOff := int64(-1), int64(-1)
+	ltIsValue := false
+
+	off := f.root
+	for i := -1; ; {
+		n, err := f.node(off)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if n.step == 0 {
+			return nil, nil, nil, fixedErr(uint64(off), "step 0")
+		}
+		i += n.step
+
+		if len(key) < i {
+			gtOff = int64(off)
+			break
+		}
+
+		if len(key) == i {
+			if n.value != fixedNoValue {
+				eqValue, err = f.value(n.value)
+				if err != nil {
+					return nil, nil, nil, err
+				}
+			}
+			if n.n > 0 {
+				gtOff = int64(n.child(0))
+			}
+			break
+		}
+
+		br := key[i]
+		j := 0
+		for j < n.n && n.label(j) < br {
+			j++
+		}
+
+		if j > 0 {
+			ltOff, ltIsValue = int64(n.child(j-1)), false
+		} else if n.value != fixedNoValue {
+			ltOff, ltIsValue = int64(n.value), true
+		}
+
+		if j < n.n && n.label(j) == br {
+			if j+1 < n.n {
+				gtOff = int64(n.child(j + 1))
+			}
+			off = n.child(j)
+			continue
+		}
+
+		if j < n.n {
+			gtOff = int64(n.child(j))
+		}
+		break
+	}
+
+	if ltOff >= 0 {
+		if ltIsValue {
+			ltValue, err = f.value(uint32(ltOff))
+		} else {
+			ltValue, err = f.mostValue(uint32(ltOff), false)
+		}
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if gtOff >= 0 {
+		gtValue, err = f.mostValue(uint32(gtOff), true)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	return
+}
+
+// prefixNode returns the offset of the node under which all keys start with
+// `prefix`, the same as Node.subtree. It returns false if no key starts with
+// `prefix`.
+func (f *FixedReader) prefixNode(prefix []byte) (uint32, bool, error) {
+
+	cnt := binary.LittleEndian.Uint32(f.data[8:])
+	off := f.root
+	i := -1
+	for d := uint32(0); d <= cnt; d++ {
+		n, err := f.node(off)
+		if err != nil {
+			return 0, false, err
+		}
+		if n.step == 0 {
+			return 0, false, fixedErr(uint64(off), "step 0")
+		}
+		i += n.step
+		if len(prefix) <= i {
+			return off, true, nil
+		}
+
+		j := 0
+		for j < n.n && n.label(j) < prefix[i] {
+			j++
+		}
+		if j == n.n || n.label(j) != prefix[i] {
+			return 0, false, nil
+		}
+		off = n.child(j)
+	}
+	return 0, false, fixedErr(uint64(off), "cycle")
+}
+
+// mostValue returns the left most value below the node at `off` if `left`
+// is true, otherwise the right most.
+func (f *FixedReader) mostValue(off uint32, left bool) ([]byte, error) {
+
+	// A malformed layout may have a cycle, but no path is longer than the
+	// number of nodes.
+	cnt := binary.LittleEndian.Uint32(f.data[8:])
+	for d := uint32(0); d <= cnt; d++ {
+		n, err := f.node(off)
+		if err != nil {
+			return nil, err
+		}
+
+		if n.n == 0 || (left && n.value != fixedNoValue) {
+			if n.value == fixedNoValue {
+				if off == f.root {
+					// An empty trie.
+					return nil, nil
+				}
+				return nil, fixedErr(uint64(off), "node without branch or value")
+			}
+			return f.value(n.value)
+		}
+
+		if left {
+			off = n.child(0)
+		} else {
+			off = n.child(n.n - 1)
+		}
+	}
+	return nil, fixedErr(uint64(off), "cycle")
+}
+
+// fixedFrame is the position of a walk in a node of the fixed layout.
+type fixedFrame struct {
+	node fixedNode
+	key  []byte
+	next int
+}
+
+// walk calls `fn` with every key and value below the node at `off`, of which
+// the key is `key`, in ascending key order, until `fn` returns false. A key
+// passed to `fn` is not reused. Every page of the nodes and values walked is
+// read, see touch.
+func (f *FixedReader) walk(off uint32, key []byte, fn func(key, value []byte) bool) error {
+
+	// A malformed layout may have a cycle, but no walk visits more nodes
+	// than the number in the header.
+	cnt := uint64(binary.LittleEndian.Uint32(f.data[8:]))
+	visited := uint64(0)
+
+	var sum uint32
+	defer func() { atomic.AddUint32(&touched, sum) }()
+
+	var stack []fixedFrame
+
+	// push enters the node at `off` and calls fn with its own value.
+	push := func(off uint32, key []byte) (bool, error) {
+
+		visited++
+		if visited > cnt {
+			return false, fixedErr(8, "more than %d nodes", cnt)
+		}
+
+		n, err := f.node(off)
+		if err == nil && n.step == 0 {
+			err = fixedErr(uint64(off), "step 0")
+		}
+		if err != nil {
+			return false, err
+		}
+		sum += touch(n.branches)
+		stack = append(stack, fixedFrame{node: n, key: key})
+
+		if n.value == fixedNoValue {
+			return true, nil
+		}
+		v, err := f.value(n.value)
+		if err != nil {
+			return false, err
+		}
+		sum += touch(v)
+		return fn(key, v), nil
+	}
+
+	more, err := push(off, key)
+	for more && err == nil && len(stack) > 0 {
+		fr := &stack[len(stack)-1]
+		if fr.next == fr.node.n {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		i := fr.next
+		fr.next++
+		more, err = push(fr.node.child(i), append(copyBytes(fr.key), fr.node.label(i)))
+	}
+	return err
+}
+
+// touched is the sum of the bytes read by touch, which is kept so that the
+// reads are not optimized away.
+var touched uint32
+
+// touchStride is not greater than the page size of any common platform,
+// thus reading a byte of every touchStride bytes reads every page.
+const touchStride = 4096
+
+// touch reads a byte of every page of `b` and returns their sum.
+func touch(b []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(b); i += touchStride {
+		sum += uint32(b[i])
+	}
+	if len(b) > 0 {
+		sum += uint32(b[len(b)-1])
+	}
+	return sum
+}
+
+// Verify checks the whole structure of the data of f: every offset, label
+// and length is in bounds, labels are ascending, every node is reached
+// from the root exactly once, and their number is the one in the header.
+// It returns a *FixedLayoutError at the first violation.
+//
+// Queries of data that passes Verify never fail. Data from an untrusted
+// source should be verified before being queried.
+//
+// It takes O(n) time for n nodes.
+//
+// Since 0.2.0
+func (f *FixedReader) Verify() error {
+
+	cnt := binary.LittleEndian.Uint32(f.data[8:])
+
+	visited := make(map[uint32]bool)
+	stack := []uint32{f.root}
+
+	for len(stack) > 0 {
+		off := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if off < fixedHeaderSize {
+			return fixedErr(uint64(off), "node in header")
+		}
+		if visited[off] {
+			return fixedErr(uint64(off), "node reached twice")
+		}
+		visited[off] = true
+		if uint64(len(visited)) > uint64(cnt) {
+			return fixedErr(8, "more than %d nodes", cnt)
+		}
+
+		n, err := f.node(off)
+		if err != nil {
+			return err
+		}
+		if n.step == 0 {
+			return fixedErr(uint64(off), "step 0")
+		}
+
+		if n.value != fixedNoValue {
+			if _, err := f.value(n.value); err != nil {
+				return err
+			}
+		} else if n.n == 0 && off != f.root {
+			return fixedErr(uint64(off), "node without branch or value")
+		}
+
+		for i := 0; i < n.n; i++ {
+			if i > 0 && n.label(i) <= n.label(i-1) {
+				return fixedErr(uint64(off)+8+5*uint64(i), "label %d not ascending", n.label(i))
+			}
+			stack = append(stack, n.child(i))
+		}
+	}
+
+	if uint64(len(visited)) != uint64(cnt) {
+		return fixedErr(8, "%d nodes, header has %d", len(visited), cnt)
+	}
+	return nil
+}
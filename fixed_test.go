@@ -0,0 +1,399 @@
+package trie
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/openacid/errors"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "regenerate golden files in testdata")
+
+// fixedVector is a conformance test vector of the fixed layout.
+// `<name>.bin` is the export of a trie of Keys and Values, values encoded as
+// is. Keys are in hex. A nil result means there is no value.
+type fixedVector struct {
+	Keys    []string      `json:"keys"`
+	Values  []string      `json:"values"`
+	Squash  bool          `json:"squash"`
+	Queries []fixedResult `json:"queries"`
+}
+
+type fixedResult struct {
+	Key string  `json:"key"`
+	Lt  *string `json:"lt"`
+	Eq  *string `json:"eq"`
+	Gt  *string `json:"gt"`
+}
+
+func (v fixedVector) build(ta *require.Assertions) *Node {
+
+	keys := make([][]byte, len(v.Keys))
+	for i, k := range v.Keys {
+		b, err := hex.DecodeString(k)
+		ta.Nil(err)
+		keys[i] = b
+	}
+
+	tr, err := NewTrie(keys, v.Values, v.Squash)
+	ta.Nil(err)
+	return tr
+}
+
+func fixedSearch(tr *Node, key []byte) fixedResult {
+
+	str := func(v interface{}) *string {
+		if v == nil {
+			return nil
+		}
+		s := v.(string)
+		return &s
+	}
+
+	lt, eq, gt := tr.Search(key)
+	return fixedResult{Key: hex.EncodeToString(key), Lt: str(lt), Eq: str(eq), Gt: str(gt)}
+}
+
+func TestExportFixed_vectors(t *testing.T) {
+
+	ta := require.New(t)
+
+	if *update {
+		writeFixedVectors(ta)
+	}
+
+	names, err := filepath.Glob("testdata/fixed/*.json")
+	ta.Nil(err)
+	ta.NotEmpty(names)
+
+	for _, name := range names {
+		j, err := ioutil.ReadFile(name)
+		ta.Nil(err)
+		var v fixedVector
+		ta.Nil(json.Unmarshal(j, &v))
+
+		want, err := ioutil.ReadFile(name[:len(name)-len(".json")] + ".bin")
+		ta.Nil(err)
+
+		tr := v.build(ta)
+		var buf bytes.Buffer
+		ta.Nil(tr.ExportFixed(&buf, strEnc))
+		ta.Equal(want, buf.Bytes(), "%s", name)
+
+		fr, err := NewFixedReader(want)
+		ta.Nil(err)
+		ta.Nil(fr.Verify(), "%s", name)
+
+		str := func(b []byte) *string {
+			if b == nil {
+				return nil
+			}
+			s := string(b)
+			return &s
+		}
+
+		for _, q := range v.Queries {
+			key, err := hex.DecodeString(q.Key)
+			ta.Nil(err)
+
+			ta.Equal(q, fixedSearch(tr, key), "%s: trie: %q", name, key)
+
+			lt, eq, gt, err := fr.Search(key)
+			ta.Nil(err)
+			got := fixedResult{Key: q.Key, Lt: str(lt), Eq: str(eq), Gt: str(gt)}
+			ta.Equal(q, got, "%s: reader: %q", name, key)
+		}
+	}
+}
+
+func wideKeys() []string {
+	keys := []string{}
+	for i := 0; i < 300; i++ {
+		keys = append(keys, fmt.Sprintf("%03d", i))
+	}
+	return append(keys, strings.Repeat("x", 300)+"a", strings.Repeat("x", 300)+"b")
+}
+
+// writeFixedVectors regenerates testdata/fixed.
+func writeFixedVectors(ta *require.Assertions) {
+
+	cases := []struct {
+		name    string
+		keys    []string
+		squash  bool
+		queries []string
+	}{
+		{"empty", []string{}, false, []string{"", "a"}},
+		{"single", []string{"a"}, false, []string{"", "a", "ab", "b"}},
+		{"empty-key", []string{"", "a"}, false, []string{"", "\x00", "a", "b"}},
+		{
+			"plain",
+			[]string{"a", "ab", "abc", "b", "ba", "c\x00", "c\xff"},
+			false,
+			[]string{"", "\x00", "a", "aa", "ab", "abb", "abc", "abcd", "ac", "b", "b\x00", "ba", "bb", "c", "c\x00", "c\x01", "c\xff", "d", "\xff"},
+		},
+		{
+			"squashed",
+			[]string{"abcd", "abce", "abx", "b", "bcdefg"},
+			true,
+			[]string{"", "a", "abcd", "abce", "abcf", "abx", "aby", "b", "bcdefg", "bc", "c"},
+		},
+		{
+			// Steps, counts and offsets of more than one byte.
+			"wide",
+			wideKeys(),
+			true,
+			[]string{"", "000", "001", "0010", "150", "299", "3", strings.Repeat("x", 300) + "a", strings.Repeat("x", 300) + "c", "y"},
+		},
+	}
+
+	for _, c := range cases {
+		v := fixedVector{Keys: []string{}, Values: []string{}, Squash: c.squash}
+		for i, k := range c.keys {
+			v.Keys = append(v.Keys, hex.EncodeToString([]byte(k)))
+			v.Values = append(v.Values, fmt.Sprintf("%c", 'A'+i))
+		}
+
+		tr := v.build(ta)
+		for _, q := range c.queries {
+			v.Queries = append(v.Queries, fixedSearch(tr, []byte(q)))
+		}
+
+		var buf bytes.Buffer
+		ta.Nil(tr.ExportFixed(&buf, strEnc))
+
+		j, err := json.MarshalIndent(v, "", "  ")
+		ta.Nil(err)
+
+		base := filepath.Join("testdata", "fixed", c.name)
+		ta.Nil(ioutil.WriteFile(base+".json", append(j, '\n'), 0644))
+		ta.Nil(ioutil.WriteFile(base+".bin", buf.Bytes(), 0644))
+	}
+}
+
+func TestExportFixed(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr, err := NewTrie(
+		[][]byte{[]byte("a"), []byte("ab"), []byte("b")}, []string{"A", "B", "C"}, false,
+		WithLazyRemove())
+	ta.Nil(err)
+	ta.True(tr.Remove([]byte("ab")))
+
+	var buf bytes.Buffer
+	ta.Nil(tr.ExportFixed(&buf, strEnc))
+
+	// header, root with 2 branches, "a", "b", and 2 values.
+	ta.Equal(16+18+8+8+5+5, buf.Len())
+
+	fr, err := NewFixedReader(buf.Bytes())
+	ta.Nil(err)
+	lt, eq, gt, err := fr.Search([]byte("ab"))
+	ta.Nil(err)
+	ta.Equal("A", string(lt))
+	ta.Nil(eq)
+	ta.Equal("C", string(gt))
+
+	encErr := errors.New("enc")
+	err = tr.ExportFixed(&buf, func(v interface{}) ([]byte, error) { return nil, encErr })
+	ta.Equal(encErr, errors.Cause(err))
+
+	tr, err = NewTrie(nil, nil, false, WithByteOrder(func(a, b byte) int { return int(b) - int(a) }))
+	ta.Nil(err)
+	err = tr.ExportFixed(&buf, strEnc)
+	ta.Equal(ErrInvalidFixedLayout, errors.Cause(err))
+}
+
+func TestExportFixed_empty(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr, err := NewTrie(
+		[][]byte{[]byte("abcdef1"), []byte("abcdef2")}, []string{"A", "B"}, false,
+		WithLazyRemove())
+	ta.Nil(err)
+	tr.Squash()
+	ta.True(tr.Remove([]byte("abcdef1")))
+	ta.True(tr.Remove([]byte("abcdef2")))
+
+	var buf bytes.Buffer
+	ta.Nil(tr.ExportFixed(&buf, strEnc))
+
+	// header and a root of Step 1 without branch.
+	ta.Equal(16+8, buf.Len())
+	ta.Equal(uint16(1), binary.LittleEndian.Uint16(buf.Bytes()[16:]))
+
+	fr, err := NewFixedReader(buf.Bytes())
+	ta.Nil(err)
+	ta.Nil(fr.Verify())
+
+	// An empty root with a greater Step, e.g., from another exporter,
+	// is searched as an empty trie too.
+	data := append([]byte{}, buf.Bytes()...)
+	binary.LittleEndian.PutUint16(data[16:], 6)
+	old, err := NewFixedReader(data)
+	ta.Nil(err)
+	ta.Nil(old.Verify())
+
+	for _, r := range []*FixedReader{fr, old} {
+		for _, key := range []string{"", "ca", "abcdef1"} {
+			lt, eq, gt, err := r.Search([]byte(key))
+			ta.Nil(err, "%q", key)
+			ta.Nil(lt)
+			ta.Nil(eq)
+			ta.Nil(gt)
+		}
+	}
+}
+
+func TestNewFixedReader_invalid(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr, err := NewTrie([][]byte{[]byte("a"), []byte("b")}, []string{"A", "B"}, false)
+	ta.Nil(err)
+	var buf bytes.Buffer
+	ta.Nil(tr.ExportFixed(&buf, strEnc))
+	good := buf.Bytes()
+
+	corrupt := func(off int, b byte) []byte {
+		d := append([]byte{}, good...)
+		d[off] = b
+		return d
+	}
+
+	for i, d := range [][]byte{
+		good[:15],
+		corrupt(0, 'x'),
+		corrupt(4, 2),
+	} {
+		_, err := NewFixedReader(d)
+		ta.Equal(ErrInvalidFixedLayout, errors.Cause(err), "%d-th", i+1)
+	}
+
+	for i, d := range [][]byte{
+		good[:len(good)-1],
+		corrupt(12, 0xff),
+		corrupt(16, 0),
+		corrupt(18, 0xff),
+	} {
+		fr, err := NewFixedReader(d)
+		ta.Nil(err)
+		_, _, _, err = fr.Search([]byte("b"))
+		ta.Equal(ErrInvalidFixedLayout, errors.Cause(err), "%d-th", i+1)
+	}
+}
+
+func TestFixedReader_Verify(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr, err := NewTrie([][]byte{[]byte("a"), []byte("b")}, []string{"A", "B"}, false)
+	ta.Nil(err)
+	var buf bytes.Buffer
+	ta.Nil(tr.ExportFixed(&buf, strEnc))
+	good := buf.Bytes()
+
+	// The root at 16 has branches "a" to 34 and "b" to 42. Values are at 50
+	// and 55.
+	patch := func(off int, b ...byte) []byte {
+		d := append([]byte{}, good...)
+		copy(d[off:], b)
+		return d
+	}
+
+	cases := []struct {
+		data    []byte
+		wantOff uint64
+	}{
+		{good[:len(good)-1], 55},
+		{patch(8, 4), 8},
+		{patch(8, 2), 8},
+		{patch(12, 0xff), 255},
+		{patch(12, 1), 1},
+		{patch(16, 0), 16},
+		{patch(18, 0xff), 16},
+		{patch(24, 'c'), 29},
+		{patch(30, 34), 34},
+		{patch(30, 16), 16},
+		{patch(38, 0xff, 0xff, 0xff, 0xff), 34},
+		{patch(38, 0xff, 0, 0, 0), 255},
+		{patch(50, 0xff), 50},
+	}
+
+	for i, c := range cases {
+		fr, err := NewFixedReader(c.data)
+		ta.Nil(err)
+
+		err = fr.Verify()
+		ta.Equal(ErrInvalidFixedLayout, errors.Cause(err), "%d-th", i+1)
+		fe, ok := err.(*FixedLayoutError)
+		ta.True(ok, "%d-th", i+1)
+		ta.Equal(c.wantOff, fe.Offset, "%d-th: %v", i+1, err)
+	}
+}
+
+func TestFixedReader_corrupted(t *testing.T) {
+
+	ta := require.New(t)
+
+	good, err := ioutil.ReadFile("testdata/fixed/plain.bin")
+	ta.Nil(err)
+
+	queries := [][]byte{{}, []byte("a"), []byte("abc"), []byte("b\x00"), []byte("c\xff"), []byte("z")}
+
+	// No corruption of a single byte panics, and queries of data that
+	// passes Verify do not fail.
+	for i := range good {
+		for _, b := range []byte{0, 1, 0x80, 0xff} {
+			d := append([]byte{}, good...)
+			d[i] ^= b
+
+			fr, err := NewFixedReader(d)
+			if err != nil {
+				continue
+			}
+			verr := fr.Verify()
+
+			for _, q := range queries {
+				_, _, _, err := fr.Search(q)
+				if verr == nil {
+					ta.Nil(err, "byte %d ^ %x: %q", i, b, q)
+				}
+			}
+		}
+	}
+}
+
+func TestFixedLayout_spec(t *testing.T) {
+
+	ta := require.New(t)
+
+	doc, err := ioutil.ReadFile("docs/fixed-layout.md")
+	ta.Nil(err)
+
+	// The example of the spec is the export of the "single" case.
+	parts := bytes.SplitN(doc, []byte("```hex\n"), 2)
+	ta.Len(parts, 2)
+	example := bytes.SplitN(parts[1], []byte("```"), 2)[0]
+
+	got, err := hex.DecodeString(string(bytes.Join(bytes.Fields(example), nil)))
+	ta.Nil(err)
+
+	want, err := ioutil.ReadFile("testdata/fixed/single.bin")
+	ta.Nil(err)
+	ta.Equal(want, got)
+
+	ta.Contains(string(doc), fmt.Sprintf("# Fixed layout, version %d", fixedVersion))
+	ta.Contains(string(doc), fmt.Sprintf("magic: `%q`", fixedMagic))
+}
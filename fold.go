@@ -0,0 +1,28 @@
+//go:build go1.18
+// +build go1.18
+
+package trie
+
+// Fold calls `fn` with every key starting with `prefix` and its value in
+// ascending key order, accumulating the result from `init`, in one traversal
+// without allocating intermediate slices.
+//
+// Keys are rebuilt the same way as Iter does, and must not be modified or
+// retained by `fn`. Same as Search, in a squashed trie keys not starting with
+// `prefix` may be included.
+//
+// Since 0.2.0
+func Fold[T any](r *Node, prefix []byte, init T, fn func(acc T, key []byte, v interface{}) T) T {
+
+	acc := init
+
+	sub, key := r.subtree(r.normalize(prefix))
+	if sub == nil {
+		return acc
+	}
+
+	sub.eachLeaf(key, func(key []byte, leaf *Node) {
+		acc = fn(acc, key, leaf.Value)
+	})
+	return acc
+}
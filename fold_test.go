@@ -0,0 +1,61 @@
+//go:build go1.18
+// +build go1.18
+
+package trie
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFold(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := [][]byte{[]byte("a"), []byte("ab"), []byte("abc"), []byte("b"), []byte("bc")}
+	values := []int{1, 2, 3, 4, 5}
+
+	sum := func(acc int, key []byte, v interface{}) int {
+		return acc + v.(int)
+	}
+
+	cases := []struct {
+		prefix string
+		want   int
+	}{
+		{"", 15},
+		{"a", 6},
+		{"ab", 5},
+		{"abc", 3},
+		{"abcd", 0},
+		{"b", 9},
+		{"c", 0},
+	}
+
+	tr, err := NewTrie(keys, values, false)
+	ta.Nil(err)
+
+	for i, c := range cases {
+		ta.Equal(c.want, Fold(tr, []byte(c.prefix), 0, sum), "%d-th: prefix: %q", i+1, c.prefix)
+	}
+
+	concat := Fold(tr, []byte("a"), "", func(acc string, key []byte, v interface{}) string {
+		return acc + string(key) + ","
+	})
+	ta.Equal("a,ab,abc,", concat)
+
+	// In a squashed trie, a prefix ending in a squashed node matches the
+	// whole node.
+	sq, err := NewTrie([][]byte{[]byte("xyz1"), []byte("xyz2")}, []int{1, 2}, true)
+	ta.Nil(err)
+	ta.Equal(3, Fold(sq, []byte("xy"), 0, sum))
+	ta.Equal(3, Fold(sq, []byte("xq"), 0, sum), "squashed bytes are not checked")
+	ta.Equal(2, Fold(sq, []byte("xyz2"), 0, sum))
+
+	// A prefix is normalized the same as keys.
+	lower, err := NewTrie(keys, values, false, WithKeyNormalizer(bytes.ToLower))
+	ta.Nil(err)
+	ta.Equal(5, Fold(lower, []byte("AB"), 0, sum))
+}
@@ -0,0 +1,131 @@
+package trie
+
+// WithDirectIndex makes a trie keep a table of the nodes reached by the first
+// `depth` bytes of keys, which is 1 or 2. Search and Get of a key of at least
+// `depth` bytes start from the node in the table, instead of descending through
+// the first `depth` levels of Children maps. It speeds up short keys, e.g.,
+// fixed-size ids, in which those levels dominate the cost of a query.
+//
+// The table has 256 entries of `depth` 1 and 65536 of `depth` 2, i.e., about
+// 4KB or 1MB on a 64-bit machine, regardless of the number of keys.
+// Any other `depth` disables it.
+//
+// The table is kept up to date by every change to the trie.
+// A key whose neighbors are not below the node in the table, or a trie whose
+// first `depth` levels are squashed, falls back to a descent from the root.
+// The table belongs to the trie created by NewTrie: tries sharing its options,
+// such as versions of a SyncTrie or the result of Filter, do not use it.
+//
+// Since 0.2.0
+func WithDirectIndex(depth int) Option {
+	return func(o *options) {
+		if depth != 1 && depth != 2 {
+			return
+		}
+		o.front = &frontIndex{
+			depth:   depth,
+			entries: make([]frontEntry, 1<<(8*uint(depth))),
+		}
+	}
+}
+
+// frontIndex maps the first depth bytes of a key to the node they reach.
+type frontIndex struct {
+	// root is the trie the table is of.
+	root    *Node
+	depth   int
+	entries []frontEntry
+}
+
+// frontEntry is the node reached by a key prefix. mid is the node of the first
+// byte, through which the node of a 2-byte prefix is reached.
+type frontEntry struct {
+	mid  *Node
+	node *Node
+}
+
+// frontNode returns the node to start the descent of a normalized `key` from,
+// and the number of bytes of `key` consumed before it minus 1, i.e., the `i`
+// of descend. It returns nil if there is no usable entry.
+func (r *Node) frontNode(key []byte) (*Node, int) {
+
+	if r.opt == nil || r.opt.front == nil {
+		return nil, 0
+	}
+
+	f := r.opt.front
+	if f.root != r || r.Step != 1 || len(key) < f.depth {
+		return nil, 0
+	}
+
+	var e *frontEntry
+	if f.depth == 1 {
+		e = &f.entries[key[0]]
+	} else {
+		e = &f.entries[int(key[0])<<8|int(key[1])]
+		// The node of the first byte is squashed after the entry was set.
+		if e.mid == nil || e.mid.Step != 1 {
+			return nil, 0
+		}
+	}
+
+	if e.node == nil {
+		return nil, 0
+	}
+	return e.node, f.depth - 1
+}
+
+// updateFront resets the entry of the prefix of a normalized `key`, after the
+// nodes along it are added or removed.
+func (r *Node) updateFront(key []byte) {
+
+	if r.opt == nil || r.opt.front == nil || r.opt.front.root != r {
+		return
+	}
+
+	f := r.opt.front
+	if len(key) < f.depth {
+		return
+	}
+
+	if f.depth == 1 {
+		f.entries[key[0]] = frontEntry{node: r.Children[int(key[0])]}
+		return
+	}
+
+	e := frontEntry{mid: r.Children[int(key[0])]}
+	if e.mid != nil && e.mid.Children != nil {
+		e.node = e.mid.Children[int(key[1])]
+	}
+	f.entries[int(key[0])<<8|int(key[1])] = e
+}
+
+// buildFront rebuilds the whole table, after changes that are not made key by
+// key, e.g., Squash.
+func (r *Node) buildFront() {
+
+	if r.opt == nil || r.opt.front == nil || r.opt.front.root != r {
+		return
+	}
+
+	f := r.opt.front
+	for i := range f.entries {
+		f.entries[i] = frontEntry{}
+	}
+
+	for _, b := range r.Branches {
+		if b == leafBranch {
+			continue
+		}
+		mid := r.Children[b]
+		if f.depth == 1 {
+			f.entries[b] = frontEntry{node: mid}
+			continue
+		}
+		for _, b2 := range mid.Branches {
+			if b2 != leafBranch {
+				f.entries[b<<8|b2] = frontEntry{mid: mid, node: mid.Children[b2]}
+			}
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package trie
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithDirectIndex(t *testing.T) {
+
+	for _, depth := range []int{1, 2} {
+		for _, squash := range []bool{false, true} {
+			for _, lazy := range []bool{false, true} {
+				msg := fmt.Sprintf("depth=%d squash=%v lazy=%v", depth, squash, lazy)
+				testDirectIndex(t, depth, squash, lazy, msg)
+			}
+		}
+	}
+}
+
+func testDirectIndex(t *testing.T, depth int, squash, lazy bool, msg string) {
+
+	ta := require.New(t)
+
+	rnd := rand.New(rand.NewSource(int64(depth)))
+	randKey := func() []byte {
+		k := make([]byte, rnd.Intn(5))
+		for i := range k {
+			k[i] = "abc\x00\xff"[rnd.Intn(5)]
+		}
+		return k
+	}
+
+	uniq := map[string]bool{}
+	for i := 0; i < 40; i++ {
+		uniq[string(randKey())] = true
+	}
+	strs := make([]string, 0, len(uniq))
+	for k := range uniq {
+		strs = append(strs, k)
+	}
+	sort.Strings(strs)
+
+	keys := make([][]byte, len(strs))
+	for i, k := range strs {
+		keys[i] = []byte(k)
+	}
+
+	var opts []Option
+	if lazy {
+		opts = append(opts, WithLazyRemove())
+	}
+
+	ref, err := NewTrie(keys, strs, squash, opts...)
+	ta.Nil(err)
+	tr, err := NewTrie(keys, strs, squash, append(opts, WithDirectIndex(depth))...)
+	ta.Nil(err)
+
+	check := func(step string) {
+		for i := 0; i < 200; i++ {
+			q := randKey()
+			lt, eq, gt := tr.Search(q)
+			wlt, weq, wgt := ref.Search(q)
+			ta.Equal([]interface{}{wlt, weq, wgt}, []interface{}{lt, eq, gt}, "%s %s: search %q", msg, step, q)
+
+			v, found := tr.Get(q)
+			wv, wfound := ref.Get(q)
+			ta.Equal(wfound, found, "%s %s: get %q", msg, step, q)
+			ta.Equal(wv, v, "%s %s: get %q", msg, step, q)
+		}
+	}
+	check("new")
+
+	if squash {
+		// A squashed trie can not be Set; remove keys then compact.
+		for i := 0; i < 20; i++ {
+			k := randKey()
+			ta.Equal(ref.Remove(k), tr.Remove(k), "%s: remove %q", msg, k)
+		}
+		check("remove")
+
+		ref.Compact()
+		tr.Compact()
+		check("compact")
+		return
+	}
+
+	for i := 0; i < 100; i++ {
+		k := randKey()
+		if rnd.Intn(2) == 0 {
+			_, err := ref.Set(k, string(k)+"'")
+			ta.Nil(err)
+			_, err = tr.Set(k, string(k)+"'")
+			ta.Nil(err)
+		} else {
+			ta.Equal(ref.Remove(k), tr.Remove(k), "%s: remove %q", msg, k)
+		}
+	}
+	check("set and remove")
+
+	ref.Squash()
+	tr.Squash()
+	check("squash")
+}
+
+func TestWithDirectIndex_invalidDepth(t *testing.T) {
+
+	ta := require.New(t)
+
+	for _, depth := range []int{-1, 0, 3} {
+		tr, err := NewTrie([][]byte{[]byte("ab")}, []string{"ab"}, false, WithDirectIndex(depth))
+		ta.Nil(err)
+		ta.Nil(tr.opt.front, "depth %d", depth)
+
+		_, eq, _ := tr.Search([]byte("ab"))
+		ta.Equal("ab", eq)
+	}
+}
+
+func TestWithDirectIndex_append(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr, err := NewTrie(nil, nil, false, WithDirectIndex(2))
+	ta.Nil(err)
+
+	for _, k := range []string{"", "a", "ab", "abc", "b", "bcd"} {
+		_, err := tr.Append([]byte(k), k)
+		ta.Nil(err)
+	}
+
+	cases := []struct {
+		key  string
+		want []interface{}
+	}{
+		{"", []interface{}{nil, "", "a"}},
+		{"aa", []interface{}{"a", nil, "ab"}},
+		{"ab", []interface{}{"a", "ab", "abc"}},
+		{"abd", []interface{}{"abc", nil, "b"}},
+		{"bb", []interface{}{"b", nil, "bcd"}},
+		{"bc", []interface{}{"b", nil, "bcd"}},
+		{"bcd", []interface{}{"b", "bcd", nil}},
+		{"c", []interface{}{"bcd", nil, nil}},
+	}
+
+	for i, c := range cases {
+		lt, eq, gt := tr.Search([]byte(c.key))
+		ta.Equal(c.want, []interface{}{lt, eq, gt}, "%d-th: search %q", i+1, c.key)
+	}
+
+	node, _ := tr.frontNode([]byte("bc"))
+	ta.Equal(tr.Children['b'].Children['c'], node)
+}
+
+func BenchmarkWithDirectIndex(b *testing.B) {
+
+	keys := make([][]byte, 0, 1<<12)
+	values := make([]int, 0, 1<<12)
+	for i := 0; i < 1<<12; i++ {
+		keys = append(keys, []byte{byte(i >> 8), byte(i), 'x', 'y'})
+		values = append(values, i)
+	}
+
+	for _, depth := range []int{0, 1, 2} {
+		tr, _ := NewTrie(keys, values, false, WithDirectIndex(depth))
+		b.Run(fmt.Sprintf("depth=%d", depth), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				tr.Get(keys[i&(1<<12-1)])
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+//go:build go1.16
+// +build go1.16
+
+package trie
+
+import (
+	"bytes"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/openacid/errors"
+)
+
+// PathTrie is a trie of the paths of a file system, built by NewFromFS.
+// Paths are slash-separated and relative to the root of the file system, as
+// those of fs.WalkDir, e.g., "a/b.txt".
+//
+// Since 0.2.0
+type PathTrie struct {
+	root *Node
+}
+
+// NewFromFS walks `fsys` with fs.WalkDir and builds a PathTrie of the files
+// and directories in it, except the root ".". The value of a path is
+// `valueFn(path, d)`, or the fs.DirEntry `d` if `valueFn` is nil. A path for
+// which `valueFn` returns nil is not added, e.g., to index only files;
+// entries below a directory not added are still walked.
+//
+// Paths are sorted before being added, thus `fsys` does not need to list
+// directories in byte order. An error walking `fsys` is returned as is,
+// wrapped.
+//
+// Since 0.2.0
+func NewFromFS(fsys fs.FS, valueFn func(path string, d fs.DirEntry) interface{}) (*PathTrie, error) {
+
+	entries := []Entry{}
+
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == "." {
+			return nil
+		}
+
+		var v interface{} = d
+		if valueFn != nil {
+			v = valueFn(p, d)
+			if v == nil {
+				return nil
+			}
+		}
+		entries = append(entries, Entry{Key: []byte(p), Value: v})
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "walk fs")
+	}
+
+	// WalkDir visits "a/b" before "a.txt", although '.' < '/'.
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].Key, entries[j].Key) < 0
+	})
+
+	keys := make([][]byte, len(entries))
+	values := make([]interface{}, len(entries))
+	for i, e := range entries {
+		keys[i] = e.Key
+		values[i] = e.Value
+	}
+
+	root, err := NewTrie(keys, values, false)
+	if err != nil {
+		return nil, err
+	}
+	return &PathTrie{root: root}, nil
+}
+
+// Trie returns the underlying trie, of which keys are paths. It must not be
+// squashed, or queries of the PathTrie match paths by bytes not compared.
+//
+// Since 0.2.0
+func (p *PathTrie) Trie() *Node {
+	return p.root
+}
+
+// Len returns the number of paths.
+//
+// Since 0.2.0
+func (p *PathTrie) Len() int {
+	return p.root.KeyCnt()
+}
+
+// Get returns the value of `name` and whether it is in the PathTrie.
+//
+// Since 0.2.0
+func (p *PathTrie) Get(name string) (interface{}, bool) {
+	return p.root.Get([]byte(name))
+}
+
+// Prefix returns the paths starting with `prefix` and their values, in
+// ascending order. It compares bytes, not path elements: to list what is
+// below directory "a", use "a/"; "a" also matches "a.txt".
+//
+// Since 0.2.0
+func (p *PathTrie) Prefix(prefix string) []Entry {
+
+	rst := []Entry{}
+	p.eachOfPrefix(prefix, func(key []byte, leaf *Node) {
+		rst = append(rst, Entry{Key: copyBytes(key), Value: leaf.Value})
+	})
+	return rst
+}
+
+// Glob returns the paths matching `pattern` and their values, in ascending
+// order. The pattern syntax is that of path.Match, thus "*" does not match
+// "/". Only paths starting with the part of `pattern` before the first
+// special character are visited.
+//
+// It returns path.ErrBadPattern, wrapped, if `pattern` is malformed.
+//
+// Since 0.2.0
+func (p *PathTrie) Glob(pattern string) ([]Entry, error) {
+
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, errors.Wrapf(err, "glob %q", pattern)
+	}
+
+	prefix := pattern
+	if i := strings.IndexAny(pattern, `*?[\`); i >= 0 {
+		prefix = pattern[:i]
+	}
+
+	rst := []Entry{}
+	p.eachOfPrefix(prefix, func(key []byte, leaf *Node) {
+		// The pattern is valid, thus there is no error.
+		if ok, _ := path.Match(pattern, string(key)); ok {
+			rst = append(rst, Entry{Key: copyBytes(key), Value: leaf.Value})
+		}
+	})
+	return rst, nil
+}
+
+// eachOfPrefix calls `fn` with every path starting with `prefix` and its
+// leaf, in ascending order.
+func (p *PathTrie) eachOfPrefix(prefix string, fn func(key []byte, leaf *Node)) {
+
+	sub, key := p.root.subtree([]byte(prefix))
+	if sub == nil {
+		return
+	}
+	sub.eachLeaf(key, fn)
+}
@@ -0,0 +1,112 @@
+//go:build go1.16
+// +build go1.16
+
+package trie
+
+import (
+	"io/fs"
+	"path"
+	"testing"
+	"testing/fstest"
+
+	"github.com/openacid/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFromFS(t *testing.T) {
+
+	ta := require.New(t)
+
+	fsys := fstest.MapFS{
+		"a.txt":          {Data: []byte("1")},
+		"a/b.txt":        {Data: []byte("22")},
+		"a/c/d.txt":      {Data: []byte("333")},
+		"a/c/e.log":      {Data: []byte("4444")},
+		"b/x.txt":        {Data: []byte("55555")},
+		"b/empty/.keep":  {},
+		"c/a.txt/f.data": {Data: []byte("666666")},
+	}
+
+	pt, err := NewFromFS(fsys, nil)
+	ta.Nil(err)
+	ta.Nil(pt.Trie().Validate())
+	ta.Equal(13, pt.Len())
+
+	v, found := pt.Get("a/c")
+	ta.True(found)
+	ta.True(v.(fs.DirEntry).IsDir())
+	_, found = pt.Get("a/c/")
+	ta.False(found)
+
+	// Only files, valued by size.
+	pt, err = NewFromFS(fsys, func(p string, d fs.DirEntry) interface{} {
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		ta.Nil(err)
+		return int(info.Size())
+	})
+	ta.Nil(err)
+	ta.Equal(len(fsys), pt.Len())
+
+	paths := func(es []Entry) []string {
+		rst := []string{}
+		for _, e := range es {
+			rst = append(rst, string(e.Key))
+		}
+		return rst
+	}
+
+	ta.Equal([]string{"a.txt", "a/b.txt", "a/c/d.txt", "a/c/e.log"}, paths(pt.Prefix("a")))
+	ta.Equal([]string{"a/c/d.txt", "a/c/e.log"}, paths(pt.Prefix("a/c/")))
+	ta.Equal([]string{}, paths(pt.Prefix("z")))
+	ta.Equal(7, len(pt.Prefix("")))
+
+	cases := []struct {
+		pattern string
+		want    []string
+	}{
+		{"*.txt", []string{"a.txt"}},
+		{"*/*.txt", []string{"a/b.txt", "b/x.txt"}},
+		{"a/*/*", []string{"a/c/d.txt", "a/c/e.log"}},
+		{"a/c/?.*", []string{"a/c/d.txt", "a/c/e.log"}},
+		{"[ab]/*/.keep", []string{"b/empty/.keep"}},
+		{"c/a.txt/f.data", []string{"c/a.txt/f.data"}},
+		{"c/a.txt", []string{}},
+	}
+	for _, c := range cases {
+		es, err := pt.Glob(c.pattern)
+		ta.Nil(err, c.pattern)
+		ta.Equal(c.want, paths(es), c.pattern)
+
+		// The same as fs.Glob, for files.
+		want, err := fs.Glob(fsys, c.pattern)
+		ta.Nil(err)
+		files := []string{}
+		for _, w := range want {
+			if _, ok := fsys[w]; ok {
+				files = append(files, w)
+			}
+		}
+		ta.Equal(files, c.want, c.pattern)
+	}
+
+	es, err := pt.Glob("a/b.txt")
+	ta.Nil(err)
+	ta.Equal([]Entry{{Key: []byte("a/b.txt"), Value: 2}}, es)
+
+	_, err = pt.Glob("a/[")
+	ta.Equal(path.ErrBadPattern, errors.Cause(err))
+
+	_, err = NewFromFS(errFS{}, nil)
+	pe, ok := errors.Cause(err).(*fs.PathError)
+	ta.True(ok)
+	ta.Equal(fs.ErrPermission, pe.Err)
+}
+
+type errFS struct{}
+
+func (errFS) Open(name string) (fs.File, error) {
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+}
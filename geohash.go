@@ -0,0 +1,217 @@
+package trie
+
+import "math"
+
+// geohashBase32 is the alphabet of geohash.
+const geohashBase32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// geohashMaxPrecision is the max number of characters of a geohash, whose 60
+// bits fit in an uint64.
+const geohashMaxPrecision = 12
+
+// GeoBox is a bounding box of latitudes and longitudes in degrees.
+// A box crossing the antimeridian has MinLon greater than MaxLon.
+//
+// Since 0.2.0
+type GeoBox struct {
+	MinLat, MinLon float64
+	MaxLat, MaxLon float64
+}
+
+// GeohashEncode returns the geohash of a location of `precision` characters.
+// `precision` is clamped to [1, 12].
+//
+// Since 0.2.0
+func GeohashEncode(lat, lon float64, precision int) []byte {
+
+	precision = clampGeoPrecision(precision)
+	latBits, lonBits := geohashBits(precision)
+
+	return geohashOf(geoCell(lat, -90, 180, latBits), geoCell(lon, -180, 360, lonBits), precision)
+}
+
+// GeohashCover returns the ascending geohash prefixes covering `box`: the
+// cells of `precision` characters intersecting it, in which every 32 cells
+// sharing a parent are replaced with the parent. `precision` is clamped to
+// [1, 12].
+//
+// The prefixes are found top-down: a cell inside `box` is returned as a
+// whole and only cells crossing the boundary of `box` are divided, thus the
+// cost is of the prefixes returned, which are mostly along the boundary, not
+// of the area of `box`.
+//
+// Since 0.2.0
+func GeohashCover(box GeoBox, precision int) [][]byte {
+
+	precision = clampGeoPrecision(precision)
+	latBits, lonBits := geohashBits(precision)
+
+	lats := [2]uint64{geoCell(box.MinLat, -90, 180, latBits), geoCell(box.MaxLat, -90, 180, latBits)}
+
+	lon0 := geoCell(box.MinLon, -180, 360, lonBits)
+	lon1 := geoCell(box.MaxLon, -180, 360, lonBits)
+
+	var lons [][2]uint64
+	switch {
+	case box.MinLon <= box.MaxLon:
+		lons = [][2]uint64{{lon0, lon1}}
+	case lon1+1 >= lon0:
+		// The two parts of a box crossing the antimeridian meet.
+		lons = [][2]uint64{{0, 1<<lonBits - 1}}
+	default:
+		lons = [][2]uint64{{0, lon1}, {lon0, 1<<lonBits - 1}}
+	}
+
+	rst := [][]byte{}
+
+	var cover func(hash []byte, bits uint64)
+	cover = func(hash []byte, bits uint64) {
+
+		// The range of cells of `precision` in the cell of hash.
+		l := len(hash)
+		cellLatBits, cellLonBits := geohashBits(l)
+		lat, lon := geohashIndexes(bits, l)
+		lat <<= latBits - cellLatBits
+		lon <<= lonBits - cellLonBits
+		latEnd := lat + 1<<(latBits-cellLatBits) - 1
+		lonEnd := lon + 1<<(lonBits-cellLonBits) - 1
+
+		if latEnd < lats[0] || lat > lats[1] {
+			return
+		}
+
+		crossed, inside := false, false
+		for _, r := range lons {
+			if lonEnd < r[0] || lon > r[1] {
+				continue
+			}
+			crossed = true
+			inside = inside || lon >= r[0] && lonEnd <= r[1]
+		}
+		if !crossed {
+			return
+		}
+
+		inside = inside && lat >= lats[0] && latEnd <= lats[1]
+		if inside || l == precision {
+			rst = append(rst, copyBytes(hash))
+			return
+		}
+
+		for i := 0; i < len(geohashBase32); i++ {
+			cover(append(hash, geohashBase32[i]), bits<<5|uint64(i))
+		}
+	}
+
+	for i := 0; i < len(geohashBase32); i++ {
+		cover([]byte{geohashBase32[i]}, uint64(i))
+	}
+	return rst
+}
+
+// SearchGeoBox calls `fn` with every key starting with a prefix of
+// GeohashCover(box, precision), and its value, in ascending key order.
+// Iteration stops if `fn` returns false.
+//
+// The keys are geohashes in cells intersecting `box`, thus a key may be out of
+// `box` and should be checked by its exact location.
+// Keys are rebuilt the same way as Iter does.
+//
+// Since 0.2.0
+func (r *Node) SearchGeoBox(box GeoBox, precision int, fn func(key []byte, v interface{}) bool) {
+
+	for _, p := range GeohashCover(box, precision) {
+
+		sub, key := r.subtree(p)
+		if sub == nil {
+			continue
+		}
+
+		stopped := false
+		sub.eachLeaf(key, func(key []byte, leaf *Node) {
+			if !stopped {
+				stopped = !fn(key, leaf.Value)
+			}
+		})
+		if stopped {
+			return
+		}
+	}
+}
+
+func clampGeoPrecision(precision int) int {
+	if precision < 1 {
+		return 1
+	}
+	if precision > geohashMaxPrecision {
+		return geohashMaxPrecision
+	}
+	return precision
+}
+
+// geohashBits returns the number of bits of latitude and longitude in a
+// geohash of `precision` characters. Bits are interleaved from a longitude
+// bit, thus longitude has the extra bit.
+func geohashBits(precision int) (latBits, lonBits uint) {
+	bits := uint(precision * 5)
+	return bits / 2, bits - bits/2
+}
+
+// geoCell returns the index of the cell `v` is in, of `bits` bits dividing
+// [min, min+span].
+func geoCell(v, min, span float64, bits uint) uint64 {
+
+	n := uint64(1) << bits
+	f := math.Floor((v - min) / span * float64(n))
+	if f < 0 {
+		return 0
+	}
+	if f >= float64(n) {
+		return n - 1
+	}
+	return uint64(f)
+}
+
+// geohashOf returns the geohash of a cell of latitude index `lat` and
+// longitude index `lon`.
+func geohashOf(lat, lon uint64, precision int) []byte {
+
+	latBits, lonBits := geohashBits(precision)
+
+	var bits uint64
+	total := latBits + lonBits
+	for i := uint(0); i < total; i++ {
+		bits <<= 1
+		if i%2 == 0 {
+			lonBits--
+			bits |= lon >> lonBits & 1
+		} else {
+			latBits--
+			bits |= lat >> latBits & 1
+		}
+	}
+
+	hash := make([]byte, precision)
+	for i := precision - 1; i >= 0; i-- {
+		hash[i] = geohashBase32[bits&31]
+		bits >>= 5
+	}
+	return hash
+}
+
+// geohashIndexes returns the latitude and longitude indexes of the cell of
+// the interleaved bits of a geohash of `precision` characters, the reverse of
+// geohashOf.
+func geohashIndexes(bits uint64, precision int) (lat, lon uint64) {
+
+	total := uint(precision * 5)
+	for i := uint(0); i < total; i++ {
+		b := bits >> (total - 1 - i) & 1
+		if i%2 == 0 {
+			lon = lon<<1 | b
+		} else {
+			lat = lat<<1 | b
+		}
+	}
+	return lat, lon
+}
@@ -0,0 +1,182 @@
+package trie
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeohashEncode(t *testing.T) {
+
+	ta := require.New(t)
+
+	cases := []struct {
+		lat, lon  float64
+		precision int
+		want      string
+	}{
+		{57.64911, 10.40744, 11, "u4pruydqqvj"},
+		{42.6, -5.6, 5, "ezs42"},
+		{-90, -180, 3, "000"},
+		{90, 180, 3, "zzz"},
+		{0, 0, 0, "s"},
+		{57.64911, 10.40744, 20, "u4pruydqqvj8"},
+	}
+
+	for i, c := range cases {
+		got := GeohashEncode(c.lat, c.lon, c.precision)
+		ta.Equal(c.want, string(got), "%d-th: %v, %v", i+1, c.lat, c.lon)
+	}
+}
+
+func TestGeohashCover(t *testing.T) {
+
+	ta := require.New(t)
+
+	strs := func(bs [][]byte) []string {
+		rst := []string{}
+		for _, b := range bs {
+			rst = append(rst, string(b))
+		}
+		return rst
+	}
+
+	// A box in one cell.
+	got := GeohashCover(GeoBox{42.6, -5.6, 42.6001, -5.5999}, 5)
+	ta.Equal([]string{"ezs42"}, strs(got))
+
+	// The whole world: all cells of precision 2 are merged.
+	got = GeohashCover(GeoBox{-90, -180, 90, 180}, 2)
+	ta.Equal(32, len(got))
+	ta.Equal("0", string(got[0]))
+	ta.Equal("z", string(got[31]))
+
+	// A box crossing the antimeridian.
+	got = GeohashCover(GeoBox{-1, 179, 1, -179}, 1)
+	ta.Equal([]string{"2", "8", "r", "x"}, strs(got))
+
+	// Every cell intersecting the box is covered.
+	box := GeoBox{30, 100, 40, 120}
+	cover := GeohashCover(box, 3)
+	for _, loc := range [][2]float64{{30, 100}, {35, 110}, {40, 120}, {30.01, 119.99}} {
+		h := GeohashEncode(loc[0], loc[1], 3)
+		found := false
+		for _, p := range cover {
+			if string(h[:len(p)]) == string(p) {
+				found = true
+			}
+		}
+		ta.True(found, "location: %v, hash: %s", loc, h)
+	}
+}
+
+func TestGeohashCover_bruteForce(t *testing.T) {
+
+	ta := require.New(t)
+
+	// coverOf returns the cover by listing all cells in box and merging
+	// siblings.
+	coverOf := func(box GeoBox, precision int) []string {
+
+		latBits, lonBits := geohashBits(precision)
+		lat0 := geoCell(box.MinLat, -90, 180, latBits)
+		lat1 := geoCell(box.MaxLat, -90, 180, latBits)
+		lon0 := geoCell(box.MinLon, -180, 360, lonBits)
+		lon1 := geoCell(box.MaxLon, -180, 360, lonBits)
+
+		cells := map[string]bool{}
+		for lat := lat0; lat <= lat1; lat++ {
+			for lon := uint64(0); lon < 1<<lonBits; lon++ {
+				in := lon >= lon0 && lon <= lon1
+				if box.MinLon > box.MaxLon {
+					in = lon >= lon0 || lon <= lon1
+				}
+				if in {
+					cells[string(geohashOf(lat, lon, precision))] = true
+				}
+			}
+		}
+
+		for l := precision; l > 1; l-- {
+			children := map[string]int{}
+			for c := range cells {
+				if len(c) == l {
+					children[c[:l-1]]++
+				}
+			}
+			for parent, n := range children {
+				if n == len(geohashBase32) {
+					for i := 0; i < len(geohashBase32); i++ {
+						delete(cells, parent+geohashBase32[i:i+1])
+					}
+					cells[parent] = true
+				}
+			}
+		}
+
+		rst := []string{}
+		for c := range cells {
+			rst = append(rst, c)
+		}
+		sort.Strings(rst)
+		return rst
+	}
+
+	rnd := rand.New(rand.NewSource(7))
+	for i := 0; i < 200; i++ {
+		box := GeoBox{
+			MinLat: rnd.Float64()*180 - 90,
+			MinLon: rnd.Float64()*360 - 180,
+		}
+		box.MaxLat = box.MinLat + rnd.Float64()*(90-box.MinLat)
+		box.MaxLon = rnd.Float64()*360 - 180
+		precision := 1 + rnd.Intn(3)
+
+		got := []string{}
+		for _, p := range GeohashCover(box, precision) {
+			got = append(got, string(p))
+		}
+		ta.Equal(coverOf(box, precision), got, "box: %v, precision: %d", box, precision)
+	}
+
+	// Not of the area.
+	got := GeohashCover(GeoBox{-90, -180, 90, 180}, 6)
+	ta.Equal(32, len(got))
+	got = GeohashCover(GeoBox{-89, -179, 89, 179}, 6)
+	ta.True(len(got) < 1<<18, "%d", len(got))
+}
+
+func TestNode_SearchGeoBox(t *testing.T) {
+
+	ta := require.New(t)
+
+	locs := [][2]float64{
+		{42.6, -5.6},
+		{57.64911, 10.40744},
+		{42.61, -5.59},
+		{-33.86, 151.21},
+	}
+
+	tr, err := NewTrie(nil, nil, false)
+	ta.Nil(err)
+	for i, l := range locs {
+		_, err = tr.Set(GeohashEncode(l[0], l[1], 8), i)
+		ta.Nil(err)
+	}
+
+	var got []interface{}
+	tr.SearchGeoBox(GeoBox{42.5, -5.7, 42.7, -5.5}, 5, func(key []byte, v interface{}) bool {
+		got = append(got, v)
+		return true
+	})
+	ta.Equal([]interface{}{0, 2}, got)
+
+	got = nil
+	tr.SearchGeoBox(GeoBox{-90, -180, 90, 180}, 1, func(key []byte, v interface{}) bool {
+		got = append(got, v)
+		return len(got) < 2
+	})
+	ta.Equal(2, len(got))
+}
@@ -0,0 +1,22 @@
+module github.com/openacid/trie/grpc
+
+go 1.23
+
+require (
+	github.com/openacid/trie v0.2.0
+	github.com/stretchr/testify v1.4.0
+	google.golang.org/grpc v1.70.0
+	google.golang.org/protobuf v1.36.12
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/openacid/errors v0.8.1 // indirect
+	github.com/openacid/low v0.1.10 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/net v0.32.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20241202173237-19429a94021a // indirect
+	gopkg.in/yaml.v2 v2.2.2 // indirect
+)
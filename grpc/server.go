@@ -0,0 +1,168 @@
+// Package grpc serves a trie with the Trie gRPC service defined in
+// trie.proto, of which the generated code is in package triepb.
+//
+// It is a module of its own, thus github.com/openacid/trie does not depend on
+// gRPC. It requires a tagged release of the trie module, and go.work in this
+// directory builds it with the trie in the parent directory instead.
+//
+// Since 0.2.0
+package grpc
+
+//go:generate buf generate
+
+import (
+	"context"
+
+	"github.com/openacid/trie"
+	"github.com/openacid/trie/grpc/triepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements triepb.TrieServer on the current version of a SyncTrie.
+// Every call reads one version, thus a stream is not affected by writes
+// during it.
+//
+// Since 0.2.0
+type Server struct {
+	triepb.UnimplementedTrieServer
+
+	trie *trie.SyncTrie
+	enc  func(v interface{}) ([]byte, error)
+}
+
+// NewServer creates a Server of `t`, encoding values with `enc`, e.g., the
+// same one as for Node.ExportFixed. Register it with
+// triepb.RegisterTrieServer.
+//
+// Since 0.2.0
+func NewServer(t *trie.SyncTrie, enc func(v interface{}) ([]byte, error)) *Server {
+	return &Server{trie: t, enc: enc}
+}
+
+// Get implements triepb.TrieServer with SyncTrie.Get.
+//
+// Since 0.2.0
+func (s *Server) Get(ctx context.Context, req *triepb.GetRequest) (*triepb.GetResponse, error) {
+
+	v, found := s.trie.Get(req.GetKey())
+	if !found {
+		return &triepb.GetResponse{}, nil
+	}
+
+	value, err := s.encode(v)
+	if err != nil {
+		return nil, err
+	}
+	return &triepb.GetResponse{Found: true, Value: value}, nil
+}
+
+// SearchPrefix implements triepb.TrieServer with an Iter of
+// trie.WithStripPrefix. The same as the Iter, in a squashed trie the bytes
+// removed by squashing are not compared with the prefix.
+//
+// Since 0.2.0
+func (s *Server) SearchPrefix(req *triepb.SearchPrefixRequest, stream triepb.Trie_SearchPrefixServer) error {
+
+	prefix := req.GetPrefix()
+	it := s.trie.Load().NewIter(trie.WithStripPrefix(prefix), trie.WithPrependPrefix(prefix))
+
+	lim := newLimit(req.GetLimit())
+	for lim.more() && it.Next() {
+		if err := s.send(stream, it.Key(), it.Value()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Range implements triepb.TrieServer with SyncTrie.Scan.
+//
+// Since 0.2.0
+func (s *Server) Range(req *triepb.RangeRequest, stream triepb.Trie_RangeServer) error {
+
+	var err error
+	lim := newLimit(req.GetLimit())
+
+	_ = s.trie.Scan(req.Start, req.End, func(key []byte, value interface{}) bool {
+		if !lim.more() {
+			return false
+		}
+		err = s.send(stream, key, value)
+		return err == nil
+	})
+	return err
+}
+
+// TopK implements triepb.TrieServer with Node.TopPrefixes. A negative K or
+// MinDepth is rejected with codes.InvalidArgument.
+//
+// Since 0.2.0
+func (s *Server) TopK(ctx context.Context, req *triepb.TopKRequest) (*triepb.TopKResponse, error) {
+
+	if req.GetK() < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "negative k: %d", req.GetK())
+	}
+	if req.GetMinDepth() < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "negative min_depth: %d", req.GetMinDepth())
+	}
+
+	rst := &triepb.TopKResponse{}
+	for _, p := range s.trie.Load().TopPrefixes(int(req.GetK()), int(req.GetMinDepth())) {
+		rst.Prefixes = append(rst.Prefixes, &triepb.PrefixCount{
+			Prefix: p.Prefix,
+			KeyCnt: uint64(p.KeyCnt),
+		})
+	}
+	return rst, nil
+}
+
+type keyValueSender interface {
+	Context() context.Context
+	Send(*triepb.KeyValue) error
+}
+
+// send sends a key and its value, unless the call is canceled.
+func (s *Server) send(stream keyValueSender, key []byte, v interface{}) error {
+
+	if err := stream.Context().Err(); err != nil {
+		return status.FromContextError(err).Err()
+	}
+
+	value, err := s.encode(v)
+	if err != nil {
+		return err
+	}
+
+	// A key of an Iter or Scan is only valid until the next one.
+	k := make([]byte, len(key))
+	copy(k, key)
+	return stream.Send(&triepb.KeyValue{Key: k, Value: value})
+}
+
+func (s *Server) encode(v interface{}) ([]byte, error) {
+	value, err := s.enc(v)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "encode value: %v", err)
+	}
+	return value, nil
+}
+
+// limit counts the keys sent against the limit of a request.
+type limit struct {
+	max, sent uint32
+}
+
+func newLimit(max uint32) *limit {
+	return &limit{max: max}
+}
+
+// more returns true and counts one more key if the limit allows it. A max of
+// 0 means no limit.
+func (l *limit) more() bool {
+	if l.max > 0 && l.sent >= l.max {
+		return false
+	}
+	l.sent++
+	return true
+}
@@ -0,0 +1,159 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/openacid/trie"
+	"github.com/openacid/trie/grpc/triepb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func strEnc(v interface{}) ([]byte, error) {
+	s, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("not a string: %v", v)
+	}
+	return []byte(s), nil
+}
+
+// newClient serves `t` on an in-memory listener and returns a client of it.
+func newClient(t *testing.T, st *trie.SyncTrie) triepb.TrieClient {
+
+	ta := require.New(t)
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	triepb.RegisterTrieServer(srv, NewServer(st, strEnc))
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	ta.Nil(err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return triepb.NewTrieClient(conn)
+}
+
+func recvAll(t *testing.T, stream interface {
+	Recv() (*triepb.KeyValue, error)
+}) []string {
+
+	ta := require.New(t)
+
+	rst := []string{}
+	for {
+		kv, err := stream.Recv()
+		if err == io.EOF {
+			return rst
+		}
+		ta.Nil(err)
+		rst = append(rst, string(kv.Key)+"="+string(kv.Value))
+	}
+}
+
+func TestServer(t *testing.T) {
+
+	ta := require.New(t)
+
+	strs := []string{"abc", "abd", "ac", "b", "bc", "bcd", "c"}
+	keys := make([][]byte, len(strs))
+	for i, s := range strs {
+		keys[i] = []byte(s)
+	}
+	st, err := trie.NewSyncTrie(keys, strs, false)
+	ta.Nil(err)
+
+	c := newClient(t, st)
+	ctx := context.Background()
+
+	got, err := c.Get(ctx, &triepb.GetRequest{Key: []byte("bc")})
+	ta.Nil(err)
+	ta.True(got.Found)
+	ta.Equal("bc", string(got.Value))
+
+	got, err = c.Get(ctx, &triepb.GetRequest{Key: []byte("bb")})
+	ta.Nil(err)
+	ta.False(got.Found)
+
+	cases := []struct {
+		prefix string
+		limit  uint32
+		want   []string
+	}{
+		{"a", 0, []string{"abc=abc", "abd=abd", "ac=ac"}},
+		{"ab", 0, []string{"abc=abc", "abd=abd"}},
+		{"b", 2, []string{"b=b", "bc=bc"}},
+		{"", 1, []string{"abc=abc"}},
+		{"x", 0, []string{}},
+	}
+	for _, cs := range cases {
+		stream, err := c.SearchPrefix(ctx, &triepb.SearchPrefixRequest{Prefix: []byte(cs.prefix), Limit: cs.limit})
+		ta.Nil(err)
+		ta.Equal(cs.want, recvAll(t, stream), "prefix: %q", cs.prefix)
+	}
+
+	rcases := []struct {
+		req  *triepb.RangeRequest
+		want []string
+	}{
+		{&triepb.RangeRequest{}, []string{"abc=abc", "abd=abd", "ac=ac", "b=b", "bc=bc", "bcd=bcd", "c=c"}},
+		{&triepb.RangeRequest{Start: []byte("ac"), End: []byte("bcd")}, []string{"ac=ac", "b=b", "bc=bc"}},
+		{&triepb.RangeRequest{Start: []byte("b"), Limit: 2}, []string{"b=b", "bc=bc"}},
+		{&triepb.RangeRequest{End: []byte("ab")}, []string{}},
+	}
+	for _, cs := range rcases {
+		stream, err := c.Range(ctx, cs.req)
+		ta.Nil(err)
+		ta.Equal(cs.want, recvAll(t, stream), "range: %v", cs.req)
+	}
+
+	top, err := c.TopK(ctx, &triepb.TopKRequest{K: 2, MinDepth: 1})
+	ta.Nil(err)
+	ta.Equal(2, len(top.Prefixes))
+	ta.Equal("a", string(top.Prefixes[0].Prefix))
+	ta.Equal(uint64(3), top.Prefixes[0].KeyCnt)
+	ta.Equal("b", string(top.Prefixes[1].Prefix))
+	ta.Equal(uint64(3), top.Prefixes[1].KeyCnt)
+
+	for _, req := range []*triepb.TopKRequest{{K: -1}, {K: 2, MinDepth: -1}} {
+		_, err = c.TopK(ctx, req)
+		ta.Equal(codes.InvalidArgument, status.Code(err), "%v", req)
+	}
+
+	// Calls read the current version.
+	ta.Nil(st.Set([]byte("d"), "d"))
+	got, err = c.Get(ctx, &triepb.GetRequest{Key: []byte("d")})
+	ta.Nil(err)
+	ta.Equal("d", string(got.Value))
+}
+
+func TestServer_encodeError(t *testing.T) {
+
+	ta := require.New(t)
+
+	st, err := trie.NewSyncTrie([][]byte{[]byte("a")}, []int{1}, false)
+	ta.Nil(err)
+
+	c := newClient(t, st)
+
+	_, err = c.Get(context.Background(), &triepb.GetRequest{Key: []byte("a")})
+	ta.Equal(codes.Internal, status.Code(err))
+
+	stream, err := c.Range(context.Background(), &triepb.RangeRequest{})
+	ta.Nil(err)
+	_, err = stream.Recv()
+	ta.Equal(codes.Internal, status.Code(err))
+}
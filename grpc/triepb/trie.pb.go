@@ -0,0 +1,549 @@
+// Trie is a read-only query service backed by a trie of
+// github.com/openacid/trie.
+//
+// Keys are raw bytes. Values are bytes encoded from trie values by the server,
+// e.g., with the value encoder of Node.ExportFixed.
+//
+// Since 0.2.0
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: trie.proto
+
+package triepb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type KeyValue struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           []byte                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value         []byte                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeyValue) Reset() {
+	*x = KeyValue{}
+	mi := &file_trie_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeyValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyValue) ProtoMessage() {}
+
+func (x *KeyValue) ProtoReflect() protoreflect.Message {
+	mi := &file_trie_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyValue.ProtoReflect.Descriptor instead.
+func (*KeyValue) Descriptor() ([]byte, []int) {
+	return file_trie_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *KeyValue) GetKey() []byte {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+func (x *KeyValue) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+type GetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           []byte                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRequest) Reset() {
+	*x = GetRequest{}
+	mi := &file_trie_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRequest) ProtoMessage() {}
+
+func (x *GetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_trie_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRequest.ProtoReflect.Descriptor instead.
+func (*GetRequest) Descriptor() ([]byte, []int) {
+	return file_trie_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetRequest) GetKey() []byte {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+type GetResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Found         bool                   `protobuf:"varint,1,opt,name=found,proto3" json:"found,omitempty"`
+	Value         []byte                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetResponse) Reset() {
+	*x = GetResponse{}
+	mi := &file_trie_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetResponse) ProtoMessage() {}
+
+func (x *GetResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_trie_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetResponse.ProtoReflect.Descriptor instead.
+func (*GetResponse) Descriptor() ([]byte, []int) {
+	return file_trie_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetResponse) GetFound() bool {
+	if x != nil {
+		return x.Found
+	}
+	return false
+}
+
+func (x *GetResponse) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+type SearchPrefixRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Prefix []byte                 `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	// limit is the max number of keys to stream. 0 means no limit.
+	Limit         uint32 `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchPrefixRequest) Reset() {
+	*x = SearchPrefixRequest{}
+	mi := &file_trie_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchPrefixRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchPrefixRequest) ProtoMessage() {}
+
+func (x *SearchPrefixRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_trie_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchPrefixRequest.ProtoReflect.Descriptor instead.
+func (*SearchPrefixRequest) Descriptor() ([]byte, []int) {
+	return file_trie_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SearchPrefixRequest) GetPrefix() []byte {
+	if x != nil {
+		return x.Prefix
+	}
+	return nil
+}
+
+func (x *SearchPrefixRequest) GetLimit() uint32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type RangeRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// start is the inclusive lower bound. An unset start means no lower bound.
+	Start []byte `protobuf:"bytes,1,opt,name=start,proto3,oneof" json:"start,omitempty"`
+	// end is the exclusive upper bound. An unset end means no upper bound.
+	End []byte `protobuf:"bytes,2,opt,name=end,proto3,oneof" json:"end,omitempty"`
+	// limit is the max number of keys to stream. 0 means no limit.
+	Limit         uint32 `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RangeRequest) Reset() {
+	*x = RangeRequest{}
+	mi := &file_trie_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RangeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RangeRequest) ProtoMessage() {}
+
+func (x *RangeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_trie_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RangeRequest.ProtoReflect.Descriptor instead.
+func (*RangeRequest) Descriptor() ([]byte, []int) {
+	return file_trie_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *RangeRequest) GetStart() []byte {
+	if x != nil {
+		return x.Start
+	}
+	return nil
+}
+
+func (x *RangeRequest) GetEnd() []byte {
+	if x != nil {
+		return x.End
+	}
+	return nil
+}
+
+func (x *RangeRequest) GetLimit() uint32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type TopKRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// k is the max number of returned prefixes. A negative k is rejected with
+	// INVALID_ARGUMENT.
+	K int32 `protobuf:"varint,1,opt,name=k,proto3" json:"k,omitempty"`
+	// min_depth is the min length of returned prefixes. A negative min_depth is
+	// rejected with INVALID_ARGUMENT.
+	MinDepth      int32 `protobuf:"varint,2,opt,name=min_depth,json=minDepth,proto3" json:"min_depth,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TopKRequest) Reset() {
+	*x = TopKRequest{}
+	mi := &file_trie_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TopKRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TopKRequest) ProtoMessage() {}
+
+func (x *TopKRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_trie_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TopKRequest.ProtoReflect.Descriptor instead.
+func (*TopKRequest) Descriptor() ([]byte, []int) {
+	return file_trie_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *TopKRequest) GetK() int32 {
+	if x != nil {
+		return x.K
+	}
+	return 0
+}
+
+func (x *TopKRequest) GetMinDepth() int32 {
+	if x != nil {
+		return x.MinDepth
+	}
+	return 0
+}
+
+type PrefixCount struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Prefix        []byte                 `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	KeyCnt        uint64                 `protobuf:"varint,2,opt,name=key_cnt,json=keyCnt,proto3" json:"key_cnt,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PrefixCount) Reset() {
+	*x = PrefixCount{}
+	mi := &file_trie_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PrefixCount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PrefixCount) ProtoMessage() {}
+
+func (x *PrefixCount) ProtoReflect() protoreflect.Message {
+	mi := &file_trie_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PrefixCount.ProtoReflect.Descriptor instead.
+func (*PrefixCount) Descriptor() ([]byte, []int) {
+	return file_trie_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *PrefixCount) GetPrefix() []byte {
+	if x != nil {
+		return x.Prefix
+	}
+	return nil
+}
+
+func (x *PrefixCount) GetKeyCnt() uint64 {
+	if x != nil {
+		return x.KeyCnt
+	}
+	return 0
+}
+
+type TopKResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Prefixes      []*PrefixCount         `protobuf:"bytes,1,rep,name=prefixes,proto3" json:"prefixes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TopKResponse) Reset() {
+	*x = TopKResponse{}
+	mi := &file_trie_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TopKResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TopKResponse) ProtoMessage() {}
+
+func (x *TopKResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_trie_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TopKResponse.ProtoReflect.Descriptor instead.
+func (*TopKResponse) Descriptor() ([]byte, []int) {
+	return file_trie_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *TopKResponse) GetPrefixes() []*PrefixCount {
+	if x != nil {
+		return x.Prefixes
+	}
+	return nil
+}
+
+var File_trie_proto protoreflect.FileDescriptor
+
+const file_trie_proto_rawDesc = "" +
+	"\n" +
+	"\n" +
+	"trie.proto\x12\x10openacid.trie.v1\"2\n" +
+	"\bKeyValue\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\fR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value\"\x1e\n" +
+	"\n" +
+	"GetRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\fR\x03key\"9\n" +
+	"\vGetResponse\x12\x14\n" +
+	"\x05found\x18\x01 \x01(\bR\x05found\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value\"C\n" +
+	"\x13SearchPrefixRequest\x12\x16\n" +
+	"\x06prefix\x18\x01 \x01(\fR\x06prefix\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\rR\x05limit\"h\n" +
+	"\fRangeRequest\x12\x19\n" +
+	"\x05start\x18\x01 \x01(\fH\x00R\x05start\x88\x01\x01\x12\x15\n" +
+	"\x03end\x18\x02 \x01(\fH\x01R\x03end\x88\x01\x01\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\rR\x05limitB\b\n" +
+	"\x06_startB\x06\n" +
+	"\x04_end\"8\n" +
+	"\vTopKRequest\x12\f\n" +
+	"\x01k\x18\x01 \x01(\x05R\x01k\x12\x1b\n" +
+	"\tmin_depth\x18\x02 \x01(\x05R\bminDepth\">\n" +
+	"\vPrefixCount\x12\x16\n" +
+	"\x06prefix\x18\x01 \x01(\fR\x06prefix\x12\x17\n" +
+	"\akey_cnt\x18\x02 \x01(\x04R\x06keyCnt\"I\n" +
+	"\fTopKResponse\x129\n" +
+	"\bprefixes\x18\x01 \x03(\v2\x1d.openacid.trie.v1.PrefixCountR\bprefixes2\xad\x02\n" +
+	"\x04Trie\x12B\n" +
+	"\x03Get\x12\x1c.openacid.trie.v1.GetRequest\x1a\x1d.openacid.trie.v1.GetResponse\x12S\n" +
+	"\fSearchPrefix\x12%.openacid.trie.v1.SearchPrefixRequest\x1a\x1a.openacid.trie.v1.KeyValue0\x01\x12E\n" +
+	"\x05Range\x12\x1e.openacid.trie.v1.RangeRequest\x1a\x1a.openacid.trie.v1.KeyValue0\x01\x12E\n" +
+	"\x04TopK\x12\x1d.openacid.trie.v1.TopKRequest\x1a\x1e.openacid.trie.v1.TopKResponseB&Z$github.com/openacid/trie/grpc/triepbb\x06proto3"
+
+var (
+	file_trie_proto_rawDescOnce sync.Once
+	file_trie_proto_rawDescData []byte
+)
+
+func file_trie_proto_rawDescGZIP() []byte {
+	file_trie_proto_rawDescOnce.Do(func() {
+		file_trie_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_trie_proto_rawDesc), len(file_trie_proto_rawDesc)))
+	})
+	return file_trie_proto_rawDescData
+}
+
+var file_trie_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_trie_proto_goTypes = []any{
+	(*KeyValue)(nil),            // 0: openacid.trie.v1.KeyValue
+	(*GetRequest)(nil),          // 1: openacid.trie.v1.GetRequest
+	(*GetResponse)(nil),         // 2: openacid.trie.v1.GetResponse
+	(*SearchPrefixRequest)(nil), // 3: openacid.trie.v1.SearchPrefixRequest
+	(*RangeRequest)(nil),        // 4: openacid.trie.v1.RangeRequest
+	(*TopKRequest)(nil),         // 5: openacid.trie.v1.TopKRequest
+	(*PrefixCount)(nil),         // 6: openacid.trie.v1.PrefixCount
+	(*TopKResponse)(nil),        // 7: openacid.trie.v1.TopKResponse
+}
+var file_trie_proto_depIdxs = []int32{
+	6, // 0: openacid.trie.v1.TopKResponse.prefixes:type_name -> openacid.trie.v1.PrefixCount
+	1, // 1: openacid.trie.v1.Trie.Get:input_type -> openacid.trie.v1.GetRequest
+	3, // 2: openacid.trie.v1.Trie.SearchPrefix:input_type -> openacid.trie.v1.SearchPrefixRequest
+	4, // 3: openacid.trie.v1.Trie.Range:input_type -> openacid.trie.v1.RangeRequest
+	5, // 4: openacid.trie.v1.Trie.TopK:input_type -> openacid.trie.v1.TopKRequest
+	2, // 5: openacid.trie.v1.Trie.Get:output_type -> openacid.trie.v1.GetResponse
+	0, // 6: openacid.trie.v1.Trie.SearchPrefix:output_type -> openacid.trie.v1.KeyValue
+	0, // 7: openacid.trie.v1.Trie.Range:output_type -> openacid.trie.v1.KeyValue
+	7, // 8: openacid.trie.v1.Trie.TopK:output_type -> openacid.trie.v1.TopKResponse
+	5, // [5:9] is the sub-list for method output_type
+	1, // [1:5] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_trie_proto_init() }
+func file_trie_proto_init() {
+	if File_trie_proto != nil {
+		return
+	}
+	file_trie_proto_msgTypes[4].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_trie_proto_rawDesc), len(file_trie_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_trie_proto_goTypes,
+		DependencyIndexes: file_trie_proto_depIdxs,
+		MessageInfos:      file_trie_proto_msgTypes,
+	}.Build()
+	File_trie_proto = out.File
+	file_trie_proto_goTypes = nil
+	file_trie_proto_depIdxs = nil
+}
@@ -0,0 +1,264 @@
+// Trie is a read-only query service backed by a trie of
+// github.com/openacid/trie.
+//
+// Keys are raw bytes. Values are bytes encoded from trie values by the server,
+// e.g., with the value encoder of Node.ExportFixed.
+//
+// Since 0.2.0
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.0
+// - protoc             (unknown)
+// source: trie.proto
+
+package triepb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Trie_Get_FullMethodName          = "/openacid.trie.v1.Trie/Get"
+	Trie_SearchPrefix_FullMethodName = "/openacid.trie.v1.Trie/SearchPrefix"
+	Trie_Range_FullMethodName        = "/openacid.trie.v1.Trie/Range"
+	Trie_TopK_FullMethodName         = "/openacid.trie.v1.Trie/TopK"
+)
+
+// TrieClient is the client API for Trie service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type TrieClient interface {
+	// Get returns the value of a key, as Node.Get does.
+	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error)
+	// SearchPrefix streams the keys starting with a prefix and their values, in
+	// ascending key order.
+	SearchPrefix(ctx context.Context, in *SearchPrefixRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[KeyValue], error)
+	// Range streams the keys in [start, end) and their values, in ascending key
+	// order, as SyncTrie.Scan does.
+	Range(ctx context.Context, in *RangeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[KeyValue], error)
+	// TopK returns the prefixes covering the most keys, as Node.TopPrefixes
+	// does.
+	TopK(ctx context.Context, in *TopKRequest, opts ...grpc.CallOption) (*TopKResponse, error)
+}
+
+type trieClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTrieClient(cc grpc.ClientConnInterface) TrieClient {
+	return &trieClient{cc}
+}
+
+func (c *trieClient) Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetResponse)
+	err := c.cc.Invoke(ctx, Trie_Get_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trieClient) SearchPrefix(ctx context.Context, in *SearchPrefixRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[KeyValue], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Trie_ServiceDesc.Streams[0], Trie_SearchPrefix_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SearchPrefixRequest, KeyValue]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Trie_SearchPrefixClient = grpc.ServerStreamingClient[KeyValue]
+
+func (c *trieClient) Range(ctx context.Context, in *RangeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[KeyValue], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Trie_ServiceDesc.Streams[1], Trie_Range_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RangeRequest, KeyValue]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Trie_RangeClient = grpc.ServerStreamingClient[KeyValue]
+
+func (c *trieClient) TopK(ctx context.Context, in *TopKRequest, opts ...grpc.CallOption) (*TopKResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TopKResponse)
+	err := c.cc.Invoke(ctx, Trie_TopK_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TrieServer is the server API for Trie service.
+// All implementations must embed UnimplementedTrieServer
+// for forward compatibility.
+type TrieServer interface {
+	// Get returns the value of a key, as Node.Get does.
+	Get(context.Context, *GetRequest) (*GetResponse, error)
+	// SearchPrefix streams the keys starting with a prefix and their values, in
+	// ascending key order.
+	SearchPrefix(*SearchPrefixRequest, grpc.ServerStreamingServer[KeyValue]) error
+	// Range streams the keys in [start, end) and their values, in ascending key
+	// order, as SyncTrie.Scan does.
+	Range(*RangeRequest, grpc.ServerStreamingServer[KeyValue]) error
+	// TopK returns the prefixes covering the most keys, as Node.TopPrefixes
+	// does.
+	TopK(context.Context, *TopKRequest) (*TopKResponse, error)
+	mustEmbedUnimplementedTrieServer()
+}
+
+// UnimplementedTrieServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedTrieServer struct{}
+
+func (UnimplementedTrieServer) Get(context.Context, *GetRequest) (*GetResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Get not implemented")
+}
+func (UnimplementedTrieServer) SearchPrefix(*SearchPrefixRequest, grpc.ServerStreamingServer[KeyValue]) error {
+	return status.Error(codes.Unimplemented, "method SearchPrefix not implemented")
+}
+func (UnimplementedTrieServer) Range(*RangeRequest, grpc.ServerStreamingServer[KeyValue]) error {
+	return status.Error(codes.Unimplemented, "method Range not implemented")
+}
+func (UnimplementedTrieServer) TopK(context.Context, *TopKRequest) (*TopKResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method TopK not implemented")
+}
+func (UnimplementedTrieServer) mustEmbedUnimplementedTrieServer() {}
+func (UnimplementedTrieServer) testEmbeddedByValue()              {}
+
+// UnsafeTrieServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TrieServer will
+// result in compilation errors.
+type UnsafeTrieServer interface {
+	mustEmbedUnimplementedTrieServer()
+}
+
+func RegisterTrieServer(s grpc.ServiceRegistrar, srv TrieServer) {
+	// If the following call panics, it indicates UnimplementedTrieServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Trie_ServiceDesc, srv)
+}
+
+func _Trie_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrieServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Trie_Get_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrieServer).Get(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Trie_SearchPrefix_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SearchPrefixRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TrieServer).SearchPrefix(m, &grpc.GenericServerStream[SearchPrefixRequest, KeyValue]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Trie_SearchPrefixServer = grpc.ServerStreamingServer[KeyValue]
+
+func _Trie_Range_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RangeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TrieServer).Range(m, &grpc.GenericServerStream[RangeRequest, KeyValue]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Trie_RangeServer = grpc.ServerStreamingServer[KeyValue]
+
+func _Trie_TopK_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TopKRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrieServer).TopK(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Trie_TopK_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrieServer).TopK(ctx, req.(*TopKRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Trie_ServiceDesc is the grpc.ServiceDesc for Trie service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Trie_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "openacid.trie.v1.Trie",
+	HandlerType: (*TrieServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Get",
+			Handler:    _Trie_Get_Handler,
+		},
+		{
+			MethodName: "TopK",
+			Handler:    _Trie_TopK_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SearchPrefix",
+			Handler:       _Trie_SearchPrefix_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Range",
+			Handler:       _Trie_Range_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "trie.proto",
+}
@@ -0,0 +1,131 @@
+package trie
+
+import (
+	"encoding/binary"
+	"reflect"
+	"runtime"
+)
+
+// WithHashConsing makes NewTrie share one instance of structurally identical
+// subtrees: subtrees with the same branches, steps and values. It turns the
+// trie into a DAG, which saves a lot of nodes if keys have many repeated
+// suffixes with the same values.
+//
+// Values are compared with ==. A subtree with a value that is not comparable,
+// e.g., a slice, or a struct of which an interface field holds a slice, is not
+// shared.
+//
+// Since nodes are shared by several keys, the trie is read only:
+// Append and Set return ErrReadOnly, and Remove removes nothing.
+// InnerNodeCnt still counts shared nodes once for every path through them.
+//
+// Since 0.2.0
+func WithHashConsing() Option {
+	return func(o *options) {
+		o.hashConsing = true
+	}
+}
+
+func (r *Node) readOnly() bool {
+	return r.opt != nil && r.opt.readOnly
+}
+
+// hashCons replaces every subtree of r with the first identical subtree found
+// in depth first order. It returns the number of subtrees replaced.
+func (r *Node) hashCons() int {
+	replaced, _ := r.hashConsWith(false)
+	r.opt.readOnly = true
+
+	// Extremes must not keep the leaves of replaced subtrees.
+	if r.cachedExtremes() {
+		r.reaggregate(r)
+	}
+
+	return replaced
+}
+
+// hashConsWith is hashCons that only counts the nodes it would replace and
+// their bytes, see Node.size, with `dryRun`.
+func (r *Node) hashConsWith(dryRun bool) (replaced, bytes int) {
+
+	// ids maps a node signature to the index of the node in nodes.
+	ids := make(map[string]int)
+	nodes := []*Node{}
+
+	valueIDs := make(map[interface{}]int)
+
+	// valueID returns the id of `v`, or false if `v` can not be a map key.
+	// A value of a comparable type may still hold an uncomparable one in an
+	// interface, which is only found when it is hashed.
+	valueID := func(v interface{}) (id int, ok bool) {
+
+		if v != nil && !reflect.TypeOf(v).Comparable() {
+			return 0, false
+		}
+
+		defer func() {
+			if e := recover(); e != nil {
+				if _, isRuntime := e.(runtime.Error); !isRuntime {
+					panic(e)
+				}
+				ok = false
+			}
+		}()
+
+		id, found := valueIDs[v]
+		if !found {
+			id = len(valueIDs)
+			valueIDs[v] = id
+		}
+		return id, true
+	}
+
+	buf := make([]byte, binary.MaxVarintLen64)
+	appendUvarint := func(sig []byte, v int) []byte {
+		n := binary.PutUvarint(buf, uint64(v))
+		return append(sig, buf[:n]...)
+	}
+
+	// walk returns the index of the node identical to n.
+	var walk func(n *Node) int
+	walk = func(n *Node) int {
+
+		var sig []byte
+
+		if len(n.Branches) == 0 {
+			vid, ok := valueID(n.Value)
+			if !ok {
+				nodes = append(nodes, n)
+				return len(nodes) - 1
+			}
+			sig = append(sig, 'L')
+			sig = appendUvarint(sig, vid)
+		} else {
+			sig = append(sig, 'N')
+			sig = appendUvarint(sig, int(n.Step))
+			for _, b := range n.Branches {
+				id := walk(n.Children[b])
+				if !dryRun {
+					n.Children[b] = nodes[id]
+				}
+
+				// leafBranch is -1.
+				sig = appendUvarint(sig, b+1)
+				sig = appendUvarint(sig, id)
+			}
+		}
+
+		if id, ok := ids[string(sig)]; ok {
+			replaced++
+			bytes += n.size()
+			return id
+		}
+
+		nodes = append(nodes, n)
+		ids[string(sig)] = len(nodes) - 1
+		return len(nodes) - 1
+	}
+	walk(r)
+
+	return replaced, bytes
+}
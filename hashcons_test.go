@@ -0,0 +1,90 @@
+package trie
+
+import (
+	"testing"
+
+	"github.com/openacid/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// distinctNodeCnt counts nodes reachable from r, counting a shared node once.
+func distinctNodeCnt(r *Node) int {
+	seen := map[*Node]bool{}
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		if seen[n] {
+			return
+		}
+		seen[n] = true
+		for _, b := range n.Branches {
+			walk(n.Children[b])
+		}
+	}
+	walk(r)
+	return len(seen)
+}
+
+func TestWithHashConsing(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := [][]byte{}
+	values := []interface{}{}
+	for _, p := range []string{"a", "b", "c"} {
+		for _, s := range []string{"/x/1", "/x/2", "/y"} {
+			keys = append(keys, []byte(p+s))
+			values = append(values, s)
+		}
+	}
+	probes := append(keys, []byte(""), []byte("a/x"), []byte("b/z"), []byte("d"))
+
+	for _, squash := range []bool{false, true} {
+
+		plain, err := NewTrie(keys, values, squash)
+		ta.Nil(err)
+
+		tr, err := NewTrie(keys, values, squash, WithHashConsing())
+		ta.Nil(err)
+
+		ta.True(distinctNodeCnt(tr) < distinctNodeCnt(plain), "squash: %v", squash)
+
+		// The subtrees below "a", "b" and "c" are shared.
+		ta.True(tr.Children['a'] == tr.Children['b'])
+		ta.True(tr.Children['a'] == tr.Children['c'])
+
+		for _, k := range probes {
+			lt, eq, gt := plain.Search(k)
+			glt, geq, ggt := tr.Search(k)
+			ta.Equal([]interface{}{lt, eq, gt}, []interface{}{glt, geq, ggt}, "key: %q", k)
+		}
+
+		ks, vs := iterAll(plain.NewIter())
+		gks, gvs := iterAll(tr.NewIter())
+		ta.Equal(ks, gks)
+		ta.Equal(vs, gvs)
+
+		_, err = tr.Append([]byte("z"), "z")
+		ta.Equal(ErrReadOnly, errors.Cause(err))
+		_, err = tr.Set([]byte("z"), "z")
+		ta.Equal(ErrReadOnly, errors.Cause(err))
+		ta.False(tr.Remove([]byte("a/y")))
+	}
+
+	// Values not comparable are not shared.
+	tr, err := NewTrie(
+		[][]byte{[]byte("a1"), []byte("b1")},
+		[]interface{}{[]int{1}, []int{1}},
+		false, WithHashConsing())
+	ta.Nil(err)
+	ta.True(tr.Children['a'] != tr.Children['b'])
+
+	// Nor are values of a comparable type holding values not comparable.
+	type holder struct{ v interface{} }
+	tr, err = NewTrie(
+		[][]byte{[]byte("a1"), []byte("b1"), []byte("c1"), []byte("d1")},
+		[]interface{}{holder{[]int{1}}, holder{[]int{1}}, holder{2}, holder{2}},
+		false, WithHashConsing())
+	ta.Nil(err)
+	ta.True(tr.Children['a'] != tr.Children['b'])
+	ta.True(tr.Children['c'] == tr.Children['d'])
+}
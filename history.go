@@ -0,0 +1,87 @@
+package trie
+
+import "time"
+
+// Version is a value a key had, and when it was set.
+//
+// Since 0.2.0
+type Version struct {
+	Value interface{}
+	Time  time.Time
+}
+
+// leafHistory is the history of the value of a leaf.
+type leafHistory struct {
+	// since is when the current value was set.
+	since time.Time
+	// prev are the prior values, the latest first.
+	prev []Version
+}
+
+// WithHistory makes Set keep the last `n` prior values of every key, which
+// History returns.
+//
+// The history of a key is dropped once the key is removed, and is not copied
+// by Clone.
+//
+// Since 0.2.0
+func WithHistory(n int) Option {
+	return func(o *options) {
+		o.historyLen = n
+		o.history = true
+	}
+}
+
+// History returns the prior values of `key` and when they were set, the
+// latest first. It returns nil if `key` is not in the trie or the trie does
+// not keep history.
+//
+// Since 0.2.0
+func (r *Node) History(key []byte) []Version {
+
+	if r.opt == nil || !r.opt.history {
+		return nil
+	}
+
+	leaf := r.leafOf(r.normalize(key))
+	if leaf == nil {
+		return nil
+	}
+
+	h := leaf.history()
+	if h == nil || len(h.prev) == 0 {
+		return nil
+	}
+	return append([]Version{}, h.prev...)
+}
+
+// recordValue records that `leaf` has been set to a new value, and that its
+// prior value was `old` if `hadOld`.
+func (r *Node) recordValue(leaf *Node, old interface{}, hadOld bool) {
+
+	if r.opt == nil || !r.opt.history {
+		return
+	}
+
+	now := time.Now()
+
+	h := leaf.history()
+	if h == nil {
+		leaf.leaf().history = &leafHistory{since: now}
+		return
+	}
+
+	// The history is replaced instead of modified, since a copy of the leaf
+	// in an older version of a SyncTrie shares it.
+	nh := &leafHistory{since: now, prev: h.prev}
+	if hadOld && old != removed && r.opt.historyLen > 0 {
+		n := len(h.prev) + 1
+		if n > r.opt.historyLen {
+			n = r.opt.historyLen
+		}
+		nh.prev = make([]Version, n)
+		nh.prev[0] = Version{Value: old, Time: h.since}
+		copy(nh.prev[1:], h.prev)
+	}
+	leaf.leaf().history = nh
+}
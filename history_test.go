@@ -0,0 +1,60 @@
+package trie
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNode_History(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr, err := NewTrie([][]byte{[]byte("/a"), []byte("/b")}, []int{1, 2}, false, WithHistory(2))
+	ta.Nil(err)
+
+	ta.Nil(tr.History([]byte("/a")))
+	ta.Nil(tr.History([]byte("/x")))
+
+	values := func(vs []Version) []interface{} {
+		rst := []interface{}{}
+		for _, v := range vs {
+			rst = append(rst, v.Value)
+		}
+		return rst
+	}
+
+	for _, v := range []int{10, 11, 12} {
+		_, err = tr.Set([]byte("/a"), v)
+		ta.Nil(err)
+	}
+
+	h := tr.History([]byte("/a"))
+	ta.Equal([]interface{}{11, 10}, values(h))
+	ta.False(h[0].Time.Before(h[1].Time))
+	ta.False(h[1].Time.IsZero())
+
+	_, eq, _ := tr.Search([]byte("/a"))
+	ta.Equal(12, eq)
+
+	// A new key set twice.
+	_, err = tr.Set([]byte("/c"), 1)
+	ta.Nil(err)
+	_, err = tr.Set([]byte("/c"), 2)
+	ta.Nil(err)
+	ta.Equal([]interface{}{1}, values(tr.History([]byte("/c"))))
+
+	ta.True(tr.Remove([]byte("/a")))
+	ta.Nil(tr.History([]byte("/a")))
+
+	_, err = tr.Set([]byte("/a"), 13)
+	ta.Nil(err)
+	ta.Nil(tr.History([]byte("/a")))
+
+	// Without WithHistory.
+	tr, err = NewTrie([][]byte{[]byte("/a")}, []int{1}, false)
+	ta.Nil(err)
+	_, err = tr.Set([]byte("/a"), 2)
+	ta.Nil(err)
+	ta.Nil(tr.History([]byte("/a")))
+}
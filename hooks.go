@@ -0,0 +1,56 @@
+package trie
+
+// MutationHooks are callbacks invoked synchronously on every change of a key
+// by Append, Set, Remove and the methods built on them, including the
+// Appends of NewTrie. A nil callback is skipped.
+//
+// `key` must not be modified or retained by a callback.
+//
+// Since 0.2.0
+type MutationHooks struct {
+	// OnInsert is called after a key is added, with a nil `old`.
+	//
+	// Since 0.2.0
+	OnInsert func(key []byte, old, value interface{})
+
+	// OnUpdate is called after the value of an existent key is changed.
+	//
+	// Since 0.2.0
+	OnUpdate func(key []byte, old, value interface{})
+
+	// OnDelete is called after a key is removed, with a nil `value`.
+	//
+	// Since 0.2.0
+	OnDelete func(key []byte, old, value interface{})
+}
+
+// WithMutationHooks registers `h`. It can be used more than once, and hooks
+// are called in the order they are registered, before events are sent to
+// subscribers of Subscribe.
+//
+// Since 0.2.0
+func WithMutationHooks(h MutationHooks) Option {
+	return func(o *options) {
+		o.hooks = append(o.hooks, h)
+	}
+}
+
+// callHooks calls the hooks of a change of `key` from `old` to `value`,
+// which is of EventType `t`.
+func (r *Node) callHooks(t EventType, key []byte, old, value interface{}) {
+
+	for _, h := range r.opt.hooks {
+		var fn func(key []byte, old, value interface{})
+		switch t {
+		case EventAdded:
+			fn = h.OnInsert
+		case EventUpdated:
+			fn = h.OnUpdate
+		case EventRemoved:
+			fn = h.OnDelete
+		}
+		if fn != nil {
+			fn(key, old, value)
+		}
+	}
+}
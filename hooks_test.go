@@ -0,0 +1,57 @@
+package trie
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithMutationHooks(t *testing.T) {
+
+	ta := require.New(t)
+
+	var got []string
+	record := func(op string) func(key []byte, old, value interface{}) {
+		return func(key []byte, old, value interface{}) {
+			got = append(got, fmt.Sprintf("%s %s %v %v", op, key, old, value))
+		}
+	}
+
+	hooks := MutationHooks{
+		OnInsert: record("insert"),
+		OnUpdate: record("update"),
+		OnDelete: record("delete"),
+	}
+	second := MutationHooks{OnDelete: record("delete2")}
+
+	tr, err := NewTrie([][]byte{[]byte("a")}, []int{1}, false, WithMutationHooks(hooks), WithMutationHooks(second))
+	ta.Nil(err)
+
+	_, err = tr.Set([]byte("a"), 2)
+	ta.Nil(err)
+	_, err = tr.Append([]byte("b"), 3)
+	ta.Nil(err)
+	ta.True(tr.Remove([]byte("a")))
+	ta.False(tr.Remove([]byte("a")))
+
+	ta.Equal([]string{
+		"insert a <nil> 1",
+		"update a 1 2",
+		"insert b <nil> 3",
+		"delete a 2 <nil>",
+		"delete2 a 2 <nil>",
+	}, got)
+
+	got = nil
+	tr, err = NewTrie(nil, nil, false, WithMutationHooks(hooks), WithMultiValue(nil))
+	ta.Nil(err)
+	_, err = tr.Append([]byte("x"), 1)
+	ta.Nil(err)
+	_, err = tr.Append([]byte("x"), 2)
+	ta.Nil(err)
+	ta.Equal([]string{
+		"insert x <nil> [1]",
+		"update x [1] [1 2]",
+	}, got)
+}
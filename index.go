@@ -0,0 +1,67 @@
+package trie
+
+// NewIndexTrie creates a trie of ascendingly ordered `keys`, in which the
+// value of keys[i] is the index i. The actual values live in a slice owned by
+// the caller, e.g., a column of a columnar storage, and are found with
+// SearchIndex.
+//
+// A leaf keeps the index as an int32 instead of a Value, thus the trie holds
+// no value per key: Search, Get and Iter find nil values. The trie is read
+// only: Append and Set return ErrReadOnly and Remove removes nothing.
+//
+// Since 0.2.0
+func NewIndexTrie(keys [][]byte, squash bool, opts ...Option) (*Node, error) {
+
+	// Leaves are built with distinct values, or hash consing would share
+	// them, and the values are replaced with indexes once built.
+	idx := make([]int32, len(keys))
+	values := make([]interface{}, len(keys))
+	for i := range idx {
+		idx[i] = int32(i)
+		values[i] = &idx[i]
+	}
+
+	if keys == nil {
+		// NewTrie returns an empty trie for nil keys, without checking values.
+		values = nil
+	}
+
+	root, err := NewTrie(keys, values, squash, opts...)
+	if root == nil {
+		return nil, err
+	}
+
+	root.eachLeaf(nil, func(_ []byte, leaf *Node) {
+		if v, ok := leaf.Value.(*int32); ok {
+			leaf.index = *v
+			leaf.Value = nil
+		}
+	})
+	root.opt.readOnly = true
+
+	return root, err
+}
+
+// SearchIndex is Search on a trie created by NewIndexTrie. It returns the
+// indexes instead of values, and -1 for an absent one.
+//
+// Since 0.2.0
+func (r *Node) SearchIndex(key []byte) (ltIndex, eqIndex, gtIndex int) {
+
+	ltIndex, eqIndex, gtIndex = -1, -1, -1
+	if len(r.Branches) == 0 {
+		return
+	}
+
+	ltNode, eqNode, gtNode := r.descend(r, -1, r.normalize(key), nil)
+	if ltNode != nil {
+		ltIndex = int(ltNode.rightMost().index)
+	}
+	if eqNode != nil {
+		eqIndex = int(eqNode.index)
+	}
+	if gtNode != nil {
+		gtIndex = int(gtNode.leftMost().index)
+	}
+	return
+}
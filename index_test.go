@@ -0,0 +1,64 @@
+package trie
+
+import (
+	"testing"
+
+	"github.com/openacid/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewIndexTrie(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := [][]byte{[]byte("abc"), []byte("abd"), []byte("b")}
+	column := []string{"v0", "v1", "v2"}
+
+	for _, squash := range []bool{false, true} {
+		tr, err := NewIndexTrie(keys, squash)
+		ta.Nil(err)
+
+		cases := []struct {
+			key        string
+			lt, eq, gt int
+		}{
+			{"", -1, -1, 0},
+			{"abc", -1, 0, 1},
+			{"abd", 0, 1, 2},
+			{"b", 1, 2, -1},
+			{"c", 2, -1, -1},
+		}
+
+		for i, c := range cases {
+			lt, eq, gt := tr.SearchIndex([]byte(c.key))
+			ta.Equal([]int{c.lt, c.eq, c.gt}, []int{lt, eq, gt}, "%d-th: key: %q", i+1, c.key)
+		}
+
+		_, eq, _ := tr.SearchIndex([]byte("abd"))
+		ta.Equal("v1", column[eq])
+
+		// Indexes are not kept as values.
+		_, v, _ := tr.Search([]byte("abd"))
+		ta.Nil(v)
+
+		_, err = tr.Append([]byte("c"), nil)
+		ta.Equal(ErrReadOnly, errors.Cause(err))
+		tr.Remove([]byte("abd"))
+		_, eq, _ = tr.SearchIndex([]byte("abd"))
+		ta.Equal(1, eq)
+	}
+
+	// Hash consing does not share leaves of different indexes.
+	tr, err := NewIndexTrie(keys, true, WithHashConsing())
+	ta.Nil(err)
+	lt, eq, gt := tr.SearchIndex([]byte("abd"))
+	ta.Equal([]int{0, 1, 2}, []int{lt, eq, gt})
+
+	tr, err = NewIndexTrie(nil, false)
+	ta.Nil(err)
+	lt, eq, gt = tr.SearchIndex([]byte("a"))
+	ta.Equal([]int{-1, -1, -1}, []int{lt, eq, gt})
+
+	_, err = NewIndexTrie([][]byte{[]byte("b"), []byte("a")}, false)
+	ta.NotNil(err)
+}
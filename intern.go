@@ -0,0 +1,73 @@
+package trie
+
+// Interner is a pool of byte fragments, in which identical fragments share one
+// backing array. Fragments are copied into large chunks, which also saves the
+// per allocation overhead of many small slices.
+//
+// An interned fragment must not be modified. An Interner is not safe for
+// concurrent use.
+//
+// Since 0.2.0
+type Interner struct {
+	frags map[string][]byte
+	chunk []byte
+
+	// Size is the number of bytes held by interned fragments.
+	//
+	// Since 0.2.0
+	Size int
+
+	// Saved is the number of bytes not allocated because a fragment was
+	// already interned.
+	//
+	// Since 0.2.0
+	Saved int
+}
+
+// internChunkSize is the size of a chunk fragments are copied into.
+// A fragment larger than a quarter of it is allocated alone.
+const internChunkSize = 4096
+
+// NewInterner creates an empty Interner.
+//
+// Since 0.2.0
+func NewInterner() *Interner {
+	return &Interner{frags: make(map[string][]byte)}
+}
+
+// Intern returns a fragment equal to `b` from the pool, adding a copy of `b`
+// if there is none. The returned slice has no spare capacity, thus appending
+// to it never overwrites another fragment.
+//
+// Since 0.2.0
+func (in *Interner) Intern(b []byte) []byte {
+
+	// The compiler does not allocate a string for a map lookup.
+	if f, ok := in.frags[string(b)]; ok {
+		in.Saved += len(b)
+		return f
+	}
+
+	var f []byte
+	if len(b) > internChunkSize/4 {
+		f = append([]byte{}, b...)
+	} else {
+		if len(in.chunk)+len(b) > cap(in.chunk) {
+			in.chunk = make([]byte, 0, internChunkSize)
+		}
+		start := len(in.chunk)
+		in.chunk = append(in.chunk, b...)
+		f = in.chunk[start:len(in.chunk):len(in.chunk)]
+	}
+
+	in.frags[string(f)] = f
+	in.Size += len(f)
+	return f
+}
+
+// Len returns the number of distinct fragments.
+//
+// Since 0.2.0
+func (in *Interner) Len() int {
+	return len(in.frags)
+}
@@ -0,0 +1,43 @@
+package trie
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterner(t *testing.T) {
+
+	ta := require.New(t)
+
+	in := NewInterner()
+
+	a := in.Intern([]byte("abc"))
+	b := in.Intern([]byte("xy"))
+	a2 := in.Intern([]byte("abc"))
+
+	ta.Equal("abc", string(a))
+	ta.Equal("xy", string(b))
+	ta.True(&a[0] == &a2[0], "identical fragments share the backing array")
+	ta.Equal(2, in.Len())
+	ta.Equal(5, in.Size)
+	ta.Equal(3, in.Saved)
+
+	// Appending to a fragment does not overwrite the next one.
+	_ = append(a, 'z')
+	ta.Equal("xy", string(b))
+	ta.Equal(len(a), cap(a))
+
+	empty := in.Intern(nil)
+	ta.Equal(0, len(empty))
+
+	big := bytes.Repeat([]byte("x"), internChunkSize)
+	ta.Equal(big, in.Intern(big))
+
+	// The input is copied.
+	src := []byte("mutable")
+	f := in.Intern(src)
+	src[0] = 'M'
+	ta.Equal("mutable", string(f))
+}
@@ -0,0 +1,146 @@
+package trie
+
+import (
+	"sort"
+
+	"github.com/openacid/errors"
+)
+
+// Interval is a closed range of numbers [Lo, Hi].
+//
+// Since 0.2.0
+type Interval struct {
+	Lo, Hi uint64
+}
+
+// IntervalIndex finds the stored intervals containing a number.
+//
+// An interval is decomposed into the fewest aligned power-of-two blocks
+// covering it, at most 126 of them, and every block is stored as the bits of
+// its common prefix, one byte per bit. Thus the intervals containing a number
+// are found on the path of the bits of the number, in at most 65 steps.
+//
+// Since 0.2.0
+type IntervalIndex struct {
+	root *Node
+	n    int
+}
+
+// NewIntervalIndex creates an IntervalIndex of `intervals`. The id of an
+// interval is its index in `intervals`.
+// It returns ErrInvalidInterval if an interval has Lo greater than Hi.
+//
+// Since 0.2.0
+func NewIntervalIndex(intervals []Interval) (*IntervalIndex, error) {
+
+	ids := make(map[string][]int)
+	for id, iv := range intervals {
+		if iv.Lo > iv.Hi {
+			return nil, errors.Wrapf(ErrInvalidInterval, "interval %d: [%d, %d]", id, iv.Lo, iv.Hi)
+		}
+		for _, p := range intervalPrefixes(iv) {
+			ids[string(p)] = append(ids[string(p)], id)
+		}
+	}
+
+	prefixes := make([]string, 0, len(ids))
+	for p := range ids {
+		prefixes = append(prefixes, p)
+	}
+	sort.Strings(prefixes)
+
+	keys := make([][]byte, len(prefixes))
+	values := make([][]int, len(prefixes))
+	for i, p := range prefixes {
+		keys[i] = []byte(p)
+		values[i] = ids[p]
+	}
+
+	root, err := NewTrie(keys, values, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &IntervalIndex{root: root, n: len(intervals)}, nil
+}
+
+// Add adds `iv` and returns its id, which is the number of intervals added
+// before it.
+// It returns ErrInvalidInterval if Lo is greater than Hi.
+//
+// Since 0.2.0
+func (x *IntervalIndex) Add(iv Interval) (int, error) {
+
+	if iv.Lo > iv.Hi {
+		return 0, errors.Wrapf(ErrInvalidInterval, "[%d, %d]", iv.Lo, iv.Hi)
+	}
+
+	id := x.n
+	for _, p := range intervalPrefixes(iv) {
+		v, _ := x.root.lookup(p)
+		ids, _ := v.([]int)
+		if _, err := x.root.Set(p, append(ids, id)); err != nil {
+			return 0, err
+		}
+	}
+
+	x.n++
+	return id, nil
+}
+
+// Stab returns the ascending ids of the intervals containing `v`.
+//
+// Since 0.2.0
+func (x *IntervalIndex) Stab(v uint64) []int {
+
+	var rst []int
+
+	node := x.root
+	for d := 0; node != nil; d++ {
+		if leaf := node.Children[leafBranch]; leaf != nil {
+			rst = append(rst, leaf.Value.([]int)...)
+		}
+		if d == 64 {
+			break
+		}
+		node = node.Children[int(v>>uint(63-d)&1)]
+	}
+
+	// The blocks of an interval are disjoint, thus an id is found once.
+	sort.Ints(rst)
+	return rst
+}
+
+// intervalPrefixes returns the bit prefixes of the aligned blocks covering
+// `iv`, from the lowest block.
+func intervalPrefixes(iv Interval) [][]byte {
+
+	var rst [][]byte
+
+	lo := iv.Lo
+	for {
+		// Find the largest block aligned at lo and not beyond Hi.
+		k := 0
+		for k < 64 && lo&(1<<uint(k)) == 0 && iv.Hi-lo >= ^uint64(0)>>uint(63-k) {
+			k++
+		}
+
+		rst = append(rst, bitPrefix(lo, 64-k))
+
+		last := lo + (^uint64(0) >> uint(64-k))
+		if last >= iv.Hi {
+			return rst
+		}
+		lo = last + 1
+	}
+}
+
+// bitPrefix returns the first `n` bits of `v`, from the most significant one,
+// one byte per bit.
+func bitPrefix(v uint64, n int) []byte {
+	p := make([]byte, n)
+	for i := range p {
+		p[i] = byte(v >> uint(63-i) & 1)
+	}
+	return p
+}
@@ -0,0 +1,80 @@
+package trie
+
+import (
+	"math"
+	"testing"
+
+	"github.com/openacid/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntervalPrefixes(t *testing.T) {
+
+	ta := require.New(t)
+
+	cases := []struct {
+		iv   Interval
+		want int
+	}{
+		{Interval{0, 0}, 1},
+		{Interval{0, math.MaxUint64}, 1},
+		{Interval{4, 7}, 1},
+		{Interval{3, 8}, 3},
+		{Interval{1, math.MaxUint64 - 1}, 126},
+		{Interval{math.MaxUint64, math.MaxUint64}, 1},
+	}
+
+	for i, c := range cases {
+		ps := intervalPrefixes(c.iv)
+		ta.Equal(c.want, len(ps), "%d-th: %v", i+1, c.iv)
+	}
+
+	ta.Equal([][]byte{bitPrefix(3, 64), bitPrefix(4, 62), bitPrefix(8, 64)}, intervalPrefixes(Interval{3, 8}))
+	ta.Equal(0, len(bitPrefix(0, 0)))
+}
+
+func TestIntervalIndex(t *testing.T) {
+
+	ta := require.New(t)
+
+	intervals := []Interval{
+		{10, 20},
+		{15, 15},
+		{0, math.MaxUint64},
+		{19, 100},
+		{math.MaxUint64 - 1, math.MaxUint64},
+	}
+
+	x, err := NewIntervalIndex(intervals)
+	ta.Nil(err)
+
+	brute := func(v uint64) []int {
+		var rst []int
+		for id, iv := range intervals {
+			if iv.Lo <= v && v <= iv.Hi {
+				rst = append(rst, id)
+			}
+		}
+		return rst
+	}
+
+	points := []uint64{0, 9, 10, 14, 15, 16, 19, 20, 21, 100, 101, math.MaxUint64 - 1, math.MaxUint64}
+	for _, v := range points {
+		ta.Equal(brute(v), x.Stab(v), "v: %d", v)
+	}
+
+	id, err := x.Add(Interval{12, 30})
+	ta.Nil(err)
+	ta.Equal(5, id)
+	intervals = append(intervals, Interval{12, 30})
+
+	for _, v := range append(points, 12, 30, 31) {
+		ta.Equal(brute(v), x.Stab(v), "v: %d", v)
+	}
+
+	_, err = x.Add(Interval{2, 1})
+	ta.Equal(ErrInvalidInterval, errors.Cause(err))
+
+	_, err = NewIntervalIndex([]Interval{{5, 4}})
+	ta.Equal(ErrInvalidInterval, errors.Cause(err))
+}
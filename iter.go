@@ -0,0 +1,227 @@
+package trie
+
+import (
+	"bytes"
+	"sort"
+)
+
+// Iter iterates over all keys and values in a trie in ascending key order.
+//
+// Keys are rebuilt from branch labels. In a squashed trie the bytes removed
+// by squashing are unknown, thus a key only contains the bytes at which its
+// path branches, unless keys are stored WithStoreKeys.
+//
+// Without a snapshot, keys can be removed from the trie during an iteration,
+// e.g., by the goroutine calling Next to prune what it iterates: a removed key
+// is not yielded if it is not yet, and every other key is yielded once.
+// In a squashed trie without WithLazyRemove, Remove squashes nodes again and
+// keys not removed may be skipped; use WithLazyRemove and Compact after the
+// iteration instead. The result of other mutations is undefined.
+// With WithSnapshot(), an Iter is not affected by Append or Squash
+// happened after it is created.
+// An Iter is not safe to use concurrently with mutations from another
+// goroutine, unless the snapshot is taken with the writer locked out.
+//
+// Since 0.2.0
+type Iter struct {
+	stack []iterFrame
+	key   []byte
+	value interface{}
+
+	order byteOrder
+
+	// out is the key yielded: key, or buf built from the key stored in a
+	// leaf, with strip replaced with prepend.
+	out     []byte
+	buf     []byte
+	strip   []byte
+	prepend []byte
+
+	// keepRemoved makes removed leaves yielded.
+	keepRemoved bool
+
+	// natural makes keys decoded into dec, after prepend, see
+	// WithNaturalOrder.
+	natural bool
+	dec     []byte
+}
+
+// iterFrame is the position of an Iter in one node.
+type iterFrame struct {
+	node *Node
+	// next is the index of the next branch in node.Branches, if the branch
+	// before it is still prev, the last branch taken. Otherwise branches are
+	// removed and the position is searched for again.
+	next    int
+	prev    int
+	started bool
+	// keyLen is the key length before the branch label of this node.
+	keyLen int
+}
+
+// IterOption configures an Iter.
+//
+// Since 0.2.0
+type IterOption func(*iterOptions)
+
+type iterOptions struct {
+	snapshot bool
+	strip    []byte
+	prepend  []byte
+}
+
+// WithSnapshot makes an Iter iterate over a copy of the trie structure taken
+// when the Iter is created. Values are shared with the trie, except those
+// implementing Cloner, which are cloned.
+//
+// It costs O(n) time and memory for a trie of n nodes.
+//
+// Since 0.2.0
+func WithSnapshot() IterOption {
+	return func(o *iterOptions) {
+		o.snapshot = true
+	}
+}
+
+// WithStripPrefix makes an Iter iterate only over keys starting with
+// `prefix`, and yield them with `prefix` removed. The keys are not traversed
+// to be skipped: the Iter starts at the node of `prefix`.
+//
+// Same as Search, in a squashed trie the bytes removed by squashing are not
+// checked, and what is removed from keys is the rebuilt part of `prefix`.
+//
+// Since 0.2.0
+func WithStripPrefix(prefix []byte) IterOption {
+	return func(o *iterOptions) {
+		o.strip = prefix
+	}
+}
+
+// WithPrependPrefix makes an Iter yield keys with `prefix` prepended, e.g., a
+// namespace. It is applied after WithStripPrefix.
+//
+// Since 0.2.0
+func WithPrependPrefix(prefix []byte) IterOption {
+	return func(o *iterOptions) {
+		o.prepend = prefix
+	}
+}
+
+// NewIter creates an Iter positioned before the first key.
+//
+// Since 0.2.0
+func (r *Node) NewIter(opts ...IterOption) *Iter {
+
+	o := &iterOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	root := r
+	strip := o.strip
+	if len(strip) > 0 {
+		strip = r.normalize(strip)
+		root, _ = r.subtree(strip)
+	}
+
+	it := &Iter{
+		key:     append([]byte{}, o.prepend...),
+		order:   r.byteOrder(),
+		strip:   strip,
+		prepend: o.prepend,
+		natural: r.opt != nil && r.opt.naturalOrder,
+	}
+	if root == nil {
+		return it
+	}
+
+	if o.snapshot {
+		root = root.clone(cloneValue)
+	}
+
+	it.push(root, len(it.key))
+	return it
+}
+
+// Next advances the Iter to the next key.
+// It returns false when there are no more keys.
+//
+// Since 0.2.0
+func (it *Iter) Next() bool {
+
+	for len(it.stack) > 0 {
+		f := &it.stack[len(it.stack)-1]
+
+		brs := f.node.Branches
+		i := f.next
+		if f.started && (i > len(brs) || brs[i-1] != f.prev) {
+			prev := it.order.rank(f.prev)
+			i = sort.Search(len(brs), func(j int) bool {
+				return it.order.rank(brs[j]) > prev
+			})
+		}
+
+		if i == len(brs) {
+			it.stack = it.stack[:len(it.stack)-1]
+			continue
+		}
+
+		br := brs[i]
+		f.next, f.prev, f.started = i+1, br, true
+
+		child := f.node.Children[br]
+		it.key = it.key[:f.keyLen]
+
+		if br == leafBranch {
+			if child.Value == removed && !it.keepRemoved {
+				continue
+			}
+			it.out = it.key
+			if child.key() != nil {
+				if !bytes.HasPrefix(child.key(), it.strip) {
+					continue
+				}
+				it.buf = append(append(it.buf[:0], it.prepend...), child.key()[len(it.strip):]...)
+				it.out = it.buf
+			}
+			if it.natural {
+				it.dec = append(it.dec[:0], it.prepend...)
+				it.dec = appendDecodedNaturalKey(it.dec, it.out[len(it.prepend):])
+				it.out = it.dec
+			}
+			it.value = child.Value
+			return true
+		}
+
+		it.key = append(it.key, byte(br))
+		it.push(child, len(it.key))
+	}
+
+	it.key = it.key[:0]
+	it.out = it.key
+	it.value = nil
+	return false
+}
+
+// Key returns the current key.
+// The returned slice must not be modified and is only valid until the next
+// call to Next.
+//
+// Since 0.2.0
+func (it *Iter) Key() []byte {
+	return it.out
+}
+
+// Value returns the current value.
+//
+// Since 0.2.0
+func (it *Iter) Value() interface{} {
+	return it.value
+}
+
+func (it *Iter) push(n *Node, keyLen int) {
+	it.stack = append(it.stack, iterFrame{
+		node:   n,
+		keyLen: keyLen,
+	})
+}
@@ -0,0 +1,201 @@
+package trie
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func iterAll(it *Iter) ([]string, []interface{}) {
+	keys := []string{}
+	values := []interface{}{}
+	for it.Next() {
+		keys = append(keys, string(it.Key()))
+		values = append(values, it.Value())
+	}
+	return keys, values
+}
+
+func TestIter(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := []string{"", "abc", "abcd", "abd", "b", "bc", "cde"}
+	values := []int{0, 1, 2, 3, 4, 5, 6}
+
+	bs := make([][]byte, len(keys))
+	for i, k := range keys {
+		bs[i] = []byte(k)
+	}
+
+	tr, err := NewTrie(bs, values, false)
+	ta.Nil(err)
+
+	for _, opts := range [][]IterOption{nil, {WithSnapshot()}} {
+		ks, vs := iterAll(tr.NewIter(opts...))
+		ta.Equal(keys, ks)
+		ta.Equal([]interface{}{0, 1, 2, 3, 4, 5, 6}, vs)
+	}
+
+	empty, err := NewTrie(nil, nil, false)
+	ta.Nil(err)
+	it := empty.NewIter()
+	ta.False(it.Next())
+	ta.False(it.Next())
+}
+
+func TestIter_squashed(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr, err := NewTrie([][]byte{{1, 2, 3}, {1, 2, 4}, {2, 3, 4}}, []int{0, 1, 2}, true)
+	ta.Nil(err)
+
+	ks, vs := iterAll(tr.NewIter())
+	ta.Equal([]string{"\x01\x03", "\x01\x04", "\x02"}, ks, "squashed byte 2 is absent")
+	ta.Equal([]interface{}{0, 1, 2}, vs)
+}
+
+func TestIter_snapshot(t *testing.T) {
+
+	ta := require.New(t)
+
+	for _, squash := range []bool{false, true} {
+
+		tr, err := NewTrie([][]byte{{1, 2}, {1, 3}}, []int{0, 1}, squash)
+		ta.Nil(err)
+
+		if squash {
+			// Append can not be called after a full Squash.
+			tr, err = NewTrie(nil, nil, true)
+			ta.Nil(err)
+			_, err = tr.Append([]byte{1, 2}, 0)
+			ta.Nil(err)
+			_, err = tr.Append([]byte{1, 3}, 1)
+			ta.Nil(err)
+		}
+
+		snap := tr.NewIter(WithSnapshot())
+		ta.True(snap.Next())
+		ta.Equal([]byte{1, 2}, snap.Key())
+
+		for _, k := range [][]byte{{1, 3, 1}, {1, 4}, {2, 5, 6}, {3}} {
+			_, err = tr.Append(k, len(k))
+			ta.Nil(err)
+		}
+		tr.Squash()
+
+		ks, vs := iterAll(snap)
+		ta.Equal([]string{"\x01\x03"}, ks, "squash: %v", squash)
+		ta.Equal([]interface{}{1}, vs)
+	}
+}
+
+func TestIter_rewriteKeys(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr := newStrTrie(ta, false, "a", "old/", "old/x", "old/xy", "old0", "z")
+
+	cases := []struct {
+		opts []IterOption
+		want []string
+	}{
+		{[]IterOption{WithStripPrefix([]byte("old/"))}, []string{"", "x", "xy"}},
+		{[]IterOption{WithStripPrefix([]byte("old"))}, []string{"/", "/x", "/xy", "0"}},
+		{[]IterOption{WithStripPrefix([]byte("no"))}, []string{}},
+		{[]IterOption{WithStripPrefix([]byte("old/xyz"))}, []string{}},
+		{[]IterOption{WithPrependPrefix([]byte("ns:"))},
+			[]string{"ns:a", "ns:old/", "ns:old/x", "ns:old/xy", "ns:old0", "ns:z"}},
+		{[]IterOption{WithPrependPrefix([]byte("new/")), WithStripPrefix([]byte("old/")), WithSnapshot()},
+			[]string{"new/", "new/x", "new/xy"}},
+	}
+
+	for i, c := range cases {
+		ks, _ := iterAll(tr.NewIter(c.opts...))
+		ta.Equal(c.want, ks, "%d-th", i+1)
+	}
+
+	var buf bytes.Buffer
+	ta.Nil(tr.WriteCSV(&buf, WithIterOptions(WithStripPrefix([]byte("old/")), WithPrependPrefix([]byte("new/")))))
+	ta.Equal("new/,old/\nnew/x,old/x\nnew/xy,old/xy\n", buf.String())
+}
+
+func TestIter_remove(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := []string{"", "a", "ab", "abc", "abd", "ac", "b", "ba", "bb", "bba", "c", "cd"}
+
+	cases := []struct {
+		name   string
+		squash bool
+		opts   []Option
+	}{
+		{"plain", false, nil},
+		{"byte order", false, []Option{WithByteOrder(func(a, b byte) int { return int(b) - int(a) })}},
+		{"lazy squashed", true, []Option{WithLazyRemove()}},
+	}
+
+	for _, c := range cases {
+		for seed := int64(0); seed < 20; seed++ {
+
+			var tr *Node
+			var err error
+			if c.squash {
+				bs := make([][]byte, len(keys))
+				for i, k := range keys {
+					bs[i] = []byte(k)
+				}
+				tr, err = NewTrie(bs, keys, true, c.opts...)
+				ta.Nil(err)
+			} else {
+				// Set accepts keys not in the byte order.
+				tr, err = NewTrie(nil, nil, false, c.opts...)
+				ta.Nil(err)
+				for _, k := range keys {
+					_, err = tr.Set([]byte(k), k)
+					ta.Nil(err)
+				}
+			}
+
+			ordered, _ := iterAll(tr.NewIter())
+			live := map[string]bool{}
+			for _, k := range ordered {
+				live[k] = true
+			}
+
+			rnd := rand.New(rand.NewSource(seed))
+			yielded := map[string]bool{}
+			got := []string{}
+
+			it := tr.NewIter()
+			for it.Next() {
+				k := string(it.Key())
+				ta.True(live[k], "%s %d: %q is removed", c.name, seed, k)
+				got = append(got, k)
+				yielded[k] = true
+
+				if rnd.Intn(2) == 0 {
+					tr.Remove([]byte(k))
+					delete(live, k)
+				}
+				// Remove a key before or after the current one.
+				other := ordered[rnd.Intn(len(ordered))]
+				tr.Remove([]byte(other))
+				delete(live, other)
+			}
+
+			// Every key is yielded unless removed before being reached.
+			want := []string{}
+			for _, k := range ordered {
+				if live[k] || yielded[k] {
+					want = append(want, k)
+				}
+			}
+			ta.Equal(want, got, "%s %d", c.name, seed)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package trie
+
+// KeyCnt returns the number of keys in the subtree of r, i.e., the keys
+// starting with the prefix r represents. A leaf has 1 key.
+// Keys removed with WithLazyRemove are not counted.
+//
+// It is maintained by every change to the trie and takes O(1) time.
+//
+// Since 0.2.0
+func (r *Node) KeyCnt() int {
+	if r.Children == nil {
+		return liveCnt(r.Value)
+	}
+	return r.keyCnt
+}
+
+// liveCnt returns the number of keys a leaf with value `v` counts as.
+func liveCnt(v interface{}) int {
+	if v == removed {
+		return 0
+	}
+	return 1
+}
+
+// addKeyCnt adds `delta` to the key count of every node along `key`, which
+// must be a path of nodes that are not squashed.
+func (r *Node) addKeyCnt(key []byte, delta int) {
+
+	node := r
+	node.keyCnt += delta
+	for _, b := range key {
+		node = node.Children[int(b)]
+		node.keyCnt += delta
+	}
+}
+
+// recount recalculates the key count of every node in the subtree of r and
+// returns the count of r.
+func (r *Node) recount() int {
+
+	if r.Children == nil {
+		return r.KeyCnt()
+	}
+
+	cnt := 0
+	for _, b := range r.Branches {
+		cnt += r.Children[b].recount()
+	}
+	r.keyCnt = cnt
+	return cnt
+}
@@ -0,0 +1,135 @@
+package trie
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// requireKeyCnt checks the key count of every node in the subtree of r
+// against the live leaves in it.
+func requireKeyCnt(ta *require.Assertions, r *Node) int {
+
+	if r.Children == nil {
+		return r.KeyCnt()
+	}
+
+	cnt := 0
+	for _, b := range r.Branches {
+		cnt += requireKeyCnt(ta, r.Children[b])
+	}
+	ta.Equal(cnt, r.KeyCnt(), "node: %s", r)
+	return cnt
+}
+
+func TestNode_KeyCnt(t *testing.T) {
+
+	ta := require.New(t)
+
+	for _, squash := range []bool{false, true} {
+
+		tr := newStrTrie(ta, squash, "a", "abc", "abd", "b", "bcd")
+		ta.Equal(5, tr.KeyCnt())
+		requireKeyCnt(ta, tr)
+
+		_, err := tr.Append([]byte("bd"), 0)
+		ta.Nil(err)
+		ta.Equal(6, tr.KeyCnt())
+		requireKeyCnt(ta, tr)
+
+		ta.Equal(2, tr.Children['a'].Children['b'].KeyCnt(), "squash: %v", squash)
+
+		ta.True(tr.Remove([]byte("abc")))
+		ta.False(tr.Remove([]byte("xyz")))
+		ta.Equal(5, tr.KeyCnt())
+		requireKeyCnt(ta, tr)
+
+		ta.Equal(2, tr.RemoveBatch([][]byte{[]byte("a"), []byte("bd")}))
+		ta.Equal(3, tr.KeyCnt())
+		requireKeyCnt(ta, tr)
+
+		f := tr.Filter(func(key []byte, v interface{}) bool { return v != "b" })
+		ta.Equal(2, f.KeyCnt())
+		requireKeyCnt(ta, f)
+	}
+}
+
+func TestNode_KeyCnt_set(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr, err := NewTrie(nil, nil, false)
+	ta.Nil(err)
+	ta.Equal(0, tr.KeyCnt())
+
+	for _, k := range []string{"b", "abc", "ab", "b", "c"} {
+		_, err = tr.Set([]byte(k), k)
+		ta.Nil(err)
+		requireKeyCnt(ta, tr)
+	}
+	ta.Equal(4, tr.KeyCnt())
+
+	// A tombstone of Overlay is not a key.
+	_, err = tr.Set([]byte("ab"), removed)
+	ta.Nil(err)
+	ta.Equal(3, tr.KeyCnt())
+	ta.True(tr.Remove([]byte("ab")))
+	ta.Equal(3, tr.KeyCnt())
+	requireKeyCnt(ta, tr)
+
+	sub := newStrTrie(ta, false, "x", "y")
+	ta.Nil(tr.ReplaceSubTrie([]byte("a"), sub))
+	ta.Equal(4, tr.KeyCnt())
+	requireKeyCnt(ta, tr)
+
+	ta.Nil(tr.ReplaceSubTrie([]byte("a"), newStrTrie(ta, false)))
+	ta.Equal(2, tr.KeyCnt())
+	requireKeyCnt(ta, tr)
+}
+
+func TestNode_KeyCnt_lazyRemove(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr, err := NewTrie([][]byte{[]byte("a"), []byte("ab"), []byte("b")}, []int{1, 2, 3}, false, WithLazyRemove())
+	ta.Nil(err)
+
+	ta.True(tr.Remove([]byte("ab")))
+	ta.Equal(2, tr.KeyCnt())
+	requireKeyCnt(ta, tr)
+
+	// Append revives a removed key.
+	_, err = tr.Append([]byte("ab"), 5)
+	ta.Nil(err)
+	ta.Equal(3, tr.KeyCnt())
+	requireKeyCnt(ta, tr)
+
+	ta.True(tr.Remove([]byte("ab")))
+	tr.Compact()
+	ta.Equal(2, tr.KeyCnt())
+	requireKeyCnt(ta, tr)
+
+	_, err = tr.Append([]byte("c"), 4)
+	ta.Nil(err)
+	ta.Equal(3, tr.KeyCnt())
+	requireKeyCnt(ta, tr)
+}
+
+func TestSyncTrie_KeyCnt(t *testing.T) {
+
+	ta := require.New(t)
+
+	st, err := NewSyncTrie(nil, nil, true)
+	ta.Nil(err)
+	ta.Nil(st.Append([]byte("ab"), 1))
+
+	old := st.Load()
+	ta.Nil(st.Append([]byte("ac"), 2))
+	ta.Nil(st.Append([]byte("b"), 3))
+
+	ta.Equal(1, old.KeyCnt())
+	requireKeyCnt(ta, old)
+
+	ta.Equal(3, st.Load().KeyCnt())
+	requireKeyCnt(ta, st.Load())
+}
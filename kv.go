@@ -0,0 +1,80 @@
+package trie
+
+// OrderedKV is a minimal ordered key-value store, the subset of the API of
+// B-tree style stores that SyncTrie implements. Code written against it can
+// use a SyncTrie, e.g., in tests or small deployments.
+//
+// Since 0.2.0
+type OrderedKV interface {
+	// Get returns the value of `key` and whether it is in the store.
+	//
+	// Since 0.2.0
+	Get(key []byte) (value interface{}, found bool)
+
+	// Set sets the value of `key`, adding it if it is not in the store.
+	//
+	// Since 0.2.0
+	Set(key []byte, value interface{}) error
+
+	// Delete removes `key`. Deleting a key not in the store is not an error.
+	//
+	// Since 0.2.0
+	Delete(key []byte) error
+
+	// Scan calls `fn` with every key in [start, end) and its value, in
+	// ascending key order, until `fn` returns false. A nil `start` means no
+	// lower bound and a nil `end` means no upper bound. The key passed to
+	// `fn` must not be modified or retained.
+	//
+	// Since 0.2.0
+	Scan(start, end []byte, fn func(key []byte, value interface{}) bool) error
+}
+
+var _ OrderedKV = (*SyncTrie)(nil)
+
+// Set is the same as Node.Set on a new version. It returns ErrSquashed if a
+// node along `key` is squashed.
+//
+// Since 0.2.0
+func (s *SyncTrie) Set(key []byte, value interface{}) error {
+	return s.Apply([]Op{{Type: OpSet, Key: key, Value: value}})
+}
+
+// Delete is the same as Node.Remove on a new version.
+//
+// Since 0.2.0
+func (s *SyncTrie) Delete(key []byte) error {
+	return s.Apply([]Op{{Type: OpRemove, Key: key}})
+}
+
+// Scan calls `fn` with every key in [start, end) of the current version and
+// its value, in ascending key order, until `fn` returns false. Writes during
+// a Scan are not seen by it.
+//
+// Keys are those in the trie, i.e., normalized ones. Same as Search, in a
+// squashed trie the bytes removed by squashing are not compared with `start`
+// and `end`, and are not in the keys passed to `fn` unless the trie is
+// created WithStoreKeys.
+//
+// It always returns nil.
+//
+// Since 0.2.0
+func (s *SyncTrie) Scan(start, end []byte, fn func(key []byte, value interface{}) bool) error {
+
+	root := s.Load()
+
+	if start != nil {
+		start = root.normalize(start)
+	}
+	if end != nil {
+		end = root.normalize(end)
+	}
+
+	root.scanRange(start, end, func(key []byte, leaf *Node) bool {
+		if leaf.key() != nil {
+			key = leaf.key()
+		}
+		return fn(key, leaf.Value)
+	})
+	return nil
+}
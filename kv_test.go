@@ -0,0 +1,109 @@
+package trie
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/openacid/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncTrie_OrderedKV(t *testing.T) {
+
+	ta := require.New(t)
+
+	var kv OrderedKV
+	s, err := NewSyncTrie(nil, nil, false)
+	ta.Nil(err)
+	kv = s
+
+	rnd := rand.New(rand.NewSource(5))
+	randKey := func() []byte {
+		k := make([]byte, rnd.Intn(5))
+		for i := range k {
+			k[i] = "abc"[rnd.Intn(3)]
+		}
+		return k
+	}
+
+	ref := map[string]int{}
+	for i := 0; i < 300; i++ {
+		k := randKey()
+		if rnd.Intn(3) == 0 {
+			ta.Nil(kv.Delete(k))
+			delete(ref, string(k))
+		} else {
+			ta.Nil(kv.Set(k, i))
+			ref[string(k)] = i
+		}
+	}
+
+	sorted := []string{}
+	for k := range ref {
+		sorted = append(sorted, k)
+		v, found := kv.Get([]byte(k))
+		ta.True(found, "get %q", k)
+		ta.Equal(ref[k], v, "get %q", k)
+	}
+	sort.Strings(sorted)
+
+	scan := func(start, end []byte) []string {
+		got := []string{}
+		ta.Nil(kv.Scan(start, end, func(key []byte, v interface{}) bool {
+			ta.Equal(ref[string(key)], v, "scan %q", key)
+			got = append(got, string(key))
+			return true
+		}))
+		return got
+	}
+
+	ta.Equal(sorted, scan(nil, nil))
+
+	for i := 0; i < 100; i++ {
+		start, end := randKey(), randKey()
+		if i%10 == 0 {
+			start = nil
+		}
+		if i%10 == 1 {
+			end = nil
+		}
+
+		want := []string{}
+		for _, k := range sorted {
+			if (start == nil || k >= string(start)) && (end == nil || k < string(end)) {
+				want = append(want, k)
+			}
+		}
+		ta.Equal(want, scan(start, end), fmt.Sprintf("scan [%q, %q)", start, end))
+	}
+
+	// Stop early.
+	n := 0
+	ta.Nil(kv.Scan(nil, nil, func(key []byte, v interface{}) bool {
+		n++
+		return n < 3
+	}))
+	ta.Equal(3, n)
+}
+
+func TestSyncTrie_OrderedKV_squash(t *testing.T) {
+
+	ta := require.New(t)
+
+	s, err := NewSyncTrie([][]byte{[]byte("abc"), []byte("abd"), []byte("x")}, []int{1, 2, 3}, true, WithStoreKeys())
+	ta.Nil(err)
+
+	got := []string{}
+	ta.Nil(s.Scan([]byte("abd"), nil, func(key []byte, v interface{}) bool {
+		got = append(got, string(key))
+		return true
+	}))
+	ta.Equal([]string{"abd", "x"}, got)
+
+	ta.Equal(ErrSquashed, errors.Cause(s.Set([]byte("abe"), 4)))
+	ta.Nil(s.Delete([]byte("x")))
+	_, found := s.Get([]byte("x"))
+	ta.False(found)
+}
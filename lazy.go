@@ -0,0 +1,225 @@
+package trie
+
+// tombstone is the value of a leaf removed in lazy remove mode.
+type tombstone struct{}
+
+// String implements fmt.Stringer.
+func (*tombstone) String() string {
+	return "(removed)"
+}
+
+// removed is the only tombstone. Comparing an interface{} to it never panics,
+// since no user value has the type *tombstone.
+var removed interface{} = &tombstone{}
+
+// WithLazyRemove makes Remove and RemoveBatch only mark leaves as removed,
+// without changing the trie structure. Removed keys are skipped by Search and
+// Iter, and are physically removed by Compact.
+//
+// Appending a removed key revives it with the new value, regardless of the
+// key order.
+//
+// Since 0.2.0
+func WithLazyRemove() Option {
+	return func(o *options) {
+		o.lazyRemove = true
+	}
+}
+
+func (r *Node) lazyRemove() bool {
+	return r.opt != nil && r.opt.lazyRemove
+}
+
+// removeLazily marks leaves of `keys` as removed.
+func (r *Node) removeLazily(keys [][]byte) int {
+
+	var path []*Node
+	var brs []int
+	cnt := 0
+
+	for _, key := range keys {
+		path, brs = r.findLeaf(key, path[:0], brs[:0])
+		if path == nil {
+			continue
+		}
+
+		leaf := path[len(path)-1].Children[leafBranch]
+		if leaf.Value != removed {
+			old := leaf.Value
+			leaf.Value = removed
+			r.forgetLeaf(leaf)
+			r.changed(key, old, removed)
+			cnt++
+			for _, n := range path {
+				n.keyCnt--
+			}
+			r.reaggregatePath(path)
+		}
+	}
+	return cnt
+}
+
+// searchLive is Search on a trie with removed leaves.
+func (r *Node) searchLive(key []byte, src KeySource) (ltValue, eqValue, gtValue interface{}) {
+
+	type level struct {
+		node   *Node
+		li, ri int
+	}
+
+	var levels []level
+
+	// gtSub is a subtree in which all keys are greater than `key`, found if
+	// `key` ends in a squashed node, or differs from the keys in it with
+	// WithStoreKeys. ltSub is the same for keys less than `key`.
+	var gtSub, ltSub *Node
+
+	diff, other := r.keyDiff(r, -1, key, src)
+
+	var eqNode = r
+	lenKey := len(key)
+
+	for i := -1; ; {
+		i += int(eqNode.Step)
+
+		if diff < i {
+			if r.byteOrder().before(key, diff, other) {
+				gtSub = eqNode
+			} else {
+				ltSub = eqNode
+			}
+			eqNode = nil
+			break
+		}
+
+		if lenKey < i {
+			gtSub = eqNode
+			eqNode = nil
+			break
+		}
+
+		var br int
+		if lenKey == i {
+			br = leafBranch
+		} else {
+			br = int(key[i])
+		}
+
+		li, ri := r.byteOrder().neighborBranches(eqNode.Branches, br)
+		levels = append(levels, level{eqNode, li, ri})
+
+		eqNode = eqNode.Children[br]
+		if eqNode == nil || br == leafBranch {
+			break
+		}
+	}
+
+	if eqNode != nil && eqNode.Value != removed && !r.expired(eqNode) {
+		eqValue = eqNode.Value
+	}
+
+	if gtSub != nil {
+		gtValue = gtSub.leftMostLive()
+	}
+	if ltSub != nil {
+		ltValue = ltSub.rightMostLive()
+	}
+
+	for i := len(levels) - 1; i >= 0 && (ltValue == nil || gtValue == nil); i-- {
+		l := levels[i]
+		brs := l.node.Branches
+
+		for j := l.li; ltValue == nil && j >= 0; j-- {
+			ltValue = l.node.Children[brs[j]].rightMostLive()
+		}
+
+		for j := l.ri; gtValue == nil && j >= 0 && j < len(brs); j++ {
+			gtValue = l.node.Children[brs[j]].leftMostLive()
+		}
+	}
+
+	return
+}
+
+// leftMostLive returns the value of the first leaf not removed.
+func (r *Node) leftMostLive() interface{} {
+
+	if len(r.Branches) == 0 {
+		if r.Value == removed {
+			return nil
+		}
+		return r.Value
+	}
+
+	for _, b := range r.Branches {
+		if v := r.Children[b].leftMostLive(); v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+// rightMostLive returns the value of the last leaf not removed.
+func (r *Node) rightMostLive() interface{} {
+
+	if len(r.Branches) == 0 {
+		if r.Value == removed {
+			return nil
+		}
+		return r.Value
+	}
+
+	for i := len(r.Branches) - 1; i >= 0; i-- {
+		if v := r.Children[r.Branches[i]].rightMostLive(); v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+// purge physically removes removed leaves in the subtree of r, and records
+// in `touched` the nodes that lost a branch and are not empty.
+// It returns true if r becomes empty.
+func (r *Node) purge(root *Node, depth int, touched map[*Node]int) bool {
+
+	if len(r.Branches) == 0 {
+		return false
+	}
+
+	brs := make([]int, len(r.Branches))
+	copy(brs, r.Branches)
+
+	changed := false
+	for _, b := range brs {
+		child := r.Children[b]
+
+		if b == leafBranch {
+			if child.Value != removed {
+				continue
+			}
+			root.addCounts(-1, 0)
+		} else {
+			if !child.purge(root, depth+1, touched) {
+				continue
+			}
+			root.InnerNodeCnt--
+			if child.Step > 1 {
+				root.addCounts(0, -1)
+			}
+		}
+
+		delete(r.Children, b)
+		r.Branches = removeBranch(r.Branches, b)
+		r.shrunk = true
+		changed = true
+	}
+
+	if changed && (len(r.Branches) > 0 || r == root) {
+		touched[r] = depth
+		if root.cachedExtremes() {
+			r.setExtremes()
+		}
+	}
+
+	return len(r.Branches) == 0 && r != root
+}
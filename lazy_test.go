@@ -0,0 +1,92 @@
+package trie
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithLazyRemove(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := []string{"a", "ab", "abc", "b", "bc", "c", "cd"}
+	bs := make([][]byte, len(keys))
+	for i, k := range keys {
+		bs[i] = []byte(k)
+	}
+
+	for _, squash := range []bool{false, true} {
+
+		tr, err := NewTrie(nil, nil, squash, WithLazyRemove())
+		ta.Nil(err)
+		for _, k := range keys {
+			_, err := tr.Append([]byte(k), k)
+			ta.Nil(err)
+		}
+		cnt := tr.InnerNodeCnt
+
+		ta.Equal(4, tr.RemoveBatch([][]byte{
+			[]byte("ab"), []byte("abc"), []byte("b"), []byte("bc"),
+		}))
+		ta.False(tr.Remove([]byte("b")), "removed twice")
+		ta.Equal(cnt, tr.InnerNodeCnt, "structure not changed")
+
+		cases := []struct {
+			key  string
+			want []interface{}
+		}{
+			{"a", []interface{}{nil, "a", "c"}},
+			{"ab", []interface{}{"a", nil, "c"}},
+			{"b", []interface{}{"a", nil, "c"}},
+			{"bc", []interface{}{"a", nil, "c"}},
+			{"c", []interface{}{"a", "c", "cd"}},
+		}
+		for _, c := range cases {
+			lt, eq, gt := tr.Search([]byte(c.key))
+			ta.Equal(c.want, []interface{}{lt, eq, gt}, "squash: %v, search: %q", squash, c.key)
+		}
+
+		ks, _ := iterAll(tr.NewIter())
+		ta.Equal([]string{"a", "c", "cd"}, ks)
+
+		// revive
+		_, err = tr.Append([]byte("b"), "B")
+		ta.Nil(err)
+		_, eq, _ := tr.Search([]byte("b"))
+		ta.Equal("B", eq)
+
+		ta.True(tr.Compact() > 0)
+		ta.Equal(tr.innerNodeCnt(), tr.InnerNodeCnt)
+
+		_, vs := iterAll(tr.NewIter())
+		ta.Equal([]interface{}{"a", "B", "c", "cd"}, vs)
+
+		if !squash {
+			want := newStrTrie(ta, false, "a", "b", "c", "cd")
+			ta.Equal(want.InnerNodeCnt, tr.InnerNodeCnt)
+		}
+
+		for _, k := range []string{"a", "c", "cd"} {
+			_, eq, _ := tr.Search([]byte(k))
+			ta.Equal(k, eq)
+		}
+	}
+}
+
+func TestWithLazyRemove_all(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr, err := NewTrie([][]byte{{1}, {1, 2}}, []int{1, 2}, false, WithLazyRemove())
+	ta.Nil(err)
+
+	ta.Equal(2, tr.RemoveBatch([][]byte{{1}, {1, 2}}))
+
+	lt, eq, gt := tr.Search([]byte{1})
+	ta.Equal([]interface{}{nil, nil, nil}, []interface{}{lt, eq, gt})
+
+	tr.Compact()
+	ta.Equal(1, tr.InnerNodeCnt)
+	ta.Equal(0, len(tr.Branches))
+}
@@ -0,0 +1,68 @@
+package trie
+
+import "sync"
+
+// Lazy is a value materialized by a function on first access, e.g., a
+// database row only loaded if its key is queried.
+//
+// It is safe for concurrent use.
+//
+// Since 0.2.0
+type Lazy struct {
+	mu    sync.Mutex
+	fn    func() (interface{}, error)
+	cache bool
+
+	done bool
+	v    interface{}
+}
+
+// NewLazy creates a Lazy value of `fn`. With `cache`, `fn` is called until it
+// succeeds once, and the value is kept. Without `cache`, `fn` is called on
+// every access.
+//
+// Since 0.2.0
+func NewLazy(fn func() (interface{}, error), cache bool) *Lazy {
+	return &Lazy{fn: fn, cache: cache}
+}
+
+// Value returns the materialized value.
+//
+// Since 0.2.0
+func (l *Lazy) Value() (interface{}, error) {
+
+	if !l.cache {
+		return l.fn()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.done {
+		return l.v, nil
+	}
+
+	v, err := l.fn()
+	if err != nil {
+		return nil, err
+	}
+
+	l.v = v
+	l.done = true
+	// Release what fn holds.
+	l.fn = nil
+	return v, nil
+}
+
+// WithLazyValues makes Append and Set wrap a value of type
+// `func() (interface{}, error)` into a *Lazy, with `cache` as NewLazy.
+// Resolve and SearchValues materialize a *Lazy, while Search returns it as
+// is.
+//
+// Since 0.2.0
+func WithLazyValues(cache bool) Option {
+	return func(o *options) {
+		o.lazyValues = true
+		o.lazyCache = cache
+	}
+}
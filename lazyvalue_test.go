@@ -0,0 +1,88 @@
+package trie
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/openacid/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithLazyValues(t *testing.T) {
+
+	ta := require.New(t)
+
+	for _, cache := range []bool{false, true} {
+
+		calls := map[string]int{}
+		thunk := func(k string) func() (interface{}, error) {
+			return func() (interface{}, error) {
+				calls[k]++
+				return "row-" + k, nil
+			}
+		}
+
+		keys := [][]byte{[]byte("a"), []byte("b"), []byte("c")}
+		values := []interface{}{thunk("a"), thunk("b"), "plain"}
+
+		tr, err := NewTrie(keys, values, false, WithLazyValues(cache))
+		ta.Nil(err)
+		ta.Equal(0, len(calls), "nothing is materialized by building")
+
+		_, eq, _ := tr.Search([]byte("a"))
+		ta.IsType(&Lazy{}, eq)
+
+		for i := 0; i < 2; i++ {
+			v, err := tr.Resolve(eq)
+			ta.Nil(err)
+			ta.Equal("row-a", v)
+		}
+
+		lt, eq, gt, err := tr.SearchValues([]byte("b"))
+		ta.Nil(err)
+		ta.Equal([]interface{}{"row-a", "row-b", "plain"}, []interface{}{lt, eq, gt})
+
+		if cache {
+			ta.Equal(map[string]int{"a": 1, "b": 1}, calls)
+		} else {
+			ta.Equal(map[string]int{"a": 3, "b": 1}, calls)
+		}
+	}
+}
+
+func TestLazy_error(t *testing.T) {
+
+	ta := require.New(t)
+
+	n := 0
+	l := NewLazy(func() (interface{}, error) {
+		n++
+		if n == 1 {
+			return nil, errors.New("not ready")
+		}
+		return fmt.Sprintf("v%d", n), nil
+	}, true)
+
+	_, err := l.Value()
+	ta.NotNil(err)
+
+	// An error is not cached.
+	v, err := l.Value()
+	ta.Nil(err)
+	ta.Equal("v2", v)
+
+	v, err = l.Value()
+	ta.Nil(err)
+	ta.Equal("v2", v)
+	ta.Equal(2, n)
+
+	// With a ValueStore, the store holds the *Lazy.
+	store := &MemValueStore{}
+	tr, err := NewTrie(nil, nil, false, WithValueStore(store), WithLazyValues(true))
+	ta.Nil(err)
+	_, err = tr.Set([]byte("k"), func() (interface{}, error) { return 1, nil })
+	ta.Nil(err)
+	_, eq, _, err := tr.SearchValues([]byte("k"))
+	ta.Nil(err)
+	ta.Equal(1, eq)
+}
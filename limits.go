@@ -0,0 +1,120 @@
+package trie
+
+import (
+	"fmt"
+
+	"github.com/openacid/errors"
+)
+
+// WithMaxKeyLen makes Append, Set, Apply and the others built on them reject a
+// key longer than `n` bytes with a *KeyLimitError, e.g., to protect a service
+// building tries of untrusted input against keys that exhaust its memory.
+// A non-positive `n` disables the limit.
+//
+// Since 0.2.0
+func WithMaxKeyLen(n int) Option {
+	return func(o *options) {
+		o.maxKeyLen = n
+	}
+}
+
+// WithMaxTrieDepth makes Append, Set, Apply and the others built on them
+// reject a key that would put its leaf more than `n` inner nodes below the
+// root, with a *KeyLimitError. It bounds the work of following a key and the
+// stack of walking a trie recursively, e.g., by String.
+//
+// A key is never added below a squashed node, thus the depth of a new key is
+// the length of the key normalized by WithKeyNormalizer, which can be longer
+// than the key WithMaxKeyLen checks. A non-positive `n` disables the limit.
+//
+// Since 0.2.0
+func WithMaxTrieDepth(n int) Option {
+	return func(o *options) {
+		o.maxTrieDepth = n
+	}
+}
+
+// WithKeyValidator makes Append, Set, Apply and the others built on them check
+// every key with `fn` before adding it, e.g., to reject keys that are not
+// UTF-8 or that start with a reserved prefix. An error `fn` returns is
+// wrapped with the key, and is the Cause of the error returned.
+//
+// `fn` is called with the key as it is passed in, before WithKeyNormalizer,
+// and after the checks of WithMaxKeyLen and WithMaxTrieDepth. It must not
+// modify the key.
+//
+// Since 0.2.0
+func WithKeyValidator(fn func(key []byte) error) Option {
+	return func(o *options) {
+		o.keyValidator = fn
+	}
+}
+
+// KeyLimitError is returned if a key exceeds WithMaxKeyLen or
+// WithMaxTrieDepth.
+// Its Cause is ErrKeyTooLong or ErrTrieTooDeep.
+//
+// Since 0.2.0
+type KeyLimitError struct {
+	// Key is the rejected key, as it is passed in.
+	Key []byte
+	// Limit is the limit exceeded.
+	Limit int
+	// Actual is the length or depth of Key.
+	Actual int
+
+	err error
+}
+
+// Error implements error.
+//
+// Since 0.2.0
+func (e *KeyLimitError) Error() string {
+
+	// The key could be huge.
+	key := fmt.Sprintf("%q", e.Key)
+	if len(e.Key) > keyLimitQuoteLen {
+		key = fmt.Sprintf("%q...", e.Key[:keyLimitQuoteLen])
+	}
+	return fmt.Sprintf("%s: key %s: %d exceeds %d", e.err, key, e.Actual, e.Limit)
+}
+
+// keyLimitQuoteLen is the number of bytes of a key KeyLimitError quotes.
+const keyLimitQuoteLen = 32
+
+// Cause returns ErrKeyTooLong or ErrTrieTooDeep.
+//
+// Since 0.2.0
+func (e *KeyLimitError) Cause() error {
+	return e.err
+}
+
+// checkKey returns a *KeyLimitError if `key`, or the normalized key `nkey`
+// that adds a leaf at depth len(nkey), exceeds the limits, or the error of
+// WithKeyValidator wrapped with `op` and `key`.
+func (r *Node) checkKey(op string, key, nkey []byte) error {
+
+	if r.opt == nil {
+		return nil
+	}
+	return r.opt.checkKey(op, key, nkey)
+}
+
+// checkKey is Node.checkKey with options `o`.
+func (o *options) checkKey(op string, key, nkey []byte) error {
+
+	if max := o.maxKeyLen; max > 0 && len(key) > max {
+		return &KeyLimitError{Key: key, Limit: max, Actual: len(key), err: ErrKeyTooLong}
+	}
+
+	if max := o.maxTrieDepth; max > 0 && len(nkey) > max {
+		return &KeyLimitError{Key: key, Limit: max, Actual: len(nkey), err: ErrTrieTooDeep}
+	}
+
+	if fn := o.keyValidator; fn != nil {
+		if err := fn(key); err != nil {
+			return errors.Wrapf(err, "%s %q", op, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package trie
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/openacid/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithMaxKeyLen(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := [][]byte{[]byte("a"), []byte("abc"), []byte("abcd")}
+
+	tr, err := NewTrie(keys, []int{1, 2, 3}, false, WithMaxKeyLen(4))
+	ta.Nil(err)
+	ta.Equal(3, tr.KeyCnt())
+
+	_, err = NewTrie(keys, []int{1, 2, 3}, false, WithMaxKeyLen(3))
+	ta.Equal(ErrKeyTooLong, errors.Cause(err))
+
+	_, err = tr.Set([]byte("bcdef"), 4)
+	ta.Equal(ErrKeyTooLong, errors.Cause(err))
+	le := err.(*KeyLimitError)
+	ta.Equal([]byte("bcdef"), le.Key)
+	ta.Equal(4, le.Limit)
+	ta.Equal(5, le.Actual)
+	ta.Equal(`key too long: key "bcdef": 5 exceeds 4`, le.Error())
+
+	_, err = tr.Append([]byte("zzzzz"), 4)
+	ta.Equal(ErrKeyTooLong, errors.Cause(err))
+	ta.Equal(3, tr.KeyCnt())
+	ta.Nil(tr.Validate())
+
+	long := bytes.Repeat([]byte("x"), 1000)
+	_, err = tr.Set(long, 5)
+	// Only the beginning of a long key is quoted.
+	ta.Equal(`key too long: key "`+strings.Repeat("x", 32)+`"...: 1000 exceeds 4`, err.Error())
+
+	// Disabled.
+	tr, err = NewTrie(nil, nil, false, WithMaxKeyLen(0))
+	ta.Nil(err)
+	_, err = tr.Set(long, 1)
+	ta.Nil(err)
+}
+
+func TestWithMaxTrieDepth(t *testing.T) {
+
+	ta := require.New(t)
+
+	double := WithKeyNormalizer(func(key []byte) []byte {
+		return append(append([]byte{}, key...), key...)
+	})
+
+	tr, err := NewTrie(nil, nil, false, WithMaxKeyLen(3), WithMaxTrieDepth(4), double)
+	ta.Nil(err)
+
+	_, err = tr.Set([]byte("ab"), 1)
+	ta.Nil(err)
+
+	// Short enough, but too deep once normalized.
+	_, err = tr.Set([]byte("abc"), 2)
+	ta.Equal(ErrTrieTooDeep, errors.Cause(err))
+	le := err.(*KeyLimitError)
+	ta.Equal([]byte("abc"), le.Key)
+	ta.Equal(4, le.Limit)
+	ta.Equal(6, le.Actual)
+
+	_, err = tr.Set([]byte("abcd"), 2)
+	ta.Equal(ErrKeyTooLong, errors.Cause(err))
+
+	maxDepth := 0
+	tr.WalkDepth(func(n *Node, depth int) bool {
+		if depth > maxDepth {
+			maxDepth = depth
+		}
+		return true
+	})
+	ta.True(maxDepth <= 4)
+
+	_, err = NewTrie([][]byte{[]byte("abcde")}, []int{1}, true, WithMaxTrieDepth(4))
+	ta.Equal(ErrTrieTooDeep, errors.Cause(err))
+}
+
+func TestWithKeyValidator(t *testing.T) {
+
+	ta := require.New(t)
+
+	errReserved := errors.New("reserved prefix")
+	validator := WithKeyValidator(func(key []byte) error {
+		if !utf8.Valid(key) {
+			return errors.New("not utf-8")
+		}
+		if bytes.HasPrefix(key, []byte("_sys")) {
+			return errReserved
+		}
+		return nil
+	})
+
+	keys := [][]byte{[]byte("a"), []byte("b")}
+	tr, err := NewTrie(keys, []int{1, 2}, false, validator)
+	ta.Nil(err)
+
+	_, err = tr.Set([]byte("_sys/x"), 3)
+	ta.Equal(errReserved, errors.Cause(err))
+	ta.Contains(err.Error(), `set "_sys/x"`)
+
+	_, err = tr.Append([]byte("z\xff"), 3)
+	ta.Contains(err.Error(), `append "z\xff"`)
+	ta.Contains(err.Error(), "not utf-8")
+
+	ta.Equal(2, tr.KeyCnt())
+	ta.Nil(tr.Validate())
+
+	_, err = NewTrie([][]byte{[]byte("_sys"), []byte("a")}, []int{1, 2}, false, validator)
+	ta.Equal(errReserved, errors.Cause(err))
+
+	// The key is validated as it is passed in, before normalization.
+	tr, err = NewTrie(nil, nil, false, validator, WithKeyNormalizer(bytes.ToLower))
+	ta.Nil(err)
+	_, err = tr.Set([]byte("_SYS"), 1)
+	ta.Nil(err)
+
+	// Limits are checked first.
+	called := false
+	tr, err = NewTrie(nil, nil, false, WithMaxKeyLen(2), WithKeyValidator(func(key []byte) error {
+		called = true
+		return nil
+	}))
+	ta.Nil(err)
+	_, err = tr.Set([]byte("abc"), 1)
+	ta.Equal(ErrKeyTooLong, errors.Cause(err))
+	ta.False(called)
+}
+
+func TestKeyLimits_apply(t *testing.T) {
+
+	ta := require.New(t)
+
+	errReserved := errors.New("reserved prefix")
+	cases := []struct {
+		opt  Option
+		want error
+	}{
+		{WithMaxKeyLen(2), ErrKeyTooLong},
+		{WithMaxTrieDepth(2), ErrTrieTooDeep},
+		{WithKeyValidator(func(key []byte) error {
+			if len(key) > 2 {
+				return errReserved
+			}
+			return nil
+		}), errReserved},
+	}
+
+	for _, c := range cases {
+		ops := []Op{
+			{Type: OpSet, Key: []byte("ab"), Value: 1},
+			{Type: OpSet, Key: []byte("abcdef"), Value: 2},
+		}
+
+		tr, err := NewTrie(nil, nil, false, c.opt)
+		ta.Nil(err)
+		err = tr.Apply(ops)
+		ta.Equal(c.want, errors.Cause(err))
+		ta.Contains(err.Error(), "apply op 1")
+		ta.Equal(0, tr.KeyCnt(), "nothing is applied")
+
+		st, err := NewSyncTrie(nil, nil, false, c.opt)
+		ta.Nil(err)
+		ta.Equal(c.want, errors.Cause(st.Apply(ops)))
+		ta.Equal(c.want, errors.Cause(st.Set([]byte("abcdef"), 2)))
+
+		tx := st.Begin()
+		tx.Set([]byte("ab"), 1)
+		tx.Set([]byte("abcdef"), 2)
+		ta.Equal(c.want, errors.Cause(tx.Commit()))
+		ta.Equal(0, st.Load().KeyCnt())
+	}
+}
@@ -0,0 +1,127 @@
+package trie
+
+import "sync"
+
+// WithLoader sets a function to load the value of a key not in the trie,
+// e.g., from a backing store. Get calls it on a miss, and adds the loaded key
+// to the trie if `found` is true, which turns the trie into an ordered
+// cache. `fn` is called with the key given to Get, not normalized.
+//
+// Since 0.2.0
+func WithLoader(fn func(key []byte) (value interface{}, found bool)) Option {
+	return func(o *options) {
+		o.loader = fn
+	}
+}
+
+// Get returns the value of `key` and whether it is in the trie.
+// On a miss, the key is loaded with the loader of WithLoader, if there is one.
+// A loaded key is added with Set, thus it is only kept if its path is not
+// squashed.
+//
+// Same as Search, a squashed trie may match a key not in it to another key.
+//
+// Since 0.2.0
+func (r *Node) Get(key []byte) (interface{}, bool) {
+
+	if v, found := r.get(r.normalize(key)); found {
+		return v, true
+	}
+
+	if r.opt == nil || r.opt.loader == nil {
+		return nil, false
+	}
+
+	v, found := r.opt.loader(key)
+	if !found {
+		return nil, false
+	}
+
+	_, _ = r.Set(key, v)
+	return v, true
+}
+
+// get returns the value of normalized `key` and whether it is in the trie,
+// without loading it. An expired key is not found.
+func (r *Node) get(key []byte) (interface{}, bool) {
+
+	if len(r.Branches) == 0 {
+		return nil, false
+	}
+
+	leaf := r.leafOf(key)
+	if leaf == nil || leaf.Value == removed {
+		return nil, false
+	}
+	if r.expired(leaf) {
+		return nil, false
+	}
+	return leaf.Value, true
+}
+
+// loadCall is a load of a key by SyncTrie.Get, which concurrent Gets of the
+// same key wait for.
+type loadCall struct {
+	wg    sync.WaitGroup
+	value interface{}
+	found bool
+}
+
+// Get is the same as Node.Get on the current version, except that a loaded
+// key is added to a new version, and the loader is called once for
+// concurrent misses of the same key.
+//
+// Since 0.2.0
+func (s *SyncTrie) Get(key []byte) (interface{}, bool) {
+
+	root := s.Load()
+	nkey := root.normalize(key)
+
+	if v, found := root.get(nkey); found {
+		return v, true
+	}
+
+	if root.opt == nil || root.opt.loader == nil {
+		return nil, false
+	}
+
+	s.loadMu.Lock()
+	if c, ok := s.loading[string(nkey)]; ok {
+		s.loadMu.Unlock()
+		c.wg.Wait()
+		return c.value, c.found
+	}
+
+	c := &loadCall{}
+	c.wg.Add(1)
+	if s.loading == nil {
+		s.loading = make(map[string]*loadCall)
+	}
+	s.loading[string(nkey)] = c
+	s.loadMu.Unlock()
+
+	c.value, c.found = root.opt.loader(key)
+	if c.found {
+		s.set(key, nkey, c.value)
+	}
+
+	s.loadMu.Lock()
+	delete(s.loading, string(nkey))
+	s.loadMu.Unlock()
+	c.wg.Done()
+
+	return c.value, c.found
+}
+
+// set sets `key`, normalized as `nkey`, in a new version.
+func (s *SyncTrie) set(key, nkey []byte, value interface{}) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	root := s.Load().copyKeyPath(nkey)
+	if _, err := root.Set(key, value); err != nil {
+		return
+	}
+	s.root.Store(root)
+}
@@ -0,0 +1,128 @@
+package trie
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNode_Get(t *testing.T) {
+
+	ta := require.New(t)
+
+	store := map[string]interface{}{"b": 2, "c": 3}
+	loads := 0
+	loader := WithLoader(func(key []byte) (interface{}, bool) {
+		loads++
+		v, ok := store[string(key)]
+		return v, ok
+	})
+
+	tr, err := NewTrie([][]byte{[]byte("a")}, []int{1}, false, loader)
+	ta.Nil(err)
+
+	v, found := tr.Get([]byte("a"))
+	ta.True(found)
+	ta.Equal(1, v)
+	ta.Equal(0, loads)
+
+	v, found = tr.Get([]byte("b"))
+	ta.True(found)
+	ta.Equal(2, v)
+	ta.Equal(1, loads)
+
+	// Loaded keys are cached.
+	v, found = tr.Get([]byte("b"))
+	ta.True(found)
+	ta.Equal(2, v)
+	ta.Equal(1, loads)
+
+	_, found = tr.Get([]byte("x"))
+	ta.False(found)
+	ta.Equal(2, loads)
+
+	lt, eq, gt := tr.Search([]byte("ab"))
+	ta.Equal(1, lt)
+	ta.Nil(eq)
+	ta.Equal(2, gt)
+
+	// Without a loader.
+	tr, err = NewTrie([][]byte{[]byte("a")}, []int{1}, false, WithLazyRemove())
+	ta.Nil(err)
+	ta.True(tr.Remove([]byte("a")))
+	_, found = tr.Get([]byte("a"))
+	ta.False(found)
+}
+
+func TestSyncTrie_Get(t *testing.T) {
+
+	ta := require.New(t)
+
+	var loads int32
+	release := make(chan struct{})
+	loader := WithLoader(func(key []byte) (interface{}, bool) {
+		atomic.AddInt32(&loads, 1)
+		<-release
+		return string(key), true
+	})
+
+	st, err := NewSyncTrie([][]byte{[]byte("a")}, []string{"a"}, false, loader)
+	ta.Nil(err)
+
+	old := st.Load()
+
+	var wg sync.WaitGroup
+	values := make([]interface{}, 8)
+	for i := range values {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			values[i], _ = st.Get([]byte("k"))
+		}(i)
+	}
+
+	// Wait for the first load to start, then let it finish.
+	for atomic.LoadInt32(&loads) == 0 {
+	}
+	close(release)
+	wg.Wait()
+
+	for _, v := range values {
+		ta.Equal("k", v)
+	}
+
+	// Gets started after the load hit the trie, others waited for it.
+	ta.Equal(int32(1), atomic.LoadInt32(&loads))
+
+	_, eq, _ := st.Search([]byte("k"))
+	ta.Equal("k", eq)
+
+	_, eq, _ = old.Search([]byte("k"))
+	ta.Nil(eq)
+}
+
+func TestSyncTrie_Get_expired(t *testing.T) {
+
+	ta := require.New(t)
+
+	loads := 0
+	st, err := NewSyncTrie(nil, nil, false, WithLoader(func(key []byte) (interface{}, bool) {
+		loads++
+		return "loaded", true
+	}))
+	ta.Nil(err)
+	ta.Nil(st.Apply([]Op{{Type: OpSetTTL, Key: []byte("k"), Value: "expired", TTL: -time.Second}}))
+
+	// An expired key is a miss, the same as Node.Get.
+	_, found := st.Load().get([]byte("k"))
+	ta.False(found)
+	for i := 0; i < 2; i++ {
+		v, found := st.Get([]byte("k"))
+		ta.True(found)
+		ta.Equal("loaded", v)
+	}
+	ta.Equal(1, loads)
+}
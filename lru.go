@@ -0,0 +1,189 @@
+package trie
+
+import "container/list"
+
+// LRUTrie is a trie of a bounded number of keys or bytes, which evicts the
+// least recently used keys to add new ones: an ordered cache that can be
+// queried by prefix or range with NewIter.
+//
+// Set and Get make a key the most recently used. Search, NewIter and Len do
+// not. An evicted key is removed with the nodes left without keys.
+//
+// An LRUTrie does not squash, thus keys are yielded as they are. It is not
+// safe for concurrent use.
+//
+// Since 0.2.0
+type LRUTrie struct {
+	root *Node
+
+	maxKeys  int
+	maxBytes int
+	size     func(key []byte, value interface{}) int
+
+	// lru is the list of *lruEntry, the most recently used first.
+	lru     *list.List
+	entries map[*Node]*list.Element
+	bytes   int
+}
+
+// lruEntry is a key in an LRUTrie.
+type lruEntry struct {
+	// key is the key as it is set, to remove it.
+	key  []byte
+	size int
+}
+
+// NewLRUTrie creates an empty LRUTrie of at most `maxKeys` keys, and of keys
+// and values of at most `maxBytes` bytes, either of which is not limited if it
+// is not positive. `opts` are the same as those of NewTrie, except that
+// WithLazyRemove, WithLoader and WithHashConsing do not apply.
+//
+// The bytes of a key and its value are len(key) plus the bytes the value
+// refers to, estimated the same as MemoryBreakdown.Values, unless it is set
+// with WithLRUSize.
+//
+// Since 0.2.0
+func NewLRUTrie(maxKeys, maxBytes int, opts ...Option) (*LRUTrie, error) {
+
+	root, err := NewTrie(nil, nil, false, opts...)
+	if err != nil {
+		return nil, err
+	}
+	root.opt.lazyRemove = false
+	root.opt.loader = nil
+	root.opt.hashConsing = false
+
+	size := root.opt.lruSize
+	if size == nil {
+		size = func(key []byte, value interface{}) int {
+			return len(key) + estimateValueSize(value)
+		}
+	}
+
+	return &LRUTrie{
+		root:     root,
+		maxKeys:  maxKeys,
+		maxBytes: maxBytes,
+		size:     size,
+		lru:      list.New(),
+		entries:  make(map[*Node]*list.Element),
+	}, nil
+}
+
+// WithLRUSize sets the function telling the bytes of a key and its value in
+// an LRUTrie, to limit the bytes of NewLRUTrie. It does not apply to other
+// tries.
+//
+// Since 0.2.0
+func WithLRUSize(fn func(key []byte, value interface{}) int) Option {
+	return func(o *options) {
+		o.lruSize = fn
+	}
+}
+
+// Set adds or updates `key` as the most recently used key, then evicts the
+// least recently used keys until the limits are met. It returns the number of
+// keys evicted. A key of which the bytes exceed the limit is evicted itself.
+//
+// Since 0.2.0
+func (t *LRUTrie) Set(key []byte, value interface{}) (evicted int, err error) {
+
+	leaf, err := t.root.Set(key, value)
+	if err != nil {
+		return 0, err
+	}
+
+	size := t.size(key, value)
+	if el, ok := t.entries[leaf]; ok {
+		e := el.Value.(*lruEntry)
+		t.bytes += size - e.size
+		e.key, e.size = copyBytes(key), size
+		t.lru.MoveToFront(el)
+	} else {
+		t.entries[leaf] = t.lru.PushFront(&lruEntry{key: copyBytes(key), size: size})
+		t.bytes += size
+	}
+
+	for t.lru.Len() > 0 && t.overLimit() {
+		t.evict(t.lru.Back())
+		evicted++
+	}
+	return evicted, nil
+}
+
+func (t *LRUTrie) overLimit() bool {
+	return (t.maxKeys > 0 && t.lru.Len() > t.maxKeys) ||
+		(t.maxBytes > 0 && t.bytes > t.maxBytes)
+}
+
+// evict removes the key of `el` from the trie and the list.
+func (t *LRUTrie) evict(el *list.Element) {
+
+	e := el.Value.(*lruEntry)
+	leaf := t.root.leafOf(t.root.normalize(e.key))
+
+	t.root.Remove(e.key)
+	t.lru.Remove(el)
+	delete(t.entries, leaf)
+	t.bytes -= e.size
+}
+
+// Get returns the value of `key` and makes it the most recently used one.
+//
+// Since 0.2.0
+func (t *LRUTrie) Get(key []byte) (interface{}, bool) {
+
+	leaf := t.root.leafOf(t.root.normalize(key))
+	el, ok := t.entries[leaf]
+	if leaf == nil || !ok {
+		return nil, false
+	}
+
+	t.lru.MoveToFront(el)
+	return leaf.Value, true
+}
+
+// Search is the same as Node.Search, and does not change how recently the
+// keys are used.
+//
+// Since 0.2.0
+func (t *LRUTrie) Search(key []byte) (ltValue, eqValue, gtValue interface{}) {
+	return t.root.Search(key)
+}
+
+// Remove removes `key` and returns true if it is in the trie.
+//
+// Since 0.2.0
+func (t *LRUTrie) Remove(key []byte) bool {
+
+	leaf := t.root.leafOf(t.root.normalize(key))
+	el, ok := t.entries[leaf]
+	if leaf == nil || !ok {
+		return false
+	}
+
+	t.evict(el)
+	return true
+}
+
+// Len returns the number of keys.
+//
+// Since 0.2.0
+func (t *LRUTrie) Len() int {
+	return t.lru.Len()
+}
+
+// Bytes returns the bytes of all keys and values, see NewLRUTrie.
+//
+// Since 0.2.0
+func (t *LRUTrie) Bytes() int {
+	return t.bytes
+}
+
+// NewIter creates an Iter over the keys in ascending order, the same as
+// Node.NewIter. The LRUTrie must not be modified during an iteration.
+//
+// Since 0.2.0
+func (t *LRUTrie) NewIter(opts ...IterOption) *Iter {
+	return t.root.NewIter(opts...)
+}
@@ -0,0 +1,164 @@
+package trie
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRUTrie(t *testing.T) {
+
+	ta := require.New(t)
+
+	c, err := NewLRUTrie(3, 0)
+	ta.Nil(err)
+
+	for _, k := range []string{"b", "a", "c"} {
+		evicted, err := c.Set([]byte(k), k)
+		ta.Nil(err)
+		ta.Equal(0, evicted)
+	}
+
+	// a is used, thus b is the least recently used.
+	v, found := c.Get([]byte("a"))
+	ta.True(found)
+	ta.Equal("a", v)
+
+	evicted, err := c.Set([]byte("d"), "d")
+	ta.Nil(err)
+	ta.Equal(1, evicted)
+
+	_, found = c.Get([]byte("b"))
+	ta.False(found)
+	ta.Equal(3, c.Len())
+
+	got := []string{}
+	for it := c.NewIter(); it.Next(); {
+		got = append(got, string(it.Key()))
+	}
+	ta.Equal([]string{"a", "c", "d"}, got)
+
+	// Search does not use keys: c is the next to evict.
+	lt, eq, gt := c.Search([]byte("b"))
+	ta.Equal("a", lt)
+	ta.Nil(eq)
+	ta.Equal("c", gt)
+
+	// Updating a key uses it.
+	_, err = c.Set([]byte("c"), "cc")
+	ta.Nil(err)
+	_, err = c.Set([]byte("e"), "e")
+	ta.Nil(err)
+	_, found = c.Get([]byte("a"))
+	ta.False(found)
+	v, _ = c.Get([]byte("c"))
+	ta.Equal("cc", v)
+
+	ta.True(c.Remove([]byte("c")))
+	ta.False(c.Remove([]byte("c")))
+	ta.False(c.Remove([]byte("zz")))
+	ta.Equal(2, c.Len())
+	ta.Nil(c.root.Validate())
+}
+
+func TestLRUTrie_bytes(t *testing.T) {
+
+	ta := require.New(t)
+
+	c, err := NewLRUTrie(0, 20)
+	ta.Nil(err)
+
+	_, err = c.Set([]byte("k1"), "12345678")
+	ta.Nil(err)
+	_, err = c.Set([]byte("k2"), "12345678")
+	ta.Nil(err)
+	ta.Equal(20, c.Bytes())
+
+	evicted, err := c.Set([]byte("k3"), "1")
+	ta.Nil(err)
+	ta.Equal(1, evicted)
+	ta.Equal(13, c.Bytes())
+
+	// Too large to keep.
+	evicted, err = c.Set([]byte("big"), string(bytes.Repeat([]byte("x"), 30)))
+	ta.Nil(err)
+	ta.Equal(3, evicted)
+	ta.Equal(0, c.Len())
+	ta.Equal(0, c.Bytes())
+	ta.Equal(1, c.root.Counters().InnerNodes, "emptied branches are pruned")
+
+	sized, err := NewLRUTrie(0, 3, WithLRUSize(func(key []byte, v interface{}) int { return 1 }))
+	ta.Nil(err)
+	for i := 0; i < 10; i++ {
+		_, err = sized.Set([]byte{byte(i)}, i)
+		ta.Nil(err)
+	}
+	ta.Equal(3, sized.Len())
+	ta.Equal(3, sized.Bytes())
+}
+
+func TestLRUTrie_random(t *testing.T) {
+
+	ta := require.New(t)
+
+	const max = 20
+
+	c, err := NewLRUTrie(max, 0, WithKeyNormalizer(bytes.ToLower))
+	ta.Nil(err)
+
+	// A reference LRU of keys.
+	ref := list.New()
+	refElems := map[string]*list.Element{}
+	use := func(k string) {
+		if el, ok := refElems[k]; ok {
+			ref.MoveToFront(el)
+			return
+		}
+		refElems[k] = ref.PushFront(k)
+		if ref.Len() > max {
+			last := ref.Back()
+			ref.Remove(last)
+			delete(refElems, last.Value.(string))
+		}
+	}
+
+	rnd := rand.New(rand.NewSource(5))
+	for i := 0; i < 3000; i++ {
+		k := fmt.Sprintf("k%02d", rnd.Intn(40))
+		key := []byte(k)
+		if rnd.Intn(2) == 0 {
+			// Normalized the same.
+			key = bytes.ToUpper(key)
+		}
+
+		switch rnd.Intn(3) {
+		case 0:
+			_, err := c.Set(key, k)
+			ta.Nil(err)
+			use(k)
+		case 1:
+			v, found := c.Get(key)
+			_, want := refElems[k]
+			ta.Equal(want, found, "get %s", k)
+			if found {
+				ta.Equal(k, v)
+				use(k)
+			}
+		default:
+			_, want := refElems[k]
+			ta.Equal(want, c.Remove(key), "remove %s", k)
+			if want {
+				ref.Remove(refElems[k])
+				delete(refElems, k)
+			}
+		}
+
+		ta.Equal(ref.Len(), c.Len())
+		ta.Equal(ref.Len(), c.root.KeyCnt())
+	}
+	ta.Nil(c.root.Validate())
+}
@@ -0,0 +1,239 @@
+package trie
+
+import (
+	"bytes"
+	"sync"
+)
+
+// LSMTrie is a two-level trie: writes go to a small unsquashed buffer
+// trie, and a full buffer is merged into a large squashed base trie in
+// background. Reads consult the buffer first.
+//
+// The keys of the base trie are kept to verify lookups, since a squashed
+// trie can not tell an absent key from a present one, and to rebuild the base
+// trie when merging.
+//
+// LSMTrie is safe for concurrent use.
+//
+// Since 0.2.0
+type LSMTrie struct {
+	mu sync.RWMutex
+
+	// base is a squashed trie created by NewIndexTrie, of indexes into
+	// baseKeys and baseValues.
+	base       *Node
+	baseKeys   [][]byte
+	baseValues []interface{}
+
+	// buf receives writes.
+	buf    *Node
+	bufCnt int
+
+	// merging is the buffer being merged into base, or nil.
+	merging *Node
+
+	maxBuf int
+	wg     sync.WaitGroup
+}
+
+// NewLSMTrie creates an LSMTrie with a base trie of ascendingly ordered
+// `keys` and `values`. A merge starts when there are `maxBuf` keys in the
+// buffer.
+//
+// Since 0.2.0
+func NewLSMTrie(keys [][]byte, values []interface{}, maxBuf int) (*LSMTrie, error) {
+
+	if len(keys) != len(values) {
+		return nil, ErrKVLenNotMatch
+	}
+
+	t := &LSMTrie{maxBuf: maxBuf}
+
+	base, err := NewIndexTrie(keys, true)
+	if err != nil {
+		return nil, err
+	}
+
+	t.base = base
+	t.baseKeys = keys
+	t.baseValues = values
+	t.buf, _ = NewTrie(nil, nil, false)
+
+	return t, nil
+}
+
+// Set sets the value of `key` in any order.
+// If the buffer becomes full, a background merge is started, unless one is
+// running.
+//
+// Since 0.2.0
+func (t *LSMTrie) Set(key []byte, value interface{}) {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.bufHas(key) {
+		t.bufCnt++
+	}
+
+	// The buffer is never squashed.
+	_, _ = t.buf.Set(key, value)
+
+	if t.bufCnt >= t.maxBuf && t.merging == nil {
+		t.startMerge()
+	}
+}
+
+// Get returns the value of `key` and whether it is found.
+//
+// Since 0.2.0
+func (t *LSMTrie) Get(key []byte) (interface{}, bool) {
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if v, ok := t.buf.lookup(key); ok {
+		return v, true
+	}
+
+	if t.merging != nil {
+		if v, ok := t.merging.lookup(key); ok {
+			return v, true
+		}
+	}
+
+	_, i, _ := t.base.SearchIndex(key)
+	if i < 0 {
+		return nil, false
+	}
+
+	if !bytes.Equal(t.baseKeys[i], key) {
+		return nil, false
+	}
+	return t.baseValues[i], true
+}
+
+// Flush merges the buffer into the base trie and waits for it to finish.
+//
+// Since 0.2.0
+func (t *LSMTrie) Flush() {
+
+	t.Wait()
+
+	t.mu.Lock()
+	if t.bufCnt > 0 {
+		t.startMerge()
+	}
+	t.mu.Unlock()
+
+	t.Wait()
+}
+
+// Wait waits for the running merge if there is one.
+//
+// Since 0.2.0
+func (t *LSMTrie) Wait() {
+	t.wg.Wait()
+}
+
+// Len returns the number of keys in the base trie and the buffer.
+// A key in both is counted twice until they are merged.
+//
+// Since 0.2.0
+func (t *LSMTrie) Len() (base, buf int) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.merging != nil {
+		buf = t.merging.leafCnt()
+	}
+	return len(t.baseKeys), buf + t.bufCnt
+}
+
+// startMerge swaps out the buffer and merges it in background.
+// It must be called with t.mu locked.
+func (t *LSMTrie) startMerge() {
+
+	t.merging = t.buf
+	t.buf, _ = NewTrie(nil, nil, false)
+	t.bufCnt = 0
+
+	baseKeys, baseValues, merging := t.baseKeys, t.baseValues, t.merging
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+
+		keys, values := mergeKVs(baseKeys, baseValues, merging)
+
+		// keys are sorted and unique.
+		base, _ := NewIndexTrie(keys, true)
+
+		t.mu.Lock()
+		t.base = base
+		t.baseKeys = keys
+		t.baseValues = values
+		t.merging = nil
+		if t.bufCnt >= t.maxBuf {
+			t.startMerge()
+		}
+		t.mu.Unlock()
+	}()
+}
+
+func (t *LSMTrie) bufHas(key []byte) bool {
+	_, ok := t.buf.lookup(key)
+	return ok
+}
+
+// lookup returns the value of `key` in an unsquashed trie.
+func (r *Node) lookup(key []byte) (interface{}, bool) {
+	leaf := r.leafOf(r.normalize(key))
+	if leaf == nil {
+		return nil, false
+	}
+	return leaf.Value, true
+}
+
+// mergeKVs merges sorted keys and the keys in an unsquashed trie. The trie
+// wins if a key is in both.
+func mergeKVs(keys [][]byte, values []interface{}, buf *Node) ([][]byte, []interface{}) {
+
+	rkeys := make([][]byte, 0, len(keys))
+	rvalues := make([]interface{}, 0, len(keys))
+
+	i := 0
+	it := buf.NewIter()
+	for it.Next() {
+		k := it.Key()
+		for i < len(keys) && bytes.Compare(keys[i], k) < 0 {
+			rkeys = append(rkeys, keys[i])
+			rvalues = append(rvalues, values[i])
+			i++
+		}
+		if i < len(keys) && bytes.Equal(keys[i], k) {
+			i++
+		}
+		rkeys = append(rkeys, append([]byte{}, k...))
+		rvalues = append(rvalues, it.Value())
+	}
+
+	rkeys = append(rkeys, keys[i:]...)
+	rvalues = append(rvalues, values[i:]...)
+
+	return rkeys, rvalues
+}
+
+// leafCnt counts leaves in the subtree rooted at r.
+func (r *Node) leafCnt() int {
+
+	cnt := 0
+	for _, b := range r.Branches {
+		if b == leafBranch {
+			cnt++
+			continue
+		}
+		cnt += r.Children[b].leafCnt()
+	}
+	return cnt
+}
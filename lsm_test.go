@@ -0,0 +1,123 @@
+package trie
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/openacid/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNode_Set(t *testing.T) {
+
+	ta := require.New(t)
+
+	root, err := NewTrie(nil, nil, false)
+	ta.Nil(err)
+
+	for _, k := range []string{"bc", "a", "abc", "", "b", "a"} {
+		_, err := root.Set([]byte(k), k+"!")
+		ta.Nil(err)
+	}
+
+	keys, values := iterAll(root.NewIter())
+	ta.Equal([]string{"", "a", "abc", "b", "bc"}, keys)
+	ta.Equal([]interface{}{"!", "a!", "abc!", "b!", "bc!"}, values)
+	ta.Equal(6, root.InnerNodeCnt)
+
+	sq := newStrTrie(ta, true, "abc", "abd", "xyz")
+	_, err = sq.Set([]byte("ab"), 1)
+	ta.Equal(ErrSquashed, errors.Cause(err))
+}
+
+func TestLSMTrie(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := [][]byte{[]byte("abc"), []byte("abd"), []byte("b")}
+	values := []interface{}{1, 2, 3}
+
+	lt, err := NewLSMTrie(keys, values, 2)
+	ta.Nil(err)
+
+	_, err = NewLSMTrie(keys, values[:1], 2)
+	ta.Equal(ErrKVLenNotMatch, err)
+
+	// A squashed base alone would match "abx" to "abc".
+	_, found := lt.Get([]byte("abx"))
+	ta.False(found)
+
+	lt.Set([]byte("abd"), 20)
+	lt.Set([]byte("a"), 10)
+	lt.Wait()
+
+	lt.Set([]byte("c"), nil)
+
+	base, buf := lt.Len()
+	ta.Equal(4, base)
+	ta.Equal(1, buf)
+
+	lt.Flush()
+	base, buf = lt.Len()
+	ta.Equal(5, base)
+	ta.Equal(0, buf)
+
+	cases := []struct {
+		key   string
+		want  interface{}
+		found bool
+	}{
+		{"", nil, false},
+		{"a", 10, true},
+		{"ab", nil, false},
+		{"abc", 1, true},
+		{"abd", 20, true},
+		{"b", 3, true},
+		{"c", nil, true},
+		{"d", nil, false},
+	}
+
+	for i, c := range cases {
+		v, found := lt.Get([]byte(c.key))
+		ta.Equal(c.found, found, "%d-th: key: %q", i+1, c.key)
+		ta.Equal(c.want, v, "%d-th: key: %q", i+1, c.key)
+	}
+}
+
+func TestLSMTrie_concurrent(t *testing.T) {
+
+	ta := require.New(t)
+
+	lt, err := NewLSMTrie(nil, nil, 16)
+	ta.Nil(err)
+
+	rnd := rand.New(rand.NewSource(7))
+	want := map[string]int{}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			lt.Get([]byte(fmt.Sprintf("%03d", i%100)))
+		}
+	}()
+
+	for i := 0; i < 1000; i++ {
+		k := fmt.Sprintf("%03d", rnd.Intn(300))
+		lt.Set([]byte(k), i)
+		want[k] = i
+	}
+	<-done
+	lt.Flush()
+
+	base, buf := lt.Len()
+	ta.Equal(len(want), base)
+	ta.Equal(0, buf)
+
+	for k, v := range want {
+		got, found := lt.Get([]byte(k))
+		ta.True(found, "key: %q", k)
+		ta.Equal(v, got, "key: %q", k)
+	}
+}
@@ -0,0 +1,206 @@
+package trie
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// WithMaxMemory limits the memory a trie is estimated to use while NewTrie,
+// or any constructor built on it, appends keys. Once the estimate exceeds
+// `bytes`, the build stops with a *MemoryLimitError, instead of growing until
+// the process is killed. A non-positive `bytes` disables the limit.
+//
+// The estimate counts the nodes, maps and branches of the trie, not keys
+// stored with WithStoreKeys or values. To build a trie larger than the
+// memory, use ExternalBuilder, which spills to disk every `memLimit` bytes.
+//
+// Since 0.2.0
+func WithMaxMemory(bytes int) Option {
+	return func(o *options) {
+		o.maxMemory = bytes
+	}
+}
+
+// MemoryLimitError is returned by a build that exceeds WithMaxMemory.
+// Its Cause is ErrMemoryLimit.
+//
+// Since 0.2.0
+type MemoryLimitError struct {
+	// Limit is the bytes of WithMaxMemory.
+	Limit int
+	// Estimated is the bytes the trie is estimated to use when it stops.
+	Estimated int
+	// KeyCnt is the number of keys appended when it stops.
+	KeyCnt int
+}
+
+// Error implements error.
+//
+// Since 0.2.0
+func (e *MemoryLimitError) Error() string {
+	return fmt.Sprintf("%s: estimated %d bytes exceeds %d after %d keys",
+		ErrMemoryLimit, e.Estimated, e.Limit, e.KeyCnt)
+}
+
+// Cause returns ErrMemoryLimit.
+//
+// Since 0.2.0
+func (e *MemoryLimitError) Cause() error {
+	return ErrMemoryLimit
+}
+
+// memEntrySize is the bytes of a map entry besides its bucket: a key and a
+// value, plus the element of Branches.
+const memEntrySize = 3 * intSize
+
+// memEstimate estimates the bytes used by the nodes of the trie from its
+// Counters in O(1) time: it assumes every map fits in one bucket, thus it
+// underestimates tries of wide nodes.
+func (r *Node) memEstimate() int {
+
+	c := r.Counters()
+
+	s := c.InnerNodes * (nodeSize + mapHeaderSize + estimateMapSize(0))
+	s += c.Branches * memEntrySize
+	if r.opt == nil || r.opt.setLeaf == nil {
+		s += c.Leaves * nodeSize
+	}
+	return s
+}
+
+// checkMemory returns a *MemoryLimitError if the trie exceeds WithMaxMemory.
+func (r *Node) checkMemory(keyCnt int) error {
+
+	max := r.opt.maxMemory
+	if max <= 0 {
+		return nil
+	}
+
+	if s := r.memEstimate(); s > max {
+		return &MemoryLimitError{Limit: max, Estimated: s, KeyCnt: keyCnt}
+	}
+	return nil
+}
+
+// MemoryBreakdown is the estimated bytes a trie uses, by component.
+//
+// Since 0.2.0
+type MemoryBreakdown struct {
+	// Nodes is of the Node structs, inner nodes and leaves, with the fields
+	// kept by options.
+	//
+	// Since 0.2.0
+	Nodes int
+
+	// Children is of the maps of children of inner nodes.
+	//
+	// Since 0.2.0
+	Children int
+
+	// Branches is of the slices of the branch labels of inner nodes.
+	//
+	// Since 0.2.0
+	Branches int
+
+	// Keys is of the keys stored in leaves by WithStoreKeys.
+	//
+	// Since 0.2.0
+	Keys int
+
+	// Values is of what the values refer to, besides the interface in a
+	// leaf, which Nodes counts: the bytes of strings and slices, and what a
+	// pointer points to. What the referred data refers to is not counted.
+	//
+	// Since 0.2.0
+	Values int
+}
+
+// Total returns the sum of all components.
+//
+// Since 0.2.0
+func (m MemoryBreakdown) Total() int {
+	return m.Nodes + m.Children + m.Branches + m.Keys + m.Values
+}
+
+// MemoryBreakdown estimates the bytes used by the trie, by component, e.g., to
+// tell whether the maps of children, the leaves or the values dominate.
+// A node shared by WithHashConsing or by the keys of a Set is counted once.
+//
+// It takes O(n) time for a trie of n nodes, unlike the estimate of
+// WithMaxMemory, which only counts nodes, maps and branches and assumes small
+// maps.
+//
+// Since 0.2.0
+func (r *Node) MemoryBreakdown() MemoryBreakdown {
+
+	var m MemoryBreakdown
+
+	var seen map[*Node]bool
+	if r.readOnly() || (r.opt != nil && r.opt.setLeaf != nil) {
+		seen = make(map[*Node]bool)
+	}
+
+	r.WalkDepth(func(n *Node, depth int) bool {
+		if seen != nil {
+			if seen[n] {
+				return false
+			}
+			seen[n] = true
+		}
+
+		m.addStructure(n)
+		m.Keys += cap(n.key())
+		if n.Children == nil && n.Value != removed {
+			m.Values += estimateValueSize(n.Value)
+		}
+		return true
+	})
+	return m
+}
+
+// addStructure adds the bytes of node `n`, its Branches and Children.
+func (m *MemoryBreakdown) addStructure(n *Node) {
+
+	m.Nodes += nodeSize
+	if n.leafExt != nil {
+		m.Nodes += leafExtSize
+	}
+	if n.innerExt != nil {
+		m.Nodes += innerExtSize
+	}
+	m.Branches += cap(n.Branches) * intSize
+	if n.Children != nil {
+		m.Children += mapHeaderSize + estimateMapSize(len(n.Children))
+	}
+}
+
+// estimateValueSize estimates the bytes value `v` refers to, see
+// MemoryBreakdown.Values.
+func estimateValueSize(v interface{}) int {
+
+	switch x := v.(type) {
+	case nil:
+		return 0
+	case string:
+		return len(x)
+	case []byte:
+		return cap(x)
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice:
+		return rv.Cap() * int(rv.Type().Elem().Size())
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return 0
+		}
+		return int(rv.Type().Elem().Size())
+	case reflect.Map, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return 0
+	}
+
+	// A value not of a pointer is copied into the interface, except a small
+	// one, which is not worth telling apart.
+	return int(rv.Type().Size())
+}
@@ -0,0 +1,118 @@
+package trie
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/openacid/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithMaxMemory(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := make([][]byte, 1000)
+	for i := range keys {
+		keys[i] = []byte(fmt.Sprintf("%04d", i))
+	}
+	values := make([]int, len(keys))
+
+	full, err := NewTrie(keys, values, false)
+	ta.Nil(err)
+	size := full.memEstimate()
+	ta.True(size > 0)
+
+	// Enough or disabled.
+	for _, max := range []int{0, -1, size} {
+		tr, err := NewTrie(keys, values, false, WithMaxMemory(max))
+		ta.Nil(err, "max=%d", max)
+		ta.Equal(len(keys), tr.KeyCnt())
+	}
+
+	tr, err := NewTrie(keys, values, false, WithMaxMemory(size/2))
+	ta.Nil(tr)
+	ta.Equal(ErrMemoryLimit, errors.Cause(err))
+
+	me := err.(*MemoryLimitError)
+	ta.Equal(size/2, me.Limit)
+	ta.True(me.Estimated > me.Limit)
+	ta.True(me.KeyCnt > 0 && me.KeyCnt < len(keys), "stops halfway: %d", me.KeyCnt)
+	ta.Contains(me.Error(), "memory limit exceeded")
+
+	// A squashing build is estimated to use less.
+	sq, err := NewTrie(keys, values, true)
+	ta.Nil(err)
+	ta.True(sq.memEstimate() < size)
+
+	// Keys of a Set share one leaf.
+	s, err := NewSet(keys, false)
+	ta.Nil(err)
+	ta.True(s.root.memEstimate() < size)
+
+	// Streaming builds are limited too.
+	_, err = NewTrieFromFunc(func(i int) ([]byte, interface{}, bool) {
+		return []byte(fmt.Sprintf("%08d", i)), i, true
+	}, false, WithMaxMemory(1<<20))
+	ta.Equal(ErrMemoryLimit, errors.Cause(err))
+}
+
+func TestNode_MemoryBreakdown(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := [][]byte{[]byte("ab"), []byte("abc"), []byte("b")}
+
+	tr, err := NewTrie(keys, []string{"x", "yy", "zzz"}, false)
+	ta.Nil(err)
+
+	m := tr.MemoryBreakdown()
+	c := tr.Counters()
+	ta.Equal((c.InnerNodes+c.Leaves)*nodeSize, m.Nodes)
+	ta.True(m.Children >= c.InnerNodes*mapHeaderSize)
+	ta.True(m.Branches >= c.Branches*intSize)
+	ta.Equal(0, m.Keys)
+	ta.Equal(6, m.Values)
+	ta.Equal(m.Nodes+m.Children+m.Branches+m.Keys+m.Values, m.Total())
+
+	stored, err := NewTrie(keys, []string{"x", "yy", "zzz"}, false, WithStoreKeys())
+	ta.Nil(err)
+	ta.True(stored.MemoryBreakdown().Keys >= 6)
+	ta.Equal(m.Nodes+c.Leaves*leafExtSize, stored.MemoryBreakdown().Nodes)
+
+	// The shared leaf of a Set is counted once.
+	s, err := NewSet(keys, false)
+	ta.Nil(err)
+	ta.Equal((c.InnerNodes+1)*nodeSize, s.root.MemoryBreakdown().Nodes)
+
+	// Hash consing shares the leaves of the same value.
+	hc, err := NewTrie(keys, []int{1, 1, 1}, false, WithHashConsing())
+	ta.Nil(err)
+	ta.True(hc.MemoryBreakdown().Nodes < m.Nodes)
+}
+
+func TestEstimateValueSize(t *testing.T) {
+
+	ta := require.New(t)
+
+	type pair struct{ a, b int64 }
+
+	cases := []struct {
+		v    interface{}
+		want int
+	}{
+		{nil, 0},
+		{"abc", 3},
+		{make([]byte, 2, 10), 10},
+		{make([]int32, 3), 12},
+		{&pair{}, 16},
+		{(*pair)(nil), 0},
+		{pair{}, 16},
+		{int64(1), 8},
+		{map[int]int{}, 0},
+	}
+
+	for i, c := range cases {
+		ta.Equal(c.want, estimateValueSize(c.v), "%d-th: %#v", i+1, c.v)
+	}
+}
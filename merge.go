@@ -0,0 +1,222 @@
+package trie
+
+import "bytes"
+
+// MergePolicy decides the value of a key found in more than one trie.
+// `values` are the values of the key in the order of the tries containing
+// it, and there are at least two of them.
+//
+// Since 0.2.0
+type MergePolicy func(key []byte, values []interface{}) interface{}
+
+// FirstWins is a MergePolicy that takes the value from the first trie.
+// It is the default policy.
+//
+// Since 0.2.0
+func FirstWins(key []byte, values []interface{}) interface{} {
+	return values[0]
+}
+
+// LastWins is a MergePolicy that takes the value from the last trie.
+//
+// Since 0.2.0
+func LastWins(key []byte, values []interface{}) interface{} {
+	return values[len(values)-1]
+}
+
+// KVSource is a serial of keys and values in ascending key order, e.g., an
+// Iter, a MergedIter, or a reader of a file of sorted keys.
+//
+// Since 0.2.0
+type KVSource interface {
+	// Next advances to the next key. It returns false when there are no more
+	// keys.
+	//
+	// Since 0.2.0
+	Next() bool
+
+	// Key returns the current key, which is only valid until the next call to
+	// Next.
+	//
+	// Since 0.2.0
+	Key() []byte
+
+	// Value returns the current value.
+	//
+	// Since 0.2.0
+	Value() interface{}
+}
+
+// MergedIter iterates over the union of the entries in several tries in
+// ascending key order, without building a merged trie.
+//
+// Every trie is iterated with an Iter, thus keys from squashed tries are
+// rebuilt the same way as Iter does.
+//
+// Since 0.2.0
+type MergedIter struct {
+	iters  []KVSource
+	alive  []bool
+	policy MergePolicy
+
+	// order is the byte order keys are compared in.
+	order byteOrder
+
+	started bool
+
+	key    []byte
+	value  interface{}
+	values []interface{}
+
+	// skipRemoved makes keys with a removed value skipped.
+	skipRemoved bool
+}
+
+// MergeIter creates a MergedIter positioned before the first key of the union
+// of `tries`. A key in several tries is yielded once, with a value chosen by
+// the MergePolicy, which is FirstWins by default.
+//
+// Keys are compared in the byte order of the first trie, see WithByteOrder.
+//
+// Since 0.2.0
+func MergeIter(tries ...*Node) *MergedIter {
+
+	iters := make([]KVSource, len(tries))
+	for i, t := range tries {
+		iters[i] = t.NewIter()
+	}
+
+	var order byteOrder
+	if len(tries) > 0 {
+		order = tries[0].byteOrder()
+	}
+
+	return newMergedIter(iters, order)
+}
+
+// MergeSources is the same as MergeIter except that it merges any KVSource,
+// of which keys are compared in the default byte order.
+//
+// Since 0.2.0
+func MergeSources(sources ...KVSource) *MergedIter {
+	return newMergedIter(sources, nil)
+}
+
+// NewTrieFromSources creates a trie of the union of `sources` without
+// collecting them first, e.g., of several files of sorted keys. A key in
+// several sources is added once, with a value chosen by `policy`, or by
+// FirstWins if it is nil. `squash` and `opts` are the same as those of
+// NewTrie, and keys are compared in the byte order of WithByteOrder.
+//
+// Every source must be ascendingly ordered without duplicate keys.
+//
+// Since 0.2.0
+func NewTrieFromSources(sources []KVSource, policy MergePolicy, squash bool, opts ...Option) (*Node, error) {
+	return newTrieFromSources(nil, sources, policy, squash, opts)
+}
+
+func newTrieFromSources(c *canceler, sources []KVSource, policy MergePolicy, squash bool, opts []Option) (*Node, error) {
+
+	m := newMergedIter(sources, newOptions(opts).byteOrder)
+	if policy != nil {
+		m.Policy(policy)
+	}
+
+	return buildTrie(c, func(i int) ([]byte, interface{}, bool) {
+		if !m.Next() {
+			return nil, nil, false
+		}
+		return m.Key(), m.Value(), true
+	}, 0, squash, opts)
+}
+
+func newMergedIter(iters []KVSource, order byteOrder) *MergedIter {
+	return &MergedIter{
+		iters:  iters,
+		alive:  make([]bool, len(iters)),
+		policy: FirstWins,
+		order:  order,
+		key:    []byte{},
+	}
+}
+
+// Policy sets the MergePolicy. It must be called before the first Next.
+//
+// Since 0.2.0
+func (m *MergedIter) Policy(p MergePolicy) *MergedIter {
+	m.policy = p
+	return m
+}
+
+// Next advances to the next key in the union.
+// It returns false when there are no more keys.
+//
+// Since 0.2.0
+func (m *MergedIter) Next() bool {
+	for m.next() {
+		if !m.skipRemoved || m.value != removed {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MergedIter) next() bool {
+
+	if !m.started {
+		m.started = true
+		for i, it := range m.iters {
+			m.alive[i] = it.Next()
+		}
+	}
+
+	// There are usually a few tries. A linear scan is faster than a heap.
+	min := -1
+	for i, it := range m.iters {
+		if !m.alive[i] {
+			continue
+		}
+		if min == -1 || m.order.compare(it.Key(), m.iters[min].Key()) < 0 {
+			min = i
+		}
+	}
+
+	if min == -1 {
+		m.key = m.key[:0]
+		m.value = nil
+		return false
+	}
+
+	m.key = append(m.key[:0], m.iters[min].Key()...)
+	m.values = m.values[:0]
+
+	for i, it := range m.iters {
+		if m.alive[i] && bytes.Equal(it.Key(), m.key) {
+			m.values = append(m.values, it.Value())
+			m.alive[i] = it.Next()
+		}
+	}
+
+	if len(m.values) == 1 {
+		m.value = m.values[0]
+	} else {
+		m.value = m.policy(m.key, m.values)
+	}
+	return true
+}
+
+// Key returns the current key.
+// The returned slice must not be modified and is only valid until the next
+// call to Next.
+//
+// Since 0.2.0
+func (m *MergedIter) Key() []byte {
+	return m.key
+}
+
+// Value returns the current value.
+//
+// Since 0.2.0
+func (m *MergedIter) Value() interface{} {
+	return m.value
+}
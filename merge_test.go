@@ -0,0 +1,134 @@
+package trie
+
+import (
+	"testing"
+
+	"github.com/openacid/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeIter(t *testing.T) {
+
+	ta := require.New(t)
+
+	a := newStrTrie(ta, false, "a", "abc", "x")
+	b := newStrTrie(ta, false, "", "abc", "b")
+	c := newStrTrie(ta, false, "abc", "x", "z")
+
+	sumLen := func(key []byte, values []interface{}) interface{} {
+		n := 0
+		for _, v := range values {
+			n += len(v.(string))
+		}
+		return n
+	}
+
+	cases := []struct {
+		tries  []*Node
+		policy MergePolicy
+		keys   []string
+		values []interface{}
+	}{
+		{nil, nil, []string{}, []interface{}{}},
+		{[]*Node{a}, nil,
+			[]string{"a", "abc", "x"},
+			[]interface{}{"a", "abc", "x"}},
+		{[]*Node{a, b, c}, nil,
+			[]string{"", "a", "abc", "b", "x", "z"},
+			[]interface{}{"", "a", "abc", "b", "x", "z"}},
+		{[]*Node{a, b, c}, sumLen,
+			[]string{"", "a", "abc", "b", "x", "z"},
+			[]interface{}{"", "a", 9, "b", 2, "z"}},
+		{[]*Node{a, newStrTrie(ta, false)}, LastWins,
+			[]string{"a", "abc", "x"},
+			[]interface{}{"a", "abc", "x"}},
+	}
+
+	for i, c := range cases {
+		m := MergeIter(c.tries...)
+		if c.policy != nil {
+			m.Policy(c.policy)
+		}
+
+		keys := []string{}
+		values := []interface{}{}
+		for m.Next() {
+			keys = append(keys, string(m.Key()))
+			values = append(values, m.Value())
+		}
+		ta.False(m.Next())
+
+		ta.Equal(c.keys, keys, "%d-th", i+1)
+		ta.Equal(c.values, values, "%d-th", i+1)
+	}
+}
+
+func TestMergeIter_policy(t *testing.T) {
+
+	ta := require.New(t)
+
+	old, err := NewTrie([][]byte{[]byte("k")}, []int{1}, false)
+	ta.Nil(err)
+	newer, err := NewTrie([][]byte{[]byte("k")}, []int{2}, false)
+	ta.Nil(err)
+
+	m := MergeIter(newer, old)
+	ta.True(m.Next())
+	ta.Equal(2, m.Value())
+
+	m = MergeIter(newer, old).Policy(LastWins)
+	ta.True(m.Next())
+	ta.Equal(1, m.Value())
+}
+
+// lineSource is a KVSource of sorted lines, of which values are the line
+// numbers.
+type lineSource struct {
+	lines []string
+	i     int
+}
+
+func (s *lineSource) Next() bool         { s.i++; return s.i <= len(s.lines) }
+func (s *lineSource) Key() []byte        { return []byte(s.lines[s.i-1]) }
+func (s *lineSource) Value() interface{} { return s.i }
+
+func TestNewTrieFromSources(t *testing.T) {
+
+	ta := require.New(t)
+
+	day1 := &lineSource{lines: []string{"a", "abc", "x"}}
+	day2 := &lineSource{lines: []string{"ab", "abc", "y"}}
+	old := newStrTrie(ta, false, "", "x", "z")
+
+	for _, squash := range []bool{false, true} {
+		day1.i, day2.i = 0, 0
+
+		tr, err := NewTrieFromSources([]KVSource{day1, day2, old.NewIter()}, LastWins, squash)
+		ta.Nil(err)
+		ta.Nil(tr.Validate())
+		ta.Equal(7, tr.KeyCnt())
+
+		want := map[string]interface{}{
+			"": "", "a": 1, "ab": 1, "abc": 2, "x": "x", "y": 3, "z": "z",
+		}
+		for k, v := range want {
+			got, found := tr.Get([]byte(k))
+			ta.True(found, "squash=%v: %q", squash, k)
+			ta.Equal(v, got, "squash=%v: %q", squash, k)
+		}
+	}
+
+	// The default policy is FirstWins, and a MergedIter is a source.
+	day1.i, day2.i = 0, 0
+	tr, err := NewTrieFromSources([]KVSource{MergeSources(day2, day1)}, nil, false)
+	ta.Nil(err)
+	v, _ := tr.Get([]byte("abc"))
+	ta.Equal(2, v)
+
+	tr, err = NewTrieFromSources(nil, nil, false)
+	ta.Nil(err)
+	ta.Equal(0, tr.KeyCnt())
+
+	_, err = NewTrieFromSources([]KVSource{&lineSource{lines: []string{"b", "a"}}}, nil, false)
+	ta.Equal(ErrKeyOutOfOrder, errors.Cause(err))
+}
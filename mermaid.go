@@ -0,0 +1,80 @@
+package trie
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ToMermaid writes a Mermaid flowchart of the trie to `w`, which can be
+// embedded in Markdown in a "```mermaid" code block.
+//
+// Inner nodes are circles, with the Step of a squashed node. Leaf nodes are
+// boxes of their values. Edges are labeled with branch labels.
+//
+// `opts` are the same as StringWith: WithMaxDepth and WithMaxChildren limit
+// the nodes in the chart, WithValueString and WithLabelStyle set how values
+// and labels are rendered. Labels are rendered with the style of
+// WithDebugLabelStyle, or LabelPrintable by default. WithKeyCounts is
+// ignored.
+//
+// Since 0.2.0
+func (r *Node) ToMermaid(w io.Writer, opts ...StringOption) error {
+
+	o := newStringOptions(append([]StringOption{WithLabelStyle(r.labelStyle(LabelPrintable))}, opts...))
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "flowchart TD")
+
+	nextID := 0
+	newID := func() string {
+		id := fmt.Sprintf("n%d", nextID)
+		nextID++
+		return id
+	}
+
+	var walk func(n *Node, id string, depth int)
+	walk = func(n *Node, id string, depth int) {
+
+		if len(n.Branches) == 0 {
+			fmt.Fprintf(bw, "    %s[\"%s\"]\n", id, mermaidEscape(o.value(n.Value)))
+			return
+		}
+
+		info := " "
+		if o.step && n.Step > 1 {
+			info = fmt.Sprintf("+%d", n.Step)
+		}
+		fmt.Fprintf(bw, "    %s((\"%s\"))\n", id, info)
+
+		if o.maxDepth > 0 && depth >= o.maxDepth {
+			more := newID()
+			fmt.Fprintf(bw, "    %s[\"...\"]\n", more)
+			fmt.Fprintf(bw, "    %s -.- %s\n", id, more)
+			return
+		}
+
+		for i, b := range n.Branches {
+			if o.maxChildren > 0 && i == o.maxChildren {
+				more := newID()
+				fmt.Fprintf(bw, "    %s[\"...(%d more)\"]\n", more, len(n.Branches)-i)
+				fmt.Fprintf(bw, "    %s -.- %s\n", id, more)
+				break
+			}
+
+			childID := newID()
+			fmt.Fprintf(bw, "    %s -->|\"%s\"| %s\n", id, mermaidEscape(o.labels.Format(b)), childID)
+			walk(n.Children[b], childID, depth+1)
+		}
+	}
+	walk(r, newID(), 0)
+
+	return bw.Flush()
+}
+
+// mermaidEscape escapes characters that end a quoted Mermaid text.
+var mermaidEscape = strings.NewReplacer(
+	`"`, "#quot;",
+	"\n", " ",
+).Replace
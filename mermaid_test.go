@@ -0,0 +1,60 @@
+package trie
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNode_ToMermaid(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr := newStrTrie(ta, true, "abc", "abd", `x"`)
+
+	var buf bytes.Buffer
+	ta.Nil(tr.ToMermaid(&buf))
+	ta.Equal(`
+flowchart TD
+    n0((" "))
+    n0 -->|"a"| n1
+    n1(("+2"))
+    n1 -->|"c"| n2
+    n2((" "))
+    n2 -->|"$"| n3
+    n3["abc"]
+    n1 -->|"d"| n4
+    n4((" "))
+    n4 -->|"$"| n5
+    n5["abd"]
+    n0 -->|"x"| n6
+    n6(("+2"))
+    n6 -->|"$"| n7
+    n7["x#quot;"]
+`[1:], buf.String())
+
+	buf.Reset()
+	ta.Nil(newStrTrie(ta, false, `"`).ToMermaid(&buf))
+	ta.Equal(`
+flowchart TD
+    n0((" "))
+    n0 -->|"#quot;"| n1
+    n1((" "))
+    n1 -->|"$"| n2
+    n2["#quot;"]
+`[1:], buf.String())
+
+	buf.Reset()
+	ta.Nil(tr.ToMermaid(&buf, WithMaxDepth(1), WithMaxChildren(1), WithLabelStyle(LabelHex)))
+	ta.Equal(`
+flowchart TD
+    n0((" "))
+    n0 -->|"\x61"| n1
+    n1(("+2"))
+    n2["..."]
+    n1 -.- n2
+    n3["...(1 more)"]
+    n0 -.- n3
+`[1:], buf.String())
+}
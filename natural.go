@@ -0,0 +1,113 @@
+package trie
+
+// WithNaturalOrder makes keys ordered naturally: a run of decimal digits in a
+// key compares by its numeric value, e.g., "file2" < "file10" < "file010",
+// where numbers of the same value are ordered by the count of their leading
+// zeros. The rest of keys compares byte by byte, and a digit goes before a
+// letter, the same as in a byte order.
+//
+// Keys are encoded with NaturalKey wherever they are normalized, after
+// WithKeyNormalizer, thus the order decides the order NewTrie and Append
+// require keys to be added in, the left and right neighbors Search returns
+// and the order Iter yields keys in, which Iter decodes back.
+// Prefixes are not normalized: a prefix to match the keys of a trie naturally
+// ordered is NaturalKey of a prefix not ending with a digit.
+//
+// Since 0.2.0
+func WithNaturalOrder() Option {
+	return func(o *options) {
+		o.naturalOrder = true
+	}
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+// NaturalKey encodes `key` into a key of which the byte order is the natural
+// order of keys, see WithNaturalOrder.
+//
+// Every run of digits is replaced with the length of the number without
+// leading zeros, the number, and the count of the leading zeros. A length is
+// encoded in digits, as one '9' for every 9 and a last digit of the rest.
+// Thus a longer number, i.e., a greater one, is encoded greater, and numbers
+// of the same length compare digit by digit.
+//
+// Since 0.2.0
+func NaturalKey(key []byte) []byte {
+
+	out := make([]byte, 0, len(key)+4)
+	for i := 0; i < len(key); {
+		if !isDigit(key[i]) {
+			out = append(out, key[i])
+			i++
+			continue
+		}
+
+		zeros := 0
+		for i < len(key) && key[i] == '0' {
+			zeros++
+			i++
+		}
+		start := i
+		for i < len(key) && isDigit(key[i]) {
+			i++
+		}
+
+		out = appendNaturalLen(out, i-start)
+		out = append(out, key[start:i]...)
+		out = appendNaturalLen(out, zeros)
+	}
+	return out
+}
+
+func appendNaturalLen(out []byte, n int) []byte {
+	for ; n >= 9; n -= 9 {
+		out = append(out, '9')
+	}
+	return append(out, '0'+byte(n))
+}
+
+// readNaturalLen reads a length encoded by appendNaturalLen at key[i:], and
+// returns it and the index after it, or -1 if key is malformed.
+func readNaturalLen(key []byte, i int) (n, end int) {
+	for ; i < len(key) && key[i] == '9'; i++ {
+		n += 9
+	}
+	if i == len(key) || !isDigit(key[i]) {
+		return 0, -1
+	}
+	return n + int(key[i]-'0'), i + 1
+}
+
+// appendDecodedNaturalKey appends `key` decoded, which is encoded by
+// NaturalKey, to `out`. A key that is not well encoded, e.g., rebuilt from a
+// squashed trie, is appended as is.
+func appendDecodedNaturalKey(out, key []byte) []byte {
+
+	start := len(out)
+	for i := 0; i < len(key); {
+		if !isDigit(key[i]) {
+			out = append(out, key[i])
+			i++
+			continue
+		}
+
+		l, j := readNaturalLen(key, i)
+		if j < 0 || j+l > len(key) {
+			return append(out[:start], key...)
+		}
+		number := key[j : j+l]
+		zeros, end := readNaturalLen(key, j+l)
+		if end < 0 {
+			return append(out[:start], key...)
+		}
+
+		for ; zeros > 0; zeros-- {
+			out = append(out, '0')
+		}
+		out = append(out, number...)
+		i = end
+	}
+	return out
+}
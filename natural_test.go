@@ -0,0 +1,224 @@
+package trie
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/openacid/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// naturalLess is a reference natural order of keys.
+func naturalLess(a, b string) bool {
+
+	for len(a) > 0 && len(b) > 0 {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			i, j := 0, 0
+			for i < len(a) && isDigit(a[i]) {
+				i++
+			}
+			for j < len(b) && isDigit(b[j]) {
+				j++
+			}
+			na, nb := a[:i], b[:j]
+			va, _ := strconv.ParseUint(na, 10, 64)
+			vb, _ := strconv.ParseUint(nb, 10, 64)
+			if va != vb {
+				return va < vb
+			}
+			if len(na) != len(nb) {
+				// More leading zeros go after.
+				return len(na) < len(nb)
+			}
+			a, b = a[i:], b[j:]
+			continue
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+func TestNaturalKey(t *testing.T) {
+
+	ta := require.New(t)
+
+	cases := []struct {
+		key, want string
+	}{
+		{"", ""},
+		{"abc", "abc"},
+		{"file2", "file120"},
+		{"file10", "file2100"},
+		{"file010", "file2101"},
+		{"0", "01"},
+		{"x000y", "x03y"},
+		{"1234567890", "911234567890" + "0"},
+		{"a1b22", "a110b2220"},
+	}
+
+	for i, c := range cases {
+		got := NaturalKey([]byte(c.key))
+		ta.Equal(c.want, string(got), "%d-th: %q", i+1, c.key)
+		ta.Equal(c.key, string(appendDecodedNaturalKey([]byte("p"), got))[1:], "%d-th: decode", i+1)
+	}
+
+	// Not well encoded keys are kept.
+	for _, k := range []string{"9", "a5x", "12"} {
+		ta.Equal(k, string(appendDecodedNaturalKey(nil, []byte(k))))
+	}
+}
+
+func TestNaturalKey_order(t *testing.T) {
+
+	ta := require.New(t)
+
+	rnd := rand.New(rand.NewSource(7))
+	randKey := func() string {
+		k := make([]byte, rnd.Intn(8))
+		for i := range k {
+			k[i] = "0019ab"[rnd.Intn(6)]
+		}
+		return string(k)
+	}
+
+	for i := 0; i < 5000; i++ {
+		a, b := randKey(), randKey()
+		ea, eb := NaturalKey([]byte(a)), NaturalKey([]byte(b))
+		ta.Equal(naturalLess(a, b), bytes.Compare(ea, eb) < 0, "%q %q", a, b)
+		ta.Equal(a == b, bytes.Equal(ea, eb), "%q %q", a, b)
+	}
+}
+
+func TestWithNaturalOrder(t *testing.T) {
+
+	ta := require.New(t)
+
+	strs := []string{"file1", "file2", "file10", "file010", "file20", "file100", "file100a", "filea", "v1.2", "v1.10"}
+	keys := make([][]byte, len(strs))
+	for i, s := range strs {
+		keys[i] = []byte(s)
+	}
+
+	// Byte order rejects them.
+	_, err := NewTrie(keys, strs, false)
+	ta.Equal(ErrKeyOutOfOrder, errors.Cause(err))
+
+	idx, err := CheckSorted(keys, WithNaturalOrder())
+	ta.Equal(-1, idx)
+	ta.Nil(err)
+
+	for _, squash := range []bool{false, true} {
+		tr, err := NewTrie(keys, strs, squash, WithNaturalOrder(), WithStoreKeys())
+		ta.Nil(err)
+
+		lt, eq, gt := tr.Search([]byte("file10"))
+		ta.Equal("file2", lt)
+		ta.Equal("file10", eq)
+		ta.Equal("file010", gt)
+
+		lt, eq, gt = tr.Search([]byte("file3"))
+		ta.Equal("file2", lt)
+		ta.Nil(eq)
+		ta.Equal("file10", gt)
+
+		got := []string{}
+		for it := tr.NewIter(); it.Next(); {
+			got = append(got, string(it.Key()))
+			ta.Equal(string(it.Key()), it.Value())
+		}
+		ta.Equal(strs, got, "squash=%v", squash)
+
+		got = got[:0]
+		for it := tr.NewIter(WithPrependPrefix([]byte("ns1/"))); it.Next(); {
+			got = append(got, string(it.Key()))
+		}
+		ta.Equal("ns1/file010", got[3])
+	}
+
+	tr, err := NewTrie(nil, nil, false, WithNaturalOrder())
+	ta.Nil(err)
+	for _, i := range rand.New(rand.NewSource(1)).Perm(200) {
+		_, err := tr.Set([]byte(fmt.Sprintf("k%d", i)), i)
+		ta.Nil(err)
+	}
+	want := []int{}
+	for i := 0; i < 200; i++ {
+		want = append(want, i)
+	}
+	got := []int{}
+	keysGot := []string{}
+	for it := tr.NewIter(); it.Next(); {
+		got = append(got, it.Value().(int))
+		keysGot = append(keysGot, string(it.Key()))
+	}
+	ta.Equal(want, got)
+	ta.True(sort.SliceIsSorted(keysGot, func(i, j int) bool { return naturalLess(keysGot[i], keysGot[j]) }))
+	ta.Equal("k199", keysGot[199])
+
+	ta.True(tr.Remove([]byte("k10")))
+	_, found := tr.Get([]byte("k10"))
+	ta.False(found)
+	ta.Equal(1, tr.RemoveBatch([][]byte{[]byte("k11")}))
+}
+
+func TestWithNaturalOrder_normalizedOnce(t *testing.T) {
+
+	ta := require.New(t)
+
+	loaded := []string{}
+	loader := WithLoader(func(key []byte) (interface{}, bool) {
+		loaded = append(loaded, string(key))
+		return string(key), true
+	})
+
+	// A loaded key is added once, and not loaded again.
+	tr, err := NewTrie(nil, nil, false, WithNaturalOrder(), loader)
+	ta.Nil(err)
+	st, err := NewSyncTrie(nil, nil, false, WithNaturalOrder(), loader)
+	ta.Nil(err)
+	for _, get := range []func(key []byte) (interface{}, bool){tr.Get, st.Get} {
+		for i := 0; i < 2; i++ {
+			v, found := get([]byte("f2"))
+			ta.True(found)
+			ta.Equal("f2", v)
+		}
+	}
+	ta.Equal([]string{"f2", "f2"}, loaded)
+	_, _, gt := st.Load().Search([]byte("f10"))
+	ta.Nil(gt)
+
+	// Sweep removes an expired key.
+	tr, err = NewTrie(nil, nil, false, WithNaturalOrder())
+	ta.Nil(err)
+	_, err = tr.SetTTL([]byte("f2"), 1, -time.Second)
+	ta.Nil(err)
+	ta.Equal(1, tr.Sweep(10))
+	ta.Equal(0, tr.KeyCnt())
+
+	// Keys in the array of a SmallTrie are not normalized again.
+	sm, err := NewSmallTrie(2, nil, nil, false, WithNaturalOrder())
+	ta.Nil(err)
+	ta.Nil(sm.Append([]byte("f2"), 2))
+	ta.Nil(sm.Append([]byte("f10"), 10))
+	ta.Nil(sm.Append([]byte("f20"), 20))
+	ta.NotNil(sm.trie)
+	for _, k := range []string{"f2", "f10", "f20"} {
+		v, found := sm.Get([]byte(k))
+		ta.True(found, "key: %s", k)
+		ta.Equal(k, fmt.Sprintf("f%d", v))
+	}
+
+	sh := NewShardedTrie(2, false, WithNaturalOrder())
+	ta.Nil(sh.Append([]byte("f2"), 2))
+	ta.Nil(sh.Append([]byte("f10"), 10))
+	lt, eq, gt := sh.Search([]byte("f10"))
+	ta.Equal([]interface{}{2, 10, nil}, []interface{}{lt, eq, gt})
+}
@@ -0,0 +1,165 @@
+package trie
+
+import (
+	"sort"
+)
+
+// Posting is an occurrence of an n-gram in the sources of an NGramIndex.
+//
+// Since 0.2.0
+type Posting struct {
+	// Source is the index of the source the n-gram is in.
+	//
+	// Since 0.2.0
+	Source int
+
+	// Pos is the position of the n-gram in the source.
+	//
+	// Since 0.2.0
+	Pos int
+}
+
+// NGramIndex is a trie of all n-grams of a set of sources, to find the sources
+// that contain a substring.
+//
+// Every n-gram is bound to its Postings. The last n-1 positions of a source
+// are indexed with the shorter grams ending the source, so that every
+// position is indexed and a query shorter than n is found too.
+//
+// Since 0.2.0
+type NGramIndex struct {
+	// N is the length of the indexed grams.
+	//
+	// Since 0.2.0
+	N int
+
+	root *Node
+}
+
+// NewNGramIndex creates an NGramIndex of the `n`-grams of `sources`.
+// It returns ErrInvalidGramSize if `n` is less than 1.
+//
+// Since 0.2.0
+func NewNGramIndex(n int, sources [][]byte) (*NGramIndex, error) {
+
+	if n < 1 {
+		return nil, ErrInvalidGramSize
+	}
+
+	postings := make(map[string][]Posting)
+	for src, s := range sources {
+		for pos := range s {
+			end := pos + n
+			if end > len(s) {
+				end = len(s)
+			}
+			g := string(s[pos:end])
+			postings[g] = append(postings[g], Posting{Source: src, Pos: pos})
+		}
+	}
+
+	grams := make([]string, 0, len(postings))
+	for g := range postings {
+		grams = append(grams, g)
+	}
+	sort.Strings(grams)
+
+	keys := make([][]byte, len(grams))
+	values := make([][]Posting, len(grams))
+	for i, g := range grams {
+		keys[i] = []byte(g)
+		values[i] = postings[g]
+	}
+
+	root, err := NewTrie(keys, values, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NGramIndex{N: n, root: root}, nil
+}
+
+// Postings returns the occurrences of `gram`, ordered by source and position.
+// A gram shorter than N is only found at the end of a source.
+//
+// Since 0.2.0
+func (x *NGramIndex) Postings(gram []byte) []Posting {
+	v, found := x.root.lookup(gram)
+	if !found {
+		return nil
+	}
+	return v.([]Posting)
+}
+
+// Find returns the occurrences of `query` in the sources, ordered by source
+// and position.
+//
+// A query not shorter than N is matched gram by gram at every offset. A
+// shorter query is matched with the grams it is a prefix of.
+//
+// Since 0.2.0
+func (x *NGramIndex) Find(query []byte) []Posting {
+
+	if len(query) < x.N {
+		sub, key := x.root.subtree(query)
+		if sub == nil {
+			return nil
+		}
+
+		var rst []Posting
+		sub.eachLeaf(key, func(key []byte, leaf *Node) {
+			rst = append(rst, leaf.Value.([]Posting)...)
+		})
+		sort.Slice(rst, func(i, j int) bool {
+			return postingLess(rst[i], rst[j])
+		})
+		return rst
+	}
+
+	rst := x.Postings(query[:x.N])
+
+	for k := 1; k+x.N <= len(query) && len(rst) > 0; k++ {
+		next := x.Postings(query[k : k+x.N])
+
+		// Both are sorted by source and position: keep the occurrences of
+		// the query followed by the gram at offset k.
+		kept := make([]Posting, 0, len(rst))
+		j := 0
+		for _, p := range rst {
+			want := Posting{Source: p.Source, Pos: p.Pos + k}
+			for j < len(next) && postingLess(next[j], want) {
+				j++
+			}
+			if j < len(next) && next[j] == want {
+				kept = append(kept, p)
+			}
+		}
+		rst = kept
+	}
+
+	if len(rst) == 0 {
+		return nil
+	}
+	return rst
+}
+
+// Candidates returns the ascending indexes of the sources containing `query`.
+//
+// Since 0.2.0
+func (x *NGramIndex) Candidates(query []byte) []int {
+
+	var srcs []int
+	for _, p := range x.Find(query) {
+		if len(srcs) == 0 || srcs[len(srcs)-1] != p.Source {
+			srcs = append(srcs, p.Source)
+		}
+	}
+	return srcs
+}
+
+func postingLess(a, b Posting) bool {
+	if a.Source != b.Source {
+		return a.Source < b.Source
+	}
+	return a.Pos < b.Pos
+}
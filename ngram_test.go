@@ -0,0 +1,63 @@
+package trie
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNGramIndex(t *testing.T) {
+
+	ta := require.New(t)
+
+	sources := [][]byte{
+		[]byte("banana"),
+		[]byte("bandana"),
+		[]byte("cab"),
+		[]byte(""),
+		[]byte("an"),
+	}
+
+	x, err := NewNGramIndex(3, sources)
+	ta.Nil(err)
+
+	ta.Equal([]Posting{{0, 1}, {0, 3}, {1, 4}}, x.Postings([]byte("ana")))
+	ta.Equal([]Posting{{2, 1}}, x.Postings([]byte("ab")))
+	ta.Nil(x.Postings([]byte("xyz")))
+
+	cases := []struct {
+		query string
+		want  []int
+	}{
+		{"banana", []int{0}},
+		{"ban", []int{0, 1}},
+		{"nan", []int{0}},
+		{"dana", []int{1}},
+		{"an", []int{0, 1, 4}},
+		{"b", []int{0, 1, 2}},
+		{"ab", []int{2}},
+		{"bananas", nil},
+		{"anana", []int{0}},
+		{"nab", nil},
+	}
+
+	for i, c := range cases {
+		ta.Equal(c.want, x.Candidates([]byte(c.query)), "%d-th: query: %q", i+1, c.query)
+
+		// Compare with a brute force search.
+		var want []int
+		for src, s := range sources {
+			if bytes.Contains(s, []byte(c.query)) {
+				want = append(want, src)
+			}
+		}
+		ta.Equal(want, x.Candidates([]byte(c.query)), "%d-th: brute force: query: %q", i+1, c.query)
+	}
+
+	ta.Equal([]Posting{{0, 1}}, x.Find([]byte("anan")))
+	ta.Equal([]Posting{{0, 1}, {0, 3}, {0, 5}, {1, 1}, {1, 4}, {1, 6}, {2, 1}, {4, 0}}, x.Find([]byte("a")))
+
+	_, err = NewNGramIndex(0, sources)
+	ta.Equal(ErrInvalidGramSize, err)
+}
@@ -0,0 +1,275 @@
+package trie
+
+import "time"
+
+// Option configures optional behaviors of a trie created by NewTrie.
+//
+// Since 0.2.0
+type Option func(*options)
+
+// options are stored in the root node of a trie.
+type options struct {
+	buildHook       func(BuildEvent)
+	buildHookEvery  int
+	expectedKeyCnt  int
+	maxMemory       int
+	maxKeyLen       int
+	maxTrieDepth    int
+	keyValidator    func(key []byte) error
+	duplicateReport bool
+	lruSize         func(key []byte, value interface{}) int
+
+	lazyRemove bool
+
+	multiValue func(acc, v interface{}) interface{}
+
+	hashConsing bool
+
+	valueStore ValueStore
+
+	lazyValues bool
+	lazyCache  bool
+
+	normalizer func(key []byte) []byte
+	// naturalOrder encodes keys with NaturalKey after normalizer.
+	naturalOrder bool
+	// keysNormalized is set while building a trie of keys already
+	// normalized, see withNormalizedKeys.
+	keysNormalized bool
+
+	byteOrder byteOrder
+
+	// labelStyle is set by WithDebugLabelStyle.
+	labelStyle    LabelStyle
+	hasLabelStyle bool
+
+	weight func(v interface{}) float64
+
+	aggregate *Monoid
+
+	timeEncoding func(t time.Time) []byte
+
+	historyLen int
+	// history is set by WithHistory.
+	history bool
+
+	// sweepFrom is the key Sweep continues from.
+	sweepFrom []byte
+
+	subscribers subscribers
+
+	hooks []MutationHooks
+
+	loader func(key []byte) (interface{}, bool)
+
+	persister *persistQueue
+
+	// lastVersion is the greatest version given to a leaf.
+	lastVersion uint64
+
+	// readOnly is set once nodes are shared by hash consing.
+	readOnly bool
+
+	front *frontIndex
+
+	cachedExtremes bool
+
+	storeKeys bool
+	// keyInterner is set by WithKeyInterner.
+	keyInterner *Interner
+
+	autoSquash *autoSquash
+
+	// setLeaf is the leaf shared by all keys of a Set.
+	setLeaf *Node
+}
+
+// BuildPhase is a step of building a trie.
+//
+// Since 0.2.0
+type BuildPhase int
+
+const (
+	// PhaseAppend is when NewTrie appends keys.
+	// With squash enabled, it includes squashing the preceding branches of
+	// every key.
+	//
+	// Since 0.2.0
+	PhaseAppend BuildPhase = iota
+
+	// PhaseSquash is a full Squash() of the trie.
+	//
+	// Since 0.2.0
+	PhaseSquash
+)
+
+// String implements fmt.Stringer.
+//
+// Since 0.2.0
+func (p BuildPhase) String() string {
+	switch p {
+	case PhaseAppend:
+		return "append"
+	case PhaseSquash:
+		return "squash"
+	}
+	return "unknown"
+}
+
+// BuildEvent reports the progress of a build phase.
+//
+// Since 0.2.0
+type BuildEvent struct {
+
+	// Phase is the phase being reported.
+	//
+	// Since 0.2.0
+	Phase BuildPhase
+
+	// KeyCnt is the number of keys appended so far.
+	//
+	// Since 0.2.0
+	KeyCnt int
+
+	// InnerNodeCnt is the number of inner nodes in the trie.
+	//
+	// Since 0.2.0
+	InnerNodeCnt int
+
+	// SquashedCnt is the number of nodes removed by a full Squash.
+	//
+	// Since 0.2.0
+	SquashedCnt int
+
+	// Processed is the number of items processed in this phase so far: keys
+	// appended in PhaseAppend, the same as KeyCnt, and inner nodes visited in
+	// PhaseSquash.
+	//
+	// Since 0.2.0
+	Processed int
+
+	// Total is the number of items this phase processes, or 0 if it is
+	// unknown: the number of keys of NewTrie or of WithExpectedKeyCnt in
+	// PhaseAppend, and the number of inner nodes in PhaseSquash.
+	//
+	// Since 0.2.0
+	Total int
+
+	// ETA is the estimated time to finish this phase, assuming the remaining
+	// items take as long as the processed ones. It is 0 if Total is unknown.
+	//
+	// Since 0.2.0
+	ETA time.Duration
+
+	// Elapsed is the time spent in this phase so far.
+	//
+	// Since 0.2.0
+	Elapsed time.Duration
+
+	// Done indicates the phase has finished.
+	//
+	// Since 0.2.0
+	Done bool
+}
+
+// WithBuildHook sets a callback to report the build progress.
+//
+// `fn` is called every `every` keys appended by NewTrie, and once at the end
+// of each phase. A non-positive `every` only reports the end of phases.
+// `fn` is also called every `every` inner nodes visited and at the end of
+// every Squash() on the root node.
+//
+// Since 0.2.0
+func WithBuildHook(every int, fn func(BuildEvent)) Option {
+	return func(o *options) {
+		o.buildHook = fn
+		o.buildHookEvery = every
+	}
+}
+
+// WithExpectedKeyCnt sets the number of keys NewTrieFromFunc or
+// NewTrieFromSources is expected to build from, which they can not know in
+// advance. It is reported as BuildEvent.Total to estimate ETA. NewTrie knows
+// it from its keys and ignores this option.
+//
+// Since 0.2.0
+func WithExpectedKeyCnt(n int) Option {
+	return func(o *options) {
+		o.expectedKeyCnt = n
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithMultiValue makes a key able to have more than one value.
+// Appending an existing key accumulates the value instead of returning
+// ErrDuplicateKeys, and Search returns the accumulated values.
+// NewTrie then accepts duplicate keys, which must be adjacent.
+//
+// The value of a key is `appender(nil, v)` when it is added, and becomes
+// `appender(acc, v)` each time the key is appended again.
+// With a nil `appender`, values are accumulated into a []interface{}.
+//
+// Since 0.2.0
+func WithMultiValue(appender func(acc, v interface{}) interface{}) Option {
+	if appender == nil {
+		appender = appendToSlice
+	}
+	return func(o *options) {
+		o.multiValue = appender
+	}
+}
+
+func appendToSlice(acc, v interface{}) interface{} {
+	s, _ := acc.([]interface{})
+	return append(s, v)
+}
+
+// WithKeyNormalizer makes every key normalized with `fn` before it is added
+// by NewTrie, Append and Set, and before it is looked up by Search,
+// SearchSorted and Remove, so that the two never disagree. E.g., a
+// normalizer of bytes.ToLower makes a trie case insensitive.
+//
+// Keys passed to NewTrie must be ascending after normalization.
+// `fn` must be idempotent, i.e., fn(fn(k)) equals fn(k), and must not modify
+// its argument. Prefixes, e.g., of Fold, WithStripPrefix, Latest,
+// ReplaceSubTrie or Subscribe, are normalized the same way, so that a prefix
+// matches the keys it matched before normalization.
+//
+// Since 0.2.0
+func WithKeyNormalizer(fn func(key []byte) []byte) Option {
+	return func(o *options) {
+		o.normalizer = fn
+	}
+}
+
+// normalize returns `key` normalized with the normalizer of the trie.
+func (r *Node) normalize(key []byte) []byte {
+	if r.opt == nil {
+		return key
+	}
+	return r.opt.normalize(key)
+}
+
+// normalizes returns true if keys are changed by normalize.
+func (o *options) normalizes() bool {
+	return o.normalizer != nil || o.naturalOrder
+}
+
+// normalize returns `key` normalized with WithKeyNormalizer, then encoded
+// for WithNaturalOrder.
+func (o *options) normalize(key []byte) []byte {
+	if o.normalizer != nil {
+		key = o.normalizer(key)
+	}
+	if o.naturalOrder {
+		key = NaturalKey(key)
+	}
+	return key
+}
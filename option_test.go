@@ -0,0 +1,327 @@
+package trie
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/openacid/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithBuildHook(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := [][]byte{
+		{1, 2, 3},
+		{1, 2, 4},
+		{2, 3, 4},
+		{2, 3, 5},
+		{3, 4, 5},
+	}
+	values := []int{0, 1, 2, 3, 4}
+
+	var events []BuildEvent
+	hook := WithBuildHook(2, func(e BuildEvent) {
+		events = append(events, e)
+	})
+
+	_, err := NewTrie(keys, values, false, hook)
+	ta.Nil(err)
+
+	type ev struct {
+		phase BuildPhase
+		keys  int
+		inner int
+		done  bool
+	}
+	got := []ev{}
+	for _, e := range events {
+		got = append(got, ev{e.Phase, e.KeyCnt, e.InnerNodeCnt, e.Done})
+	}
+	ta.Equal([]ev{
+		{PhaseAppend, 2, 5, false},
+		{PhaseAppend, 4, 9, false},
+		{PhaseAppend, 5, 12, true},
+	}, got)
+
+	events = nil
+	tr, err := NewTrie(keys, values, true, hook)
+	ta.Nil(err)
+
+	// 3 append events, 5 of visiting 10 inner nodes by squash, and the end
+	// of squash.
+	ta.Equal(9, len(events))
+	for i, e := range events[3:8] {
+		ta.Equal(PhaseSquash, e.Phase)
+		ta.False(e.Done)
+		ta.Equal(2*(i+1), e.Processed)
+		ta.Equal(10, e.Total)
+	}
+
+	last := events[8]
+	ta.Equal(PhaseSquash, last.Phase)
+	ta.True(last.Done)
+	ta.Equal(2, last.SquashedCnt)
+	ta.Equal(8, last.InnerNodeCnt)
+	ta.Equal(10, last.Processed)
+	ta.Equal(time.Duration(0), last.ETA)
+
+	// Squash on the root reports too.
+	events = nil
+	tr.Squash()
+	ta.Equal(5, len(events))
+	last = events[4]
+	ta.Equal(PhaseSquash, last.Phase)
+	ta.True(last.Done)
+	ta.Equal(0, last.SquashedCnt)
+	ta.Equal(8, last.Total)
+}
+
+func TestWithBuildHook_progress(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := make([][]byte, 100)
+	for i := range keys {
+		keys[i] = []byte(fmt.Sprintf("%03d", i))
+	}
+
+	var events []BuildEvent
+	hook := WithBuildHook(10, func(e BuildEvent) {
+		if e.Phase == PhaseAppend {
+			events = append(events, e)
+		}
+	})
+
+	_, err := NewIndexTrie(keys, false, hook)
+	ta.Nil(err)
+	ta.Equal(11, len(events))
+	for _, e := range events {
+		ta.Equal(100, e.Total)
+		ta.Equal(e.KeyCnt, e.Processed)
+		if e.Done {
+			ta.Equal(time.Duration(0), e.ETA)
+		}
+	}
+	e := events[4]
+	ta.Equal(50, e.Processed)
+	ta.InDelta(float64(e.Elapsed), float64(e.ETA), 1, "half is done")
+
+	// A streaming build knows the total only from WithExpectedKeyCnt.
+	next := func(i int) ([]byte, interface{}, bool) {
+		if i == len(keys) {
+			return nil, nil, false
+		}
+		return keys[i], i, true
+	}
+	for _, expected := range []int{0, 100} {
+		events = nil
+		_, err = NewTrieFromFunc(next, false, hook, WithExpectedKeyCnt(expected))
+		ta.Nil(err)
+		ta.Equal(11, len(events))
+		for _, e := range events {
+			ta.Equal(expected, e.Total)
+			if expected == 0 {
+				ta.Equal(time.Duration(0), e.ETA)
+			}
+		}
+	}
+}
+
+func TestEstimateETA(t *testing.T) {
+
+	ta := require.New(t)
+
+	cases := []struct {
+		elapsed          time.Duration
+		processed, total int
+		want             time.Duration
+	}{
+		{time.Second, 0, 10, 0},
+		{time.Second, 1, 0, 0},
+		{time.Second, 10, 10, 0},
+		{time.Second, 11, 10, 0},
+		{time.Second, 1, 10, 9 * time.Second},
+		{time.Second, 4, 10, 1500 * time.Millisecond},
+	}
+
+	for i, c := range cases {
+		ta.Equal(c.want, estimateETA(c.elapsed, c.processed, c.total), "%d-th: %+v", i+1, c)
+	}
+}
+
+func TestWithBuildHook_everyDisabled(t *testing.T) {
+
+	ta := require.New(t)
+
+	cnt := 0
+	_, err := NewTrie([][]byte{{1}, {2}, {3}}, []int{1, 2, 3}, false,
+		WithBuildHook(0, func(e BuildEvent) {
+			cnt++
+			ta.True(e.Done)
+			ta.Equal(3, e.KeyCnt)
+		}))
+	ta.Nil(err)
+	ta.Equal(1, cnt)
+}
+
+func TestWithMultiValue(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := [][]byte{[]byte("a"), []byte("a"), []byte("ab"), []byte("b"), []byte("b"), []byte("b")}
+	values := []int{1, 2, 3, 4, 5, 6}
+
+	for _, squash := range []bool{false, true} {
+		tr, err := NewTrie(keys, values, squash, WithMultiValue(nil))
+		ta.Nil(err)
+
+		_, eq, _ := tr.Search([]byte("a"))
+		ta.Equal([]interface{}{1, 2}, eq)
+		_, eq, _ = tr.Search([]byte("ab"))
+		ta.Equal([]interface{}{3}, eq)
+		_, eq, _ = tr.Search([]byte("b"))
+		ta.Equal([]interface{}{4, 5, 6}, eq)
+
+		if !squash {
+			// An existent key does not need to be the last one.
+			_, err = tr.Append([]byte("a"), 7)
+			ta.Nil(err)
+			_, eq, _ = tr.Search([]byte("a"))
+			ta.Equal([]interface{}{1, 2, 7}, eq)
+		}
+	}
+
+	sum := func(acc, v interface{}) interface{} {
+		n, _ := acc.(int)
+		return n + v.(int)
+	}
+
+	tr, err := NewTrie(keys, values, false, WithMultiValue(sum))
+	ta.Nil(err)
+	ks, vs := iterAll(tr.NewIter())
+	ta.Equal([]string{"a", "ab", "b"}, ks)
+	ta.Equal([]interface{}{3, 3, 15}, vs)
+
+	_, err = NewTrie(keys, values, false)
+	ta.Equal(ErrDuplicateKeys, errors.Cause(err))
+}
+
+func TestWithKeyNormalizer(t *testing.T) {
+
+	ta := require.New(t)
+
+	lower := WithKeyNormalizer(bytes.ToLower)
+
+	for _, squash := range []bool{false, true} {
+
+		tr, err := NewTrie([][]byte{[]byte("ab"), []byte("Cd")}, []int{1, 2}, squash, lower)
+		ta.Nil(err)
+
+		_, err = tr.Append([]byte("EF"), 3)
+		ta.Nil(err)
+
+		_, err = tr.Append([]byte("ef"), 4)
+		ta.Equal(ErrDuplicateKeys, errors.Cause(err), "squash: %v", squash)
+
+		cases := []struct {
+			key        string
+			lt, eq, gt interface{}
+		}{
+			{"AB", nil, 1, 2},
+			{"cD", 1, 2, 3},
+			{"Ef", 2, 3, nil},
+		}
+
+		for i, c := range cases {
+			lt, eq, gt := tr.Search([]byte(c.key))
+			ta.Equal(c.lt, lt, "%d-th: squash: %v, lt", i+1, squash)
+			ta.Equal(c.eq, eq, "%d-th: squash: %v, eq", i+1, squash)
+			ta.Equal(c.gt, gt, "%d-th: squash: %v, gt", i+1, squash)
+		}
+
+		rst := tr.SearchSorted([][]byte{[]byte("AB"), []byte("EF")})
+		ta.Equal(1, rst[0].Eq)
+		ta.Equal(3, rst[1].Eq)
+	}
+
+	tr, err := NewTrie(nil, nil, false, lower)
+	ta.Nil(err)
+
+	_, err = tr.Set([]byte("XY"), 1)
+	ta.Nil(err)
+	_, err = tr.Set([]byte("ab"), 2)
+	ta.Nil(err)
+	_, err = tr.Set([]byte("Xy"), 3)
+	ta.Nil(err)
+
+	_, eq, _ := tr.Search([]byte("xy"))
+	ta.Equal(3, eq)
+
+	ta.True(tr.Remove([]byte("AB")))
+	_, eq, _ = tr.Search([]byte("ab"))
+	ta.Nil(eq)
+
+	trim := WithKeyNormalizer(func(key []byte) []byte {
+		return bytes.TrimSpace(key)
+	})
+
+	st, err := NewSyncTrie(nil, nil, false, trim)
+	ta.Nil(err)
+	ta.Nil(st.Append([]byte(" a "), 1))
+	_, eq, _ = st.Search([]byte("a"))
+	ta.Equal(1, eq)
+
+	sh := NewShardedTrie(2, false, trim)
+	ta.Nil(sh.Append([]byte("  b"), 2))
+	_, eq, _ = sh.Search([]byte("b "))
+	ta.Equal(2, eq)
+}
+
+func TestWithKeyNormalizer_prefixes(t *testing.T) {
+
+	ta := require.New(t)
+
+	lower := WithKeyNormalizer(bytes.ToLower)
+
+	tr, err := NewTrie([][]byte{[]byte("ab1"), []byte("ab2"), []byte("c")}, []int{1, 2, 3}, false, lower)
+	ta.Nil(err)
+
+	keys := func(it *Iter) []string {
+		rst := []string{}
+		for it.Next() {
+			rst = append(rst, string(it.Key()))
+		}
+		return rst
+	}
+
+	ta.Equal([]string{"1", "2"}, keys(tr.NewIter(WithStripPrefix([]byte("AB")))))
+
+	events, cancel := tr.Subscribe([]byte("AB"))
+	_, err = tr.Set([]byte("Ab3"), 4)
+	ta.Nil(err)
+	cancel()
+	ta.Equal(Event{EventAdded, []byte("ab3"), 4}, <-events)
+
+	sub, err := NewTrie([][]byte{[]byte("x")}, []int{5}, false)
+	ta.Nil(err)
+	ta.Nil(tr.ReplaceSubTrie([]byte("AB"), sub))
+	ta.Equal([]string{"abx", "c"}, keys(tr.NewIter()))
+
+	// A SyncTrie normalizes a prefix once.
+	natural := []Option{lower, WithNaturalOrder()}
+	st, err := NewSyncTrie([][]byte{[]byte("a1b"), []byte("a2")}, []int{1, 2}, false, natural...)
+	ta.Nil(err)
+	sub, err = NewTrie([][]byte{[]byte("z")}, []int{6}, false)
+	ta.Nil(err)
+	ta.Nil(st.ReplaceSubTrie([]byte("A1"), sub))
+	_, eq, _ := st.Search([]byte("a1b"))
+	ta.Nil(eq)
+	v, found := st.Get([]byte("a2"))
+	ta.True(found)
+	ta.Equal(2, v)
+	ta.Equal(2, st.Load().KeyCnt())
+}
@@ -0,0 +1,244 @@
+package trie
+
+// Overlay is a view of a small delta trie stacked over a base trie, that
+// answers Get, Search and iteration as if the two were merged. It allows
+// trying out changes without modifying or copying the base.
+//
+// Changes made with Set and Remove go to the delta, which is never squashed.
+// A removed key is recorded in the delta as a tombstone, which hides the key
+// from the base.
+//
+// The base can be squashed, or removed lazily. Keys from a squashed base are
+// compared in the form Iter rebuilds them, thus a squashed base should only be
+// used if all changes are made to keys found by iterating it.
+//
+// An Overlay is not safe for concurrent use.
+//
+// Since 0.2.0
+type Overlay struct {
+	// Base is the trie under the overlay. It is never modified by Overlay.
+	//
+	// Since 0.2.0
+	Base *Node
+
+	// Delta holds the changes. Removed keys are in it with a tombstone value.
+	//
+	// Since 0.2.0
+	Delta *Node
+}
+
+// NewOverlay creates an Overlay with an empty delta over `base`.
+//
+// Since 0.2.0
+func NewOverlay(base *Node) *Overlay {
+	delta, _ := NewTrie(nil, nil, false)
+	delta.opt.byteOrder = base.byteOrder()
+	return &Overlay{Base: base, Delta: delta}
+}
+
+// Set sets the value of `key` in the view.
+//
+// Since 0.2.0
+func (o *Overlay) Set(key []byte, value interface{}) {
+	// The delta is never squashed.
+	_, _ = o.Delta.Set(key, value)
+}
+
+// Remove removes `key` from the view.
+//
+// Since 0.2.0
+func (o *Overlay) Remove(key []byte) {
+	_, _ = o.Delta.Set(key, removed)
+}
+
+// Get returns the value of `key` and whether it is in the view.
+// Same as Search, a squashed base may match a key not in it to another key.
+//
+// Since 0.2.0
+func (o *Overlay) Get(key []byte) (interface{}, bool) {
+
+	v, found := o.Delta.lookup(key)
+	if !found {
+		v, found = o.Base.lookup(key)
+	}
+
+	if !found || v == removed {
+		return nil, false
+	}
+	return v, true
+}
+
+// Search is the same as Node.Search on the merged view.
+//
+// Since 0.2.0
+func (o *Overlay) Search(key []byte) (ltValue, eqValue, gtValue interface{}) {
+
+	eqValue, _ = o.Get(key)
+
+	if lt := o.neighbor(key, true); lt != nil {
+		ltValue = lt.value
+	}
+	if gt := o.neighbor(key, false); gt != nil {
+		gtValue = gt.value
+	}
+	return
+}
+
+// NewIter creates an iterator over the merged view, positioned before the
+// first key.
+//
+// Since 0.2.0
+func (o *Overlay) NewIter() *MergedIter {
+
+	delta := o.Delta.NewIter()
+	delta.keepRemoved = true
+
+	m := newMergedIter([]KVSource{delta, o.Base.NewIter()}, o.Base.byteOrder())
+	m.skipRemoved = true
+	return m
+}
+
+// neighbor returns the closest entry in the view that is less than `key`, or
+// greater if `less` is false.
+func (o *Overlay) neighbor(key []byte, less bool) *kv {
+
+	d := o.Delta.neighbor(key, less)
+
+	// Skip the entries of the base hidden by a tombstone.
+	k := key
+	var b *kv
+	for {
+		b = o.Base.neighbor(k, less)
+		if b == nil {
+			break
+		}
+		if v, found := o.Delta.lookup(b.key); !found || v != removed {
+			break
+		}
+		k = b.key
+	}
+
+	switch {
+	case d == nil:
+		return b
+	case b == nil:
+		return d
+	}
+
+	c := o.Base.byteOrder().compare(d.key, b.key)
+	if c == 0 || (c > 0) == less {
+		return d
+	}
+	return b
+}
+
+// kv is a key and its value.
+type kv struct {
+	key   []byte
+	value interface{}
+}
+
+// neighbor returns the greatest leaf less than `key`, or the least leaf
+// greater than `key` if `less` is false. Removed leaves are skipped.
+// Keys are rebuilt the same way as Iter does.
+func (r *Node) neighbor(key []byte, less bool) *kv {
+
+	type level struct {
+		node      *Node
+		prefixLen int
+		li, ri    int
+	}
+
+	var levels []level
+	prefix := []byte{}
+
+	// gtSub is a subtree in which all keys are greater than `key`, found if
+	// `key` ends in a squashed node.
+	var gtSub *Node
+
+	node := r
+	for i := -1; ; {
+		i += int(node.Step)
+
+		if len(key) < i {
+			gtSub = node
+			break
+		}
+
+		br := leafBranch
+		if len(key) > i {
+			br = int(key[i])
+		}
+
+		li, ri := r.byteOrder().neighborBranches(node.Branches, br)
+		levels = append(levels, level{node, len(prefix), li, ri})
+
+		child := node.Children[br]
+		if child == nil || br == leafBranch {
+			break
+		}
+		prefix = append(prefix, byte(br))
+		node = child
+	}
+
+	if !less && gtSub != nil {
+		if e := gtSub.edge(prefix, true); e != nil {
+			return e
+		}
+	}
+
+	for i := len(levels) - 1; i >= 0; i-- {
+		l := levels[i]
+		brs := l.node.Branches
+		p := prefix[:l.prefixLen]
+
+		if less {
+			for j := l.li; j >= 0; j-- {
+				if e := l.node.Children[brs[j]].edge(withLabel(p, brs[j]), false); e != nil {
+					return e
+				}
+			}
+		} else {
+			for j := l.ri; j >= 0 && j < len(brs); j++ {
+				if e := l.node.Children[brs[j]].edge(withLabel(p, brs[j]), true); e != nil {
+					return e
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// edge returns the first leaf not removed in the subtree of r, or the last one
+// if `first` is false. `prefix` is the key rebuilt up to r.
+func (r *Node) edge(prefix []byte, first bool) *kv {
+
+	if len(r.Branches) == 0 {
+		if r.Value == removed {
+			return nil
+		}
+		return &kv{key: append([]byte{}, prefix...), value: r.Value}
+	}
+
+	n := len(r.Branches)
+	for j := 0; j < n; j++ {
+		b := r.Branches[j]
+		if !first {
+			b = r.Branches[n-1-j]
+		}
+		if e := r.Children[b].edge(withLabel(prefix, b), first); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// withLabel returns a new slice of `prefix` followed by the label of branch
+// `br`.
+func withLabel(prefix []byte, br int) []byte {
+	if br == leafBranch {
+		return prefix
+	}
+	return append(prefix[:len(prefix):len(prefix)], byte(br))
+}
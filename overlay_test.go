@@ -0,0 +1,121 @@
+package trie
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverlay(t *testing.T) {
+
+	ta := require.New(t)
+
+	base := newStrTrie(ta, false, "a", "abc", "abd", "b", "bcd")
+
+	o := NewOverlay(base)
+	o.Set([]byte("abc"), "ABC")
+	o.Set([]byte("ab"), "ab")
+	o.Set([]byte("z"), "z")
+	o.Remove([]byte("abd"))
+	o.Remove([]byte("b"))
+	o.Remove([]byte("not-found"))
+
+	keys := []string{}
+	values := []interface{}{}
+	it := o.NewIter()
+	for it.Next() {
+		keys = append(keys, string(it.Key()))
+		values = append(values, it.Value())
+	}
+	ta.Equal([]string{"a", "ab", "abc", "bcd", "z"}, keys)
+	ta.Equal([]interface{}{"a", "ab", "ABC", "bcd", "z"}, values)
+
+	cases := []struct {
+		key        string
+		lt, eq, gt interface{}
+	}{
+		{"", nil, nil, "a"},
+		{"a", nil, "a", "ab"},
+		{"abc", "ab", "ABC", "bcd"},
+		{"abd", "ABC", nil, "bcd"},
+		{"b", "ABC", nil, "bcd"},
+		{"bc", "ABC", nil, "bcd"},
+		{"c", "bcd", nil, "z"},
+		{"zz", "z", nil, nil},
+	}
+
+	for i, c := range cases {
+		lt, eq, gt := o.Search([]byte(c.key))
+		ta.Equal([]interface{}{c.lt, c.eq, c.gt}, []interface{}{lt, eq, gt}, "%d-th: key: %q", i+1, c.key)
+
+		v, found := o.Get([]byte(c.key))
+		ta.Equal(c.eq, v, "%d-th: key: %q", i+1, c.key)
+		ta.Equal(c.eq != nil, found, "%d-th: key: %q", i+1, c.key)
+	}
+
+	// The base is never modified.
+	ks, _ := iterAll(base.NewIter())
+	ta.Equal([]string{"a", "abc", "abd", "b", "bcd"}, ks)
+}
+
+func TestOverlay_random(t *testing.T) {
+
+	ta := require.New(t)
+
+	rnd := rand.New(rand.NewSource(11))
+	randKey := func() string {
+		return fmt.Sprintf("%x", rnd.Intn(64))
+	}
+
+	for round := 0; round < 20; round++ {
+
+		want := map[string]string{}
+		for i := 0; i < 20; i++ {
+			k := randKey()
+			want[k] = k
+		}
+		baseKeys := make([]string, 0, len(want))
+		for k := range want {
+			baseKeys = append(baseKeys, k)
+		}
+		sort.Strings(baseKeys)
+
+		o := NewOverlay(newStrTrie(ta, false, baseKeys...))
+		for i := 0; i < 20; i++ {
+			k := randKey()
+			if rnd.Intn(2) == 0 {
+				o.Set([]byte(k), k+"'")
+				want[k] = k + "'"
+			} else {
+				o.Remove([]byte(k))
+				delete(want, k)
+			}
+		}
+
+		merged := make([]string, 0, len(want))
+		for k := range want {
+			merged = append(merged, k)
+		}
+		sort.Strings(merged)
+
+		// The merged trie has values of the keys from the overlay.
+		bs := make([][]byte, len(merged))
+		vs := make([]string, len(merged))
+		for i, k := range merged {
+			bs[i] = []byte(k)
+			vs[i] = want[k]
+		}
+		m, err := NewTrie(bs, vs, false)
+		ta.Nil(err)
+
+		for i := 0; i < 80; i++ {
+			k := []byte(randKey())
+			lt, eq, gt := o.Search(k)
+			wlt, weq, wgt := m.Search(k)
+			ta.Equal([]interface{}{wlt, weq, wgt}, []interface{}{lt, eq, gt}, "key: %q", k)
+		}
+	}
+}
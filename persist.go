@@ -0,0 +1,170 @@
+package trie
+
+import "sync"
+
+// Persister receives the changes of a trie created WithPersister, e.g., to
+// mirror it into a key-value store or to append them to a file.
+// Applying the events to an empty trie with Replay rebuilds the trie.
+//
+// Since 0.2.0
+type Persister interface {
+	// Persist stores `events` in the order of them. An Event of EventRemoved
+	// carries the removed value.
+	//
+	// Since 0.2.0
+	Persist(events []Event) error
+}
+
+// PersisterFunc adapts a function to a Persister.
+//
+// Since 0.2.0
+type PersisterFunc func(events []Event) error
+
+// Persist implements Persister.
+//
+// Since 0.2.0
+func (f PersisterFunc) Persist(events []Event) error {
+	return f(events)
+}
+
+// WithPersister makes every change by Append, Set, Remove and the methods
+// built on them, including the Appends of NewTrie, be persisted by `p`.
+//
+// With a `batch` not greater than 1, `p` is called synchronously on each
+// change. Otherwise changes are collected until there are `batch` of them,
+// then persisted by another goroutine, in order, without blocking the writer.
+// Flush persists the collected changes and waits for all of them.
+//
+// An error returned by `p` does not stop the trie from being modified: the
+// first one is kept and returned by Flush.
+//
+// Since 0.2.0
+func WithPersister(p Persister, batch int) Option {
+	return func(o *options) {
+		o.persister = &persistQueue{p: p, batch: batch}
+	}
+}
+
+// persistQueue collects the changes of a trie for a Persister.
+type persistQueue struct {
+	p     Persister
+	batch int
+
+	mu      sync.Mutex
+	pending []Event
+	// last is closed once the last batch handed off is persisted.
+	last chan struct{}
+	err  error
+
+	// replaying is set by Replay to not persist what is being replayed.
+	replaying bool
+}
+
+// add persists `e`, or queues it if persisting in batches.
+func (q *persistQueue) add(e Event) {
+
+	if q.replaying {
+		return
+	}
+
+	if q.batch <= 1 {
+		q.setErr(q.p.Persist([]Event{e}))
+		return
+	}
+
+	q.pending = append(q.pending, e)
+	if len(q.pending) >= q.batch {
+		q.handOff()
+	}
+}
+
+// handOff persists the pending events in a new goroutine, after the batch
+// handed off before it.
+func (q *persistQueue) handOff() chan struct{} {
+
+	events := q.pending
+	q.pending = nil
+
+	prev := q.last
+	done := make(chan struct{})
+	q.last = done
+
+	go func() {
+		defer close(done)
+		if prev != nil {
+			<-prev
+		}
+		q.setErr(q.p.Persist(events))
+	}()
+
+	return done
+}
+
+func (q *persistQueue) setErr(err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.err == nil {
+		q.err = err
+	}
+}
+
+// Flush persists the changes not yet persisted and waits for them.
+// It returns the first error of the Persister since the last Flush.
+// It must be called by the goroutine writing the trie.
+// It does nothing on a trie without a Persister.
+//
+// Since 0.2.0
+func (r *Node) Flush() error {
+
+	if r.opt == nil || r.opt.persister == nil {
+		return nil
+	}
+
+	q := r.opt.persister
+
+	done := q.last
+	if len(q.pending) > 0 {
+		done = q.handOff()
+	}
+	if done != nil {
+		<-done
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	err := q.err
+	q.err = nil
+	return err
+}
+
+// Replay applies `events` in order: an EventAdded or EventUpdated sets the
+// value of the key and an EventRemoved removes the key.
+// Events being replayed are not persisted again by the Persister of r.
+//
+// Keys of events are those in the trie, i.e., normalized ones, thus they are
+// not normalized again, and r must be created with the same
+// WithKeyNormalizer and WithNaturalOrder as the trie of the events.
+//
+// It stops at the first event failing to apply and returns the error.
+//
+// Since 0.2.0
+func (r *Node) Replay(events []Event) error {
+
+	if r.opt != nil && r.opt.persister != nil {
+		r.opt.persister.replaying = true
+		defer func() { r.opt.persister.replaying = false }()
+	}
+
+	for _, e := range events {
+		switch e.Type {
+		case EventAdded, EventUpdated:
+			_, err := r.setKey(e.Key, e.Value)
+			if err != nil {
+				return err
+			}
+		case EventRemoved:
+			r.removeKeys([][]byte{e.Key})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package trie
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithPersister(t *testing.T) {
+
+	ta := require.New(t)
+
+	for _, batch := range []int{0, 1, 3, 100} {
+
+		var events []Event
+		p := PersisterFunc(func(es []Event) error {
+			events = append(events, es...)
+			return nil
+		})
+
+		tr, err := NewTrie(
+			[][]byte{[]byte("a"), []byte("b")}, []int{1, 2}, false,
+			WithPersister(p, batch),
+		)
+		ta.Nil(err)
+
+		_, err = tr.Set([]byte("b"), 3)
+		ta.Nil(err)
+		_, err = tr.Set([]byte("c"), 4)
+		ta.Nil(err)
+		ta.True(tr.Remove([]byte("a")))
+
+		ta.Nil(tr.Flush())
+
+		ta.Equal([]Event{
+			{EventAdded, []byte("a"), 1},
+			{EventAdded, []byte("b"), 2},
+			{EventUpdated, []byte("b"), 3},
+			{EventAdded, []byte("c"), 4},
+			{EventRemoved, []byte("a"), 1},
+		}, events, "batch: %d", batch)
+
+		// Replay rebuilds the trie, without persisting the events again.
+		n := len(events)
+		replayed, err := NewTrie(nil, nil, false, WithPersister(p, batch))
+		ta.Nil(err)
+		ta.Nil(replayed.Replay(events))
+		ta.Nil(replayed.Flush())
+		ta.Equal(n, len(events))
+
+		ks, vs := iterAll(replayed.NewIter())
+		ta.Equal([]string{"b", "c"}, ks)
+		ta.Equal([]interface{}{3, 4}, vs)
+	}
+}
+
+func TestWithPersister_error(t *testing.T) {
+
+	ta := require.New(t)
+
+	errA := errors.New("a")
+	errB := errors.New("b")
+	calls := 0
+	p := PersisterFunc(func(es []Event) error {
+		calls++
+		switch calls {
+		case 1:
+			return errA
+		case 2:
+			return errB
+		}
+		return nil
+	})
+
+	tr, err := NewTrie(nil, nil, false, WithPersister(p, 2))
+	ta.Nil(err)
+
+	for _, k := range []string{"a", "b", "c"} {
+		_, err = tr.Set([]byte(k), k)
+		ta.Nil(err)
+	}
+
+	// The first error is returned, and only once.
+	ta.Equal(errA, tr.Flush())
+	ta.Equal(2, calls)
+	ta.Nil(tr.Flush())
+
+	// The trie is modified regardless.
+	_, eq, _ := tr.Search([]byte("c"))
+	ta.Equal("c", eq)
+
+	ta.Nil((&Node{}).Flush())
+}
+
+func TestNode_Replay_naturalOrder(t *testing.T) {
+
+	ta := require.New(t)
+
+	var events []Event
+	p := PersisterFunc(func(es []Event) error {
+		events = append(events, es...)
+		return nil
+	})
+
+	tr, err := NewTrie([][]byte{[]byte("file2"), []byte("file10")}, []int{2, 10}, false, WithNaturalOrder(), WithPersister(p, 1))
+	ta.Nil(err)
+	_, err = tr.Set([]byte("file3"), 3)
+	ta.Nil(err)
+	ta.True(tr.Remove([]byte("file2")))
+
+	replayed, err := NewTrie(nil, nil, false, WithNaturalOrder())
+	ta.Nil(err)
+	ta.Nil(replayed.Replay(events))
+
+	ks, vs := iterAll(replayed.NewIter())
+	ta.Equal([]string{"file3", "file10"}, ks)
+	ta.Equal([]interface{}{3, 10}, vs)
+
+	v, found := replayed.Get([]byte("file10"))
+	ta.True(found)
+	ta.Equal(10, v)
+	_, found = replayed.Get([]byte("file2"))
+	ta.False(found)
+}
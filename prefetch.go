@@ -0,0 +1,179 @@
+package trie
+
+import "sync"
+
+// PrefetchIter iterates over keys and resolved values of a trie, the same
+// as an Iter followed by Resolve, while a goroutine resolves the values of
+// up to `ahead` keys after the current one. For values on disk or remote, in
+// a ValueStore or of *Lazy, a sequential scan does not wait for every value
+// to be read one after another. FixedReader.NewPrefetchIter does the same for
+// the fixed layout in a memory-mapped file.
+//
+// The trie is read by the goroutine, thus it must not be modified during an
+// iteration, unless the iteration is of a snapshot, see WithSnapshot.
+// A PrefetchIter not iterated to the end must be closed to stop the
+// goroutine. It is not safe for concurrent use.
+//
+// Since 0.2.0
+type PrefetchIter struct {
+	ch   chan prefetched
+	done chan struct{}
+	once sync.Once
+
+	cur prefetched
+	err error
+}
+
+// prefetched is a key and its resolved value.
+type prefetched struct {
+	key   []byte
+	value interface{}
+	err   error
+}
+
+// NewPrefetchIter creates a PrefetchIter positioned before the first key,
+// which resolves values of at most `ahead` keys in advance, or of one if
+// `ahead` is not positive. `opts` are the same as those of NewIter.
+//
+// Since 0.2.0
+func (r *Node) NewPrefetchIter(ahead int, opts ...IterOption) *PrefetchIter {
+
+	p := newPrefetchIter(ahead)
+
+	// The Iter is created before returning, so that a snapshot is taken
+	// before the caller goes on.
+	it := r.NewIter(opts...)
+	go p.fetch(r, it)
+	return p
+}
+
+func newPrefetchIter(ahead int) *PrefetchIter {
+
+	if ahead < 1 {
+		ahead = 1
+	}
+
+	return &PrefetchIter{
+		ch:   make(chan prefetched, ahead),
+		done: make(chan struct{}),
+	}
+}
+
+func (p *PrefetchIter) fetch(r *Node, it *Iter) {
+
+	defer close(p.ch)
+
+	for it.Next() {
+		v, err := r.Resolve(it.Value())
+		if !p.send(prefetched{key: copyBytes(it.Key()), value: v, err: err}) || err != nil {
+			return
+		}
+	}
+}
+
+// send sends `e` to the consumer. It returns false if the PrefetchIter is
+// closed.
+func (p *PrefetchIter) send(e prefetched) bool {
+	select {
+	case p.ch <- e:
+		return true
+	case <-p.done:
+		return false
+	}
+}
+
+// NewPrefetchIter creates a PrefetchIter over the keys of f in ascending
+// order, with values that are slices of the data of f, the same as those of
+// Search. A goroutine walks up to `ahead` keys in advance, or one if `ahead`
+// is not positive, and reads every page of their nodes and values; when the
+// data is a memory-mapped file, a sequential scan finds them already read
+// from disk.
+//
+// Keys are rebuilt from branch labels, the same as Iter does. A malformed
+// node found stops the iteration with a *FixedLayoutError, see Err. The same
+// as for Search, data from an untrusted source should pass Verify first.
+//
+// Since 0.2.0
+func (f *FixedReader) NewPrefetchIter(ahead int) *PrefetchIter {
+
+	p := newPrefetchIter(ahead)
+	go p.fetchFixed(f)
+	return p
+}
+
+func (p *PrefetchIter) fetchFixed(f *FixedReader) {
+
+	defer close(p.ch)
+
+	err := f.walk(f.root, []byte{}, func(key, value []byte) bool {
+		return p.send(prefetched{key: key, value: value})
+	})
+	if err != nil {
+		p.send(prefetched{err: err})
+	}
+}
+
+// Next advances to the next key. It returns false when there are no more
+// keys, when resolving a value fails, see Err, or after Close.
+//
+// Since 0.2.0
+func (p *PrefetchIter) Next() bool {
+
+	if p.err != nil {
+		return false
+	}
+
+	select {
+	case <-p.done:
+		p.cur = prefetched{}
+		return false
+	default:
+	}
+
+	e, ok := <-p.ch
+	if !ok {
+		p.cur = prefetched{}
+		return false
+	}
+	if e.err != nil {
+		p.err = e.err
+		p.cur = prefetched{}
+		return false
+	}
+
+	p.cur = e
+	return true
+}
+
+// Key returns the current key. Unlike Iter.Key, the returned slice is not
+// reused, and is valid after Next.
+//
+// Since 0.2.0
+func (p *PrefetchIter) Key() []byte {
+	return p.cur.key
+}
+
+// Value returns the resolved value of the current key.
+//
+// Since 0.2.0
+func (p *PrefetchIter) Value() interface{} {
+	return p.cur.value
+}
+
+// Err returns the error of resolving a value that stopped the iteration, or
+// nil.
+//
+// Since 0.2.0
+func (p *PrefetchIter) Err() error {
+	return p.err
+}
+
+// Close stops the iteration and the goroutine resolving values. A value being
+// resolved is not waited for. It is safe to call Close more than once.
+//
+// Since 0.2.0
+func (p *PrefetchIter) Close() {
+	p.once.Do(func() {
+		close(p.done)
+	})
+}
@@ -0,0 +1,244 @@
+package trie
+
+import (
+	"bytes"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openacid/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// countingStore is a ValueStore counting Get, which fails on a handle of
+// `fail`.
+type countingStore struct {
+	MemValueStore
+	gets int32
+	fail interface{}
+}
+
+func (s *countingStore) Get(handle interface{}) (interface{}, error) {
+	atomic.AddInt32(&s.gets, 1)
+	if s.fail != nil && handle == s.fail {
+		return nil, errors.Wrapf(ErrValueNotFound, "handle %v", handle)
+	}
+	return s.MemValueStore.Get(handle)
+}
+
+func prefetchTestTrie(ta *require.Assertions, n int, store ValueStore) *Node {
+	keys := make([][]byte, n)
+	values := make([]string, n)
+	for i := range keys {
+		keys[i] = []byte(fmt.Sprintf("%04d", i))
+		values[i] = fmt.Sprintf("v%d", i)
+	}
+	tr, err := NewTrie(keys, values, false, WithValueStore(store))
+	ta.Nil(err)
+	return tr
+}
+
+func TestNode_NewPrefetchIter(t *testing.T) {
+
+	ta := require.New(t)
+
+	store := &countingStore{}
+	tr := prefetchTestTrie(ta, 100, store)
+
+	for _, ahead := range []int{0, 1, 7, 200} {
+		it := tr.NewPrefetchIter(ahead)
+		n := 0
+		var prev []byte
+		for it.Next() {
+			ta.Equal(fmt.Sprintf("%04d", n), string(it.Key()))
+			ta.Equal(fmt.Sprintf("v%d", n), it.Value())
+			if prev != nil {
+				ta.Equal(fmt.Sprintf("%04d", n-1), string(prev), "keys are not reused")
+			}
+			prev = it.Key()
+			n++
+		}
+		ta.Equal(100, n)
+		ta.Nil(it.Err())
+		ta.False(it.Next())
+		it.Close()
+	}
+
+	// Iter options apply.
+	it := tr.NewPrefetchIter(4, WithStripPrefix([]byte("009")))
+	got := []string{}
+	for it.Next() {
+		got = append(got, string(it.Key()))
+	}
+	ta.Equal([]string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"}, got)
+}
+
+func TestNode_NewPrefetchIter_readAhead(t *testing.T) {
+
+	ta := require.New(t)
+
+	store := &countingStore{}
+	tr := prefetchTestTrie(ta, 100, store)
+	atomic.StoreInt32(&store.gets, 0)
+
+	it := tr.NewPrefetchIter(5)
+	defer it.Close()
+	ta.True(it.Next())
+
+	// The goroutine fills the buffer of 5 and blocks with one more resolved.
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(&store.gets) < 7 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	ta.Equal(int32(7), atomic.LoadInt32(&store.gets))
+}
+
+func TestNode_NewPrefetchIter_error(t *testing.T) {
+
+	ta := require.New(t)
+
+	store := &countingStore{fail: 10}
+	tr := prefetchTestTrie(ta, 100, store)
+
+	it := tr.NewPrefetchIter(3)
+	n := 0
+	for it.Next() {
+		n++
+	}
+	ta.Equal(10, n)
+	ta.Equal(ErrValueNotFound, errors.Cause(it.Err()))
+	ta.Nil(it.Key())
+	ta.False(it.Next())
+}
+
+func TestPrefetchIter_Close(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr := prefetchTestTrie(ta, 100, &MemValueStore{})
+
+	it := tr.NewPrefetchIter(2)
+	ta.True(it.Next())
+	it.Close()
+	it.Close()
+	ta.False(it.Next())
+	ta.Nil(it.Err())
+
+	// The goroutine exits: the channel is closed.
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-it.ch:
+			if !ok {
+				return
+			}
+		case <-deadline:
+			ta.Fail("goroutine not stopped")
+			return
+		}
+	}
+}
+
+func TestPrefetchIter_KVSource(t *testing.T) {
+
+	ta := require.New(t)
+
+	a := prefetchTestTrie(ta, 10, &MemValueStore{})
+	b := prefetchTestTrie(ta, 20, &MemValueStore{})
+
+	tr, err := NewTrieFromSources([]KVSource{a.NewPrefetchIter(4), b.NewPrefetchIter(4)}, nil, false)
+	ta.Nil(err)
+	ta.Equal(20, tr.KeyCnt())
+	_, eq, _ := tr.Search([]byte("0015"))
+	ta.Equal("v15", eq)
+}
+
+func TestFixedReader_NewPrefetchIter(t *testing.T) {
+
+	ta := require.New(t)
+
+	for _, squash := range []bool{false, true} {
+
+		keys := [][]byte{}
+		values := []string{}
+		for _, k := range []string{"", "a", "ab", "abcd", "abce", "b", "bcdefg", "c\xff"} {
+			keys = append(keys, []byte(k))
+			values = append(values, "v"+k)
+		}
+		tr, err := NewTrie(keys, values, squash)
+		ta.Nil(err)
+
+		var buf bytes.Buffer
+		ta.Nil(tr.ExportFixed(&buf, strEnc))
+		fr, err := NewFixedReader(buf.Bytes())
+		ta.Nil(err)
+
+		want := []string{}
+		for it := tr.NewIter(); it.Next(); {
+			want = append(want, fmt.Sprintf("%q=%s", it.Key(), it.Value()))
+		}
+
+		for _, ahead := range []int{0, 1, 3, 100} {
+			it := fr.NewPrefetchIter(ahead)
+			got := []string{}
+			for it.Next() {
+				got = append(got, fmt.Sprintf("%q=%s", it.Key(), it.Value()))
+			}
+			ta.Nil(it.Err())
+			ta.Equal(want, got, "squash=%v ahead=%d", squash, ahead)
+			it.Close()
+		}
+	}
+
+	// An empty trie.
+	tr, err := NewTrie(nil, nil, false)
+	ta.Nil(err)
+	var buf bytes.Buffer
+	ta.Nil(tr.ExportFixed(&buf, strEnc))
+	fr, err := NewFixedReader(buf.Bytes())
+	ta.Nil(err)
+	it := fr.NewPrefetchIter(2)
+	ta.False(it.Next())
+	ta.Nil(it.Err())
+}
+
+func TestFixedReader_NewPrefetchIter_corrupted(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr, err := NewTrie([][]byte{[]byte("a"), []byte("b")}, []string{"A", "B"}, false)
+	ta.Nil(err)
+	var buf bytes.Buffer
+	ta.Nil(tr.ExportFixed(&buf, strEnc))
+	good := buf.Bytes()
+
+	patch := func(off int, b ...byte) []byte {
+		d := append([]byte{}, good...)
+		copy(d[off:], b)
+		return d
+	}
+
+	// The root at 16 has branches "a" to 34 and "b" to 42. Values are at 50
+	// and 55.
+	for i, c := range []struct {
+		data []byte
+		keys int
+	}{
+		{good[:len(good)-1], 1},
+		{patch(42, 0), 1},
+		{patch(30, 16), 1},
+	} {
+		fr, err := NewFixedReader(c.data)
+		ta.Nil(err)
+
+		it := fr.NewPrefetchIter(1)
+		n := 0
+		for it.Next() {
+			n++
+		}
+		ta.Equal(c.keys, n, "%d-th", i+1)
+		ta.Equal(ErrInvalidFixedLayout, errors.Cause(it.Err()), "%d-th", i+1)
+	}
+}
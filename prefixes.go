@@ -0,0 +1,284 @@
+package trie
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"unsafe"
+)
+
+// PrefixCount is a key prefix and the number of keys starting with it.
+//
+// Since 0.2.0
+type PrefixCount struct {
+	// Prefix is rebuilt the same way as Iter rebuilds keys.
+	Prefix []byte
+
+	// KeyCnt is the number of keys starting with Prefix.
+	KeyCnt int
+}
+
+// TopPrefixes returns at most `n` prefixes of at least `minDepth` bytes that
+// cover the most keys, in descending order of key count, then ascending order
+// of prefix.
+//
+// A prefix longer than `minDepth` covering the same keys as its parent
+// prefix is not returned, since the parent describes the same set of keys.
+// A non-positive `n` returns no prefix.
+//
+// Since 0.2.0
+func (r *Node) TopPrefixes(n int, minDepth int) []PrefixCount {
+
+	rst := []PrefixCount{}
+	if n <= 0 {
+		return rst
+	}
+
+	var walk func(node *Node, prefix []byte)
+	walk = func(node *Node, prefix []byte) {
+
+		rst = append(rst, PrefixCount{Prefix: prefix, KeyCnt: node.KeyCnt()})
+
+		for _, b := range node.Branches {
+			child := node.Children[b]
+			// A subtree without keys has no prefix to return.
+			if b == leafBranch || child.KeyCnt() == 0 {
+				continue
+			}
+			walk(child, withLabel(prefix, b))
+		}
+	}
+	walk(r, []byte{})
+
+	// Drop prefixes too short, or covering the same keys as their parent.
+	// rst is in depth first order, a parent is the last shorter prefix before
+	// a node.
+	filtered := rst[:0]
+	var stack []PrefixCount
+	for _, pc := range rst {
+		for len(stack) > 0 && !bytes.HasPrefix(pc.Prefix, stack[len(stack)-1].Prefix) {
+			stack = stack[:len(stack)-1]
+		}
+
+		redundant := len(stack) > 0 &&
+			len(stack[len(stack)-1].Prefix) >= minDepth &&
+			stack[len(stack)-1].KeyCnt == pc.KeyCnt
+
+		if len(pc.Prefix) >= minDepth && pc.KeyCnt > 0 && !redundant {
+			filtered = append(filtered, pc)
+		}
+		stack = append(stack, pc)
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return filtered[i].KeyCnt > filtered[j].KeyCnt
+	})
+
+	if len(filtered) > n {
+		filtered = filtered[:n]
+	}
+	return filtered
+}
+
+// WithWeight sets the weight of a key to `fn(value)`, which HeavyPrefixes
+// aggregates. Without it every key weighs 1.
+//
+// Since 0.2.0
+func WithWeight(fn func(v interface{}) float64) Option {
+	return func(o *options) {
+		o.weight = fn
+	}
+}
+
+// PrefixWeight is a key prefix and the total weight of keys starting with it.
+//
+// Since 0.2.0
+type PrefixWeight struct {
+	// Prefix is rebuilt the same way as Iter rebuilds keys.
+	Prefix []byte
+
+	// Weight is the sum of the weights of keys starting with Prefix.
+	Weight float64
+}
+
+// HeavyPrefixes returns the prefixes whose total weight of keys is greater
+// than `threshold` times the total weight of all keys, in ascending order of
+// prefix. Weights of keys are set with WithWeight.
+//
+// Of the prefixes covering the same keys, only the longest one is returned,
+// e.g., with keys "/api/a" and "/api/b", it is "/api/" and not "/" or "/a".
+//
+// Since 0.2.0
+func (r *Node) HeavyPrefixes(threshold float64) []PrefixWeight {
+
+	weight := func(v interface{}) float64 { return 1 }
+	if r.opt != nil && r.opt.weight != nil {
+		weight = r.opt.weight
+	}
+
+	rst := []PrefixWeight{}
+
+	var walk func(node *Node, prefix []byte) float64
+	walk = func(node *Node, prefix []byte) float64 {
+
+		// A node with a single inner branch covers the same keys as its
+		// child, thus is not the longest prefix.
+		last := len(node.Branches) != 1 || node.Branches[0] == leafBranch
+
+		idx := len(rst)
+		if last {
+			rst = append(rst, PrefixWeight{Prefix: prefix})
+		}
+
+		w := 0.0
+		for _, b := range node.Branches {
+			child := node.Children[b]
+			if b == leafBranch {
+				if child.Value != removed {
+					w += weight(child.Value)
+				}
+				continue
+			}
+			w += walk(child, withLabel(prefix, b))
+		}
+
+		if last {
+			rst[idx].Weight = w
+		}
+		return w
+	}
+	total := walk(r, []byte{})
+
+	filtered := rst[:0]
+	for _, pw := range rst {
+		if pw.Weight > threshold*total {
+			filtered = append(filtered, pw)
+		}
+	}
+	return filtered
+}
+
+// PrefixSize is a key prefix with the number of keys and the bytes of nodes
+// under it.
+//
+// Since 0.2.0
+type PrefixSize struct {
+	// Prefix is rebuilt the same way as Iter rebuilds keys.
+	Prefix []byte
+
+	// KeyCnt is the number of keys starting with Prefix.
+	KeyCnt int
+
+	// Size is the estimated bytes of the nodes of the keys starting with
+	// Prefix, including the node of Prefix itself. Values are not counted.
+	Size int
+}
+
+// PrefixSizes returns a PrefixSize for every inner node with a prefix of at
+// most `maxDepth` bytes, in ascending order of prefix. A non-positive
+// `maxDepth` means no limit.
+//
+// KeyCnt and Size of a prefix include those of longer prefixes starting with
+// it, thus the result can be drawn as a treemap.
+//
+// Since 0.2.0
+func (r *Node) PrefixSizes(maxDepth int) []PrefixSize {
+
+	rst := []PrefixSize{}
+
+	var walk func(node *Node, prefix []byte) (int, int)
+	walk = func(node *Node, prefix []byte) (int, int) {
+
+		report := maxDepth <= 0 || len(prefix) <= maxDepth
+		idx := len(rst)
+		if report {
+			rst = append(rst, PrefixSize{Prefix: prefix})
+		}
+
+		cnt, size := 0, node.size()
+		for _, b := range node.Branches {
+			child := node.Children[b]
+			if b == leafBranch {
+				if child.Value != removed {
+					cnt++
+				}
+				size += child.size()
+				continue
+			}
+			c, s := walk(child, withLabel(prefix, b))
+			cnt += c
+			size += s
+		}
+
+		if report {
+			rst[idx].KeyCnt = cnt
+			rst[idx].Size = size
+		}
+		return cnt, size
+	}
+	walk(r, []byte{})
+
+	return rst
+}
+
+// WriteFolded writes the sizes of the trie in the folded stack format of
+// flame graph tools: a line for every prefix from PrefixSizes(maxDepth), of
+// branch labels separated by ";", a space and the bytes of the prefix not
+// counted by its longer prefixes. The root is the frame "$".
+//
+// Labels are rendered with LabelPrintable.
+//
+// Since 0.2.0
+func (r *Node) WriteFolded(w io.Writer, maxDepth int) error {
+
+	sizes := r.PrefixSizes(maxDepth)
+
+	// Subtract the sizes of the children from their parent to get the self
+	// size. A parent is the nearest shorter prefix before a prefix.
+	self := make([]int, len(sizes))
+	var stack []int
+	for i, ps := range sizes {
+		for len(stack) > 0 && !bytes.HasPrefix(ps.Prefix, sizes[stack[len(stack)-1]].Prefix) {
+			stack = stack[:len(stack)-1]
+		}
+		self[i] = ps.Size
+		if len(stack) > 0 {
+			self[stack[len(stack)-1]] -= ps.Size
+		}
+		stack = append(stack, i)
+	}
+
+	bw := bufio.NewWriter(w)
+	for i, ps := range sizes {
+		bw.WriteString("$")
+		for _, b := range ps.Prefix {
+			bw.WriteString(";")
+			bw.WriteString(LabelPrintable.Format(int(b)))
+		}
+		fmt.Fprintf(bw, " %d\n", self[i])
+	}
+	return bw.Flush()
+}
+
+// nodeSize is the size of a Node without its Branches and Children.
+var nodeSize = int(unsafe.Sizeof(Node{}))
+
+// leafExtSize and innerExtSize are the sizes of the optional fields of a
+// Node, see leafExt and innerExt.
+var (
+	leafExtSize  = int(unsafe.Sizeof(leafExt{}))
+	innerExtSize = int(unsafe.Sizeof(innerExt{}))
+)
+
+// mapHeaderSize is the size of the header of a Go map.
+const mapHeaderSize = 48
+
+// size estimates the bytes used by r, its Branches and Children, not
+// including the child nodes and the value.
+func (r *Node) size() int {
+	var m MemoryBreakdown
+	m.addStructure(r)
+	return m.Nodes + m.Children + m.Branches
+}
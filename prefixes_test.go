@@ -0,0 +1,136 @@
+package trie
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNode_TopPrefixes(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr := newStrTrie(ta, false,
+		"a", "abc", "abd", "abe",
+		"b", "bcd", "bce",
+		"xyz",
+	)
+
+	type pc = PrefixCount
+	p := func(s string, n int) PrefixCount { return PrefixCount{[]byte(s), n} }
+
+	cases := []struct {
+		n, minDepth int
+		want        []pc
+	}{
+		{-1, 0, []pc{}},
+		{0, 0, []pc{}},
+		{1, 0, []pc{p("", 8)}},
+		{3, 1, []pc{p("a", 4), p("ab", 3), p("b", 3)}},
+		{10, 2, []pc{p("ab", 3), p("bc", 2), p("abc", 1), p("abd", 1), p("abe", 1), p("bcd", 1), p("bce", 1), p("xy", 1)}},
+		{10, 4, []pc{}},
+	}
+
+	for i, c := range cases {
+		got := tr.TopPrefixes(c.n, c.minDepth)
+		ta.Equal(c.want, got, "%d-th: n=%d minDepth=%d", i+1, c.n, c.minDepth)
+	}
+
+	tr.Remove([]byte("abc"))
+	ta.Equal([]pc{p("a", 3), p("b", 3)}, tr.TopPrefixes(2, 1))
+
+	tr, err := NewTrie(
+		[][]byte{[]byte("a"), []byte("bcd"), []byte("bce")}, []string{"a", "bcd", "bce"}, false,
+		WithLazyRemove())
+	ta.Nil(err)
+	ta.True(tr.Remove([]byte("bcd")))
+	ta.True(tr.Remove([]byte("bce")))
+	ta.Equal([]pc{p("a", 1)}, tr.TopPrefixes(10, 1))
+}
+
+func TestNode_PrefixSizes(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr := newStrTrie(ta, false, "a", "ab", "ac", "b")
+
+	leaf := (&Node{}).size()
+	sizes := tr.PrefixSizes(0)
+
+	prefixes := []string{}
+	cnts := []int{}
+	for _, ps := range sizes {
+		prefixes = append(prefixes, string(ps.Prefix))
+		cnts = append(cnts, ps.KeyCnt)
+	}
+	ta.Equal([]string{"", "a", "ab", "ac", "b"}, prefixes)
+	ta.Equal([]int{4, 3, 1, 1, 1}, cnts)
+
+	// A parent is larger than the sum of its children.
+	ta.True(sizes[1].Size > sizes[2].Size+sizes[3].Size)
+	ta.True(sizes[0].Size > sizes[1].Size+sizes[4].Size)
+	ta.True(sizes[2].Size > leaf)
+
+	total := sizes[0].Size
+
+	limited := tr.PrefixSizes(1)
+	ta.Equal(3, len(limited))
+	ta.Equal(total, limited[0].Size)
+	ta.Equal(sizes[1], limited[1])
+
+	var buf bytes.Buffer
+	ta.Nil(tr.WriteFolded(&buf, 1))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	ta.Equal(3, len(lines))
+
+	sum := 0
+	for i, want := range []string{"$ ", "$;a ", "$;b "} {
+		ta.True(strings.HasPrefix(lines[i], want), "line: %q", lines[i])
+		n, err := strconv.Atoi(strings.TrimPrefix(lines[i], want))
+		ta.Nil(err)
+		sum += n
+	}
+	ta.Equal(total, sum, "self sizes add up to the total")
+}
+
+func TestNode_HeavyPrefixes(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := [][]byte{
+		[]byte("/api/a"),
+		[]byte("/api/b"),
+		[]byte("/img/x"),
+		[]byte("/img/y"),
+	}
+	values := []float64{5, 3, 1, 1}
+
+	p := func(s string, w float64) PrefixWeight { return PrefixWeight{[]byte(s), w} }
+
+	tr, err := NewTrie(keys, values, false, WithWeight(func(v interface{}) float64 {
+		return v.(float64)
+	}))
+	ta.Nil(err)
+
+	cases := []struct {
+		threshold float64
+		want      []PrefixWeight
+	}{
+		{1, []PrefixWeight{}},
+		{0.5, []PrefixWeight{p("/", 10), p("/api/", 8)}},
+		{0.25, []PrefixWeight{p("/", 10), p("/api/", 8), p("/api/a", 5), p("/api/b", 3)}},
+	}
+
+	for i, c := range cases {
+		ta.Equal(c.want, tr.HeavyPrefixes(c.threshold), "%d-th: threshold=%v", i+1, c.threshold)
+	}
+
+	// Without WithWeight every key weighs 1.
+	tr, err = NewTrie(keys, values, false)
+	ta.Nil(err)
+	ta.Equal([]PrefixWeight{p("/", 4), p("/api/", 2), p("/img/", 2)}, tr.HeavyPrefixes(0.4))
+}
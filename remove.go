@@ -0,0 +1,245 @@
+package trie
+
+import (
+	"bytes"
+	"sort"
+)
+
+// Remove removes `key` from the trie.
+// It returns true if a key is removed.
+//
+// Same as Search, a squashed trie may match a key not in it to another key,
+// which would be removed.
+//
+// Since 0.2.0
+func (r *Node) Remove(key []byte) bool {
+	return r.RemoveBatch([][]byte{key}) == 1
+}
+
+// RemoveBatch removes `keys` from the trie, and returns the number of keys
+// removed. `keys` do not need to be sorted.
+//
+// Nodes left without any key are pruned as soon as they become empty.
+// If the trie squashes, nodes left with a single branch are squashed once
+// after all keys are removed, except those on the right most path, which
+// are kept to allow Append.
+//
+// Same as Search, a squashed trie may match a key not in it to another key,
+// which would be removed.
+//
+// With WithLazyRemove, leaves are only marked as removed, see WithLazyRemove.
+// With WithHashConsing, nothing is removed and it returns 0.
+//
+// Since 0.2.0
+func (r *Node) RemoveBatch(keys [][]byte) int {
+
+	if r.opt != nil && r.opt.normalizes() {
+		normalized := make([][]byte, len(keys))
+		for i, k := range keys {
+			normalized[i] = r.normalize(k)
+		}
+		keys = normalized
+	}
+
+	return r.removeKeys(keys)
+}
+
+// removeKeys is RemoveBatch of normalized `keys`.
+func (r *Node) removeKeys(keys [][]byte) int {
+
+	if r.readOnly() {
+		return 0
+	}
+
+	if r.lazyRemove() {
+		return r.removeLazily(keys)
+	}
+
+	// touched records nodes that lost a branch but are not empty, with their
+	// depth.
+	touched := make(map[*Node]int)
+	removedCnt := r.removeBatch(keys, touched)
+
+	if r.squash {
+		r.resquash(touched)
+	}
+
+	return removedCnt
+}
+
+// removeBatch removes normalized `keys` without squashing, and adds to
+// `touched` the nodes to resquash.
+func (r *Node) removeBatch(keys [][]byte, touched map[*Node]int) int {
+
+	sorted := make([][]byte, len(keys))
+	copy(sorted, keys)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i], sorted[j]) < 0
+	})
+
+	var path []*Node
+	var brs []int
+	removedCnt := 0
+
+	for i, key := range sorted {
+		if i > 0 && bytes.Equal(key, sorted[i-1]) {
+			continue
+		}
+
+		path, brs = r.findLeaf(key, path[:0], brs[:0])
+		if path == nil {
+			continue
+		}
+		removedCnt++
+
+		leaf := path[len(path)-1].Children[leafBranch]
+		r.forgetLeaf(leaf)
+		r.changed(key, leaf.Value, removed)
+
+		r.addCounts(-1, 0)
+
+		cnt := leaf.KeyCnt()
+		for _, n := range path {
+			n.keyCnt -= cnt
+		}
+
+		for d := len(path) - 1; d >= 0; d-- {
+			n := path[d]
+			delete(n.Children, brs[d])
+			n.Branches = removeBranch(n.Branches, brs[d])
+			n.shrunk = true
+
+			if len(n.Branches) > 0 || d == 0 {
+				touched[n] = d
+				break
+			}
+			r.InnerNodeCnt--
+			if n.Step > 1 {
+				r.addCounts(0, -1)
+			}
+		}
+		r.updateFront(key)
+		r.reaggregatePath(path)
+	}
+
+	return removedCnt
+}
+
+// findLeaf appends to `path` the nodes from r to the one holding the leaf of
+// `key`, and to `brs` the branches taken at them.
+// It returns nil if `key` is not found.
+func (r *Node) findLeaf(key []byte, path []*Node, brs []int) ([]*Node, []int) {
+
+	node := r
+	for i := -1; ; {
+		i += int(node.Step)
+		if len(key) < i {
+			return nil, nil
+		}
+
+		br := leafBranch
+		if len(key) > i {
+			br = int(key[i])
+		}
+
+		child := node.Children[br]
+		if child == nil {
+			return nil, nil
+		}
+
+		path = append(path, node)
+		brs = append(brs, br)
+
+		if br == leafBranch {
+			if !child.isKey(key) {
+				return nil, nil
+			}
+			return path, brs
+		}
+		node = child
+	}
+}
+
+// leafOf returns the leaf of `key`, or nil if `key` is not found. It is
+// findLeaf without recording the path, and does not allocate.
+func (r *Node) leafOf(key []byte) *Node {
+
+	var leaf *Node
+	if start, i := r.frontNode(key); start != nil {
+		leaf = leafFrom(start, i, key)
+	} else {
+		leaf = leafFrom(r, -1, key)
+	}
+
+	if leaf != nil && !leaf.isKey(key) {
+		return nil
+	}
+	return leaf
+}
+
+// leafFrom is leafOf starting from `node`, before which `i`+1 bytes of `key`
+// are consumed.
+func leafFrom(node *Node, i int, key []byte) *Node {
+
+	for {
+		i += int(node.Step)
+		if len(key) < i {
+			return nil
+		}
+
+		br := leafBranch
+		if len(key) > i {
+			br = int(key[i])
+		}
+
+		child := node.Children[br]
+		if child == nil || br == leafBranch {
+			return child
+		}
+		node = child
+	}
+}
+
+// resquash squashes `touched` nodes that have only one branch and are not on
+// the right most path. Deeper nodes are squashed first.
+func (r *Node) resquash(touched map[*Node]int) {
+
+	nodes := make([]*Node, 0, len(touched))
+	for n := range touched {
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return touched[nodes[i]] > touched[nodes[j]]
+	})
+
+	right := make(map[*Node]bool)
+	for n := r; len(n.Branches) > 0; {
+		right[n] = true
+		n = n.Children[n.Branches[len(n.Branches)-1]]
+	}
+
+	for _, n := range nodes {
+		if right[n] {
+			continue
+		}
+
+		// A pruned node has no branch and is skipped too.
+		for len(n.Branches) == 1 && n.Branches[0] != leafBranch && mergeable(n, n.Children[n.Branches[0]]) {
+			child := n.Children[n.Branches[0]]
+			r.addCounts(0, mergeDelta(n, child))
+			n.Branches = child.Branches
+			n.Children = child.Children
+			n.Step += child.Step
+			n.shrunk = child.shrunk
+			r.InnerNodeCnt--
+		}
+	}
+}
+
+// forgetLeaf drops what is kept about the value of a removed leaf.
+func (r *Node) forgetLeaf(leaf *Node) {
+	if leaf.leafExt != nil {
+		leaf.leafExt.history = nil
+		leaf.leafExt.expiry = 0
+	}
+}
@@ -0,0 +1,139 @@
+package trie
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNode_Remove(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr := newStrTrie(ta, false, "", "a", "ab", "abc", "b", "bcd")
+
+	ta.False(tr.Remove([]byte("x")))
+	ta.False(tr.Remove([]byte("bc")))
+	ta.True(tr.Remove([]byte("ab")))
+	ta.False(tr.Remove([]byte("ab")))
+
+	ks, _ := iterAll(tr.NewIter())
+	ta.Equal([]string{"", "a", "abc", "b", "bcd"}, ks)
+
+	ta.Equal(3, tr.RemoveBatch([][]byte{[]byte("bcd"), []byte(""), []byte("abc"), []byte("bcd")}))
+	ks, _ = iterAll(tr.NewIter())
+	ta.Equal([]string{"a", "b"}, ks)
+	ta.Equal(tr.innerNodeCnt(), tr.InnerNodeCnt)
+	ta.Equal(3, tr.InnerNodeCnt)
+
+	ta.Equal(2, tr.RemoveBatch([][]byte{[]byte("a"), []byte("b")}))
+	ks, _ = iterAll(tr.NewIter())
+	ta.Equal([]string{}, ks)
+	ta.Equal(1, tr.InnerNodeCnt)
+
+	_, err := tr.Append([]byte("x"), "x")
+	ta.Nil(err)
+	_, eq, _ := tr.Search([]byte("x"))
+	ta.Equal("x", eq)
+}
+
+func TestNode_RemoveBatch_squash(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr, err := NewTrie(nil, nil, true)
+	ta.Nil(err)
+
+	keys := []string{"abcd", "abce", "abd", "b", "bcde", "bcdf", "c"}
+	for _, k := range keys {
+		_, err := tr.Append([]byte(k), k)
+		ta.Nil(err)
+	}
+
+	ta.Equal(2, tr.RemoveBatch([][]byte{[]byte("abce"), []byte("bcdf")}))
+	ta.Equal(tr.innerNodeCnt(), tr.InnerNodeCnt)
+
+	want := `
+*3
+-97->+2*2
+     -99->+2
+          -$->=abcd
+     -100->
+           -$->=abd
+-98->*2
+     -$->=b
+     -99->+3
+          -$->=bcde
+-99->
+     -$->=c`[1:]
+	ta.Equal(want, tr.String())
+
+	for _, k := range []string{"abcd", "abd", "b", "bcde", "c"} {
+		_, eq, _ := tr.Search([]byte(k))
+		ta.Equal(k, eq)
+	}
+
+	_, err = tr.Append([]byte("cd"), "cd")
+	ta.Nil(err)
+}
+
+func TestNode_RemoveBatch_random(t *testing.T) {
+
+	ta := require.New(t)
+	rnd := rand.New(rand.NewSource(1))
+
+	for round := 0; round < 50; round++ {
+
+		keys := []string{}
+		for i := 0; i < 200; i++ {
+			keys = append(keys, fmt.Sprintf("%x", rnd.Intn(2000)))
+		}
+		keys = sortUniqueStrs(keys)
+
+		for _, squash := range []bool{false, true} {
+
+			tr, err := NewTrie(nil, nil, squash)
+			ta.Nil(err)
+			for _, k := range keys {
+				_, err := tr.Append([]byte(k), k)
+				ta.Nil(err)
+			}
+
+			var rm [][]byte
+			var kept []string
+			for _, k := range keys {
+				if rnd.Intn(2) == 0 {
+					rm = append(rm, []byte(k))
+				} else {
+					kept = append(kept, k)
+				}
+			}
+
+			ta.Equal(len(rm), tr.RemoveBatch(rm))
+			ta.Equal(tr.innerNodeCnt(), tr.InnerNodeCnt)
+
+			_, vs := iterAll(tr.NewIter())
+			ta.Equal(len(kept), len(vs))
+			for i, k := range kept {
+				ta.Equal(k, vs[i])
+				_, eq, _ := tr.Search([]byte(k))
+				ta.Equal(k, eq)
+			}
+		}
+	}
+}
+
+func sortUniqueStrs(ss []string) []string {
+	sort.Strings(ss)
+	rst := ss[:0]
+	for i, s := range ss {
+		if i > 0 && s == ss[i-1] {
+			continue
+		}
+		rst = append(rst, s)
+	}
+	return rst
+}
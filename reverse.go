@@ -0,0 +1,164 @@
+package trie
+
+import "sort"
+
+// ReverseTrie is a trie storing every key reversed, thus keys sharing a
+// suffix, e.g., hostnames in one domain, share a prefix in it. Keys passed to
+// and returned by its methods are never reversed by the caller.
+//
+// Since 0.2.0
+type ReverseTrie struct {
+	root *Node
+}
+
+// NewReverseTrie creates a ReverseTrie from `keys` and corresponding
+// `values`, with the same `squash` and `opts` as NewTrie.
+// `keys` do not need to be sorted.
+//
+// Since 0.2.0
+func NewReverseTrie(keys [][]byte, values interface{}, squash bool, opts ...Option) (*ReverseTrie, error) {
+
+	if keys == nil {
+		root, err := NewTrie(nil, nil, squash, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return &ReverseTrie{root: root}, nil
+	}
+
+	valSlice, err := toSlice(values)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) != len(valSlice) {
+		return nil, ErrKVLenNotMatch
+	}
+
+	order := newOptions(opts).byteOrder
+
+	rkeys := make([][]byte, len(keys))
+	for i, k := range keys {
+		rkeys[i] = reverseBytes(k)
+	}
+
+	idx := make([]int, len(keys))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		return order.compare(rkeys[idx[i]], rkeys[idx[j]]) < 0
+	})
+
+	sortedKeys := make([][]byte, len(idx))
+	sortedValues := make([]interface{}, len(idx))
+	for i, j := range idx {
+		sortedKeys[i] = rkeys[j]
+		sortedValues[i] = valSlice[j]
+	}
+
+	root, err := NewTrie(sortedKeys, sortedValues, squash, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &ReverseTrie{root: root}, nil
+}
+
+// Get returns the value of `key` and whether it is in the trie.
+// Same as Search, a squashed trie may match a key not in it to another key.
+//
+// Since 0.2.0
+func (t *ReverseTrie) Get(key []byte) (interface{}, bool) {
+	_, eq, _ := t.root.Search(reverseBytes(key))
+	return eq, eq != nil
+}
+
+// Set sets the value of `key`, adding it if it is not in the trie.
+// Same as Node.Set, it returns ErrSquashed if a node along `key` is squashed.
+//
+// Since 0.2.0
+func (t *ReverseTrie) Set(key []byte, value interface{}) error {
+	_, err := t.root.Set(reverseBytes(key), value)
+	return err
+}
+
+// Remove removes `key` and returns whether it was in the trie.
+//
+// Since 0.2.0
+func (t *ReverseTrie) Remove(key []byte) bool {
+	return t.root.Remove(reverseBytes(key))
+}
+
+// LongestSuffix returns the longest key that `s` ends with, and its value.
+// E.g., with keys ".com" and ".example.com", the longest suffix of
+// "www.example.com" is ".example.com". It returns false if no key is a suffix
+// of `s`.
+//
+// The returned key is taken from `s`, thus in a squashed trie, in which the
+// bytes removed by squashing are not checked, it may not be a key in the trie.
+//
+// Since 0.2.0
+func (t *ReverseTrie) LongestSuffix(s []byte) (suffix []byte, value interface{}, found bool) {
+
+	rs := t.root.normalize(reverseBytes(s))
+
+	node := t.root
+	for i := -1; ; {
+		i += int(node.Step)
+		if len(rs) < i {
+			break
+		}
+
+		if leaf := node.Children[leafBranch]; leaf != nil && leaf.Value != removed {
+			suffix, value, found = reverseBytes(rs[:i]), leaf.Value, true
+		}
+
+		if len(rs) == i {
+			break
+		}
+
+		node = node.Children[int(rs[i])]
+		if node == nil {
+			break
+		}
+	}
+	return
+}
+
+// EachWithSuffix calls `fn` with every key ending with `suffix` and its value,
+// in ascending order of reversed keys. Iteration stops if `fn` returns false.
+//
+// Keys are rebuilt the same way as Iter does, thus they are incomplete in a
+// squashed trie, and same as Search, keys not ending with `suffix` may be
+// included.
+//
+// Since 0.2.0
+func (t *ReverseTrie) EachWithSuffix(suffix []byte, fn func(key []byte, value interface{}) bool) {
+
+	sub, rkey := t.root.subtree(t.root.normalize(reverseBytes(suffix)))
+	if sub == nil {
+		return
+	}
+
+	stopped := false
+	sub.eachLeaf(rkey, func(rkey []byte, leaf *Node) {
+		if !stopped {
+			stopped = !fn(reverseBytes(rkey), leaf.Value)
+		}
+	})
+}
+
+// Trie returns the underlying trie, in which keys are reversed.
+//
+// Since 0.2.0
+func (t *ReverseTrie) Trie() *Node {
+	return t.root
+}
+
+// reverseBytes returns a reversed copy of `b`.
+func reverseBytes(b []byte) []byte {
+	r := make([]byte, len(b))
+	for i, c := range b {
+		r[len(b)-1-i] = c
+	}
+	return r
+}
@@ -0,0 +1,91 @@
+package trie
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReverseTrie(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := [][]byte{
+		[]byte(".example.com"),
+		[]byte(".com"),
+		[]byte("api.example.com"),
+		[]byte(".org"),
+	}
+	values := []int{0, 1, 2, 3}
+
+	for _, squash := range []bool{false, true} {
+
+		tr, err := NewReverseTrie(keys, values, squash)
+		ta.Nil(err)
+
+		for i, k := range keys {
+			v, found := tr.Get(k)
+			ta.True(found, "squash: %v, key: %q", squash, k)
+			ta.Equal(i, v)
+		}
+
+		cases := []struct {
+			s      string
+			suffix string
+			value  interface{}
+			found  bool
+		}{
+			{"www.example.com", ".example.com", 0, true},
+			{"api.example.com", "api.example.com", 2, true},
+			{"example.com", ".com", 1, true},
+			{"a.b.org", ".org", 3, true},
+			{"example.net", "", nil, false},
+			{"", "", nil, false},
+		}
+
+		for i, c := range cases {
+			suffix, v, found := tr.LongestSuffix([]byte(c.s))
+			ta.Equal(c.found, found, "%d-th: squash: %v", i+1, squash)
+			ta.Equal(c.value, v, "%d-th: squash: %v", i+1, squash)
+			if found {
+				ta.Equal(c.suffix, string(suffix), "%d-th: squash: %v", i+1, squash)
+			}
+		}
+	}
+
+	_, err := NewReverseTrie(keys, []int{1}, false)
+	ta.Equal(ErrKVLenNotMatch, err)
+}
+
+func TestReverseTrie_EachWithSuffix(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr, err := NewReverseTrie(nil, nil, false)
+	ta.Nil(err)
+
+	for i, k := range []string{"a.example.com", "b.example.com", "example.org", "x.com"} {
+		ta.Nil(tr.Set([]byte(k), i))
+	}
+
+	got := map[string]interface{}{}
+	tr.EachWithSuffix([]byte(".example.com"), func(key []byte, v interface{}) bool {
+		got[string(key)] = v
+		return true
+	})
+	ta.Equal(map[string]interface{}{"a.example.com": 0, "b.example.com": 1}, got)
+
+	n := 0
+	tr.EachWithSuffix([]byte(".com"), func(key []byte, v interface{}) bool {
+		n++
+		return false
+	})
+	ta.Equal(1, n)
+
+	ta.True(tr.Remove([]byte("a.example.com")))
+	_, found := tr.Get([]byte("a.example.com"))
+	ta.False(found)
+
+	_, _, found = tr.LongestSuffix([]byte("a.example.com"))
+	ta.False(found)
+}
@@ -0,0 +1,75 @@
+package trie
+
+// SearchResult is the result of Search of one key.
+//
+// Since 0.2.0
+type SearchResult struct {
+	Lt, Eq, Gt interface{}
+}
+
+// SearchSorted is the same as calling Search with every one of `keys`, but
+// faster if `keys` are ascendingly sorted: the descent a key shares with the
+// previous key is reused instead of restarting at the root.
+//
+// Results are correct in any order of `keys`, but the more adjacent keys
+// share prefixes, the less work is done. Keys are resolved the same way as
+// Search does, e.g., compared with the keys of WithStoreKeys, and expired
+// keys are not matched.
+//
+// Since 0.2.0
+func (r *Node) SearchSorted(keys [][]byte) []SearchResult {
+
+	rst := make([]SearchResult, len(keys))
+	if len(r.Branches) == 0 {
+		return rst
+	}
+
+	var levels []descentLevel
+	var prev []byte
+
+	for k, key := range keys {
+
+		key = r.normalize(key)
+
+		// The levels in which only the bytes shared with the previous key
+		// are examined are the same for this key.
+		cp := commonPrefixLen(prev, key)
+		n := 0
+		for n < len(levels) && levels[n].i+int(levels[n].node.Step) < cp {
+			n++
+		}
+		prev = key
+
+		if k > 0 && n == len(levels) {
+			// The previous descent ended before the end of the shared prefix.
+			rst[k] = rst[k-1]
+			continue
+		}
+
+		l := descentLevel{node: r, i: -1}
+		if n < len(levels) {
+			l = levels[n]
+		}
+		levels = levels[:n]
+
+		ltNode, eqNode, gtNode := r.descendFrom(l, key, nil, &levels)
+
+		res := &rst[k]
+		res.Lt, res.Eq, res.Gt = r.searchValues(key, nil, ltNode, eqNode, gtNode)
+	}
+
+	return rst
+}
+
+func commonPrefixLen(a, b []byte) int {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
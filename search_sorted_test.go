@@ -0,0 +1,87 @@
+package trie
+
+import (
+	"bytes"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNode_SearchSorted(t *testing.T) {
+
+	ta := require.New(t)
+
+	rnd := rand.New(rand.NewSource(3))
+	randKeys := func(n int) [][]byte {
+		keys := make([][]byte, n)
+		for i := range keys {
+			k := make([]byte, rnd.Intn(6))
+			for j := range k {
+				k[j] = "abc"[rnd.Intn(3)]
+			}
+			keys[i] = k
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return bytes.Compare(keys[i], keys[j]) < 0
+		})
+		return keys
+	}
+
+	for round := 0; round < 50; round++ {
+
+		keys := randKeys(30)
+		uniq := keys[:0]
+		for i, k := range keys {
+			if i == 0 || !bytes.Equal(k, keys[i-1]) {
+				uniq = append(uniq, k)
+			}
+		}
+		values := make([]int, len(uniq))
+		for i := range values {
+			values[i] = i
+		}
+
+		squash := round%2 == 1
+		var opts []Option
+		if round%3 == 2 {
+			opts = append(opts, WithLazyRemove())
+		}
+		if round%4 >= 2 {
+			opts = append(opts, WithStoreKeys())
+		}
+
+		tr, err := NewTrie(uniq, values, false, opts...)
+		ta.Nil(err)
+		tr.Remove(uniq[rnd.Intn(len(uniq))])
+
+		// An expired key is not matched.
+		_, err = tr.SetTTL(uniq[rnd.Intn(len(uniq))], -1, -time.Second)
+		ta.Nil(err)
+		if squash {
+			tr.Squash()
+		}
+
+		queries := randKeys(40)
+		// Not sorted queries are correct too.
+		if round%5 == 4 {
+			rnd.Shuffle(len(queries), func(i, j int) {
+				queries[i], queries[j] = queries[j], queries[i]
+			})
+		}
+
+		got := tr.SearchSorted(queries)
+		ta.Equal(len(queries), len(got))
+		for i, q := range queries {
+			lt, eq, gt := tr.Search(q)
+			ta.Equal(SearchResult{lt, eq, gt}, got[i], "round %d: key: %q", round, q)
+		}
+	}
+
+	empty, err := NewTrie(nil, nil, false)
+	ta.Nil(err)
+	ta.Equal([]SearchResult{{}, {}}, empty.SearchSorted([][]byte{{}, {1}}))
+	ta.Equal([]SearchResult{}, empty.SearchSorted(nil))
+}
@@ -0,0 +1,128 @@
+package trie
+
+// Set is a trie of keys without values. All keys share one leaf, thus a key
+// costs only the inner nodes and branches of its path, and no leaf node and
+// value of its own.
+//
+// Same as Search, a squashed Set does not compare the bytes removed by
+// squashing: Has returns true for a key that differs from a key in the Set only
+// in these bytes.
+//
+// A Set is not safe for concurrent use if it is modified.
+//
+// Since 0.2.0
+type Set struct {
+	root *Node
+}
+
+// member is the value of the leaf shared by keys of a Set.
+type member struct{}
+
+// NewSet creates a Set from a serial of ascendingly ordered keys. `squash`
+// and `opts` are the same as those of NewTrie, except that options about
+// values and leaves do not apply: WithLazyRemove, WithMultiValue,
+// WithValueStore, WithLazyValues, WithHistory and WithStoreKeys are ignored.
+//
+// Since 0.2.0
+func NewSet(keys [][]byte, squash bool, opts ...Option) (*Set, error) {
+
+	opts = append(opts[:len(opts):len(opts)], withSetLeaf())
+
+	root, err := newTrie(nil, keys, len(keys), func(int) interface{} { return member{} }, squash, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Set{root: root}, nil
+}
+
+// withSetLeaf makes all keys share one leaf, see Set. It disables options
+// that keep something in leaves.
+func withSetLeaf() Option {
+	return func(o *options) {
+		o.setLeaf = &Node{Value: member{}}
+
+		o.lazyRemove = false
+		o.multiValue = nil
+		o.valueStore = nil
+		o.lazyValues = false
+		o.historyLen = 0
+		o.history = false
+		o.storeKeys = false
+	}
+}
+
+// newLeaf returns a leaf for a new key: the shared one of a Set or a new one.
+func (r *Node) newLeaf() *Node {
+	if r.opt != nil && r.opt.setLeaf != nil {
+		return r.opt.setLeaf
+	}
+	return &Node{}
+}
+
+// Add adds `key` to the Set. Unlike NewSet, keys do not need to be added in
+// order, but a node along `key` must not be squashed, or it returns
+// ErrSquashed.
+//
+// Since 0.2.0
+func (s *Set) Add(key []byte) error {
+	_, err := s.root.Set(key, member{})
+	return err
+}
+
+// Has returns true if `key` is in the Set.
+//
+// Since 0.2.0
+func (s *Set) Has(key []byte) bool {
+	_, found := s.root.Get(key)
+	return found
+}
+
+// Remove removes `key` from the Set and returns true if it is in the Set.
+//
+// Since 0.2.0
+func (s *Set) Remove(key []byte) bool {
+	return s.root.Remove(key)
+}
+
+// Len returns the number of keys in the Set.
+//
+// Since 0.2.0
+func (s *Set) Len() int {
+	return s.root.KeyCnt()
+}
+
+// PrefixCount returns the number of keys starting with `prefix`, in O(len(prefix))
+// time. Same as WithStripPrefix, in a squashed Set the bytes removed by
+// squashing are not checked.
+//
+// Since 0.2.0
+func (s *Set) PrefixCount(prefix []byte) int {
+
+	sub, _ := s.root.subtree(s.root.normalize(prefix))
+	if sub == nil {
+		return 0
+	}
+	return sub.KeyCnt()
+}
+
+// NewIter creates an Iter over keys of the Set. Value of the Iter is
+// meaningless.
+//
+// Since 0.2.0
+func (s *Set) NewIter(opts ...IterOption) *Iter {
+	return s.root.NewIter(opts...)
+}
+
+// Keys returns all keys of the Set in ascending order, rebuilt the same way
+// as Iter does.
+//
+// Since 0.2.0
+func (s *Set) Keys() [][]byte {
+
+	keys := make([][]byte, 0, s.Len())
+	it := s.NewIter()
+	for it.Next() {
+		keys = append(keys, copyBytes(it.Key()))
+	}
+	return keys
+}
@@ -0,0 +1,121 @@
+package trie
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/openacid/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSet(t *testing.T) {
+
+	ta := require.New(t)
+
+	rnd := rand.New(rand.NewSource(3))
+	randKey := func() string {
+		k := make([]byte, rnd.Intn(6))
+		for i := range k {
+			k[i] = "abc"[rnd.Intn(3)]
+		}
+		return string(k)
+	}
+
+	uniq := map[string]bool{}
+	for i := 0; i < 40; i++ {
+		uniq[randKey()] = true
+	}
+	strs := []string{}
+	for k := range uniq {
+		strs = append(strs, k)
+	}
+	sort.Strings(strs)
+	keys := make([][]byte, len(strs))
+	for i, k := range strs {
+		keys[i] = []byte(k)
+	}
+
+	s, err := NewSet(keys, false, WithLazyRemove(), WithStoreKeys())
+	ta.Nil(err)
+
+	check := func(step string) {
+		msg := fmt.Sprintf("%s: keys=%q", step, strs)
+
+		ta.Nil(s.root.Validate(), msg)
+		ta.Equal(len(strs), s.Len(), msg)
+
+		got := []string{}
+		for _, k := range s.Keys() {
+			got = append(got, string(k))
+		}
+		ta.Equal(strs, got, msg)
+
+		for i := 0; i < 100; i++ {
+			q := randKey()
+			ta.Equal(uniq[q], s.Has([]byte(q)), "%s: has %q", msg, q)
+
+			cnt := 0
+			for _, k := range strs {
+				if strings.HasPrefix(k, q) {
+					cnt++
+				}
+			}
+			ta.Equal(cnt, s.PrefixCount([]byte(q)), "%s: prefix count %q", msg, q)
+		}
+	}
+	check("new")
+
+	for i := 0; i < 100; i++ {
+		k := randKey()
+		if rnd.Intn(2) == 0 {
+			ta.Nil(s.Add([]byte(k)))
+			uniq[k] = true
+		} else {
+			ta.Equal(uniq[k], s.Remove([]byte(k)), "remove %q", k)
+			delete(uniq, k)
+		}
+	}
+	strs = strs[:0]
+	for k := range uniq {
+		strs = append(strs, k)
+	}
+	sort.Strings(strs)
+	check("add and remove")
+
+	// All keys share one leaf.
+	leaves := map[*Node]bool{}
+	s.root.WalkDepth(func(n *Node, depth int) bool {
+		if n.Children == nil {
+			leaves[n] = true
+		}
+		return true
+	})
+	ta.Equal(1, len(leaves))
+}
+
+func TestSet_squash(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := [][]byte{[]byte("abc"), []byte("abd"), []byte("xyz")}
+	s, err := NewSet(keys, true)
+	ta.Nil(err)
+
+	ta.True(s.Has([]byte("abc")))
+	ta.True(s.Has([]byte("xyz")))
+	ta.False(s.Has([]byte("ab")))
+	ta.Equal(2, s.PrefixCount([]byte("ab")))
+	ta.Equal(3, s.PrefixCount(nil))
+
+	ta.Equal(3, s.root.Counters().Leaves)
+	ta.True(s.Remove([]byte("xyz")))
+	ta.Equal(2, s.Len())
+
+	ta.Equal(ErrSquashed, errors.Cause(s.Add([]byte("abe"))))
+
+	_, err = NewSet([][]byte{[]byte("b"), []byte("a")}, false)
+	ta.Equal(ErrKeyOutOfOrder, errors.Cause(err))
+}
@@ -0,0 +1,106 @@
+package trie
+
+import (
+	"sync"
+)
+
+// ShardedTrie is a set of tries, each of them holds keys with the same first
+// several bits and is protected by its own lock.
+// Appends to different shards proceed in parallel.
+//
+// Keys only need to be ascending within a shard: e.g., with 8 bits, "b1" can be
+// appended after "a2" and "b2" is accepted after it. The empty key has its own
+// shard.
+//
+// Since 0.2.0
+type ShardedTrie struct {
+	bits uint
+
+	// shards[0] holds the empty key. shards[i+1] holds keys with the first
+	// `bits` bits equal to i.
+	shards []shard
+}
+
+type shard struct {
+	mu   sync.RWMutex
+	root *Node
+}
+
+// NewShardedTrie creates an empty ShardedTrie with 2^bits shards for non-empty
+// keys. Every shard is created with NewTrie(nil, nil, squash, opts...).
+//
+// `bits` must be in [1, 8], or it panics.
+//
+// Since 0.2.0
+func NewShardedTrie(bits uint, squash bool, opts ...Option) *ShardedTrie {
+
+	if bits < 1 || bits > 8 {
+		panic("bits must be in [1, 8]")
+	}
+
+	s := &ShardedTrie{
+		bits:   bits,
+		shards: make([]shard, 1+(1<<bits)),
+	}
+
+	for i := range s.shards {
+		// NewTrie never fails with nil keys.
+		s.shards[i].root, _ = NewTrie(nil, nil, squash, opts...)
+	}
+	return s
+}
+
+// shardIndex returns the index of the shard of `key`, which must be
+// normalized.
+func (s *ShardedTrie) shardIndex(key []byte) int {
+	if len(key) == 0 {
+		return 0
+	}
+	return 1 + int(key[0]>>(8-s.bits))
+}
+
+// Append adds a key-value pair into the shard `key` belongs to.
+// The key must be greater than any existent key in the shard.
+//
+// Since 0.2.0
+func (s *ShardedTrie) Append(key []byte, value interface{}) error {
+
+	sh := &s.shards[s.shardIndex(s.shards[0].root.normalize(key))]
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	_, err := sh.root.Append(key, value)
+	return err
+}
+
+// Search is the same as Node.Search.
+// Neighbor values are looked up in adjacent shards if absent in the shard
+// `key` belongs to.
+//
+// Since 0.2.0
+func (s *ShardedTrie) Search(key []byte) (ltValue, eqValue, gtValue interface{}) {
+
+	i := s.shardIndex(s.shards[0].root.normalize(key))
+
+	sh := &s.shards[i]
+	sh.mu.RLock()
+	ltValue, eqValue, gtValue = sh.root.Search(key)
+	sh.mu.RUnlock()
+
+	for j := i - 1; ltValue == nil && j >= 0; j-- {
+		sh := &s.shards[j]
+		sh.mu.RLock()
+		ltValue = sh.root.rightMostLive()
+		sh.mu.RUnlock()
+	}
+
+	for j := i + 1; gtValue == nil && j < len(s.shards); j++ {
+		sh := &s.shards[j]
+		sh.mu.RLock()
+		gtValue = sh.root.leftMostLive()
+		sh.mu.RUnlock()
+	}
+
+	return
+}
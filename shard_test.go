@@ -0,0 +1,83 @@
+package trie
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/openacid/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardedTrie(t *testing.T) {
+
+	ta := require.New(t)
+
+	ta.Panics(func() { NewShardedTrie(0, false) })
+	ta.Panics(func() { NewShardedTrie(9, false) })
+
+	s := NewShardedTrie(8, false)
+
+	ta.Nil(s.Append([]byte("b1"), 3))
+	ta.Nil(s.Append([]byte("a1"), 1))
+	ta.Nil(s.Append([]byte("a2"), 2))
+	ta.Nil(s.Append([]byte("d1"), 4))
+	ta.Nil(s.Append([]byte(""), 0))
+
+	err := s.Append([]byte("a0"), 5)
+	ta.Equal(ErrKeyOutOfOrder, errors.Cause(err))
+
+	cases := []struct {
+		key  string
+		want []interface{}
+	}{
+		{"", []interface{}{nil, 0, 1}},
+		{"a", []interface{}{0, nil, 1}},
+		{"a1", []interface{}{0, 1, 2}},
+		{"a3", []interface{}{2, nil, 3}},
+		{"b1", []interface{}{2, 3, 4}},
+		{"c", []interface{}{3, nil, 4}},
+		{"d1", []interface{}{3, 4, nil}},
+		{"e", []interface{}{4, nil, nil}},
+	}
+
+	for _, c := range cases {
+		lt, eq, gt := s.Search([]byte(c.key))
+		ta.Equal(c.want, []interface{}{lt, eq, gt}, "search %q", c.key)
+	}
+
+	s = NewShardedTrie(1, false)
+	ta.Nil(s.Append([]byte{0x90}, 1))
+	ta.Nil(s.Append([]byte{0x10}, 0))
+	err = s.Append([]byte{0x80}, 2)
+	ta.Equal(ErrKeyOutOfOrder, errors.Cause(err), "0x80 and 0x90 are in the same shard")
+}
+
+func TestShardedTrie_parallelAppend(t *testing.T) {
+
+	ta := require.New(t)
+
+	s := NewShardedTrie(8, true)
+
+	var wg sync.WaitGroup
+	for b := 0; b < 16; b++ {
+		wg.Add(1)
+		go func(b byte) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				if err := s.Append([]byte{b, byte(i)}, int(b)*256+i); err != nil {
+					t.Error(err)
+					return
+				}
+				s.Search([]byte{b + 1})
+			}
+		}(byte(b))
+	}
+	wg.Wait()
+
+	for b := 0; b < 16; b++ {
+		for i := 0; i < 200; i++ {
+			_, eq, _ := s.Search([]byte{byte(b), byte(i)})
+			ta.Equal(b*256+i, eq)
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package trie
+
+import (
+	"sort"
+
+	"github.com/openacid/errors"
+)
+
+// SmallTrie stores a small set of keys in a sorted array, which is binary
+// searched, and converts itself to a trie created by NewTrie once it has
+// `threshold` keys. A small set in a trie costs a node, a map and a slice per
+// key byte, while in an array it costs a key and a value.
+//
+// In the array, keys are normalized by WithKeyNormalizer, ordered by
+// WithByteOrder and checked by WithMaxKeyLen, WithMaxTrieDepth and
+// WithKeyValidator. Other options only take effect after the conversion, e.g.,
+// WithMutationHooks are not called for changes before it.
+// Unlike in a squashed trie, Search in the array has no false positive.
+//
+// A SmallTrie never converts back to an array.
+//
+// Since 0.2.0
+type SmallTrie struct {
+	threshold int
+	squash    bool
+	opts      []Option
+	opt       *options
+
+	keys   [][]byte
+	values []interface{}
+
+	// trie is the converted trie, or nil if keys are in the array.
+	trie *Node
+}
+
+// NewSmallTrie creates a SmallTrie from ascendingly ordered `keys` and
+// corresponding `values`, with the same `squash` and `opts` as NewTrie.
+//
+// Since 0.2.0
+func NewSmallTrie(threshold int, keys [][]byte, values interface{}, squash bool, opts ...Option) (*SmallTrie, error) {
+
+	t := &SmallTrie{
+		threshold: threshold,
+		squash:    squash,
+		opts:      opts,
+		opt:       newOptions(opts),
+	}
+
+	if len(keys) >= threshold {
+		root, err := NewTrie(keys, values, squash, opts...)
+		if err != nil {
+			return nil, err
+		}
+		t.trie = root
+		return t, nil
+	}
+
+	if keys == nil {
+		return t, nil
+	}
+
+	valSlice, err := toSlice(values)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) != len(valSlice) {
+		return nil, ErrKVLenNotMatch
+	}
+
+	for i, k := range keys {
+		if err := t.Append(k, valSlice[i]); err != nil {
+			return nil, errors.Wrapf(err, "trie failed to add kvs")
+		}
+	}
+	return t, nil
+}
+
+// Search is the same as Node.Search.
+//
+// Since 0.2.0
+func (t *SmallTrie) Search(key []byte) (ltValue, eqValue, gtValue interface{}) {
+
+	if t.trie != nil {
+		return t.trie.Search(key)
+	}
+
+	i, found := t.find(t.normalize(key))
+	if i > 0 {
+		ltValue = t.values[i-1]
+	}
+	if found {
+		eqValue = t.values[i]
+		i++
+	}
+	if i < len(t.values) {
+		gtValue = t.values[i]
+	}
+	return
+}
+
+// Get returns the value of `key` and whether it is in the trie.
+//
+// Since 0.2.0
+func (t *SmallTrie) Get(key []byte) (interface{}, bool) {
+
+	if t.trie != nil {
+		return t.trie.Get(key)
+	}
+
+	i, found := t.find(t.normalize(key))
+	if !found {
+		return nil, false
+	}
+	return t.values[i], true
+}
+
+// Append is the same as Node.Append, except that it does not return the leaf
+// node.
+//
+// Since 0.2.0
+func (t *SmallTrie) Append(key []byte, value interface{}) error {
+
+	if t.trie != nil {
+		_, err := t.trie.Append(key, value)
+		return err
+	}
+
+	nkey := t.normalize(key)
+	if err := t.opt.checkKey("append", key, nkey); err != nil {
+		return err
+	}
+	key = nkey
+
+	if n := len(t.keys); n > 0 {
+		c := t.opt.byteOrder.compare(t.keys[n-1], key)
+		if c == 0 {
+			return ErrDuplicateKeys
+		}
+		if c > 0 {
+			return errors.Wrapf(ErrKeyOutOfOrder, "append %q", key)
+		}
+	}
+
+	return t.insert(len(t.keys), key, value)
+}
+
+// Set is the same as Node.Set, except that it does not return the leaf node.
+//
+// Since 0.2.0
+func (t *SmallTrie) Set(key []byte, value interface{}) error {
+
+	if t.trie != nil {
+		_, err := t.trie.Set(key, value)
+		return err
+	}
+
+	nkey := t.normalize(key)
+	if err := t.opt.checkKey("set", key, nkey); err != nil {
+		return err
+	}
+	key = nkey
+
+	i, found := t.find(key)
+	if found {
+		t.values[i] = value
+		return nil
+	}
+	return t.insert(i, key, value)
+}
+
+// Remove is the same as Node.Remove.
+//
+// Since 0.2.0
+func (t *SmallTrie) Remove(key []byte) bool {
+
+	if t.trie != nil {
+		return t.trie.Remove(key)
+	}
+
+	i, found := t.find(t.normalize(key))
+	if !found {
+		return false
+	}
+
+	t.keys = append(t.keys[:i], t.keys[i+1:]...)
+	t.values = append(t.values[:i], t.values[i+1:]...)
+	return true
+}
+
+// KeyCnt returns the number of keys.
+//
+// Since 0.2.0
+func (t *SmallTrie) KeyCnt() int {
+	if t.trie != nil {
+		return t.trie.KeyCnt()
+	}
+	return len(t.keys)
+}
+
+// Trie converts t to a trie if it is not yet, and returns the trie, for
+// methods a SmallTrie does not have. Changes made to the trie are seen by t.
+//
+// Since 0.2.0
+func (t *SmallTrie) Trie() (*Node, error) {
+
+	if t.trie != nil {
+		return t.trie, nil
+	}
+
+	if err := t.convert(); err != nil {
+		return nil, err
+	}
+	return t.trie, nil
+}
+
+// find returns the position of a normalized `key` in the array, or where to
+// insert it.
+func (t *SmallTrie) find(key []byte) (int, bool) {
+
+	order := t.opt.byteOrder
+
+	i := sort.Search(len(t.keys), func(i int) bool {
+		return order.compare(t.keys[i], key) >= 0
+	})
+	return i, i < len(t.keys) && order.compare(t.keys[i], key) == 0
+}
+
+// insert inserts a normalized `key` at position `i`, and converts t to a
+// trie if the threshold is reached. Nothing is inserted if the conversion
+// fails.
+func (t *SmallTrie) insert(i int, key []byte, value interface{}) error {
+
+	t.keys = append(t.keys, nil)
+	copy(t.keys[i+1:], t.keys[i:])
+	t.keys[i] = copyBytes(key)
+
+	t.values = append(t.values, nil)
+	copy(t.values[i+1:], t.values[i:])
+	t.values[i] = value
+
+	if len(t.keys) < t.threshold {
+		return nil
+	}
+
+	err := t.convert()
+	if err != nil {
+		t.keys = append(t.keys[:i], t.keys[i+1:]...)
+		t.values = append(t.values[:i], t.values[i+1:]...)
+	}
+	return err
+}
+
+// convert builds the trie of the keys in the array.
+// The array is kept if it fails.
+func (t *SmallTrie) convert() error {
+
+	if t.keys == nil {
+		t.keys, t.values = [][]byte{}, []interface{}{}
+	}
+
+	opts := append(t.opts[:len(t.opts):len(t.opts)], withNormalizedKeys())
+	root, err := NewTrie(t.keys, t.values, t.squash, opts...)
+	if err != nil {
+		return errors.Wrapf(err, "convert small trie")
+	}
+
+	t.trie = root
+	t.keys, t.values = nil, nil
+	return nil
+}
+
+// withNormalizedKeys makes NewTrie build from keys that are already
+// normalized, such as those in the array of a SmallTrie, since normalizing a
+// key twice may change it again, e.g., with WithNaturalOrder.
+func withNormalizedKeys() Option {
+	return func(o *options) {
+		o.keysNormalized = true
+	}
+}
+
+func (t *SmallTrie) normalize(key []byte) []byte {
+	return t.opt.normalize(key)
+}
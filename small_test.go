@@ -0,0 +1,157 @@
+package trie
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/openacid/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSmallTrie(t *testing.T) {
+
+	ta := require.New(t)
+
+	st, err := NewSmallTrie(4, [][]byte{[]byte("b"), []byte("d")}, []string{"b", "d"}, false)
+	ta.Nil(err)
+	ta.Nil(st.trie)
+
+	ref, err := NewTrie([][]byte{[]byte("b"), []byte("d")}, []string{"b", "d"}, false)
+	ta.Nil(err)
+
+	queries := []string{"", "a", "b", "bb", "c", "d", "e", "f"}
+	check := func(msg string) {
+		ta.Equal(ref.KeyCnt(), st.KeyCnt(), msg)
+		for _, q := range queries {
+			lt, eq, gt := st.Search([]byte(q))
+			wlt, weq, wgt := ref.Search([]byte(q))
+			ta.Equal([]interface{}{wlt, weq, wgt}, []interface{}{lt, eq, gt}, "%s: search %q", msg, q)
+
+			v, found := st.Get([]byte(q))
+			wv, wfound := ref.Get([]byte(q))
+			ta.Equal(wfound, found, "%s: get %q", msg, q)
+			ta.Equal(wv, v, "%s: get %q", msg, q)
+		}
+	}
+	check("new")
+
+	ta.Nil(st.Set([]byte("a"), "a"))
+	_, _ = ref.Set([]byte("a"), "a")
+	ta.Nil(st.Set([]byte("b"), "B"))
+	_, _ = ref.Set([]byte("b"), "B")
+	ta.Nil(st.trie)
+	check("set")
+
+	ta.True(st.Remove([]byte("a")))
+	ta.False(st.Remove([]byte("a")))
+	ref.Remove([]byte("a"))
+	check("remove")
+
+	ta.Equal(ErrDuplicateKeys, errors.Cause(st.Append([]byte("d"), "x")))
+	ta.Equal(ErrKeyOutOfOrder, errors.Cause(st.Append([]byte("c"), "x")))
+
+	ta.Nil(st.Append([]byte("e"), "e"))
+	_, _ = ref.Append([]byte("e"), "e")
+	ta.Nil(st.trie)
+	check("append")
+
+	// The 4th key converts it.
+	ta.Nil(st.Append([]byte("f"), "f"))
+	_, _ = ref.Append([]byte("f"), "f")
+	ta.NotNil(st.trie)
+	ta.Nil(st.keys)
+	check("converted")
+
+	ta.True(st.Remove([]byte("b")))
+	ref.Remove([]byte("b"))
+	check("remove converted")
+}
+
+func TestSmallTrie_Trie(t *testing.T) {
+
+	ta := require.New(t)
+
+	st, err := NewSmallTrie(10, nil, nil, false,
+		WithKeyNormalizer(bytes.ToLower),
+		WithByteOrder(func(a, b byte) int { return int(b) - int(a) }))
+	ta.Nil(err)
+
+	for _, k := range []string{"B", "a", "C"} {
+		ta.Nil(st.Set([]byte(k), k))
+	}
+
+	v, found := st.Get([]byte("A"))
+	ta.True(found)
+	ta.Equal("a", v)
+
+	// Descending order: "c", "b", "bb", "a".
+	lt, eq, gt := st.Search([]byte("bb"))
+	ta.Equal([]interface{}{"B", nil, "a"}, []interface{}{lt, eq, gt})
+
+	tr, err := st.Trie()
+	ta.Nil(err)
+	ta.Equal(3, tr.KeyCnt())
+
+	lt, eq, gt = st.Search([]byte("bb"))
+	ta.Equal([]interface{}{"B", nil, "a"}, []interface{}{lt, eq, gt})
+
+	// The trie is shared.
+	_, err = tr.Set([]byte("d"), "d")
+	ta.Nil(err)
+	v, _ = st.Get([]byte("D"))
+	ta.Equal("d", v)
+
+	// Empty.
+	st, err = NewSmallTrie(10, nil, nil, true)
+	ta.Nil(err)
+	tr, err = st.Trie()
+	ta.Nil(err)
+	ta.Equal(0, tr.KeyCnt())
+}
+
+func TestNewSmallTrie(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := [][]byte{}
+	values := []int{}
+	for i := 0; i < 5; i++ {
+		keys = append(keys, []byte(fmt.Sprintf("%d", i)))
+		values = append(values, i)
+	}
+
+	for _, threshold := range []int{0, 5, 6} {
+		st, err := NewSmallTrie(threshold, keys, values, true)
+		ta.Nil(err)
+		ta.Equal(threshold > 5, st.trie == nil, "threshold: %d", threshold)
+		ta.Equal(5, st.KeyCnt())
+	}
+
+	_, err := NewSmallTrie(10, keys, values[1:], true)
+	ta.Equal(ErrKVLenNotMatch, err)
+
+	_, err = NewSmallTrie(10, [][]byte{[]byte("b"), []byte("a")}, []int{1, 2}, true)
+	ta.Equal(ErrKeyOutOfOrder, errors.Cause(err))
+}
+
+func TestSmallTrie_keyLimits(t *testing.T) {
+
+	ta := require.New(t)
+
+	st, err := NewSmallTrie(3, nil, nil, false, WithMaxKeyLen(2))
+	ta.Nil(err)
+
+	ta.Equal(ErrKeyTooLong, errors.Cause(st.Append([]byte("abc"), 1)))
+	ta.Equal(ErrKeyTooLong, errors.Cause(st.Set([]byte("abc"), 1)))
+	ta.Equal(0, st.KeyCnt())
+
+	// The array still converts once it has enough keys.
+	for i, k := range []string{"a", "b", "c"} {
+		ta.Nil(st.Append([]byte(k), i))
+	}
+	tr, err := st.Trie()
+	ta.Nil(err)
+	ta.Equal(3, tr.KeyCnt())
+	ta.Equal(ErrKeyTooLong, errors.Cause(st.Set([]byte("abc"), 1)))
+}
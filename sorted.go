@@ -0,0 +1,84 @@
+package trie
+
+import "fmt"
+
+// UnsortedError tells the first pair of adjacent keys that can not be added
+// to a trie in the order they are given.
+// Its Cause is ErrKeyOutOfOrder, or ErrDuplicateKeys if the two keys are the
+// same.
+//
+// Since 0.2.0
+type UnsortedError struct {
+	// Index is the index of Key, and Index-1 that of Prev.
+	Index int
+	Prev  []byte
+	Key   []byte
+
+	err error
+}
+
+// Error implements error.
+//
+// Since 0.2.0
+func (e *UnsortedError) Error() string {
+	return fmt.Sprintf("%s: key %q at %d after %q", e.err, e.Key, e.Index, e.Prev)
+}
+
+// Cause returns ErrKeyOutOfOrder or ErrDuplicateKeys.
+//
+// Since 0.2.0
+func (e *UnsortedError) Cause() error {
+	return e.err
+}
+
+// CheckSorted checks that `keys` are ascending without duplicates, as NewTrie
+// requires, in O(n) time and without building a trie. It returns -1 and nil
+// if they are, or the index of the first key out of order and an
+// *UnsortedError of it.
+//
+// Only the options that decide the order of keys apply: keys are compared in
+// the order of WithByteOrder or WithNaturalOrder, after WithKeyNormalizer.
+//
+// Since 0.2.0
+func CheckSorted(keys [][]byte, opts ...Option) (firstBadIndex int, err error) {
+	return CheckSortedFunc(func(i int) ([]byte, bool) {
+		if i == len(keys) {
+			return nil, false
+		}
+		return keys[i], true
+	}, opts...)
+}
+
+// CheckSortedFunc is the same as CheckSorted except that it checks the keys
+// returned by `next`, which is called with 0, 1, 2... until it returns false,
+// the same as NewTrieFromFunc. Keys are not retained, thus `next` can reuse
+// one buffer for them.
+//
+// Since 0.2.0
+func CheckSortedFunc(next func(i int) (key []byte, ok bool), opts ...Option) (firstBadIndex int, err error) {
+
+	o := newOptions(opts)
+
+	// prev is a copy of the last normalized key, and prevKey of it as given.
+	var prev, prevKey []byte
+	for i := 0; ; i++ {
+		key, ok := next(i)
+		if !ok {
+			return -1, nil
+		}
+
+		nkey := o.normalize(key)
+		if i > 0 {
+			if c := o.byteOrder.compare(prev, nkey); c >= 0 {
+				e := &UnsortedError{Index: i, Prev: prevKey, Key: copyBytes(key), err: ErrKeyOutOfOrder}
+				if c == 0 {
+					e.err = ErrDuplicateKeys
+				}
+				return i, e
+			}
+		}
+
+		prev = append(prev[:0], nkey...)
+		prevKey = append(prevKey[:0], key...)
+	}
+}
@@ -0,0 +1,100 @@
+package trie
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/openacid/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckSorted(t *testing.T) {
+
+	ta := require.New(t)
+
+	cases := []struct {
+		keys    []string
+		wantIdx int
+		wantErr error
+	}{
+		{nil, -1, nil},
+		{[]string{"a"}, -1, nil},
+		{[]string{"", "a", "ab", "b"}, -1, nil},
+		{[]string{"a", "b", "a"}, 2, ErrKeyOutOfOrder},
+		{[]string{"a", "ab", "a"}, 2, ErrKeyOutOfOrder},
+		{[]string{"a", "b", "b", "a"}, 2, ErrDuplicateKeys},
+		{[]string{"b", "a"}, 1, ErrKeyOutOfOrder},
+	}
+
+	for i, c := range cases {
+		keys := make([][]byte, len(c.keys))
+		for j, k := range c.keys {
+			keys[j] = []byte(k)
+		}
+
+		idx, err := CheckSorted(keys)
+		ta.Equal(c.wantIdx, idx, "%d-th: %v", i+1, c.keys)
+		ta.Equal(c.wantErr, errors.Cause(err), "%d-th: %v", i+1, c.keys)
+
+		_, buildErr := NewTrie(keys, make([]int, len(keys)), false)
+		ta.Equal(err == nil, buildErr == nil, "%d-th: same as NewTrie", i+1)
+
+		if err != nil {
+			ue := err.(*UnsortedError)
+			ta.Equal(c.wantIdx, ue.Index)
+			ta.Equal(keys[idx-1], ue.Prev)
+			ta.Equal(keys[idx], ue.Key)
+		}
+	}
+
+	_, err := CheckSorted([][]byte{[]byte("a"), []byte("ab"), []byte("a")})
+	ta.Equal(`keys not ascending sorted: key "a" at 2 after "ab"`, err.Error())
+}
+
+func TestCheckSorted_options(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := [][]byte{[]byte("a"), []byte("B"), []byte("c")}
+
+	idx, err := CheckSorted(keys)
+	ta.Equal(1, idx)
+	ta.Equal(ErrKeyOutOfOrder, errors.Cause(err))
+
+	idx, err = CheckSorted(keys, WithKeyNormalizer(bytes.ToLower))
+	ta.Equal(-1, idx)
+	ta.Nil(err)
+
+	idx, err = CheckSorted(keys, WithByteOrder(func(a, b byte) int {
+		return int(bytes.ToLower([]byte{a})[0]) - int(bytes.ToLower([]byte{b})[0])
+	}))
+	ta.Equal(-1, idx)
+	ta.Nil(err)
+
+	// Duplicates after normalization.
+	idx, err = CheckSorted([][]byte{[]byte("a"), []byte("A")}, WithKeyNormalizer(bytes.ToLower))
+	ta.Equal(1, idx)
+	ta.Equal(ErrDuplicateKeys, errors.Cause(err))
+}
+
+func TestCheckSortedFunc(t *testing.T) {
+
+	ta := require.New(t)
+
+	buf := []byte{}
+	idx, err := CheckSortedFunc(func(i int) ([]byte, bool) {
+		if i == 10 {
+			return nil, false
+		}
+		v := byte(i)
+		if i == 7 {
+			v = 3
+		}
+		buf = append(buf[:0], 'k', v)
+		return buf, true
+	})
+	ta.Equal(7, idx)
+	ue := err.(*UnsortedError)
+	ta.Equal([]byte{'k', 6}, ue.Prev)
+	ta.Equal([]byte{'k', 3}, ue.Key)
+}
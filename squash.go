@@ -0,0 +1,60 @@
+package trie
+
+// SquashResult describes what a Squash does.
+//
+// Since 0.2.0
+type SquashResult struct {
+	// Merged is the number of nodes merged with their only child, i.e., what
+	// Squash returns.
+	//
+	// Since 0.2.0
+	Merged int
+
+	// MaxChain is the greatest number of nodes merged into one node, i.e.,
+	// the longest chain of single-branch nodes collapsed.
+	//
+	// Since 0.2.0
+	MaxChain int
+
+	// Remaining is the number of inner nodes after squashing.
+	//
+	// Since 0.2.0
+	Remaining int
+
+	// BytesSaved is an estimate of the memory released: of the nodes, maps
+	// and branch slices dropped.
+	//
+	// Since 0.2.0
+	BytesSaved int
+}
+
+// SquashOption configures SquashDetailed.
+//
+// Since 0.2.0
+type SquashOption func(*squashState)
+
+// WithDryRun makes SquashDetailed report what it would do without modifying
+// the trie, e.g., to decide whether squashing a live trie is worth the pause.
+//
+// Since 0.2.0
+func WithDryRun() SquashOption {
+	return func(st *squashState) {
+		st.dryRun = true
+	}
+}
+
+// SquashDetailed is the same as Squash except that it returns a SquashResult.
+// Squash keeps returning the number of nodes merged.
+//
+// A dry run takes the same O(n) time as squashing, for a trie of n nodes, and
+// does not call the hook of WithBuildHook.
+//
+// Since 0.2.0
+func (r *Node) SquashDetailed(opts ...SquashOption) SquashResult {
+
+	st := &squashState{}
+	for _, opt := range opts {
+		opt(st)
+	}
+	return r.squashWith(st)
+}
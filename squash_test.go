@@ -0,0 +1,44 @@
+package trie
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNode_SquashDetailed(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := [][]byte{
+		{1, 2, 3, 4, 0},
+		{1, 2, 3, 4, 1},
+		{1, 2, 3, 4, 2},
+		{1, 2, 3, 4, 3},
+		{1, 3, 3, 5, 4},
+	}
+	values := []int{0, 1, 2, 3, 4}
+
+	tr, err := NewTrie(keys, values, false)
+	ta.Nil(err)
+	before := tr.String()
+	ta.Equal(13, tr.innerNodeCnt())
+
+	dry := tr.SquashDetailed(WithDryRun())
+	ta.Equal(before, tr.String(), "dry run does not modify")
+	ta.Equal(6, dry.Merged)
+	ta.Equal(3, dry.MaxChain, "1, 3, 3, 5 becomes one node")
+	ta.Equal(7, dry.Remaining)
+	ta.True(dry.BytesSaved > 6*nodeSize)
+
+	res := tr.SquashDetailed()
+	ta.Equal(dry, res)
+	ta.Equal(7, tr.innerNodeCnt())
+
+	want, err := NewTrie(keys, values, false)
+	ta.Nil(err)
+	want.Squash()
+	ta.Equal(want.String(), tr.String())
+
+	ta.Equal(SquashResult{Remaining: 7}, tr.SquashDetailed(WithDryRun()))
+}
@@ -0,0 +1,184 @@
+package trie
+
+import "bytes"
+
+// WithStoreKeys makes every leaf keep a copy of its whole key, as normalized
+// by WithKeyNormalizer. It costs the key bytes and a slice header per key,
+// of which WithKeyInterner packs the bytes, and makes a squashed trie exact:
+//
+// Search and Get do not match a key that is not in the trie, which a
+// squashed trie does if the key differs only in the bytes removed by
+// squashing, and Search returns the right neighbors of it. Remove does not
+// remove another key.
+// Iter yields whole keys instead of keys rebuilt from branch labels, and keys
+// not starting with the prefix of WithStripPrefix are skipped.
+//
+// In an unsquashed trie, keys are stored but nothing else changes.
+// Leaves of a trie passed to ReplaceSubTrie get keys prefixed with the
+// replaced prefix: their stored keys if they have, otherwise keys rebuilt
+// from branch labels.
+//
+// Since 0.2.0
+func WithStoreKeys() Option {
+	return func(o *options) {
+		o.storeKeys = true
+	}
+}
+
+func (r *Node) storeKeys() bool {
+	return r.opt != nil && r.opt.storeKeys
+}
+
+// WithKeyInterner makes WithStoreKeys keep keys in `in` instead of copying
+// every key alone: keys are packed into chunks of `in`, and a key stored again,
+// e.g., after it is removed, or by another trie of the same Interner, shares
+// the bytes already there. CompressionReport estimates what it saves.
+//
+// The bytes of a removed key are kept by `in`. An Interner is not safe for
+// concurrent use, thus tries sharing one must not be written concurrently.
+// Without WithStoreKeys it has no effect.
+//
+// Since 0.2.0
+func WithKeyInterner(in *Interner) Option {
+	return func(o *options) {
+		o.keyInterner = in
+	}
+}
+
+// storedKey returns the copy of `key` a leaf stores, see WithKeyInterner.
+func (r *Node) storedKey(key []byte) []byte {
+	if r.opt.keyInterner != nil {
+		return r.opt.keyInterner.Intern(key)
+	}
+	return copyBytes(key)
+}
+
+// noDiff is returned by keyDiff when keys need not be compared.
+const noDiff = int(^uint(0) >> 1)
+
+// keyDiff returns the index of the first byte at which `key` differs from the
+// keys of the subtree `key` leads to, from `node` before which i+1 bytes of
+// `key` are consumed, and the stored key of a leaf in the subtree.
+// All keys of the subtree share the bytes before the index: it is in bytes
+// removed by squashing or after the subtree, and tells on which side of the
+// subtree `key` is.
+//
+// Keys of leaves are from `src`, or stored ones if it is nil.
+// It returns noDiff if `key` is found or there is no key to compare with.
+func (r *Node) keyDiff(node *Node, i int, key []byte, src KeySource) (int, []byte) {
+
+	if src == nil && !r.storeKeys() {
+		return noDiff, nil
+	}
+
+	n := node
+	for {
+		i += int(n.Step)
+		if len(key) < i {
+			break
+		}
+
+		br := leafBranch
+		if len(key) > i {
+			br = int(key[i])
+		}
+
+		child := n.Children[br]
+		if child == nil {
+			break
+		}
+		n = child
+		if br == leafBranch {
+			break
+		}
+	}
+
+	leaf := n.leftMost()
+	if leaf.Value == removed {
+		// Which side of a subtree without live key `key` is on does not
+		// matter.
+		leaf = n.firstLiveLeaf()
+	}
+	if leaf == nil || leaf.Children != nil {
+		// An empty trie.
+		return noDiff, nil
+	}
+	other := r.keyOf(leaf, src)
+	if other == nil {
+		return noDiff, nil
+	}
+
+	p := 0
+	for p < len(key) && p < len(other) && key[p] == other[p] {
+		p++
+	}
+	if p == len(key) && p == len(other) {
+		return noDiff, nil
+	}
+	return p, other
+}
+
+// firstLiveLeaf returns the first leaf not removed in the subtree of r, or nil.
+func (r *Node) firstLiveLeaf() *Node {
+
+	if r.Children == nil {
+		if r.Value == removed {
+			return nil
+		}
+		return r
+	}
+
+	for _, b := range r.Branches {
+		if leaf := r.Children[b].firstLiveLeaf(); leaf != nil {
+			return leaf
+		}
+	}
+	return nil
+}
+
+// keyOf returns the key of `leaf` from `src`, or the stored key if `src` is
+// nil, which is nil if keys are not stored.
+func (r *Node) keyOf(leaf *Node, src KeySource) []byte {
+	if src != nil {
+		return src(leaf)
+	}
+	return leaf.key()
+}
+
+// before returns true if `key`, which differs from `other` first at index
+// `p`, is less than `other`.
+func (o byteOrder) before(key []byte, p int, other []byte) bool {
+	return p == len(key) || o.rank(int(key[p])) < o.rank(int(other[p]))
+}
+
+// isKey returns false if leaf `n` has a stored key other than `key`.
+func (n *Node) isKey(key []byte) bool {
+	return n.key() == nil || bytes.Equal(n.key(), key)
+}
+
+// storeSubKeys sets the stored keys of leaves of `n`, which is put at
+// `prefix` in r, to `prefix` followed by their stored key if they have,
+// otherwise the key rebuilt from branch labels.
+func (r *Node) storeSubKeys(n *Node, prefix []byte) {
+
+	var buf []byte
+
+	var walk func(n *Node, key []byte)
+	walk = func(n *Node, key []byte) {
+		for _, b := range n.Branches {
+			child := n.Children[b]
+			if b != leafBranch {
+				walk(child, append(key, byte(b)))
+				continue
+			}
+
+			if child.key() != nil {
+				buf = append(append(buf[:0], prefix...), child.key()...)
+				child.leaf().key = r.storedKey(buf)
+			} else {
+				child.leaf().key = r.storedKey(key)
+			}
+		}
+	}
+	walk(n, append([]byte{}, prefix...))
+}
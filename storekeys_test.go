@@ -0,0 +1,190 @@
+package trie
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// bruteSearch is Search of `q` in ascending `keys`, of which values are the
+// keys themselves.
+func bruteSearch(keys []string, q string) []interface{} {
+
+	res := []interface{}{nil, nil, nil}
+	i := sort.SearchStrings(keys, q)
+	if i > 0 {
+		res[0] = keys[i-1]
+	}
+	if i < len(keys) && keys[i] == q {
+		res[1] = q
+		i++
+	}
+	if i < len(keys) {
+		res[2] = keys[i]
+	}
+	return res
+}
+
+func TestWithStoreKeys(t *testing.T) {
+
+	ta := require.New(t)
+
+	for _, lazy := range []bool{false, true} {
+		for seed := int64(0); seed < 10; seed++ {
+
+			rnd := rand.New(rand.NewSource(seed))
+			randKey := func() string {
+				k := make([]byte, rnd.Intn(6))
+				for i := range k {
+					k[i] = "abc"[rnd.Intn(3)]
+				}
+				return string(k)
+			}
+
+			uniq := map[string]bool{}
+			for i := 0; i < 12; i++ {
+				uniq[randKey()] = true
+			}
+			keys := []string{}
+			for k := range uniq {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+
+			bs := make([][]byte, len(keys))
+			for i, k := range keys {
+				bs[i] = []byte(k)
+			}
+
+			opts := []Option{WithStoreKeys()}
+			if lazy {
+				opts = append(opts, WithLazyRemove())
+			}
+			tr, err := NewTrie(bs, keys, true, opts...)
+			ta.Nil(err)
+
+			msg := fmt.Sprintf("lazy=%v seed=%d keys=%q", lazy, seed, keys)
+
+			check := func(step string) {
+				ks, _ := iterAll(tr.NewIter())
+				ta.Equal(keys, ks, "%s %s: iter", msg, step)
+
+				for i := 0; i < 100; i++ {
+					q := randKey()
+					lt, eq, gt := tr.Search([]byte(q))
+					ta.Equal(bruteSearch(keys, q), []interface{}{lt, eq, gt}, "%s %s: search %q", msg, step, q)
+
+					_, found := tr.Get([]byte(q))
+					ta.Equal(uniq[q], found, "%s %s: get %q", msg, step, q)
+				}
+			}
+			check("new")
+
+			for i := 0; i < 5; i++ {
+				q := randKey()
+				ta.Equal(uniq[q], tr.Remove([]byte(q)), "%s: remove %q", msg, q)
+				if uniq[q] {
+					delete(uniq, q)
+					j := sort.SearchStrings(keys, q)
+					keys = append(keys[:j], keys[j+1:]...)
+				}
+			}
+			check("remove")
+		}
+	}
+}
+
+func TestWithStoreKeys_iter(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := [][]byte{[]byte("abc"), []byte("abd"), []byte("xyz")}
+	tr, err := NewTrie(keys, []int{0, 1, 2}, true, WithStoreKeys())
+	ta.Nil(err)
+
+	ks, _ := iterAll(tr.NewIter())
+	ta.Equal([]string{"abc", "abd", "xyz"}, ks)
+
+	// "xbz" leads to the squashed "xyz", which does not start with it.
+	ks, _ = iterAll(tr.NewIter(WithStripPrefix([]byte("xb"))))
+	ta.Equal([]string{}, ks)
+
+	ks, _ = iterAll(tr.NewIter(WithStripPrefix([]byte("ab")), WithPrependPrefix([]byte("-"))))
+	ta.Equal([]string{"-c", "-d"}, ks)
+
+	// Without stored keys, keys are rebuilt from branch labels.
+	tr, err = NewTrie(keys, []int{0, 1, 2}, true)
+	ta.Nil(err)
+	ks, _ = iterAll(tr.NewIter())
+	ta.Equal([]string{"ac", "ad", "x"}, ks)
+}
+
+func TestWithStoreKeys_replaceSubTrie(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr, err := NewTrie(nil, nil, false, WithStoreKeys())
+	ta.Nil(err)
+	for _, k := range []string{"a", "b"} {
+		_, err = tr.Set([]byte(k), k)
+		ta.Nil(err)
+	}
+
+	stored, err := NewTrie([][]byte{[]byte("x")}, []string{"x"}, false, WithStoreKeys())
+	ta.Nil(err)
+	ta.Nil(tr.ReplaceSubTrie([]byte("a"), stored))
+
+	ta.Nil(tr.ReplaceSubTrie([]byte("b"), newStrTrie(ta, false, "", "y")))
+
+	tr.Squash()
+
+	ks, _ := iterAll(tr.NewIter())
+	ta.Equal([]string{"ax", "b", "by"}, ks)
+
+	_, found := tr.Get([]byte("az"))
+	ta.False(found)
+	_, found = tr.Get([]byte("ax"))
+	ta.True(found)
+}
+
+func TestWithKeyInterner(t *testing.T) {
+
+	ta := require.New(t)
+
+	in := NewInterner()
+	keys := [][]byte{[]byte("abc1"), []byte("abc2"), []byte("abd")}
+
+	tr, err := NewTrie(keys, []int{1, 2, 3}, false, WithStoreKeys(), WithKeyInterner(in))
+	ta.Nil(err)
+	ta.Equal(3, in.Len())
+	ta.Equal(11, in.Size)
+
+	leaf := tr.leafOf([]byte("abc1"))
+	ta.Equal("abc1", string(leaf.key()))
+	ta.Equal(len(leaf.key()), cap(leaf.key()))
+
+	// A key stored again shares the interned bytes.
+	ta.True(tr.Remove([]byte("abd")))
+	_, err = tr.Set([]byte("abd"), 4)
+	ta.Nil(err)
+	ta.Equal(3, in.Len())
+	ta.Equal(3, in.Saved)
+
+	other, err := NewTrie(keys[:1], []int{5}, false, WithStoreKeys(), WithKeyInterner(in))
+	ta.Nil(err)
+	ta.True(&other.leafOf([]byte("abc1")).key()[0] == &leaf.key()[0])
+
+	tr.Squash()
+	_, found := tr.Get([]byte("abx1"))
+	ta.False(found, "stored keys are compared")
+
+	sub := newStrTrie(ta, false, "x")
+	tr, err = NewTrie(nil, nil, false, WithStoreKeys(), WithKeyInterner(in))
+	ta.Nil(err)
+	ta.Nil(tr.ReplaceSubTrie([]byte("p"), sub))
+	ta.Equal(4, in.Len())
+	ta.Equal("px", string(tr.leafOf([]byte("px")).key()))
+}
@@ -0,0 +1,181 @@
+package trie
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+)
+
+// EventType is the kind of change of a key.
+//
+// Since 0.2.0
+type EventType int
+
+const (
+	// EventAdded means a key is added.
+	//
+	// Since 0.2.0
+	EventAdded EventType = iota
+
+	// EventUpdated means the value of an existent key is changed.
+	//
+	// Since 0.2.0
+	EventUpdated
+
+	// EventRemoved means a key is removed.
+	//
+	// Since 0.2.0
+	EventRemoved
+)
+
+// String implements fmt.Stringer.
+//
+// Since 0.2.0
+func (t EventType) String() string {
+	switch t {
+	case EventAdded:
+		return "added"
+	case EventUpdated:
+		return "updated"
+	case EventRemoved:
+		return "removed"
+	}
+	return "unknown"
+}
+
+// Event is a change of a key, sent to subscribers by Subscribe.
+//
+// Since 0.2.0
+type Event struct {
+	Type EventType
+
+	// Key is the changed key, normalized by WithKeyNormalizer and
+	// WithNaturalOrder. It must not be modified.
+	Key []byte
+
+	// Value is the new value, or the removed value of EventRemoved.
+	Value interface{}
+}
+
+// subscribeBufSize is the capacity of the channel of a subscription.
+const subscribeBufSize = 64
+
+// subscription is a channel of events of keys starting with a prefix.
+type subscription struct {
+	prefix []byte
+	ch     chan Event
+	done   chan struct{}
+}
+
+// subscribers are the subscriptions of a trie.
+type subscribers struct {
+	// cnt is the number of subscriptions, read without locking mu.
+	cnt  int32
+	mu   sync.Mutex
+	subs []*subscription
+}
+
+// Subscribe returns a channel receiving an Event for every key starting with
+// `prefix` added, updated or removed by Append, Set, Remove and the methods
+// built on them, in the order of the changes. `cancel` stops the
+// subscription and closes the channel.
+//
+// Events are sent synchronously by the writer: the channel is buffered, but
+// a subscriber that stops receiving blocks the writer until `cancel` is
+// called. `cancel` is safe to call from any goroutine, and more than once.
+//
+// Since 0.2.0
+func (r *Node) Subscribe(prefix []byte) (events <-chan Event, cancel func()) {
+
+	// A Node not created by NewTrie has no options to keep subscriptions
+	// in.
+	if r.opt == nil {
+		r.opt = newOptions(nil)
+	}
+
+	s := &subscription{
+		prefix: r.normalize(copyBytes(prefix)),
+		ch:     make(chan Event, subscribeBufSize),
+		done:   make(chan struct{}),
+	}
+
+	subs := &r.opt.subscribers
+	subs.mu.Lock()
+	subs.subs = append(subs.subs, s)
+	atomic.AddInt32(&subs.cnt, 1)
+	subs.mu.Unlock()
+
+	var once sync.Once
+	cancel = func() {
+		once.Do(func() {
+			// Unblock the writer before waiting for it to release mu.
+			close(s.done)
+
+			subs.mu.Lock()
+			for i, x := range subs.subs {
+				if x == s {
+					subs.subs = append(subs.subs[:i], subs.subs[i+1:]...)
+					break
+				}
+			}
+			atomic.AddInt32(&subs.cnt, -1)
+			subs.mu.Unlock()
+
+			close(s.ch)
+		})
+	}
+
+	return s.ch, cancel
+}
+
+// changed reports a change of `key` from `old` to `value`, to hooks of
+// WithMutationHooks and subscribers. `old` is removed if the key is added, and
+// `value` is removed if the key is removed.
+func (r *Node) changed(key []byte, old, value interface{}) {
+
+	if r.opt == nil {
+		return
+	}
+	if len(r.opt.hooks) == 0 && r.opt.persister == nil && atomic.LoadInt32(&r.opt.subscribers.cnt) == 0 {
+		return
+	}
+
+	t := EventUpdated
+	switch {
+	case old == removed && value == removed:
+		return
+	case old == removed:
+		t, old = EventAdded, nil
+	case value == removed:
+		t, value = EventRemoved, nil
+	}
+
+	r.callHooks(t, key, old, value)
+
+	if r.opt.persister == nil && atomic.LoadInt32(&r.opt.subscribers.cnt) == 0 {
+		return
+	}
+
+	e := Event{Type: t, Key: copyBytes(key), Value: value}
+	if t == EventRemoved {
+		e.Value = old
+	}
+
+	if r.opt.persister != nil {
+		r.opt.persister.add(e)
+	}
+
+	subs := &r.opt.subscribers
+	subs.mu.Lock()
+	defer subs.mu.Unlock()
+
+	for _, s := range subs.subs {
+		if !bytes.HasPrefix(e.Key, s.prefix) {
+			continue
+		}
+		select {
+		case s.ch <- e:
+		case <-s.done:
+		}
+	}
+}
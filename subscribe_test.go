@@ -0,0 +1,113 @@
+package trie
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNode_Subscribe(t *testing.T) {
+
+	ta := require.New(t)
+
+	for _, opts := range [][]Option{nil, {WithLazyRemove()}} {
+
+		tr, err := NewTrie(nil, nil, false, opts...)
+		ta.Nil(err)
+
+		events, cancel := tr.Subscribe([]byte("a/"))
+		all, cancelAll := tr.Subscribe(nil)
+
+		_, err = tr.Append([]byte("a/1"), 1)
+		ta.Nil(err)
+		_, err = tr.Append([]byte("b/1"), 2)
+		ta.Nil(err)
+		_, err = tr.Set([]byte("a/1"), 3)
+		ta.Nil(err)
+		_, err = tr.Set([]byte("a/0"), 4)
+		ta.Nil(err)
+		ta.Equal(2, tr.RemoveBatch([][]byte{[]byte("a/1"), []byte("b/1"), []byte("c")}))
+
+		cancel()
+		cancel()
+
+		got := []Event{}
+		for e := range events {
+			got = append(got, e)
+		}
+		ta.Equal([]Event{
+			{EventAdded, []byte("a/1"), 1},
+			{EventUpdated, []byte("a/1"), 3},
+			{EventAdded, []byte("a/0"), 4},
+			{EventRemoved, []byte("a/1"), 3},
+		}, got)
+
+		// No more events after cancel.
+		_, err = tr.Set([]byte("a/2"), 5)
+		ta.Nil(err)
+
+		cancelAll()
+		n := 0
+		for range all {
+			n++
+		}
+		ta.Equal(7, n)
+	}
+}
+
+func TestNode_Subscribe_noOptions(t *testing.T) {
+
+	ta := require.New(t)
+
+	// A Node built by hand has no options.
+	tr := &Node{Children: make(map[int]*Node), Step: 1}
+	_, err := tr.Append([]byte("a"), 1)
+	ta.Nil(err)
+
+	events, cancel := tr.Subscribe(nil)
+	_, err = tr.Set([]byte("b"), 2)
+	ta.Nil(err)
+	cancel()
+
+	got := []Event{}
+	for e := range events {
+		got = append(got, e)
+	}
+	ta.Equal([]Event{{EventAdded, []byte("b"), 2}}, got)
+
+	v, found := tr.Get([]byte("a"))
+	ta.True(found)
+	ta.Equal(1, v)
+}
+
+func TestNode_Subscribe_cancelUnblocksWriter(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr, err := NewTrie(nil, nil, false)
+	ta.Nil(err)
+
+	_, cancel := tr.Subscribe(nil)
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < subscribeBufSize*2; i++ {
+			_, _ = tr.Append([]byte{byte(i)}, i)
+		}
+		close(done)
+	}()
+
+	cancel()
+	<-done
+	ta.Equal(subscribeBufSize*2, tr.KeyCnt())
+}
+
+func TestEventType_String(t *testing.T) {
+
+	ta := require.New(t)
+
+	ta.Equal("added", EventAdded.String())
+	ta.Equal("updated", EventUpdated.String())
+	ta.Equal("removed", EventRemoved.String())
+	ta.Equal("unknown", EventType(-1).String())
+}
@@ -0,0 +1,167 @@
+package trie
+
+import (
+	"sort"
+
+	"github.com/openacid/errors"
+)
+
+// ReplaceSubTrie replaces all keys starting with `prefix` with the keys in
+// `newSub`. Keys in `newSub` are relative to `prefix`: a key k in `newSub`
+// becomes prefix+k. If `newSub` is empty, all keys starting with `prefix` are
+// removed.
+//
+// `newSub` becomes part of r and must not be used afterwards.
+// The nodes along `prefix` must not be squashed, or it returns ErrSquashed.
+//
+// Since 0.2.0
+func (r *Node) ReplaceSubTrie(prefix []byte, newSub *Node) error {
+	return r.replaceSubTrie(r.normalize(prefix), newSub)
+}
+
+// replaceSubTrie is ReplaceSubTrie of a normalized `prefix`.
+func (r *Node) replaceSubTrie(prefix []byte, newSub *Node) error {
+
+	path := make([]*Node, 0, len(prefix)+1)
+	node := r
+	path = append(path, node)
+
+	if r.Step > 1 {
+		return errors.Wrapf(ErrSquashed, "replace %q", prefix)
+	}
+
+	for _, b := range prefix {
+		br := int(b)
+		child := node.Children[br]
+		if child == nil {
+			child = &Node{Children: make(map[int]*Node), Step: 1, squash: r.squash}
+			node.Children[br] = child
+			node.Branches = r.byteOrder().insertBranch(node.Branches, br)
+			r.InnerNodeCnt++
+		} else if child.Step > 1 {
+			return errors.Wrapf(ErrSquashed, "replace %q", prefix)
+		}
+		node = child
+		path = append(path, node)
+	}
+
+	if r.storeKeys() {
+		r.storeSubKeys(newSub, prefix)
+	}
+
+	old := node.countAll()
+	r.InnerNodeCnt -= old.InnerNodes
+	r.addCounts(-old.Leaves, -old.SquashedChains)
+
+	delta := newSub.recount() - node.KeyCnt()
+	for _, n := range path {
+		n.keyCnt += delta
+	}
+
+	if len(newSub.Branches) > 0 {
+		node.Branches = newSub.Branches
+		node.Children = newSub.Children
+		node.Step = newSub.Step
+		c := newSub.countAll()
+		r.InnerNodeCnt += c.InnerNodes
+		r.addCounts(c.Leaves, c.SquashedChains)
+		r.reaggregate(newSub)
+		r.reaggregatePath(path)
+		r.buildFront()
+		return nil
+	}
+
+	node.Branches = nil
+	node.Children = make(map[int]*Node)
+	node.Step = 1
+
+	if node == r {
+		r.InnerNodeCnt++
+		r.reaggregatePath(path)
+		r.buildFront()
+		return nil
+	}
+
+	// Remove nodes without any key.
+	for i := len(prefix) - 1; i >= 0; i-- {
+		p := path[i]
+		br := int(prefix[i])
+		delete(p.Children, br)
+		p.Branches = removeBranch(p.Branches, br)
+		p.shrunk = true
+
+		if len(p.Branches) > 0 || p == r {
+			break
+		}
+		r.InnerNodeCnt--
+	}
+	r.reaggregatePath(path)
+	r.buildFront()
+
+	return nil
+}
+
+// ReplaceSubTrie is the same as Node.ReplaceSubTrie. Readers see either all
+// keys before the replacement or all keys after it.
+//
+// Since 0.2.0
+func (s *SyncTrie) ReplaceSubTrie(prefix []byte, newSub *Node) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old := s.Load()
+	nprefix := old.normalize(prefix)
+	root := old.copyPath(nprefix)
+
+	err := root.replaceSubTrie(nprefix, newSub)
+	if err != nil {
+		return err
+	}
+
+	s.root.Store(root)
+	return nil
+}
+
+// innerNodeCnt counts inner nodes in the subtree rooted at r.
+func (r *Node) innerNodeCnt() int {
+
+	if len(r.Branches) == 0 {
+		if r.Children != nil {
+			// an empty root
+			return 1
+		}
+		return 0
+	}
+
+	cnt := 1
+	for _, b := range r.Branches {
+		cnt += r.Children[b].innerNodeCnt()
+	}
+	return cnt
+}
+
+// insertBranch inserts `br` into ascendingly sorted `branches`.
+func insertBranch(branches []int, br int) []int {
+	i := sort.SearchInts(branches, br)
+	branches = append(branches, 0)
+	copy(branches[i+1:], branches[i:])
+	branches[i] = br
+	return branches
+}
+
+// removeBranch removes `br` from ascendingly sorted `branches` in place.
+// The capacity is kept, which records the max fan-out a node had, see
+// Compact.
+func removeBranch(branches []int, br int) []int {
+	i := sort.SearchInts(branches, br)
+	if i == len(branches) || branches[i] != br {
+		// branches in a custom byte order are not sorted by value.
+		for i = 0; i < len(branches) && branches[i] != br; i++ {
+		}
+		if i == len(branches) {
+			return branches
+		}
+	}
+	return append(branches[:i], branches[i+1:]...)
+}
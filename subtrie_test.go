@@ -0,0 +1,97 @@
+package trie
+
+import (
+	"testing"
+
+	"github.com/openacid/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func newStrTrie(ta *require.Assertions, squash bool, keys ...string) *Node {
+	bs := make([][]byte, len(keys))
+	values := make([]string, len(keys))
+	for i, k := range keys {
+		bs[i] = []byte(k)
+		values[i] = k
+	}
+	tr, err := NewTrie(bs, values, squash)
+	ta.Nil(err)
+	return tr
+}
+
+func TestNode_ReplaceSubTrie(t *testing.T) {
+
+	ta := require.New(t)
+
+	base := []string{"a", "ab", "b", "bc", "bcd", "c"}
+
+	cases := []struct {
+		prefix string
+		sub    []string
+		want   []string
+	}{
+		{"b", []string{"x", "y"}, []string{"a", "ab", "bx", "by", "c"}},
+		{"b", []string{"", "z"}, []string{"a", "ab", "b", "bz", "c"}},
+		{"bc", []string{"1"}, []string{"a", "ab", "b", "bc1", "c"}},
+		{"bb", []string{"1"}, []string{"a", "ab", "b", "bb1", "bc", "bcd", "c"}},
+		{"x", []string{"1"}, []string{"a", "ab", "b", "bc", "bcd", "c", "x1"}},
+		{"", []string{"1"}, []string{"1"}},
+		{"b", []string{}, []string{"a", "ab", "c"}},
+		{"bcd", []string{}, []string{"a", "ab", "b", "bc", "c"}},
+		{"x", []string{}, []string{"a", "ab", "b", "bc", "bcd", "c"}},
+		{"", []string{}, []string{}},
+	}
+
+	for i, c := range cases {
+		tr := newStrTrie(ta, false, base...)
+		sub := newStrTrie(ta, false, c.sub...)
+
+		err := tr.ReplaceSubTrie([]byte(c.prefix), sub)
+		ta.Nil(err)
+
+		ks, _ := iterAll(tr.NewIter())
+		ta.Equal(c.want, ks, "%d-th: prefix: %q", i+1, c.prefix)
+
+		want := newStrTrie(ta, false, c.want...)
+		ta.Equal(want.InnerNodeCnt, tr.InnerNodeCnt, "%d-th: InnerNodeCnt", i+1)
+	}
+}
+
+func TestNode_ReplaceSubTrie_squashed(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr := newStrTrie(ta, false, "abc", "abd", "b")
+	sub := newStrTrie(ta, false, "1", "2")
+	sub.Squash()
+
+	ta.Nil(tr.ReplaceSubTrie([]byte("x"), newStrTrie(ta, true, "123", "124")))
+	_, eq, _ := tr.Search([]byte("x124"))
+	ta.Equal("124", eq)
+
+	tr.Squash()
+	err := tr.ReplaceSubTrie([]byte("ab"), sub)
+	ta.Equal(ErrSquashed, errors.Cause(err))
+}
+
+func TestSyncTrie_ReplaceSubTrie(t *testing.T) {
+
+	ta := require.New(t)
+
+	s, err := NewSyncTrie(nil, nil, false)
+	ta.Nil(err)
+	for _, k := range []string{"a", "b1", "b2", "c"} {
+		ta.Nil(s.Append([]byte(k), k))
+	}
+
+	before := s.Load()
+
+	ta.Nil(s.ReplaceSubTrie([]byte("b"), newStrTrie(ta, false, "3")))
+
+	ks, _ := iterAll(before.NewIter())
+	ta.Equal([]string{"a", "b1", "b2", "c"}, ks)
+
+	ks, vs := iterAll(s.Load().NewIter())
+	ta.Equal([]string{"a", "b3", "c"}, ks)
+	ta.Equal([]interface{}{"a", "3", "c"}, vs)
+}
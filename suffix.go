@@ -0,0 +1,186 @@
+package trie
+
+import (
+	"bytes"
+	"sort"
+)
+
+// SuffixTrie is a squashed trie of all suffixes of a text.
+//
+// Every leaf holds the start position of the suffix it represents.
+// Building it takes O(n²) time and temporary space for a text of n bytes.
+//
+// Since 0.2.0
+type SuffixTrie struct {
+	*Node
+
+	// Text is the indexed text.
+	//
+	// Since 0.2.0
+	Text []byte
+}
+
+// NewSuffixTrie builds a SuffixTrie of `text`.
+//
+// Since 0.2.0
+func NewSuffixTrie(text []byte) (*SuffixTrie, error) {
+
+	sfxs := sortedSuffixes(text)
+
+	positions := make([]int, len(sfxs))
+	for i, s := range sfxs {
+		positions[i] = len(text) - len(s)
+	}
+
+	root, err := NewTrie(sfxs, positions, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SuffixTrie{Node: root, Text: text}, nil
+}
+
+// LongestRepeatedSubstring returns the longest substring that occurs at least
+// twice in the text. Occurrences may overlap.
+//
+// If there are several of them, the lexicographically smallest one is
+// returned. It returns an empty slice if no byte repeats.
+//
+// Since 0.2.0
+func (st *SuffixTrie) LongestRepeatedSubstring() []byte {
+
+	bestDepth := 0
+	var best *Node
+
+	var walk func(n *Node, parentIdx int)
+	walk = func(n *Node, parentIdx int) {
+
+		if len(n.Branches) == 0 {
+			return
+		}
+
+		// idx is the number of bytes shared by all suffixes below n.
+		idx := parentIdx + int(n.Step)
+		if len(n.Branches) >= 2 && idx > bestDepth {
+			bestDepth = idx
+			best = n
+		}
+
+		for _, br := range n.Branches {
+			walk(n.Children[br], idx)
+		}
+	}
+	walk(st.Node, -1)
+
+	if best == nil {
+		return []byte{}
+	}
+
+	pos := best.leftMost().Value.(int)
+	return st.Text[pos : pos+bestDepth]
+}
+
+// LongestCommonSubstring returns the longest substring of both `a` and `b`.
+//
+// If there are several of them, the lexicographically smallest one is
+// returned. It returns an empty slice if `a` and `b` share no byte.
+//
+// Since 0.2.0
+func LongestCommonSubstring(a, b []byte) []byte {
+
+	sa := sortedSuffixes(a)
+	sb := sortedSuffixes(b)
+
+	// Merge the two sorted suffix lists. A suffix shared by `a` and `b` is
+	// stored once. src records where a suffix comes from: 1 for `a`, 2 for `b`.
+	keys := make([][]byte, 0, len(sa)+len(sb))
+	src := make([]int, 0, len(sa)+len(sb))
+
+	i, j := 0, 0
+	for i < len(sa) || j < len(sb) {
+		var c int
+		switch {
+		case i == len(sa):
+			c = 1
+		case j == len(sb):
+			c = -1
+		default:
+			c = bytes.Compare(sa[i], sb[j])
+		}
+
+		switch {
+		case c < 0:
+			keys = append(keys, sa[i])
+			src = append(src, 1)
+			i++
+		case c > 0:
+			keys = append(keys, sb[j])
+			src = append(src, 2)
+			j++
+		default:
+			keys = append(keys, sa[i])
+			src = append(src, 3)
+			i++
+			j++
+		}
+	}
+
+	if len(keys) == 0 {
+		return []byte{}
+	}
+
+	indexes := make([]int, len(keys))
+	for i := range indexes {
+		indexes[i] = i
+	}
+
+	root, err := NewTrie(keys, indexes, true)
+	if err != nil {
+		// keys are sorted and unique.
+		panic(err)
+	}
+
+	bestDepth := 0
+	var best []byte
+
+	// walk returns the sources of all suffixes below n.
+	var walk func(n *Node, parentIdx int) int
+	walk = func(n *Node, parentIdx int) int {
+
+		if len(n.Branches) == 0 {
+			return src[n.Value.(int)]
+		}
+
+		idx := parentIdx + int(n.Step)
+
+		s := 0
+		for _, br := range n.Branches {
+			s |= walk(n.Children[br], idx)
+		}
+
+		if s == 3 && idx > bestDepth {
+			bestDepth = idx
+			best = keys[n.leftMost().Value.(int)][:idx]
+		}
+		return s
+	}
+	walk(root, -1)
+
+	if best == nil {
+		return []byte{}
+	}
+	return best
+}
+
+// sortedSuffixes returns all non-empty suffixes of `text` in ascending order.
+func sortedSuffixes(text []byte) [][]byte {
+
+	sfxs := make([][]byte, len(text))
+	for i := range text {
+		sfxs[i] = text[i:]
+	}
+	sort.Slice(sfxs, func(i, j int) bool {
+		return bytes.Compare(sfxs[i], sfxs[j]) < 0
+	})
+	return sfxs
+}
@@ -0,0 +1,64 @@
+package trie
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuffixTrie_LongestRepeatedSubstring(t *testing.T) {
+
+	ta := require.New(t)
+
+	cases := []struct {
+		text string
+		want string
+	}{
+		{"", ""},
+		{"a", ""},
+		{"abc", ""},
+		{"aa", "a"},
+		{"aaa", "aa"},
+		{"banana", "ana"},
+		{"abcabc", "abc"},
+		{"abcxabdyab", "ab"},
+		{"xbcyabc", "bc"},
+		{"mississippi", "issi"},
+	}
+
+	for i, c := range cases {
+		st, err := NewSuffixTrie([]byte(c.text))
+		ta.Nil(err)
+
+		got := st.LongestRepeatedSubstring()
+		ta.Equal(c.want, string(got), "%d-th: text: %q", i+1, c.text)
+	}
+}
+
+func TestLongestCommonSubstring(t *testing.T) {
+
+	ta := require.New(t)
+
+	cases := []struct {
+		a, b string
+		want string
+	}{
+		{"", "", ""},
+		{"abc", "", ""},
+		{"abc", "xyz", ""},
+		{"abc", "abc", "abc"},
+		{"xab", "ab", "ab"},
+		{"abcdef", "zcdemn", "cde"},
+		{"aaa", "a", "a"},
+		{"xyzabc", "abcxyz", "abc"},
+		{"banana", "ananas", "anana"},
+	}
+
+	for i, c := range cases {
+		got := LongestCommonSubstring([]byte(c.a), []byte(c.b))
+		ta.Equal(c.want, string(got), "%d-th: a: %q, b: %q", i+1, c.a, c.b)
+
+		got = LongestCommonSubstring([]byte(c.b), []byte(c.a))
+		ta.Equal(c.want, string(got), "%d-th: swapped: a: %q, b: %q", i+1, c.b, c.a)
+	}
+}
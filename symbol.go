@@ -0,0 +1,306 @@
+//go:build go1.18
+// +build go1.18
+
+package trie
+
+import (
+	"github.com/openacid/errors"
+)
+
+// Symbol is the type of the elements of keys of a SymbolTrie, e.g., rune for
+// keys of code points or int for keys of token IDs. Symbols must not be
+// negative.
+//
+// Since 0.2.0
+type Symbol interface {
+	~uint8 | ~uint16 | ~int8 | ~int16 | ~int32 | ~int
+}
+
+// SymbolTrie is a trie of keys of symbols, such as []rune or []uint16,
+// instead of bytes. A branch is labeled with a symbol, thus keys are indexed
+// without being encoded into bytes, and are ordered symbol by symbol.
+//
+// It is built on the same nodes as a trie of NewTrie, and is squashed the
+// same way: in a squashed SymbolTrie, the symbols removed by squashing are
+// not compared by Search and Get, and keys yielded by Range contain only the
+// symbols at which their paths branch.
+//
+// Options of NewTrie, which are about byte keys, do not apply.
+//
+// Since 0.2.0
+type SymbolTrie[S Symbol] struct {
+	root *Node
+}
+
+// NewSymbolTrie creates a SymbolTrie from a serial of ascendingly ordered
+// keys and corresponding values, the same as NewTrie.
+//
+// `values` must be a slice, or it returns a *ValuesNotSliceError.
+//
+// Since 0.2.0
+func NewSymbolTrie[S Symbol](keys [][]S, values interface{}, squash bool) (*SymbolTrie[S], error) {
+
+	root := newRoot(squash, nil)
+
+	t := &SymbolTrie[S]{root: root}
+	if keys == nil {
+		return t, nil
+	}
+
+	valSlice, err := toSlice(values)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) != len(valSlice) {
+		return nil, ErrKVLenNotMatch
+	}
+
+	for i, key := range keys {
+		if err := t.Append(key, valSlice[i]); err != nil {
+			return nil, errors.Wrapf(err, "trie failed to add kvs")
+		}
+	}
+
+	if squash {
+		root.Squash()
+	}
+	return t, nil
+}
+
+// symbolBranches returns the branch labels of `key`.
+func symbolBranches[S Symbol](key []S) ([]int, error) {
+
+	brs := make([]int, len(key))
+	for i, s := range key {
+		if s < 0 {
+			return nil, errors.Wrapf(ErrNegativeSymbol, "symbol %d at %d", s, i)
+		}
+		brs[i] = int(s)
+	}
+	return brs, nil
+}
+
+// Append adds a key-value pair, the same as Node.Append: `key` must be
+// greater than any key in the trie, and the trie squashes the preceding
+// branches if it is created with `squash`.
+//
+// Since 0.2.0
+func (t *SymbolTrie[S]) Append(key []S, value interface{}) error {
+
+	r := t.root
+	if r.Step > 1 {
+		return errors.Wrapf(ErrSquashed, "append %v", key)
+	}
+
+	brs, err := symbolBranches(key)
+	if err != nil {
+		return err
+	}
+
+	path := []*Node{r}
+	node := r
+	j := 0
+	for ; j < len(brs); j++ {
+		br := brs[j]
+
+		l := len(node.Branches)
+		if l > 0 && node.Branches[l-1] > br {
+			return errors.Wrapf(ErrKeyOutOfOrder, "append %v", key)
+		}
+
+		child := node.Children[br]
+		if child == nil {
+			break
+		}
+		if child.Step > 1 {
+			return errors.Wrapf(ErrSquashed, "append %v", key)
+		}
+		node = child
+		path = append(path, node)
+	}
+
+	if j == len(brs) {
+		if node.Children[leafBranch] != nil {
+			return errors.Wrapf(ErrDuplicateKeys, "append %v", key)
+		}
+		if len(node.Branches) != 0 {
+			return errors.Wrapf(ErrKeyOutOfOrder, "append %v is a prefix", key)
+		}
+	}
+
+	var ltNode *Node
+	if l := len(node.Branches); l > 0 {
+		ltNode = node.Children[node.Branches[l-1]]
+	}
+
+	for _, n := range path {
+		n.keyCnt++
+	}
+
+	for _, br := range brs[j:] {
+		n := &Node{Children: make(map[int]*Node), Step: 1, squash: r.squash, keyCnt: 1}
+		node.Children[br] = n
+		node.Branches = append(node.Branches, br)
+		node = n
+		r.InnerNodeCnt++
+	}
+
+	node.Children[leafBranch] = &Node{Value: value}
+	node.Branches = append(node.Branches, leafBranch)
+	r.addCounts(1, 0)
+
+	if r.squash && ltNode != nil {
+		ltNode.squashSubtree(r, nil)
+	}
+	return nil
+}
+
+// Search returns the values of the greatest key less than `key`, of `key` and
+// of the least key greater than `key`, the same as Node.Search. Any of them
+// could be nil.
+//
+// Since 0.2.0
+func (t *SymbolTrie[S]) Search(key []S) (ltValue, eqValue, gtValue interface{}) {
+
+	var ltNode, gtNode *Node
+	eqNode := t.root
+
+	for i := -1; ; {
+		i += int(eqNode.Step)
+
+		if len(key) < i {
+			gtNode = eqNode
+			eqNode = nil
+			break
+		}
+
+		br := leafBranch
+		if len(key) > i {
+			if key[i] < 0 {
+				// Less than any symbol in the trie.
+				gtNode = eqNode
+				eqNode = nil
+				break
+			}
+			br = int(key[i])
+		}
+
+		li, ri := neighborBranches(eqNode.Branches, br)
+		if li >= 0 {
+			ltNode = eqNode.Children[eqNode.Branches[li]]
+		}
+		if ri >= 0 {
+			gtNode = eqNode.Children[eqNode.Branches[ri]]
+		}
+
+		eqNode = eqNode.Children[br]
+		if eqNode == nil || br == leafBranch {
+			break
+		}
+	}
+
+	if ltNode != nil {
+		ltValue = ltNode.rightMost().Value
+	}
+	if eqNode != nil {
+		eqValue = eqNode.Value
+	}
+	if gtNode != nil {
+		gtValue = gtNode.leftMost().Value
+	}
+	return
+}
+
+// Get returns the value of `key` and whether it is in the trie.
+//
+// Since 0.2.0
+func (t *SymbolTrie[S]) Get(key []S) (interface{}, bool) {
+
+	node := t.root
+	for i := -1; ; {
+		i += int(node.Step)
+		if len(key) < i {
+			return nil, false
+		}
+
+		br := leafBranch
+		if len(key) > i {
+			if key[i] < 0 {
+				return nil, false
+			}
+			br = int(key[i])
+		}
+
+		child := node.Children[br]
+		if child == nil {
+			return nil, false
+		}
+		if br == leafBranch {
+			return child.Value, true
+		}
+		node = child
+	}
+}
+
+// Squash removes nodes with a single branch, the same as Node.Squash, and
+// returns the number of nodes removed.
+//
+// Since 0.2.0
+func (t *SymbolTrie[S]) Squash() int {
+	return t.root.Squash()
+}
+
+// Len returns the number of keys in the trie.
+//
+// Since 0.2.0
+func (t *SymbolTrie[S]) Len() int {
+	return t.root.KeyCnt()
+}
+
+// Counters returns the counters of the trie, see Node.Counters.
+//
+// Since 0.2.0
+func (t *SymbolTrie[S]) Counters() Counters {
+	return t.root.Counters()
+}
+
+// Range calls `fn` with every key and its value in ascending key order, until
+// `fn` returns false. Keys are rebuilt from branch labels, and must not be
+// modified or retained by `fn`.
+//
+// It does not recurse, thus it does not overflow the stack on deep tries.
+//
+// Since 0.2.0
+func (t *SymbolTrie[S]) Range(fn func(key []S, v interface{}) bool) {
+
+	type frame struct {
+		n *Node
+		// keyLen is the key length of n, of which the last symbol is label.
+		keyLen int
+		label  S
+	}
+
+	var key []S
+	stack := []frame{{n: t.root}}
+	for len(stack) > 0 {
+		f := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if f.keyLen > 0 {
+			// The parent has set key[:f.keyLen-1].
+			key = append(key[:f.keyLen-1], f.label)
+		}
+
+		brs := f.n.Branches
+		if len(brs) > 0 && brs[0] == leafBranch {
+			if !fn(key, f.n.Children[leafBranch].Value) {
+				return
+			}
+			brs = brs[1:]
+		}
+
+		for i := len(brs) - 1; i >= 0; i-- {
+			stack = append(stack, frame{n: f.n.Children[brs[i]], keyLen: f.keyLen + 1, label: S(brs[i])})
+		}
+	}
+}
@@ -0,0 +1,136 @@
+//go:build go1.18
+// +build go1.18
+
+package trie
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/openacid/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSymbolTrie(t *testing.T) {
+
+	for _, squash := range []bool{false, true} {
+		for seed := int64(0); seed < 5; seed++ {
+			testSymbolTrie(t, squash, seed)
+		}
+	}
+}
+
+func testSymbolTrie(t *testing.T, squash bool, seed int64) {
+
+	ta := require.New(t)
+	msg := fmt.Sprintf("squash=%v seed=%d", squash, seed)
+
+	rnd := rand.New(rand.NewSource(seed))
+	symbols := []rune{'a', 'é', '中', 0x1F600}
+	randKey := func() []rune {
+		k := make([]rune, rnd.Intn(5))
+		for i := range k {
+			k[i] = symbols[rnd.Intn(len(symbols))]
+		}
+		return k
+	}
+
+	uniq := map[string]bool{}
+	for i := 0; i < 30; i++ {
+		uniq[string(randKey())] = true
+	}
+	strs := []string{}
+	for k := range uniq {
+		strs = append(strs, k)
+	}
+	// Strings of runes sort by code points, the same as the keys do.
+	sort.Strings(strs)
+
+	keys := make([][]rune, len(strs))
+	for i, k := range strs {
+		keys[i] = []rune(k)
+	}
+
+	tr, err := NewSymbolTrie(keys, strs, squash)
+	ta.Nil(err)
+	ta.Equal(len(strs), tr.Len())
+
+	c := tr.Counters()
+	ta.Equal(len(strs), c.Leaves, msg)
+	ta.Nil(tr.root.Validate(), msg)
+
+	for i := 0; i < 100; i++ {
+		q := string(randKey())
+		if squash && !uniq[q] {
+			// Runes removed by squashing are not compared.
+			continue
+		}
+
+		want := bruteSearch(strs, q)
+		lt, eq, gt := tr.Search([]rune(q))
+		ta.Equal(want, []interface{}{lt, eq, gt}, "%s: search %q", msg, q)
+
+		v, found := tr.Get([]rune(q))
+		ta.Equal(uniq[q], found, "%s: get %q", msg, q)
+		if found {
+			ta.Equal(q, v)
+		}
+	}
+
+	got := []string{}
+	tr.Range(func(key []rune, v interface{}) bool {
+		if !squash {
+			ta.Equal(v, string(key))
+		}
+		got = append(got, v.(string))
+		return true
+	})
+	ta.Equal(strs, got, msg)
+}
+
+func TestSymbolTrie_tokens(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := [][]int{{1, 300}, {1, 300, 70000}, {2}, {256}}
+	tr, err := NewSymbolTrie(keys, []string{"a", "b", "c", "d"}, false)
+	ta.Nil(err)
+
+	lt, eq, gt := tr.Search([]int{1, 300, 5})
+	ta.Equal([]interface{}{"a", nil, "b"}, []interface{}{lt, eq, gt})
+
+	lt, eq, gt = tr.Search([]int{-1})
+	ta.Equal([]interface{}{nil, nil, "a"}, []interface{}{lt, eq, gt})
+
+	lt, eq, gt = tr.Search([]int{256})
+	ta.Equal([]interface{}{"c", "d", nil}, []interface{}{lt, eq, gt})
+
+	_, found := tr.Get([]int{1, -1})
+	ta.False(found)
+
+	n := 0
+	tr.Range(func(key []int, v interface{}) bool {
+		n++
+		return n < 2
+	})
+	ta.Equal(2, n)
+
+	ta.Equal(ErrKeyOutOfOrder, errors.Cause(tr.Append([]int{3}, "x")))
+	ta.Equal(ErrDuplicateKeys, errors.Cause(tr.Append([]int{256}, "x")))
+	ta.Equal(ErrNegativeSymbol, errors.Cause(tr.Append([]int{300, -2}, "x")))
+	ta.Nil(tr.Append([]int{300}, "e"))
+	ta.Nil(tr.Append([]int{300, 1, 2}, "f"))
+
+	ta.Equal(2, tr.Squash())
+	ta.Equal(ErrSquashed, errors.Cause(tr.Append([]int{300, 1, 3}, "x")))
+	v, found := tr.Get([]int{1, 300, 70000})
+	ta.True(found)
+	ta.Equal("b", v)
+
+	_, err = NewSymbolTrie([][]uint16{{2}, {1}}, []int{0, 1}, false)
+	ta.Equal(ErrKeyOutOfOrder, errors.Cause(err))
+	_, err = NewSymbolTrie([][]uint16{{2}, {1}}, []int{0}, false)
+	ta.Equal(ErrKVLenNotMatch, err)
+}
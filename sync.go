@@ -0,0 +1,160 @@
+package trie
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// SyncTrie is a trie safe for one writer and any number of concurrent readers.
+//
+// Readers never block: a writer does not modify any node reachable by readers.
+// Instead, Append copies the nodes on the path it modifies and publishes the
+// new root atomically. Readers see either the trie before or after an Append,
+// never a half-linked node.
+//
+// # Memory model
+//
+// The root is published with an atomic store and read with an atomic load.
+// All writes to a new version happen before the store that publishes it, thus
+// a reader that loads a version observes every node, branch and value in it
+// completely initialized.
+// Branches slices and Children maps of a published version are never written
+// again: a writer works on copies of them. A reader holding a version with
+// Load can keep using it for as long as it likes, concurrently with any
+// number of writes.
+//
+// Values are shared between versions and are not protected: a value that is
+// modified after being appended must be synchronized by the user.
+//
+// Since 0.2.0
+type SyncTrie struct {
+	// mu serializes writers.
+	mu   sync.Mutex
+	root atomic.Value
+
+	// loading are the keys being loaded by Get.
+	loadMu  sync.Mutex
+	loading map[string]*loadCall
+}
+
+// NewSyncTrie creates a SyncTrie with the same arguments as NewTrie.
+//
+// Since 0.2.0
+func NewSyncTrie(keys [][]byte, values interface{}, squash bool, opts ...Option) (*SyncTrie, error) {
+
+	root, err := NewTrie(keys, values, squash, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &SyncTrie{}
+	s.root.Store(root)
+	return s, nil
+}
+
+// Load returns the current version of the trie.
+// The returned trie must not be modified. It is not affected by following
+// writes and can be read without locking, e.g., iterated with NewIter.
+//
+// Since 0.2.0
+func (s *SyncTrie) Load() *Node {
+	return s.root.Load().(*Node)
+}
+
+// Search is the same as Node.Search on the current version.
+//
+// Since 0.2.0
+func (s *SyncTrie) Search(key []byte) (ltValue, eqValue, gtValue interface{}) {
+	return s.Load().Search(key)
+}
+
+// Append is the same as Node.Append except that it does not return the leaf
+// node, which is shared with readers and must not be modified.
+//
+// Since 0.2.0
+func (s *SyncTrie) Append(key []byte, value interface{}) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old := s.Load()
+	root := old.copyPath(old.normalize(key))
+
+	_, err := root.Append(key, value)
+	if err != nil {
+		return err
+	}
+
+	s.root.Store(root)
+	return nil
+}
+
+// copyPath returns a copy of r, in which every node Append(key) would modify,
+// including the leaf of an existing key, is copied and the others are shared
+// with r.
+func (r *Node) copyPath(key []byte) *Node {
+
+	root := r.copyNode()
+
+	node := root
+	j := 0
+	for ; j < len(key); j++ {
+		br := int(key[j])
+		child := node.Children[br]
+		if child == nil || child.Step > 1 {
+			break
+		}
+		child = child.copyNode()
+		node.Children[br] = child
+		node = child
+	}
+
+	if leaf := node.Children[leafBranch]; j == len(key) && leaf != nil {
+		// Append of an existing key revives a removed leaf or appends to
+		// its value, of which readers must not see the change.
+		node.Children[leafBranch] = leaf.copyNode()
+		return root
+	}
+
+	if !node.squash {
+		return root
+	}
+
+	// Append squashes the last branch of the node it adds a branch to.
+	// Only the right most path of it is not yet squashed thus would be
+	// modified.
+	for len(node.Branches) > 0 {
+		br := node.Branches[len(node.Branches)-1]
+		if br == leafBranch {
+			break
+		}
+		child := node.Children[br].copyNode()
+		node.Children[br] = child
+		node = child
+	}
+
+	return root
+}
+
+// copyNode returns a copy of r that shares no map or slice with r.
+// Children and Value are shared.
+func (r *Node) copyNode() *Node {
+
+	n := *r
+	n.counts = copyCounts(r.counts)
+	n.copyExt()
+
+	if r.Branches != nil {
+		n.Branches = make([]int, len(r.Branches), len(r.Branches)+1)
+		copy(n.Branches, r.Branches)
+	}
+
+	if r.Children != nil {
+		n.Children = make(map[int]*Node, len(r.Children)+1)
+		for b, c := range r.Children {
+			n.Children[b] = c
+		}
+	}
+
+	return &n
+}
@@ -0,0 +1,162 @@
+package trie
+
+import (
+	"encoding/binary"
+	"sync"
+	"testing"
+
+	"github.com/openacid/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncTrie(t *testing.T) {
+
+	ta := require.New(t)
+
+	for _, squash := range []bool{false, true} {
+
+		s, err := NewSyncTrie(nil, nil, squash)
+		ta.Nil(err)
+
+		ta.Nil(s.Append([]byte{1, 2}, 0))
+		ta.Nil(s.Append([]byte{1, 3}, 1))
+
+		before := s.Load()
+		beforeStr := before.String()
+
+		ta.Nil(s.Append([]byte{1, 3, 4}, 2))
+		ta.Nil(s.Append([]byte{2}, 3))
+
+		err = s.Append([]byte{1, 5}, 4)
+		ta.Equal(ErrKeyOutOfOrder, errors.Cause(err))
+
+		ta.Equal(beforeStr, before.String(), "old version is not modified")
+
+		_, eq, gt := before.Search([]byte{1, 3})
+		ta.Equal(1, eq)
+		ta.Nil(gt)
+
+		lt, eq, gt := s.Search([]byte{1, 3})
+		ta.Equal([]interface{}{0, 1, 2}, []interface{}{lt, eq, gt})
+
+		_, eq, _ = s.Search([]byte{2})
+		ta.Equal(3, eq)
+	}
+}
+
+func TestSyncTrie_concurrentRead(t *testing.T) {
+
+	ta := require.New(t)
+
+	s, err := NewSyncTrie(nil, nil, true)
+	ta.Nil(err)
+
+	n := 2000
+	key := func(i int) []byte {
+		k := make([]byte, 4)
+		binary.BigEndian.PutUint32(k, uint32(i*7))
+		return k
+	}
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				tr := s.Load()
+				it := tr.NewIter()
+				cnt := 0
+				for it.Next() {
+					if it.Value().(int) != cnt {
+						t.Errorf("expect value %d, got %v", cnt, it.Value())
+						return
+					}
+					cnt++
+				}
+
+				for i := 0; i < cnt; i++ {
+					if _, eq, _ := tr.Search(key(i)); eq != i {
+						t.Errorf("search %v: expect %d, got %v", key(i), i, eq)
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		ta.Nil(s.Append(key(i), i))
+	}
+	close(done)
+	wg.Wait()
+}
+
+func TestSyncTrie_Append_revive(t *testing.T) {
+
+	ta := require.New(t)
+
+	s, err := NewSyncTrie([][]byte{[]byte("a"), []byte("b")}, []int{1, 2}, false, WithLazyRemove())
+	ta.Nil(err)
+	ta.Nil(s.Delete([]byte("b")))
+
+	before := s.Load()
+	ta.Nil(s.Append([]byte("b"), 3))
+
+	_, eq, _ := before.Search([]byte("b"))
+	ta.Nil(eq, "old version is not modified")
+	ta.Equal(1, before.KeyCnt())
+
+	_, eq, _ = s.Search([]byte("b"))
+	ta.Equal(3, eq)
+	ta.Equal(2, s.Load().KeyCnt())
+}
+
+func TestSyncTrie_Append_multiValue(t *testing.T) {
+
+	ta := require.New(t)
+
+	s, err := NewSyncTrie([][]byte{[]byte("a")}, []int{1}, false, WithMultiValue(nil))
+	ta.Nil(err)
+
+	before := s.Load()
+	ta.Nil(s.Append([]byte("a"), 2))
+
+	_, eq, _ := before.Search([]byte("a"))
+	ta.Equal([]interface{}{1}, eq, "old version is not modified")
+
+	_, eq, _ = s.Search([]byte("a"))
+	ta.Equal([]interface{}{1, 2}, eq)
+}
+
+func TestNode_copyNode_ext(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr, err := NewTrie([][]byte{[]byte("a"), []byte("b")}, []int{1, 2}, false, WithStoreKeys(), WithCachedExtremes())
+	ta.Nil(err)
+
+	// Fields kept by options are not shared with the copy.
+	cp := tr.copyNode()
+	cp.inner().first = nil
+	ta.NotNil(tr.first())
+
+	leaf := tr.leafOf([]byte("a"))
+	lc := leaf.copyNode()
+	lc.leaf().key = []byte("x")
+	ta.Equal("a", string(leaf.key()))
+
+	// Leaves without options keep none.
+	plain, err := NewTrie([][]byte{[]byte("a")}, []int{1}, false)
+	ta.Nil(err)
+	ta.Nil(plain.innerExt)
+	ta.Nil(plain.leafOf([]byte("a")).leafExt)
+}
@@ -0,0 +1,174 @@
+package trie
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// Entry is a key and its value.
+//
+// Since 0.2.0
+type Entry struct {
+	Key   []byte
+	Value interface{}
+}
+
+// crockford32 is the alphabet of ULID.
+const crockford32 = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// WithTimeEncoding sets how Between encodes a time into the bytes following
+// the prefix of a key. The encoding must be ordered the same as time. It is
+// TimeUnixNano by default.
+//
+// Since 0.2.0
+func WithTimeEncoding(enc func(t time.Time) []byte) Option {
+	return func(o *options) {
+		o.timeEncoding = enc
+	}
+}
+
+// TimeUnixNano encodes `t` as 8 bytes of big-endian nanoseconds since the Unix
+// epoch. Times before the epoch are not ordered.
+//
+// Since 0.2.0
+func TimeUnixNano(t time.Time) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(t.UnixNano()))
+	return b
+}
+
+// TimeULID encodes `t` as the 10 characters timestamp part of a ULID: the
+// milliseconds since the Unix epoch in 48 bits of Crockford's base32.
+//
+// Since 0.2.0
+func TimeULID(t time.Time) []byte {
+
+	ms := uint64(t.UnixNano() / int64(time.Millisecond))
+
+	b := make([]byte, 10)
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i] = crockford32[ms&31]
+		ms >>= 5
+	}
+	return b
+}
+
+// Latest returns the last `n` keys starting with `prefix`, and their values,
+// in descending key order. With keys of a prefix followed by a timestamp, they
+// are the latest `n` ones. Only the nodes of the returned keys are visited.
+//
+// Keys are rebuilt the same way as Iter does. Same as Search, in a squashed
+// trie keys not starting with `prefix` may be included.
+//
+// Since 0.2.0
+func (r *Node) Latest(prefix []byte, n int) []Entry {
+
+	rst := []Entry{}
+
+	sub, key := r.subtree(r.normalize(prefix))
+	if sub == nil {
+		return rst
+	}
+
+	var walk func(node *Node, key []byte)
+	walk = func(node *Node, key []byte) {
+		for i := len(node.Branches) - 1; i >= 0 && len(rst) < n; i-- {
+			b := node.Branches[i]
+			child := node.Children[b]
+			if b == leafBranch {
+				if child.Value != removed {
+					rst = append(rst, Entry{Key: copyBytes(key), Value: child.Value})
+				}
+				continue
+			}
+			walk(child, append(key, byte(b)))
+		}
+	}
+	walk(sub, key)
+
+	return rst
+}
+
+// Between returns the keys of `prefix` followed by a time in [t1, t2), and
+// their values, in ascending key order. Times are encoded with the encoding set
+// by WithTimeEncoding. Nodes of keys out of the window are not visited.
+//
+// Keys are rebuilt the same way as Iter does. Same as Search, in a squashed
+// trie the bytes removed by squashing are not compared.
+//
+// Since 0.2.0
+func (r *Node) Between(prefix []byte, t1, t2 time.Time) []Entry {
+
+	enc := TimeUnixNano
+	if r.opt != nil && r.opt.timeEncoding != nil {
+		enc = r.opt.timeEncoding
+	}
+
+	lo := append(append([]byte{}, prefix...), enc(t1)...)
+	hi := append(append([]byte{}, prefix...), enc(t2)...)
+
+	rst := []Entry{}
+	r.scanRange(r.normalize(lo), r.normalize(hi), func(key []byte, leaf *Node) bool {
+		rst = append(rst, Entry{Key: copyBytes(key), Value: leaf.Value})
+		return true
+	})
+	return rst
+}
+
+// scanRange calls `fn` with every key in [lo, hi) and its leaf, in ascending
+// key order, until `fn` returns false. A nil `hi` means no upper bound. Only
+// nodes on the paths of `lo` and `hi` and nodes in between are visited.
+func (r *Node) scanRange(lo, hi []byte, fn func(key []byte, leaf *Node) bool) {
+
+	order := r.byteOrder()
+	stopped := false
+
+	var walk func(node *Node, i int, key []byte, hasLo, hasHi bool)
+	walk = func(node *Node, i int, key []byte, hasLo, hasHi bool) {
+
+		i += int(node.Step)
+
+		loBr := leafBranch
+		if hasLo {
+			if len(lo) > i {
+				loBr = int(lo[i])
+			} else {
+				// Every key of node starts with lo.
+				hasLo = false
+			}
+		}
+
+		hiBr := leafBranch
+		if hasHi {
+			if len(hi) <= i {
+				// Every key of node starts with hi.
+				return
+			}
+			hiBr = int(hi[i])
+		}
+
+		for _, b := range node.Branches {
+
+			if stopped {
+				return
+			}
+			if hasLo && order.rank(b) < order.rank(loBr) {
+				continue
+			}
+			if hasHi && order.rank(b) > order.rank(hiBr) {
+				return
+			}
+
+			child := node.Children[b]
+			if b == leafBranch {
+				if child.Value != removed {
+					stopped = !fn(key, child)
+				}
+				continue
+			}
+
+			walk(child, i, append(key, byte(b)), hasLo && b == loBr, hasHi && b == hiBr)
+		}
+	}
+	walk(r, -1, []byte{}, true, hi != nil)
+}
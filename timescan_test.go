@@ -0,0 +1,103 @@
+package trie
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeULID(t *testing.T) {
+
+	ta := require.New(t)
+
+	tm := time.Unix(0, 1469922850259*int64(time.Millisecond))
+	ta.Equal("01ARZ3NDEK", string(TimeULID(tm)))
+	ta.True(bytes.Compare(TimeULID(tm), TimeULID(tm.Add(time.Millisecond))) < 0)
+	ta.True(bytes.Compare(TimeUnixNano(tm), TimeUnixNano(tm.Add(1))) < 0)
+}
+
+// newEventTrie creates a trie of keys of a prefix, a time and an id byte,
+// with the offset of the time in seconds as values.
+func newEventTrie(ta *require.Assertions, base time.Time, enc func(time.Time) []byte, squash bool, opts ...Option) *Node {
+
+	tr, err := NewTrie(nil, nil, squash, opts...)
+	ta.Nil(err)
+
+	for _, prefix := range []string{"a/", "b/"} {
+		for sec := 0; sec < 10; sec++ {
+			k := append([]byte(prefix), enc(base.Add(time.Duration(sec)*time.Second))...)
+			_, err = tr.Append(append(k, 'x'), sec)
+			ta.Nil(err)
+		}
+	}
+	return tr
+}
+
+func TestNode_Latest(t *testing.T) {
+
+	ta := require.New(t)
+
+	base := time.Unix(1600000000, 0)
+
+	for _, squash := range []bool{false, true} {
+		tr := newEventTrie(ta, base, TimeUnixNano, squash)
+
+		got := []interface{}{}
+		for _, e := range tr.Latest([]byte("a/"), 3) {
+			got = append(got, e.Value)
+		}
+		ta.Equal([]interface{}{9, 8, 7}, got, "squash: %v", squash)
+
+		ta.Equal(10, len(tr.Latest([]byte("b/"), 100)))
+		ta.Equal(0, len(tr.Latest([]byte("c/"), 3)))
+		ta.Equal(0, len(tr.Latest([]byte("a/"), 0)))
+	}
+
+	tr := newEventTrie(ta, base, TimeUnixNano, false)
+	rst := tr.Latest([]byte("b/"), 1)
+	ta.Equal(append(append([]byte("b/"), TimeUnixNano(base.Add(9*time.Second))...), 'x'), rst[0].Key)
+}
+
+func TestNode_Between(t *testing.T) {
+
+	ta := require.New(t)
+
+	base := time.Unix(1600000000, 0)
+	at := func(sec int) time.Time { return base.Add(time.Duration(sec) * time.Second) }
+
+	cases := []struct {
+		prefix string
+		t1, t2 int
+		want   []interface{}
+	}{
+		{"a/", 2, 5, []interface{}{2, 3, 4}},
+		{"b/", 0, 10, []interface{}{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}},
+		{"b/", -5, 1, []interface{}{0}},
+		{"a/", 5, 5, []interface{}{}},
+		{"c/", 0, 10, []interface{}{}},
+	}
+
+	encs := []struct {
+		enc  func(time.Time) []byte
+		opts []Option
+	}{
+		{TimeUnixNano, nil},
+		{TimeULID, []Option{WithTimeEncoding(TimeULID)}},
+	}
+
+	for _, e := range encs {
+		for _, squash := range []bool{false, true} {
+			tr := newEventTrie(ta, base, e.enc, squash, e.opts...)
+
+			for i, c := range cases {
+				got := []interface{}{}
+				for _, ent := range tr.Between([]byte(c.prefix), at(c.t1), at(c.t2)) {
+					got = append(got, ent.Value)
+				}
+				ta.Equal(c.want, got, "%d-th: squash: %v", i+1, squash)
+			}
+		}
+	}
+}
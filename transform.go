@@ -0,0 +1,172 @@
+package trie
+
+// MapValues replaces every value in the trie with `fn(key, value)`, in one
+// traversal in ascending key order. Keys are rebuilt the same way as Iter
+// does, and must not be modified or retained by `fn`.
+//
+// To produce a new trie instead, call it on a Clone.
+//
+// A trie with nodes shared by WithHashConsing can not be mapped, because a
+// leaf may belong to several keys; it returns ErrReadOnly.
+//
+// Since 0.2.0
+func (r *Node) MapValues(fn func(key []byte, v interface{}) interface{}) error {
+
+	if r.readOnly() {
+		return ErrReadOnly
+	}
+
+	r.eachLeaf([]byte{}, func(key []byte, leaf *Node) {
+		leaf.Value = fn(key, leaf.Value)
+	})
+	r.reaggregate(r)
+
+	return nil
+}
+
+// eachLeaf calls `fn` with every leaf not removed in the subtree of r, in
+// ascending key order. `key` is the key rebuilt up to r; it is extended in
+// place and passed to `fn`.
+func (r *Node) eachLeaf(key []byte, fn func(key []byte, leaf *Node)) {
+
+	var walk func(n *Node, key []byte) []byte
+	walk = func(n *Node, key []byte) []byte {
+		for _, b := range n.Branches {
+			child := n.Children[b]
+			if b == leafBranch {
+				if child.Value != removed {
+					fn(key, child)
+				}
+				continue
+			}
+			key = walk(child, append(key, byte(b)))
+			key = key[:len(key)-1]
+		}
+		return key
+	}
+	walk(r, key)
+}
+
+// subtree returns the node under which all keys start with `prefix`, and the
+// key rebuilt up to the node. It returns nil if no key starts with `prefix`.
+//
+// Same as Search, in a squashed trie the bytes removed by squashing are not
+// checked, thus the node may have keys not starting with `prefix`.
+func (r *Node) subtree(prefix []byte) (*Node, []byte) {
+
+	key := []byte{}
+	node := r
+	for i := -1; ; {
+		i += int(node.Step)
+		if len(prefix) <= i {
+			return node, key
+		}
+
+		br := int(prefix[i])
+		child := node.Children[br]
+		if child == nil {
+			return nil, nil
+		}
+		key = append(key, byte(br))
+		node = child
+	}
+}
+
+// Filter returns a new trie of the keys and values for which `pred` returns
+// true. Keys are rebuilt the same way as Iter does, and must not be modified
+// or retained by `pred`.
+//
+// The new trie squashes if r does, and has the same options. Subtrees in
+// which all keys are kept are shared with r, thus r must not be modified
+// while the new trie is in use; Clone r first if it is. Nodes on the right
+// most path of the new trie are not shared, so that Append to it does not
+// affect r.
+//
+// Since 0.2.0
+func (r *Node) Filter(pred func(key []byte, v interface{}) bool) *Node {
+
+	var filter func(n *Node, key []byte, isRoot bool) *Node
+	filter = func(n *Node, key []byte, isRoot bool) *Node {
+
+		kept := make([]int, 0, len(n.Branches))
+		children := make([]*Node, 0, len(n.Branches))
+		changed := false
+
+		for _, b := range n.Branches {
+			child := n.Children[b]
+
+			var c *Node
+			if b == leafBranch {
+				if child.Value != removed && pred(key, child.Value) {
+					c = child
+				}
+			} else {
+				c = filter(child, withLabel(key, b), false)
+			}
+
+			if c != child {
+				changed = true
+			}
+			if c != nil {
+				kept = append(kept, b)
+				children = append(children, c)
+			}
+		}
+
+		if !changed {
+			return n
+		}
+		if len(kept) == 0 {
+			return nil
+		}
+
+		if n.squash && !isRoot && len(kept) == 1 && kept[0] != leafBranch && mergeable(n, children[0]) {
+			// Squash the node with its only child.
+			c := *children[0]
+			c.Step += n.Step
+			return &c
+		}
+
+		nn := &Node{
+			Children: make(map[int]*Node, len(kept)),
+			Branches: kept,
+			Step:     n.Step,
+			squash:   n.squash,
+		}
+		for i, b := range kept {
+			nn.Children[b] = children[i]
+			nn.keyCnt += children[i].KeyCnt()
+		}
+		if m := r.monoid(); m != nil {
+			nn.inner().agg = m.combineChildren(nn)
+		}
+		if r.cachedExtremes() {
+			nn.setExtremes()
+		}
+		return nn
+	}
+
+	root := filter(r, []byte{}, true)
+	if root == nil {
+		root = &Node{Children: make(map[int]*Node), Branches: []int{}, Step: r.Step, squash: r.squash}
+		if m := r.monoid(); m != nil {
+			root.inner().agg = m.Identity
+		}
+	}
+
+	// Unshare the right most path, which Append modifies.
+	root = root.copyNode()
+	for n := root; len(n.Branches) > 0; {
+		br := n.Branches[len(n.Branches)-1]
+		if br == leafBranch {
+			break
+		}
+		child := n.Children[br].copyNode()
+		n.Children[br] = child
+		n = child
+	}
+
+	root.opt = r.opt
+	root.setCounts()
+	return root
+}
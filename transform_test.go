@@ -0,0 +1,94 @@
+package trie
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNode_MapValues(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr := newStrTrie(ta, false, "", "a", "ab", "b")
+	tr.Remove([]byte("a"))
+
+	seen := []string{}
+	err := tr.MapValues(func(key []byte, v interface{}) interface{} {
+		seen = append(seen, string(key))
+		return string(key) + "=" + v.(string)
+	})
+	ta.Nil(err)
+	ta.Equal([]string{"", "ab", "b"}, seen)
+
+	_, vs := iterAll(tr.NewIter())
+	ta.Equal([]interface{}{"=", "ab=ab", "b=b"}, vs)
+
+	// A new trie from a Clone.
+	c := tr.Clone()
+	ta.Nil(c.MapValues(func(key []byte, v interface{}) interface{} { return len(v.(string)) }))
+	_, vs = iterAll(c.NewIter())
+	ta.Equal([]interface{}{1, 5, 3}, vs)
+	_, vs = iterAll(tr.NewIter())
+	ta.Equal([]interface{}{"=", "ab=ab", "b=b"}, vs)
+
+	shared, err := NewTrie([][]byte{[]byte("a"), []byte("b")}, []int{1, 1}, false, WithHashConsing())
+	ta.Nil(err)
+	ta.Equal(ErrReadOnly, shared.MapValues(func(key []byte, v interface{}) interface{} { return v }))
+}
+
+func TestNode_Filter(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := []string{"a", "ab", "abc", "abd", "b", "bc", "bcd", "c"}
+
+	isOdd := func(key []byte, v interface{}) bool {
+		return len(v.(string))%2 == 1
+	}
+
+	for _, squash := range []bool{false, true} {
+
+		tr := newStrTrie(ta, squash, keys...)
+		before := tr.String()
+
+		f := tr.Filter(isOdd)
+
+		ks, vs := iterAll(f.NewIter())
+		wantKeys, wantValues := iterAll(newStrTrie(ta, squash, "a", "abc", "abd", "b", "bcd", "c").NewIter())
+		ta.Equal(wantKeys, ks, "squash: %v", squash)
+		ta.Equal(wantValues, vs, "squash: %v", squash)
+
+		for _, k := range keys {
+			_, eq, _ := f.Search([]byte(k))
+			if len(k)%2 == 1 {
+				ta.Equal(k, eq, "squash: %v, key: %q", squash, k)
+			}
+		}
+		ta.Equal(before, tr.String(), "r is not modified")
+		ta.Equal(f.innerNodeCnt(), f.InnerNodeCnt)
+
+		// Append to the new trie does not affect r.
+		_, err := f.Append([]byte("d"), "d")
+		ta.Nil(err)
+		ta.Equal(before, tr.String())
+
+		all := tr.Filter(func(key []byte, v interface{}) bool { return true })
+		ta.Equal(before, all.String())
+		ta.True(all.Children['a'] == tr.Children['a'], "unchanged subtree is shared")
+
+		none := tr.Filter(func(key []byte, v interface{}) bool { return false })
+		ta.False(none.NewIter().Next())
+		_, err = none.Append([]byte("x"), "x")
+		ta.Nil(err)
+	}
+
+	// Squashed after filtering.
+	tr := newStrTrie(ta, true, "a", "xa", "xyz1", "xyz2")
+	f := tr.Filter(func(key []byte, v interface{}) bool { return v.(string) != "xa" })
+	ta.True(f.Children['x'].Step > 1, "x has only one branch left")
+	for _, k := range []string{"a", "xyz1", "xyz2"} {
+		_, eq, _ := f.Search([]byte(k))
+		ta.Equal(k, eq)
+	}
+}
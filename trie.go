@@ -4,6 +4,12 @@
 package trie
 
 import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"time"
+
 	"github.com/openacid/errors"
 	"github.com/openacid/low/tree"
 	"github.com/openacid/low/typehelper"
@@ -37,80 +43,606 @@ type Node struct {
 	// squash indicates whether to remove nodes with only one child.
 	squash bool
 
+	// shrunk is set once an inner node loses a branch, until Compact
+	// reallocates it.
+	shrunk bool
+
+	// index is the index of the key of a leaf of NewIndexTrie. It fits in the
+	// padding after the bools, thus costs no space.
+	index int32
+
 	// InnerNodeCnt records the number of outgoing branches to inner nodes.
+	// It is kept by the root, see Counters.
 	InnerNodeCnt int
+
+	// opt is the options a trie is created with. Only the root node has it.
+	opt *options
+
+	// version is the version of the value of a leaf, see KeyVersion. Every
+	// leaf has one, thus it is not in leafExt.
+	version uint64
+
+	// keyCnt is the number of keys in the subtree of an inner node, see
+	// KeyCnt.
+	keyCnt int
+
+	// leafExt and innerExt are the fields only some options keep, of a leaf
+	// and of an inner node. They are nil until one is set.
+	leafExt  *leafExt
+	innerExt *innerExt
+
+	// counts is the counters the root keeps besides InnerNodeCnt, see
+	// Counters.
+	counts *nodeCounts
+}
+
+// leafExt is the fields of a leaf kept by options, out of Node to keep
+// the leaves without them small.
+type leafExt struct {
+
+	// key is the whole key of a leaf, see WithStoreKeys.
+	key []byte
+
+	// expiry is when a leaf set with SetTTL expires, in Unix nanoseconds. It
+	// is 0 if the leaf does not expire.
+	expiry int64
+
+	// history is the history of the value of a leaf, see WithHistory.
+	history *leafHistory
+}
+
+// innerExt is the fields of an inner node kept by options, out of Node to
+// keep the inner nodes without them small.
+type innerExt struct {
+
+	// agg is the aggregate of the values in the subtree of an inner node, see
+	// WithAggregate.
+	agg interface{}
+
+	// first and last are the first and last leaves in the subtree of an inner
+	// node, see WithCachedExtremes.
+	first, last *Node
+}
+
+// leaf returns the leafExt of r, allocating it if it is nil.
+func (r *Node) leaf() *leafExt {
+	if r.leafExt == nil {
+		r.leafExt = &leafExt{}
+	}
+	return r.leafExt
+}
+
+// inner returns the innerExt of r, allocating it if it is nil.
+func (r *Node) inner() *innerExt {
+	if r.innerExt == nil {
+		r.innerExt = &innerExt{}
+	}
+	return r.innerExt
+}
+
+// copyExt makes r keep copies of the leafExt and innerExt it shares with the
+// node it is copied from.
+func (r *Node) copyExt() {
+	if r.leafExt != nil {
+		e := *r.leafExt
+		r.leafExt = &e
+	}
+	if r.innerExt != nil {
+		e := *r.innerExt
+		r.innerExt = &e
+	}
+}
+
+// key returns the whole key of a leaf, see WithStoreKeys.
+func (r *Node) key() []byte {
+	if r.leafExt == nil {
+		return nil
+	}
+	return r.leafExt.key
+}
+
+// history returns the history of the value of a leaf, see WithHistory.
+func (r *Node) history() *leafHistory {
+	if r.leafExt == nil {
+		return nil
+	}
+	return r.leafExt.history
+}
+
+// expiry returns when a leaf expires, see leafExt.
+func (r *Node) expiry() int64 {
+	if r.leafExt == nil {
+		return 0
+	}
+	return r.leafExt.expiry
+}
+
+// agg returns the aggregate of an inner node, see WithAggregate.
+func (r *Node) agg() interface{} {
+	if r.innerExt == nil {
+		return nil
+	}
+	return r.innerExt.agg
+}
+
+// first returns the cached first leaf of an inner node, see
+// WithCachedExtremes.
+func (r *Node) first() *Node {
+	if r.innerExt == nil {
+		return nil
+	}
+	return r.innerExt.first
+}
+
+// last returns the cached last leaf of an inner node, see
+// WithCachedExtremes.
+func (r *Node) last() *Node {
+	if r.innerExt == nil {
+		return nil
+	}
+	return r.innerExt.last
 }
 
 const leafBranch = -1
 
 // NewTrie creates a trie from a serial of ascendingly ordered keys and corresponding values.
 //
-// `values` must be a slice, or it panic.
+// `values` must be a slice, or it returns a *ValuesNotSliceError.
 // if `squash` is `true`, indicate this trie to squash preceding branches every time after Append a new
 // key.
 //
 // Since 0.1.0
-func NewTrie(keys [][]byte, values interface{}, squash bool) (root *Node, err error) {
+func NewTrie(keys [][]byte, values interface{}, squash bool, opts ...Option) (root *Node, err error) {
 
-	root = &Node{Children: make(map[int]*Node), Step: 1, squash: squash, InnerNodeCnt: 1}
+	if keys == nil {
+		return newTrie(nil, nil, 0, nil, squash, opts)
+	}
+
+	valSlice, err := toSlice(values)
+	if err != nil {
+		return nil, err
+	}
+	return newTrie(nil, keys, len(valSlice), func(i int) interface{} { return valSlice[i] }, squash, opts)
+}
+
+// ValuesNotSliceError is returned by NewTrie and the constructors built on it
+// if `values` is not a slice.
+// Its Cause is ErrValuesNotSlice.
+//
+// Since 0.2.0
+type ValuesNotSliceError struct {
+	// Kind is the kind of `values`, reflect.Invalid if it is nil.
+	Kind reflect.Kind
+}
+
+// Error implements error.
+//
+// Since 0.2.0
+func (e *ValuesNotSliceError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrValuesNotSlice, e.Kind)
+}
+
+// Cause returns ErrValuesNotSlice.
+//
+// Since 0.2.0
+func (e *ValuesNotSliceError) Cause() error {
+	return ErrValuesNotSlice
+}
+
+// toSlice returns the elements of slice `values`, or a *ValuesNotSliceError.
+func toSlice(values interface{}) ([]interface{}, error) {
+	if k := reflect.ValueOf(values).Kind(); k != reflect.Slice {
+		return nil, &ValuesNotSliceError{Kind: k}
+	}
+	return typehelper.ToSlice(values), nil
+}
+
+// newTrie is NewTrie of `n` values, of which the i-th is `value(i)`.
+func newTrie(c *canceler, keys [][]byte, n int, value func(i int) interface{}, squash bool, opts []Option) (*Node, error) {
 
 	if keys == nil {
-		return
+		return buildTrie(c, nil, 0, squash, opts)
+	}
+
+	if len(keys) != n {
+		return newRoot(squash, opts), ErrKVLenNotMatch
+	}
+
+	return buildTrie(c, func(i int) ([]byte, interface{}, bool) {
+		if i == len(keys) {
+			return nil, nil, false
+		}
+		return keys[i], value(i), true
+	}, len(keys), squash, opts)
+}
+
+// NewTrieFromFunc creates a trie from the keys and values returned by `next`,
+// which is called with 0, 1, 2... until it returns false, instead of from
+// slices of all of them. Keys must be ascendingly ordered, the same as those
+// of NewTrie, and are not retained by the trie, thus `next` can reuse one
+// buffer for them.
+//
+// Since 0.2.0
+func NewTrieFromFunc(next func(i int) (key []byte, val interface{}, ok bool), squash bool, opts ...Option) (*Node, error) {
+	return buildTrie(nil, next, 0, squash, opts)
+}
+
+// newRoot creates an empty trie.
+func newRoot(squash bool, opts []Option) *Node {
+
+	root := &Node{Children: make(map[int]*Node), Step: 1, squash: squash, InnerNodeCnt: 1}
+	root.counts = &nodeCounts{}
+	root.opt = newOptions(opts)
+	if m := root.monoid(); m != nil {
+		root.inner().agg = m.Identity
+	}
+	if root.opt.front != nil {
+		root.opt.front.root = root
 	}
+	if root.opt.autoSquash != nil {
+		root.opt.autoSquash.root = root
+	}
+	return root
+}
 
-	valSlice := typehelper.ToSlice(values)
+// buildTrie creates a trie of what `next` returns, see NewTrieFromFunc. A nil
+// `next` creates an empty trie. `total` is the number of keys to report, or 0
+// to use that of WithExpectedKeyCnt. It stops with the error of `c` once `c`
+// is canceled.
+func buildTrie(c *canceler, next func(i int) ([]byte, interface{}, bool), total int, squash bool, opts []Option) (root *Node, err error) {
 
-	if len(keys) != len(valSlice) {
-		err = ErrKVLenNotMatch
+	root = newRoot(squash, opts)
+	if next == nil {
 		return
 	}
 
-	for i := 0; i < len(keys); i++ {
-		key := keys[i]
-		_, err = root.Append(key, valSlice[i])
-		if err != nil {
+	hook := root.opt.buildHook
+	every := root.opt.buildHookEvery
+	if total == 0 {
+		total = root.opt.expectedKeyCnt
+	}
+	var start time.Time
+	if hook != nil {
+		start = time.Now()
+	}
+
+	// dups is the report of WithDuplicateReport, and prev and prevLeaf are
+	// the index and the leaf of the last key added.
+	var dups *DuplicateKeysError
+	var prevLeaf *Node
+	prev := -1
+
+	i := 0
+	for ; ; i++ {
+		if c.canceled() {
+			return nil, c.err
+		}
+
+		key, value, ok := next(i)
+		if !ok {
+			break
+		}
+
+		var leaf *Node
+		if root.opt.keysNormalized {
+			leaf, err = root.appendKey(key, value)
+		} else {
+			leaf, err = root.Append(key, value)
+		}
+		if errors.Cause(err) == ErrDuplicateKeys && root.opt.duplicateReport {
+			if leaf != prevLeaf {
+				// It duplicates a key before the last one.
+				err = errors.Wrapf(ErrKeyOutOfOrder, "trie failed to add kvs: append %q", key)
+				return
+			}
+			if dups == nil {
+				dups = &DuplicateKeysError{}
+			}
+			dups.add(key, prev, i)
+		} else if err != nil {
 			err = errors.Wrapf(err, "trie failed to add kvs")
 			return
+		} else {
+			prev = i
+			prevLeaf = leaf
+		}
+		err = nil
+
+		if err = root.checkMemory(i + 1); err != nil {
+			return nil, err
+		}
+
+		if hook != nil && every > 0 && (i+1)%every == 0 {
+			hook(root.buildEvent(PhaseAppend, i+1, total, start, false))
 		}
 	}
 
+	if hook != nil {
+		hook(root.buildEvent(PhaseAppend, i, total, start, true))
+	}
+
 	if squash {
-		root.Squash()
+		if a := root.autoSquashing(); a != nil {
+			root.finishSquash(a)
+		} else {
+			st := &squashState{cancel: c}
+			root.squashWith(st)
+			if c != nil && c.err != nil {
+				return nil, c.err
+			}
+		}
+	}
+
+	if root.opt.hashConsing {
+		root.hashCons()
 	}
 
+	root.buildFront()
+	root.opt.keysNormalized = false
+
+	if dups != nil {
+		err = dups
+	}
 	return
 }
 
+func (r *Node) buildEvent(phase BuildPhase, keyCnt, total int, start time.Time, done bool) BuildEvent {
+	e := BuildEvent{
+		Phase:        phase,
+		KeyCnt:       keyCnt,
+		InnerNodeCnt: r.InnerNodeCnt,
+		Processed:    keyCnt,
+		Total:        total,
+		Elapsed:      time.Since(start),
+		Done:         done,
+	}
+	e.ETA = estimateETA(e.Elapsed, e.Processed, e.Total)
+	return e
+}
+
+// estimateETA returns the time to process the rest of `total` items, at the
+// rate of `processed` items in `elapsed`. It is 0 if it is unknown.
+func estimateETA(elapsed time.Duration, processed, total int) time.Duration {
+	if processed <= 0 || total <= processed {
+		return 0
+	}
+	return time.Duration(float64(elapsed) / float64(processed) * float64(total-processed))
+}
+
 // String outputs multiline trie structure.
 //
 // Since 0.1.0
 func (r *Node) String() string {
-	s := &trieStringly{tnode: r}
-	return tree.String(s)
+	return tree.String(r.stringly())
 }
 
 // Squash compresses a Trie by removing single-branch nodes.
 //
 // Since 0.1.0
 func (r *Node) Squash() int {
+	return r.squashLevels(nil)
+}
 
-	var cnt int
+// SquashLevel is the statistics of squashing the nodes of a level, i.e., of
+// the same depth, of a trie.
+//
+// Since 0.2.0
+type SquashLevel struct {
+	// Nodes is the number of inner nodes of the level.
+	//
+	// Since 0.2.0
+	Nodes int
+
+	// Squashed is the number of nodes of the level merged with their only
+	// child.
+	//
+	// Since 0.2.0
+	Squashed int
+}
+
+// SquashLevels is the same as Squash except that it returns the statistics of
+// every level, from the root at level 0 to the deepest inner nodes, before
+// squashing. The sum of Squashed of all levels is what Squash returns.
+//
+// Since 0.2.0
+func (r *Node) SquashLevels() []SquashLevel {
+	levels := []SquashLevel{}
+	r.squashLevels(&levels)
+	return levels
+}
+
+// squashLevels is Squash that records the statistics to `levels` if it is not nil.
+func (r *Node) squashLevels(levels *[]SquashLevel) int {
+	return r.squashWith(&squashState{levels: levels}).Merged
+}
+
+// squashWith squashes r, or only reports what it would do with st.dryRun.
+func (r *Node) squashWith(st *squashState) SquashResult {
+
+	if st.dryRun || r.opt == nil || r.opt.buildHook == nil {
+		r.squashSubtree(r, st)
+		if !st.dryRun {
+			r.buildFront()
+		}
+		return st.res
+	}
+
+	st.progress = &squashProgress{
+		hook:  r.opt.buildHook,
+		every: r.opt.buildHookEvery,
+		total: r.InnerNodeCnt,
+		start: time.Now(),
+	}
+	r.squashSubtree(r, st)
+	r.buildFront()
+
+	if st.cancel == nil || st.cancel.err == nil {
+		st.progress.report(r, true)
+	}
 
-	for _, n := range r.Children {
-		cnt += n.Squash()
+	return st.res
+}
+
+// squashState is what squashSubtree records, if it is not nil.
+type squashState struct {
+	levels   *[]SquashLevel
+	dryRun   bool
+	res      SquashResult
+	progress *squashProgress
+	// cancel stops squashing once it is canceled.
+	cancel *canceler
+}
+
+// squashProgress reports the progress of a Squash to a build hook.
+type squashProgress struct {
+	hook  func(BuildEvent)
+	every int
+	// total is the number of inner nodes to visit.
+	total    int
+	visited  int
+	squashed int
+	start    time.Time
+}
+
+// visit counts a visited inner node and reports every `every` of them.
+func (p *squashProgress) visit(root *Node, squashed bool) {
+	p.visited++
+	if squashed {
+		p.squashed++
+	}
+	if p.every > 0 && p.visited%p.every == 0 {
+		p.report(root, false)
+	}
+}
+
+func (p *squashProgress) report(root *Node, done bool) {
+	e := BuildEvent{
+		Phase:        PhaseSquash,
+		InnerNodeCnt: root.InnerNodeCnt,
+		SquashedCnt:  p.squashed,
+		Processed:    p.visited,
+		Total:        p.total,
+		Elapsed:      time.Since(p.start),
+		Done:         done,
+	}
+	e.ETA = estimateETA(e.Elapsed, e.Processed, e.Total)
+	p.hook(e)
+}
+
+// squashSubtree squashes the subtree rooted at r and returns the number of nodes
+// removed, which are discounted from the counters of `root`. Statistics are
+// recorded to `st` if it is not nil.
+//
+// It does not recurse: a trie of long keys is as deep as its longest key.
+// Nodes are visited in post order with an explicit stack, thus a node is
+// squashed after its children are.
+func (r *Node) squashSubtree(root *Node, st *squashState) int {
+
+	type frame struct {
+		n     *Node
+		depth int
+		// expanded is set once children of n are pushed.
+		expanded bool
 	}
 
-	if len(r.Branches) == 1 && r.Branches[0] != leafBranch {
-		child := r.Children[r.Branches[0]]
-		r.Branches = child.Branches
-		r.Children = child.Children
-		r.Step = child.Step + 1
-		cnt++
+	var cnt int
+
+	// chain is the number of nodes merged into the node visited last. A node
+	// with a single child is visited right after the child.
+	chain := 0
+
+	stack := []frame{{n: r}}
+	for len(stack) > 0 {
+		if st != nil && st.cancel.canceled() {
+			// Every merge is done in one step, thus the trie is valid, only
+			// partly squashed.
+			break
+		}
+
+		top := len(stack) - 1
+		f := stack[top]
+
+		if !f.expanded {
+			stack[top].expanded = true
+			for _, child := range f.n.Children {
+				if child.Children != nil {
+					stack = append(stack, frame{n: child, depth: f.depth + 1})
+				}
+			}
+			continue
+		}
+		stack = stack[:top]
+
+		n := f.n
+		squashed := len(n.Branches) == 1 && n.Branches[0] != leafBranch &&
+			mergeable(n, n.Children[n.Branches[0]])
+		if squashed {
+			chain++
+			cnt++
+		} else {
+			chain = 0
+		}
+
+		if st != nil {
+			st.record(n, f.depth, squashed, chain)
+		}
+
+		if squashed && (st == nil || !st.dryRun) {
+			child := n.Children[n.Branches[0]]
+			root.InnerNodeCnt--
+			root.addCounts(0, mergeDelta(n, child))
+			n.Branches = child.Branches
+			n.Children = child.Children
+			n.Step += child.Step
+			n.shrunk = child.shrunk
+		}
+
+		if st != nil && st.progress != nil {
+			st.progress.visit(root, squashed)
+		}
 	}
 
 	return cnt
 }
 
+// mergeable returns true if inner node `n` of a single branch can be merged
+// with its only child `child`: Step of the merged node must fit in uint16,
+// thus a chain of a key longer than math.MaxUint16 is split into several
+// nodes.
+func mergeable(n, child *Node) bool {
+	return int(n.Step)+int(child.Step) <= math.MaxUint16
+}
+
+// record adds inner node `n` at `depth` to the statistics, before it is
+// squashed. `chain` is the number of nodes merged into it.
+func (st *squashState) record(n *Node, depth int, squashed bool, chain int) {
+
+	if st.levels != nil {
+		levels := st.levels
+		for len(*levels) <= depth {
+			*levels = append(*levels, SquashLevel{})
+		}
+		l := &(*levels)[depth]
+		l.Nodes++
+		if squashed {
+			l.Squashed++
+		}
+	}
+
+	if !squashed {
+		st.res.Remaining++
+		return
+	}
+
+	st.res.Merged++
+	if chain > st.res.MaxChain {
+		st.res.MaxChain = chain
+	}
+	// The child is released, and the map and slice of n are replaced with
+	// those of the child.
+	st.res.BytesSaved += nodeSize + estimateMapSize(len(n.Children)) + cap(n.Branches)*intSize
+}
+
 // removeSameLeaf removes leaf that has the same value as preceding leaf.
 //
 //   a ------->e =1
@@ -149,6 +681,7 @@ func (r *Node) removeSameLeaf() {
 				b := branch.(int)
 
 				delete(p.Children, b)
+				p.shrunk = true
 
 				for i, bb := range p.Branches {
 					if bb == b {
@@ -161,6 +694,12 @@ func (r *Node) removeSameLeaf() {
 
 			}
 		})
+
+	r.recount()
+	if r.counts != nil {
+		r.setCounts()
+	}
+	r.reaggregate(r)
 }
 
 // Search for `key` in a Trie.
@@ -172,18 +711,109 @@ func (r *Node) removeSameLeaf() {
 //
 // Any of them could be nil.
 //
+// In a squashed trie, the bytes removed by squashing are not compared: a key
+// not in the trie matches a key that differs from it only in these bytes. See
+// SearchCandidate, SearchVerified and WithStoreKeys for exact lookups.
+//
 // Since 0.1.0
 func (r *Node) Search(key []byte) (ltValue, eqValue, gtValue interface{}) {
+	return r.search(r.normalize(key), nil)
+}
 
-	var eqNode = r
-	var ltNode *Node
-	var gtNode *Node
+// search is Search of a normalized `key`, in which keys of leaves are from
+// `src` if it is not nil, see SearchVerified.
+func (r *Node) search(key []byte, src KeySource) (ltValue, eqValue, gtValue interface{}) {
+
+	if len(r.Branches) == 0 {
+		return
+	}
+
+	if r.keyCnt == 1 {
+		if lt, eq, gt, ok := r.searchSingle(key, src); ok {
+			return lt, eq, gt
+		}
+	}
 
+	var ltNode, eqNode, gtNode *Node
+	if start, i := r.frontNode(key); start != nil {
+		ltNode, eqNode, gtNode = r.descend(start, i, key, src)
+	}
+	// Neighbors not found below the node of WithDirectIndex are above it.
+	if ltNode == nil || gtNode == nil {
+		ltNode, eqNode, gtNode = r.descend(r, -1, key, src)
+	}
+
+	return r.searchValues(key, src, ltNode, eqNode, gtNode)
+}
+
+// searchValues returns the values of Search of a normalized `key` from the
+// nodes descend returns.
+func (r *Node) searchValues(key []byte, src KeySource, ltNode, eqNode, gtNode *Node) (ltValue, eqValue, gtValue interface{}) {
+
+	if ltNode != nil {
+		ltValue = ltNode.rightMost().Value
+	}
+	if gtNode != nil {
+		gtValue = gtNode.leftMost().Value
+	}
+	if eqNode != nil && !r.expired(eqNode) {
+		eqValue = eqNode.Value
+	}
+
+	if ltValue == removed || eqValue == removed || gtValue == removed {
+		return r.searchLive(key, src)
+	}
+
+	return
+}
+
+// descentLevel is the state of a descent when entering a node: the node,
+// the number of bytes of the key consumed before it and the nodes of the
+// nearest smaller and greater keys found above it.
+type descentLevel struct {
+	node           *Node
+	i              int
+	ltNode, gtNode *Node
+}
+
+// descend walks down from `node` along `key`, of which `i` bytes are
+// consumed before `node`, and returns the nodes of the nearest smaller key,
+// the key and the nearest greater key. The root is descended from with `i` of
+// -1. See keyDiff for `src`.
+func (r *Node) descend(node *Node, i int, key []byte, src KeySource) (ltNode, eqNode, gtNode *Node) {
+	return r.descendFrom(descentLevel{node: node, i: i}, key, src, nil)
+}
+
+// descendFrom is descend from level `l`, which appends every level it enters
+// to `levels` if it is not nil.
+func (r *Node) descendFrom(l descentLevel, key []byte, src KeySource, levels *[]descentLevel) (ltNode, eqNode, gtNode *Node) {
+
+	order := r.byteOrder()
+
+	// With WithStoreKeys, `key` may differ from the keys in bytes removed by
+	// squashing, in which the descent stops.
+	diff, other := r.keyDiff(l.node, l.i, key, src)
+
+	eqNode, ltNode, gtNode = l.node, l.ltNode, l.gtNode
+	i := l.i
 	lenKey := len(key)
 
-	for i := -1; ; {
+	for {
+		if levels != nil {
+			*levels = append(*levels, descentLevel{eqNode, i, ltNode, gtNode})
+		}
 		i += int(eqNode.Step)
 
+		if diff < i {
+			if order.before(key, diff, other) {
+				gtNode = eqNode
+			} else {
+				ltNode = eqNode
+			}
+			eqNode = nil
+			break
+		}
+
 		if lenKey < i {
 			gtNode = eqNode
 			eqNode = nil
@@ -197,7 +827,7 @@ func (r *Node) Search(key []byte) (ltValue, eqValue, gtValue interface{}) {
 			br = int(key[i])
 		}
 
-		li, ri := neighborBranches(eqNode.Branches, br)
+		li, ri := order.neighborBranches(eqNode.Branches, br)
 		if li >= 0 {
 			ltNode = eqNode.Children[eqNode.Branches[li]]
 		}
@@ -215,26 +845,91 @@ func (r *Node) Search(key []byte) (ltValue, eqValue, gtValue interface{}) {
 			break
 		}
 	}
+	return
+}
 
-	if ltNode != nil {
-		ltValue = ltNode.rightMost().Value
+// searchSingle is Search in a trie of a single key, in which every node has
+// exactly one branch. It compares `key` with the only key along the path
+// instead of looking up neighbor branches.
+// It returns false if r is not of this shape or the key is expired.
+func (r *Node) searchSingle(key []byte, src KeySource) (ltValue, eqValue, gtValue interface{}, ok bool) {
+
+	order := r.byteOrder()
+
+	// cmp is the result of comparing `key` with the only key.
+	cmp := 0
+	node := r
+	for i := -1; ; {
+		if len(node.Branches) != 1 {
+			return nil, nil, nil, false
+		}
+		i += int(node.Step)
+		br := node.Branches[0]
+
+		if cmp == 0 {
+			switch {
+			case len(key) < i:
+				cmp = -1
+			case len(key) == i:
+				if br != leafBranch {
+					cmp = -1
+				}
+			case br == leafBranch:
+				cmp = 1
+			default:
+				if c := order.rank(int(key[i])) - order.rank(br); c != 0 {
+					cmp = c
+				}
+			}
+		}
+
+		node = node.Children[br]
+		if br == leafBranch {
+			break
+		}
 	}
-	if gtNode != nil {
-		gtValue = gtNode.leftMost().Value
+
+	if r.expired(node) {
+		return nil, nil, nil, false
 	}
-	if eqNode != nil {
-		eqValue = eqNode.Value
+
+	if k := r.keyOf(node, src); k != nil {
+		cmp = order.compare(key, k)
 	}
 
-	return
+	switch {
+	case cmp < 0:
+		gtValue = node.Value
+	case cmp > 0:
+		ltValue = node.Value
+	default:
+		eqValue = node.Value
+	}
+	return ltValue, eqValue, gtValue, true
 }
 
+// wideFanout is the fan-out above which a binary search is faster than a
+// linear scan to find a branch. By BenchmarkNeighborBranches on amd64, they
+// are about even at 8 branches, the binary search is about 1.4 times faster
+// at 16, and a linear scan of 256 branches is about 7 times slower.
+const wideFanout = 8
+
 func neighborBranches(branches []int, br int) (ltIndex, rtIndex int) {
 
 	if len(branches) == 0 {
-		return
+		return -1, -1
 	}
 
+	if len(branches) > wideFanout {
+		return neighborBranchesWide(branches, br)
+	}
+	return neighborBranchesLinear(branches, br)
+}
+
+// neighborBranchesLinear is neighborBranches with a linear scan of non-empty
+// `branches`.
+func neighborBranchesLinear(branches []int, br int) (ltIndex, rtIndex int) {
+
 	var i int
 	var b int
 
@@ -266,8 +961,28 @@ func neighborBranches(branches []int, br int) (ltIndex, rtIndex int) {
 	return
 }
 
+// neighborBranchesWide is neighborBranches with a binary search.
+func neighborBranchesWide(branches []int, br int) (ltIndex, rtIndex int) {
+
+	i := sort.SearchInts(branches, br)
+
+	ltIndex = i - 1
+	rtIndex = i
+	if i < len(branches) && branches[i] == br {
+		rtIndex = i + 1
+	}
+	if rtIndex == len(branches) {
+		rtIndex = -1
+	}
+	return
+}
+
 func (r *Node) leftMost() *Node {
 
+	if r.first() != nil {
+		return r.first()
+	}
+
 	node := r
 	for {
 		if len(node.Branches) == 0 {
@@ -281,6 +996,10 @@ func (r *Node) leftMost() *Node {
 
 func (r *Node) rightMost() *Node {
 
+	if r.last() != nil {
+		return r.last()
+	}
+
 	node := r
 	for {
 		if len(node.Branches) == 0 {
@@ -295,40 +1014,118 @@ func (r *Node) rightMost() *Node {
 // Append adds a key-value pair into Trie.
 //
 // The key to add must be greater than any existent key in the Trie.
+// With WithMultiValue, it can also be an existent key.
 //
 // It returns the leaf node representing the added key.
 //
 // Since 0.1.0
 func (r *Node) Append(key []byte, value interface{}) (leaf *Node, err error) {
 
+	if r.readOnly() {
+		err = errors.Wrapf(ErrReadOnly, "append %q", key)
+		return
+	}
+
+	if r.Step > 1 {
+		err = errors.Wrapf(ErrSquashed, "append %q", key)
+		return
+	}
+
+	nkey := r.normalize(key)
+	if err = r.checkKey("append", key, nkey); err != nil {
+		return
+	}
+
+	return r.appendKey(nkey, value)
+}
+
+// appendKey is Append of a normalized `key`.
+func (r *Node) appendKey(key []byte, value interface{}) (leaf *Node, err error) {
+
+	order := r.byteOrder()
+
+	var appender func(acc, v interface{}) interface{}
+	if r.opt != nil {
+		appender = r.opt.multiValue
+	}
+
 	var node = r
 	var j int
 
+	// outOfOrder is set if key goes into a branch other than the last one,
+	// i.e., there is a greater key.
+	var outOfOrder bool
+
 	for j = 0; j < len(key); j++ {
 		br := int(key[j])
-		if node.Children[br] == nil {
-			l := len(node.Branches)
-			if l > 0 && node.Branches[l-1] > br {
-				err = errors.Wrapf(ErrKeyOutOfOrder, "append %q", key)
-				return
-			}
+
+		l := len(node.Branches)
+		if l > 0 && order.rank(node.Branches[l-1]) > order.rank(br) {
+			outOfOrder = true
+		}
+
+		child := node.Children[br]
+		if child == nil || child.Step > 1 {
+			// A squashed node can not be followed byte by byte.
 			break
 		}
-		node = node.Children[br]
+		node = child
 	}
 
-	if j == len(key) {
+	if j == len(key) && node.Children[leafBranch] != nil {
 		leaf = node.Children[leafBranch]
-		if leaf != nil {
-			err = ErrDuplicateKeys
+		if leaf.Value == removed || appender != nil {
+			value, err = r.prepareValue(value)
+			if err != nil {
+				leaf = nil
+				err = errors.Wrapf(err, "append %q", key)
+				return
+			}
+		}
+		if leaf.Value == removed {
+			if appender != nil {
+				value = appender(nil, value)
+			}
+			leaf.Value = value
+			r.addKeyCnt(key, 1)
+			r.reaggregateKey(key)
+			r.recordValue(leaf, nil, false)
+			r.newVersion(leaf)
+			r.changed(key, removed, leaf.Value)
+			return
+		}
+		if appender != nil {
+			old := leaf.Value
+			leaf.Value = appender(leaf.Value, value)
+			r.reaggregateKey(key)
+			r.newVersion(leaf)
+			r.changed(key, old, leaf.Value)
 			return
 		}
+		err = ErrDuplicateKeys
+		return
+	}
 
+	if outOfOrder {
+		err = errors.Wrapf(ErrKeyOutOfOrder, "append %q", key)
+		return
+	}
+
+	if j == len(key) {
 		if len(node.Branches) != 0 {
 			// means this key is a prefix of an existed key, so key's adding order is not ascending.
 			err = errors.Wrapf(ErrKeyOutOfOrder, "append %q is a prefix", key)
 			return
 		}
+	} else if node.Children[int(key[j])] != nil {
+		err = errors.Wrapf(ErrSquashed, "append %q", key)
+		return
+	}
+
+	value, err = r.prepareValue(value)
+	if err != nil {
+		err = errors.Wrapf(err, "append %q", key)
+		return
 	}
 
 	commonNode := node
@@ -339,9 +1136,11 @@ func (r *Node) Append(key []byte, value interface{}) (leaf *Node, err error) {
 		ltNode = commonNode.Children[commonNode.Branches[numBr-1]]
 	}
 
+	r.addKeyCnt(key[:j], 1)
+
 	for _, b := range key[j:] {
 		br := int(b)
-		n := &Node{Children: make(map[int]*Node), Step: 1, squash: node.squash}
+		n := &Node{Children: make(map[int]*Node), Step: 1, squash: node.squash, keyCnt: 1}
 
 		node.Children[br] = n
 		node.Branches = append(node.Branches, br)
@@ -350,16 +1149,135 @@ func (r *Node) Append(key []byte, value interface{}) (leaf *Node, err error) {
 		r.InnerNodeCnt++
 	}
 
-	leaf = &Node{Value: value}
+	if appender != nil {
+		value = appender(nil, value)
+	}
+	leaf = r.newLeaf()
+	leaf.Value = value
+	r.addCounts(1, 0)
+	if r.storeKeys() {
+		leaf.leaf().key = r.storedKey(key)
+	}
 
 	node.Children[leafBranch] = leaf
 	node.Branches = append(node.Branches, leafBranch)
 
+	r.updateFront(key)
+	r.addAggregate(key, j, value)
+	r.recordValue(leaf, nil, false)
+	r.newVersion(leaf)
+	r.changed(key, removed, value)
+
 	if commonNode.squash {
 		if ltNode != nil {
-			r.InnerNodeCnt -= ltNode.Squash()
+			if a := r.autoSquashing(); a != nil {
+				r.addSquash(a, ltNode, j+1, len(key)-j)
+			} else {
+				ltNode.squashSubtree(r, nil)
+			}
+		}
+	}
+
+	return
+}
+
+// Set sets the value of `key`, adding it if it is not in the trie.
+// Unlike Append, `key` does not need to be greater than existent keys.
+//
+// A node along `key` must not be squashed, or it returns ErrSquashed.
+// Set does not squash.
+//
+// It returns the leaf node representing `key`.
+//
+// Since 0.2.0
+func (r *Node) Set(key []byte, value interface{}) (leaf *Node, err error) {
+
+	if r.readOnly() {
+		err = errors.Wrapf(ErrReadOnly, "set %q", key)
+		return
+	}
+
+	nkey := r.normalize(key)
+	if err = r.checkKey("set", key, nkey); err != nil {
+		return
+	}
+
+	return r.setKey(nkey, value)
+}
+
+// setKey is Set of a normalized `key`.
+func (r *Node) setKey(key []byte, value interface{}) (leaf *Node, err error) {
+
+	if r.readOnly() {
+		err = errors.Wrapf(ErrReadOnly, "set %q", key)
+		return
+	}
+
+	if r.Step > 1 {
+		err = errors.Wrapf(ErrSquashed, "set %q", key)
+		return
+	}
+
+	value, err = r.prepareValue(value)
+	if err != nil {
+		err = errors.Wrapf(err, "set %q", key)
+		return
+	}
+
+	return r.set(key, value)
+}
+
+// set is Set of a normalized `key` and a value already prepared by
+// prepareValue.
+func (r *Node) set(key []byte, value interface{}) (leaf *Node, err error) {
+
+	order := r.byteOrder()
+
+	node := r
+	for _, b := range key {
+		br := int(b)
+		child := node.Children[br]
+		if child == nil {
+			child = &Node{Children: make(map[int]*Node), Step: 1, squash: r.squash}
+			node.Children[br] = child
+			node.Branches = order.insertBranch(node.Branches, br)
+			r.InnerNodeCnt++
+		} else if child.Step > 1 {
+			err = errors.Wrapf(ErrSquashed, "set %q", key)
+			return
 		}
+		node = child
+	}
+
+	leaf = node.Children[leafBranch]
+	existed := leaf != nil
+	delta := liveCnt(value)
+	if leaf == nil {
+		leaf = r.newLeaf()
+		r.addCounts(1, 0)
+		if r.storeKeys() {
+			leaf.leaf().key = r.storedKey(key)
+		}
+		node.Children[leafBranch] = leaf
+		node.Branches = order.insertBranch(node.Branches, leafBranch)
+		r.updateFront(key)
+	} else {
+		delta -= leaf.KeyCnt()
+	}
+
+	r.addKeyCnt(key, delta)
+	old := leaf.Value
+	if !existed {
+		old = removed
+	}
+	leaf.Value = value
+	r.reaggregateKey(key)
+	r.recordValue(leaf, old, existed)
+	r.newVersion(leaf)
+	if leaf.leafExt != nil {
+		leaf.leafExt.expiry = 0
 	}
+	r.changed(key, old, value)
 
 	return
 }
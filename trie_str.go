@@ -1,13 +1,19 @@
 package trie
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/openacid/low/tree"
+)
 
 // trieStringly is a wrapper that implements tree.Tree .
 // It is a helper to convert trie to string.
 //
 // Since 0.5.1
 type trieStringly struct {
-	tnode *Node
+	tnode  *Node
+	labels LabelStyle
 }
 
 // Child implements tree.Tree
@@ -48,13 +54,86 @@ func (s *trieStringly) NodeID(node interface{}) string {
 //
 // Since 0.5.5
 func (s *trieStringly) LabelInfo(label interface{}) string {
-	l := label.(int)
-	if l == -1 {
+	return s.labels.Format(label.(int))
+}
+
+// LabelStyle is how a branch label is rendered in debug output.
+// The leaf branch is always rendered as "$".
+//
+// Since 0.2.0
+type LabelStyle int
+
+const (
+	// LabelDecimal renders a label as a decimal number, e.g., "97".
+	// It is the default style.
+	//
+	// Since 0.2.0
+	LabelDecimal LabelStyle = iota
+
+	// LabelPrintable renders a visible ASCII character label as is, e.g., "a",
+	// and others as "\xNN", e.g., "\x00". "$" and "\" are also rendered as
+	// "\xNN" to not be confused with the leaf branch or an escape.
+	//
+	// Since 0.2.0
+	LabelPrintable
+
+	// LabelHex renders a label as "\xNN", e.g., "\x61".
+	//
+	// Since 0.2.0
+	LabelHex
+)
+
+// Format returns the string of branch label `l`.
+//
+// Since 0.2.0
+func (s LabelStyle) Format(l int) string {
+	if l == leafBranch {
 		return "$"
 	}
+
+	switch s {
+	case LabelPrintable:
+		if l > 0x20 && l < 0x7f && l != '$' && l != '\\' {
+			return string(rune(l))
+		}
+		return fmt.Sprintf("\\x%02x", l)
+	case LabelHex:
+		return fmt.Sprintf("\\x%02x", l)
+	}
 	return fmt.Sprintf("%d", l)
 }
 
+// formatLabels returns the string of branch labels `ls`, e.g., "[$ a b]".
+func (s LabelStyle) formatLabels(ls []int) string {
+	strs := make([]string, len(ls))
+	for i, l := range ls {
+		strs[i] = s.Format(l)
+	}
+	return "[" + strings.Join(strs, " ") + "]"
+}
+
+// WithDebugLabelStyle sets how the debug output of a trie renders branch
+// labels: String, TreeView, StringWith, ToMermaid, DebugHandler and the
+// errors of Validate.
+// WithLabelStyle of StringWith or ToMermaid overrides it.
+//
+// Since 0.2.0
+func WithDebugLabelStyle(style LabelStyle) Option {
+	return func(o *options) {
+		o.labelStyle = style
+		o.hasLabelStyle = true
+	}
+}
+
+// labelStyle returns the LabelStyle of WithDebugLabelStyle, or `def` if it
+// is not set.
+func (r *Node) labelStyle(def LabelStyle) LabelStyle {
+	if r.opt == nil || !r.opt.hasLabelStyle {
+		return def
+	}
+	return r.opt.labelStyle
+}
+
 // NodeInfo implements tree.Tree
 //
 // Since 0.5.1
@@ -81,3 +160,170 @@ func (s *trieStringly) trieNode(node interface{}) *Node {
 	}
 	return node.(*Node)
 }
+
+// TreeView returns the trie as a tree.Tree of github.com/openacid/low/tree,
+// the adapter String is built on, e.g., to walk it with tree.DepthFirst or
+// to output it with tree.String.
+//
+// A node argument is a *Node, and a nil node is the root: Child(nil, nil)
+// returns r. A label is the int branch to a child: a byte of keys, or -1 for
+// the branch to the leaf of the key ending at the node, which is before the
+// other branches in Labels. LabelInfo formats a label with the style of
+// WithDebugLabelStyle, LabelDecimal by default.
+//
+// Child returns nil for an int label that is not a branch of the node. LeafVal
+// returns the Value of a node and whether it is not nil, i.e., true only for
+// leaves. In a trie WithLazyRemove, a removed leaf is still a leaf, of a
+// value printed as "(removed)". NodeInfo returns "+<step>" for a squashed
+// node, and NodeID returns "".
+//
+// Since 0.2.0
+func (r *Node) TreeView() tree.Tree {
+	return r.stringly()
+}
+
+func (r *Node) stringly() *trieStringly {
+	return &trieStringly{tnode: r, labels: r.labelStyle(LabelDecimal)}
+}
+
+// StringOption configures StringWith.
+//
+// Since 0.2.0
+type StringOption func(*stringOptions)
+
+type stringOptions struct {
+	maxDepth    int
+	maxChildren int
+	value       func(v interface{}) string
+	step        bool
+	keyCounts   bool
+	labels      LabelStyle
+}
+
+// WithMaxDepth limits the depth of nodes to output. The root is at depth 0.
+// Deeper nodes are replaced with a line of "...". A non-positive `depth`
+// means no limit.
+//
+// Since 0.2.0
+func WithMaxDepth(depth int) StringOption {
+	return func(o *stringOptions) {
+		o.maxDepth = depth
+	}
+}
+
+// WithMaxChildren limits the number of children to output per node. The
+// others are replaced with a line of "...(<n> more)". A non-positive `n` means
+// no limit.
+//
+// Since 0.2.0
+func WithMaxChildren(n int) StringOption {
+	return func(o *stringOptions) {
+		o.maxChildren = n
+	}
+}
+
+// WithValueString sets how to output a value. By default a value is formatted
+// with fmt "%v".
+//
+// Since 0.2.0
+func WithValueString(fn func(v interface{}) string) StringOption {
+	return func(o *stringOptions) {
+		o.value = fn
+	}
+}
+
+// WithStep sets whether to output the Step of squashed nodes as "+<step>".
+// It is shown by default.
+//
+// Since 0.2.0
+func WithStep(show bool) StringOption {
+	return func(o *stringOptions) {
+		o.step = show
+	}
+}
+
+// WithKeyCounts outputs the number of keys below every inner node, i.e., its
+// KeyCnt, as "(<n> keys)".
+//
+// Since 0.2.0
+func WithKeyCounts() StringOption {
+	return func(o *stringOptions) {
+		o.keyCounts = true
+	}
+}
+
+// WithLabelStyle sets how to render branch labels. It is the style of
+// WithDebugLabelStyle of the trie, or LabelDecimal by default.
+//
+// Since 0.2.0
+func WithLabelStyle(style LabelStyle) StringOption {
+	return func(o *stringOptions) {
+		o.labels = style
+	}
+}
+
+// StringWith outputs multiline trie structure in the same form as String,
+// configured by `opts`.
+//
+// Since 0.2.0
+func (r *Node) StringWith(opts ...StringOption) string {
+
+	o := newStringOptions(append([]StringOption{WithLabelStyle(r.labelStyle(LabelDecimal))}, opts...))
+	lines := o.render(r, "", 0, "", nil)
+	return strings.Join(lines, "\n")
+}
+
+func newStringOptions(opts []StringOption) *stringOptions {
+	o := &stringOptions{
+		value: func(v interface{}) string {
+			return fmt.Sprintf("%v", v)
+		},
+		step: true,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// render appends the lines of the subtree of `n` to `lines`.
+func (o *stringOptions) render(n *Node, label string, depth int, indent string, lines []string) []string {
+
+	line := ""
+	if label != "" {
+		line = "-" + label + "->"
+	}
+	childIndent := indent + strings.Repeat(" ", len(line))
+
+	if o.step && n.Step > 1 {
+		line += fmt.Sprintf("+%d", n.Step)
+	}
+	if len(n.Branches) > 1 {
+		line += fmt.Sprintf("*%d", len(n.Branches))
+	}
+	if o.keyCounts && len(n.Branches) > 0 {
+		line += fmt.Sprintf("(%d keys)", n.KeyCnt())
+	}
+	if n.Value != nil {
+		line += "=" + o.value(n.Value)
+	}
+	lines = append(lines, indent+line)
+
+	if len(n.Branches) == 0 {
+		return lines
+	}
+
+	if o.maxDepth > 0 && depth >= o.maxDepth {
+		return append(lines, childIndent+"...")
+	}
+
+	for i, b := range n.Branches {
+		if o.maxChildren > 0 && i == o.maxChildren {
+			lines = append(lines, childIndent+fmt.Sprintf("...(%d more)", len(n.Branches)-i))
+			break
+		}
+		lines = o.render(n.Children[b], o.labels.Format(b), depth+1, childIndent, lines)
+	}
+
+	return lines
+}
@@ -0,0 +1,190 @@
+package trie
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/openacid/low/tree"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNode_StringWith(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := [][]byte{
+		{1, 2, 3},
+		{1, 2, 3, 4},
+		{2, 3},
+		{2, 3, 4},
+		{2, 3, 4, 5},
+	}
+	values := []int{0, 1, 2, 3, 4}
+
+	for _, squash := range []bool{false, true} {
+		tr, err := NewTrie(keys, values, squash)
+		ta.Nil(err)
+		ta.Equal(tr.String(), tr.StringWith())
+	}
+
+	tr, err := NewTrie(keys, values, true)
+	ta.Nil(err)
+
+	cases := []struct {
+		opts []StringOption
+		want string
+	}{
+		{
+			[]StringOption{WithMaxDepth(1)},
+			`
+*2
+-1->+3*2
+    ...
+-2->+2*2
+    ...`[1:],
+		},
+		{
+			[]StringOption{WithMaxChildren(1), WithStep(false)},
+			`
+*2
+-1->*2
+    -$->=0
+    ...(1 more)
+...(1 more)`[1:],
+		},
+		{
+			[]StringOption{WithKeyCounts(), WithMaxDepth(2),
+				WithValueString(func(v interface{}) string { return fmt.Sprintf("<%v>", v) })},
+			`
+*2(5 keys)
+-1->+3*2(2 keys)
+    -$->=<0>
+    -4->(1 keys)
+        ...
+-2->+2*2(3 keys)
+    -$->=<2>
+    -4->*2(2 keys)
+        ...`[1:],
+		},
+	}
+
+	for i, c := range cases {
+		ta.Equal(c.want, tr.StringWith(c.opts...), "%d-th", i+1)
+	}
+}
+
+func TestNode_StringWith_keyCounts(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr, err := NewTrie(
+		[][]byte{[]byte("ab"), []byte("ac"), []byte("b")}, []string{"ab", "ac", "b"}, false,
+		WithLazyRemove())
+	ta.Nil(err)
+	ta.True(tr.Remove([]byte("ac")))
+
+	// Keys lazily removed are not counted.
+	lines := strings.Split(tr.StringWith(WithKeyCounts(), WithMaxDepth(1)), "\n")
+	ta.Equal("*2(2 keys)", lines[0])
+	ta.Equal("-97->*2(1 keys)", lines[1])
+}
+
+func TestWithDebugLabelStyle(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr, err := NewTrie(
+		[][]byte{[]byte("a"), []byte("b\x00")}, []int{1, 2}, false,
+		WithDebugLabelStyle(LabelPrintable))
+	ta.Nil(err)
+
+	want := `
+*2
+-a->
+    -$->=1
+-b->
+    -\x00->
+           -$->=2`[1:]
+	ta.Equal(want, tr.String())
+	ta.Equal(want, tr.StringWith())
+	ta.Equal(tree.String(tr.TreeView()), tr.String())
+	ta.Contains(tr.StringWith(WithLabelStyle(LabelDecimal)), "-97->")
+
+	var buf bytes.Buffer
+	ta.Nil(tr.ToMermaid(&buf))
+	ta.Contains(buf.String(), `"\x00"`)
+
+	sub := tr.Children['b']
+	sub.Branches = append(sub.Branches, 'c')
+	sub.Children['c'] = nil
+	ta.Contains(tr.Validate().Error(), "no child of branch c")
+	delete(sub.Children, 'c')
+	sub.Branches = sub.Branches[:1]
+	tr.Branches[0], tr.Branches[1] = tr.Branches[1], tr.Branches[0]
+	ta.Contains(tr.Validate().Error(), "branches not ascending: [b a]")
+}
+
+func TestLabelStyle_Format(t *testing.T) {
+
+	ta := require.New(t)
+
+	cases := []struct {
+		label                   int
+		decimal, printable, hex string
+	}{
+		{leafBranch, "$", "$", "$"},
+		{0, "0", `\x00`, `\x00`},
+		{' ', "32", `\x20`, `\x20`},
+		{'$', "36", `\x24`, `\x24`},
+		{'\\', "92", `\x5c`, `\x5c`},
+		{'a', "97", "a", `\x61`},
+		{'~', "126", "~", `\x7e`},
+		{0x7f, "127", `\x7f`, `\x7f`},
+		{0xff, "255", `\xff`, `\xff`},
+	}
+
+	for i, c := range cases {
+		ta.Equal(c.decimal, LabelDecimal.Format(c.label), "%d-th", i+1)
+		ta.Equal(c.printable, LabelPrintable.Format(c.label), "%d-th", i+1)
+		ta.Equal(c.hex, LabelHex.Format(c.label), "%d-th", i+1)
+	}
+
+	tr := newStrTrie(ta, false, "a\x00", "b")
+	ta.Equal(`
+*2
+-a->
+    -\x00->
+           -$->=a`[1:]+"\x00"+`
+-b->
+    -$->=b`, tr.StringWith(WithLabelStyle(LabelPrintable)))
+}
+
+func TestNode_TreeView(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr, err := NewTrie([][]byte{[]byte("ab"), []byte("abc"), []byte("b")}, []int{0, 1, 2}, true)
+	ta.Nil(err)
+
+	tv := tr.TreeView()
+	ta.Equal(tr.String(), tree.String(tv))
+	ta.Equal(tr, tv.Child(nil, nil))
+	ta.Nil(tv.Child(nil, int('z')))
+
+	// Leaves are visited before their parents, the leaf branch first.
+	visited := []string{}
+	tree.DepthFirst(tv, func(t tree.Tree, parent, label, node interface{}) {
+		if label == nil {
+			ta.Equal(tr, node)
+			return
+		}
+		s := t.LabelInfo(label) + t.NodeInfo(node)
+		if v, isLeaf := t.LeafVal(node); isLeaf {
+			s += fmt.Sprintf("=%v", v)
+		}
+		visited = append(visited, s)
+	})
+	ta.Equal([]string{"$=0", "$=1", "99", "97+2", "$=2", "98"}, visited)
+}
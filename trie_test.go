@@ -1,6 +1,7 @@
 package trie
 
 import (
+	"bytes"
 	"fmt"
 	"reflect"
 	"testing"
@@ -300,6 +301,73 @@ func TestTrieSearch(t *testing.T) {
 	}
 }
 
+func TestTrieSearch_empty(t *testing.T) {
+
+	ta := require.New(t)
+
+	trie, err := NewTrie(nil, nil, false)
+	ta.Nil(err)
+
+	for _, k := range [][]byte{{}, {1}, {1, 2}} {
+		lt, eq, gt := trie.Search(k)
+		ta.Equal([]interface{}{nil, nil, nil}, []interface{}{lt, eq, gt}, "search: %v", k)
+	}
+}
+
+func TestTrieSearch_single(t *testing.T) {
+
+	ta := require.New(t)
+
+	queries := []string{"", "\x00", "a", "ab", "abc", "abcd", "abb", "abd", "axc", "b", "\xff"}
+
+	for _, squash := range []bool{false, true} {
+		for _, opt := range []Option{WithLazyRemove(), WithByteOrder(func(a, b byte) int { return int(b) - int(a) })} {
+			for _, key := range []string{"", "a", "abc"} {
+
+				tr, err := NewTrie([][]byte{[]byte(key)}, []string{key}, squash, opt)
+				ta.Nil(err)
+
+				// A key count other than 1 disables the fast path.
+				slow := *tr
+				slow.keyCnt = 2
+
+				for _, q := range queries {
+					lt, eq, gt := tr.Search([]byte(q))
+					wlt, weq, wgt := slow.Search([]byte(q))
+					ta.Equal([]interface{}{wlt, weq, wgt}, []interface{}{lt, eq, gt},
+						"squash: %v, key: %q, search: %q", squash, key, q)
+				}
+			}
+		}
+	}
+
+	// Not of a single path.
+	tr, err := NewTrie([][]byte{[]byte("a"), []byte("b")}, []string{"a", "b"}, false, WithLazyRemove())
+	ta.Nil(err)
+	ta.True(tr.Remove([]byte("a")))
+	lt, eq, gt := tr.Search([]byte("a"))
+	ta.Equal([]interface{}{nil, nil, "b"}, []interface{}{lt, eq, gt})
+}
+
+func TestTrie_tinyNoAlloc(t *testing.T) {
+
+	ta := require.New(t)
+
+	for _, keys := range [][][]byte{{}, {[]byte("abc")}} {
+		tr, err := NewTrie(keys, make([]int, len(keys)), false)
+		ta.Nil(err)
+
+		for _, k := range []string{"abc", "ab", "x"} {
+			key := []byte(k)
+			allocs := testing.AllocsPerRun(10, func() {
+				tr.Search(key)
+				tr.Get(key)
+			})
+			ta.Equal(0.0, allocs, "keys: %q, search: %q", keys, k)
+		}
+	}
+}
+
 func TestTrieNew(t *testing.T) {
 
 	ta := require.New(t)
@@ -328,9 +396,8 @@ func TestTrieNew(t *testing.T) {
 			expectedErr: errors.Wrapf(ErrDuplicateKeys, "key: abc"),
 		},
 	}
-	ta.Panics(func() {
-		NewTrie([][]byte{{'a', 'b', 'c'}}, map[string]int{"abc": 0}, false)
-	}, "values is map")
+	_, err := NewTrie([][]byte{{'a', 'b', 'c'}}, map[string]int{"abc": 0}, false)
+	ta.Equal(&ValuesNotSliceError{Kind: reflect.Map}, err, "values is map")
 
 	for _, c := range cases {
 		_, err := NewTrie(c.keys, c.values, false)
@@ -344,8 +411,13 @@ func TestNewError(t *testing.T) {
 
 	ta := require.New(t)
 
-	ta.Panics(func() { NewTrie([][]byte{}, nil, false) }, "values is nil")
-	ta.Panics(func() { NewTrie([][]byte{{1}}, 1, false) }, "values is int")
+	_, err := NewTrie([][]byte{}, nil, false)
+	ta.Equal(&ValuesNotSliceError{Kind: reflect.Invalid}, err, "values is nil")
+	_, err = NewTrie([][]byte{{1}}, 1, false)
+	ta.Equal(&ValuesNotSliceError{Kind: reflect.Int}, err, "values is int")
+	ta.Equal("values must be a slice: int", err.Error())
+	_, err = NewReverseTrie([][]byte{{1}}, "1", false)
+	ta.Equal(ErrValuesNotSlice, errors.Cause(err), "values is string")
 
 	cases := []struct {
 		keys    [][]byte
@@ -360,6 +432,7 @@ func TestNewError(t *testing.T) {
 		{[][]byte{{1, 2}, {1}}, []int{1, 2}, ErrKeyOutOfOrder},
 		{[][]byte{{1, 2}, {1, 1}}, []int{1, 2}, ErrKeyOutOfOrder},
 		{[][]byte{{1, 2}, {1, 2}}, []int{1, 2}, ErrDuplicateKeys},
+		{[][]byte{{1}}, map[int]int{}, ErrValuesNotSlice},
 	}
 
 	for i, c := range cases {
@@ -371,6 +444,51 @@ func TestNewError(t *testing.T) {
 	}
 }
 
+func TestNewTrieFromFunc(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := [][]byte{}
+	values := []int{}
+	for i := 0; i < 300; i++ {
+		keys = append(keys, []byte(fmt.Sprintf("%04d", i*3)))
+		values = append(values, i)
+	}
+
+	for _, squash := range []bool{false, true} {
+
+		buf := make([]byte, 0, 4)
+		events := []int{}
+		tr, err := NewTrieFromFunc(func(i int) ([]byte, interface{}, bool) {
+			if i == 300 {
+				return nil, nil, false
+			}
+			buf = append(buf[:0], fmt.Sprintf("%04d", i*3)...)
+			return buf, i, true
+		}, squash, WithBuildHook(100, func(e BuildEvent) {
+			if e.Phase == PhaseAppend {
+				events = append(events, e.KeyCnt)
+			}
+		}))
+		ta.Nil(err)
+		ta.Equal([]int{100, 200, 300, 300}, events)
+
+		want, err := NewTrie(keys, values, squash)
+		ta.Nil(err)
+		ta.Equal(want.String(), tr.String())
+		ta.Nil(tr.Validate())
+	}
+
+	tr, err := NewTrieFromFunc(func(i int) ([]byte, interface{}, bool) { return nil, nil, false }, false)
+	ta.Nil(err)
+	ta.Equal(0, tr.KeyCnt())
+
+	_, err = NewTrieFromFunc(func(i int) ([]byte, interface{}, bool) {
+		return []byte{byte(10 - i)}, i, i < 2
+	}, false)
+	ta.Equal(ErrKeyOutOfOrder, errors.Cause(err))
+}
+
 func TestAppend(t *testing.T) {
 
 	tr, err := NewTrie([][]byte{{2, 3}, {2, 5}}, []int{1, 2}, false)
@@ -390,6 +508,9 @@ func TestAppend(t *testing.T) {
 		{[]byte{2, 4}, ErrKeyOutOfOrder},
 		{[]byte{2, 5}, ErrDuplicateKeys},
 		{[]byte{2, 6}, nil},
+		{[]byte{2, 3, 1}, ErrKeyOutOfOrder},
+		{[]byte{2, 5, 1}, ErrKeyOutOfOrder},
+		{[]byte{2, 6, 1}, nil},
 	}
 
 	for i, c := range cases {
@@ -401,6 +522,19 @@ func TestAppend(t *testing.T) {
 	}
 }
 
+func TestAppend_duplicateLeaf(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr, err := NewTrie([][]byte{{2, 3}}, []int{1}, false)
+	ta.Nil(err)
+
+	leaf, err := tr.Append([]byte{2, 3}, 2)
+	ta.Equal(ErrDuplicateKeys, err)
+	ta.Equal(tr.Children[2].Children[3].Children[leafBranch], leaf)
+	ta.Equal(1, leaf.Value)
+}
+
 func TestAppendOneKeySquash(t *testing.T) {
 
 	keys := [][]byte{
@@ -512,6 +646,129 @@ func TestNewTrieSquash(t *testing.T) {
 	}
 }
 
+func TestTrie_SquashLevels(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := [][]byte{
+		{1, 2, 3, 4, 0},
+		{1, 2, 3, 4, 1},
+		{1, 2, 3, 4, 2},
+		{1, 2, 3, 4, 3},
+		{1, 3, 3, 5, 4},
+	}
+	values := []int{0, 1, 2, 3, 4}
+
+	rt, err := NewTrie(keys, values, false)
+	ta.Nil(err)
+
+	levels := rt.SquashLevels()
+	ta.Equal([]SquashLevel{
+		{Nodes: 1, Squashed: 1},
+		{Nodes: 1, Squashed: 0},
+		{Nodes: 2, Squashed: 2},
+		{Nodes: 2, Squashed: 2},
+		{Nodes: 2, Squashed: 1},
+		{Nodes: 5, Squashed: 0},
+	}, levels)
+
+	want, err := NewTrie(keys, values, false)
+	ta.Nil(err)
+	ta.Equal(6, want.Squash())
+	ta.Equal(want.String(), rt.String())
+
+	ta.Equal([]SquashLevel{
+		{Nodes: 1, Squashed: 0},
+		{Nodes: 2, Squashed: 0},
+		{Nodes: 4, Squashed: 0},
+	}, rt.SquashLevels())
+}
+
+func TestTrie_Squash_deep(t *testing.T) {
+
+	ta := require.New(t)
+
+	key := bytes.Repeat([]byte("a"), 50000)
+	rt, err := NewTrie([][]byte{key, append(key, 'b')}, []int{1, 2}, false)
+	ta.Nil(err)
+
+	ta.Equal(50000, rt.Squash())
+
+	_, eq, gt := rt.Search(key)
+	ta.Equal(1, eq)
+	ta.Equal(2, gt)
+}
+
+func TestTrie_Squash_long(t *testing.T) {
+
+	ta := require.New(t)
+
+	// The shared prefix is longer than a Step can be.
+	prefix := bytes.Repeat([]byte("a"), 70000)
+	k1 := append(append([]byte{}, prefix...), 'a')
+	k2 := append(append([]byte{}, prefix...), 'b')
+
+	rt, err := NewTrie([][]byte{k1, k2}, []int{1, 2}, false)
+	ta.Nil(err)
+	rt.Squash()
+	ta.Nil(rt.Validate())
+	for i, k := range [][]byte{k1, k2} {
+		_, eq, _ := rt.Search(k)
+		ta.Equal(i+1, eq)
+	}
+
+	// Removing a branch merges 2 chains that are shorter than a Step can be
+	// into a longer one.
+	x := bytes.Repeat([]byte("x"), 40000)
+	y := bytes.Repeat([]byte("y"), 40000)
+	k1 = append(append(append(append([]byte{}, x...), 'a'), y...), '1')
+	k2 = append(append(append(append([]byte{}, x...), 'a'), y...), '2')
+	k3 := append(append([]byte{}, x...), 'b')
+	keys := [][]byte{[]byte("a"), k1, k2, k3, []byte("z")}
+
+	rt, err = NewTrie(keys, []int{0, 1, 2, 3, 4}, true)
+	ta.Nil(err)
+
+	f := rt.Filter(func(key []byte, v interface{}) bool { return v != 3 })
+	ta.Nil(f.Validate())
+	ta.True(rt.Remove(k3))
+	ta.Nil(rt.Validate())
+
+	for _, tr := range []*Node{f, rt} {
+		for i, k := range keys {
+			_, eq, _ := tr.Search(k)
+			if i == 3 {
+				ta.Nil(eq)
+			} else {
+				ta.Equal(i, eq)
+			}
+		}
+	}
+}
+
+func TestTrie_Squash_resquashed(t *testing.T) {
+
+	ta := require.New(t)
+
+	for _, opts := range [][]Option{nil, {WithStoreKeys()}} {
+
+		tr, err := NewTrie([][]byte{[]byte("a"), []byte("bcd1"), []byte("bcd2"), []byte("bce")}, []int{1, 2, 3, 4}, true, opts...)
+		ta.Nil(err)
+
+		// Remove squashes "bc" and "d" into one node, which Append squashes
+		// again once it is not on the right most path.
+		ta.True(tr.Remove([]byte("bce")))
+		_, err = tr.Append([]byte("c"), 5)
+		ta.Nil(err)
+		ta.Nil(tr.Validate())
+
+		for k, want := range map[string]int{"a": 1, "bcd1": 2, "bcd2": 3, "c": 5} {
+			_, eq, _ := tr.Search([]byte(k))
+			ta.Equal(want, eq, "key: %s", k)
+		}
+	}
+}
+
 func TestToStrings(t *testing.T) {
 	var keys = [][]byte{
 		{'a', 'b', 'c'},
@@ -802,3 +1059,75 @@ func TestTrie_SquashedTrieSearch(t *testing.T) {
 		}
 	}
 }
+
+func TestNeighborBranches_wide(t *testing.T) {
+
+	ta := require.New(t)
+
+	for _, n := range []int{1, 2, wideFanout, wideFanout + 1, 100, 257} {
+
+		// Every other label, with the leaf branch.
+		branches := []int{leafBranch}
+		for i := 0; len(branches) < n; i++ {
+			branches = append(branches, i*2)
+		}
+
+		for br := leafBranch; br <= 2*n+1; br++ {
+
+			lt, rt := -1, -1
+			for i, b := range branches {
+				if b < br {
+					lt = i
+				}
+				if b > br && rt == -1 {
+					rt = i
+				}
+			}
+
+			gotLt, gotRt := neighborBranches(branches, br)
+			ta.Equal([]int{lt, rt}, []int{gotLt, gotRt}, "n=%d br=%d", n, br)
+
+			gotLt, gotRt = neighborBranchesWide(branches, br)
+			ta.Equal([]int{lt, rt}, []int{gotLt, gotRt}, "wide: n=%d br=%d", n, br)
+
+			gotLt, gotRt = neighborBranchesLinear(branches, br)
+			ta.Equal([]int{lt, rt}, []int{gotLt, gotRt}, "linear: n=%d br=%d", n, br)
+		}
+	}
+}
+
+// neighborSink keeps the results of BenchmarkNeighborBranches.
+var neighborSink int
+
+// BenchmarkNeighborBranches compares the linear scan and the binary search
+// of neighborBranches, which switches at wideFanout, by fan-out:
+//
+//	go test -run NONE -bench NeighborBranches
+func BenchmarkNeighborBranches(b *testing.B) {
+
+	for _, n := range []int{8, 16, 24, 32, 64, 256} {
+
+		branches := make([]int, n)
+		for i := range branches {
+			branches[i] = i * 256 / n
+		}
+
+		// Look up every label, as random keys do.
+		for _, c := range []struct {
+			name string
+			fn   func([]int, int) (int, int)
+		}{
+			{"linear", neighborBranchesLinear},
+			{"binary", neighborBranchesWide},
+		} {
+			b.Run(fmt.Sprintf("%s/%d", c.name, n), func(b *testing.B) {
+				var ds int
+				for i := 0; i < b.N; i++ {
+					lt, rt := c.fn(branches, i&0xff)
+					ds += lt + rt
+				}
+				neighborSink = ds
+			})
+		}
+	}
+}
@@ -0,0 +1,22 @@
+//go:build go1.18
+// +build go1.18
+
+package trietest
+
+import "testing"
+
+func FuzzCheck(f *testing.F) {
+
+	f.Add([]byte{0, 8, 1, 16, 1, 2, 6, 7})
+	f.Add([]byte{8, 2, 8, 1, 7, 14, 1})
+	f.Add([]byte{24, 1, 2, 3, 16, 1, 2, 0, 31, 3, 3, 3})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ops := OpsFromBytes(data)
+		for _, squash := range []bool{false, true} {
+			if err := Check(ops, squash); err != nil {
+				t.Fatalf("squash: %v: %v\nops: %v", squash, err, ops)
+			}
+		}
+	})
+}
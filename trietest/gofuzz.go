@@ -0,0 +1,22 @@
+//go:build gofuzz
+// +build gofuzz
+
+package trietest
+
+// Fuzz is the entry point for go-fuzz.
+//
+// Since 0.2.0
+func Fuzz(data []byte) int {
+
+	ops := OpsFromBytes(data)
+	for _, squash := range []bool{false, true} {
+		if err := Check(ops, squash); err != nil {
+			panic(err)
+		}
+	}
+
+	if len(ops) == 0 {
+		return 0
+	}
+	return 1
+}
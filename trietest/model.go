@@ -0,0 +1,86 @@
+// Package trietest provides helpers to test trie.Node and code built on top of
+// it.
+//
+// Since 0.2.0
+package trietest
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/openacid/trie"
+)
+
+// Model is a reference implementation of a trie built with sorted slices.
+//
+// Since 0.2.0
+type Model struct {
+
+	// Keys are the keys appended so far, in ascending order.
+	//
+	// Since 0.2.0
+	Keys [][]byte
+
+	// Values are the values of Keys.
+	//
+	// Since 0.2.0
+	Values []interface{}
+}
+
+// Append adds a key-value pair, following the same rules as trie.Node.Append:
+// a key must be greater than all existing keys.
+//
+// Since 0.2.0
+func (m *Model) Append(key []byte, value interface{}) error {
+
+	l := len(m.Keys)
+	if l > 0 {
+		c := bytes.Compare(m.Keys[l-1], key)
+		if c == 0 {
+			return trie.ErrDuplicateKeys
+		}
+		if c > 0 {
+			return trie.ErrKeyOutOfOrder
+		}
+	}
+
+	m.Keys = append(m.Keys, key)
+	m.Values = append(m.Values, value)
+	return nil
+}
+
+// Search returns the value of the greatest key less than `key`, the value of
+// `key` and the value of the smallest key greater than `key`.
+// Any of them could be nil.
+//
+// Since 0.2.0
+func (m *Model) Search(key []byte) (ltValue, eqValue, gtValue interface{}) {
+
+	i := sort.Search(len(m.Keys), func(i int) bool {
+		return bytes.Compare(m.Keys[i], key) >= 0
+	})
+
+	if i > 0 {
+		ltValue = m.Values[i-1]
+	}
+
+	if i < len(m.Keys) && bytes.Equal(m.Keys[i], key) {
+		eqValue = m.Values[i]
+		i++
+	}
+
+	if i < len(m.Keys) {
+		gtValue = m.Values[i]
+	}
+	return
+}
+
+// Has reports whether `key` is in the model.
+//
+// Since 0.2.0
+func (m *Model) Has(key []byte) bool {
+	i := sort.Search(len(m.Keys), func(i int) bool {
+		return bytes.Compare(m.Keys[i], key) >= 0
+	})
+	return i < len(m.Keys) && bytes.Equal(m.Keys[i], key)
+}
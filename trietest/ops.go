@@ -0,0 +1,310 @@
+package trietest
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/openacid/errors"
+	"github.com/openacid/trie"
+)
+
+// OpType is the type of an operation applied by Check.
+//
+// Since 0.2.0
+type OpType int
+
+const (
+	// OpAppend appends Op.Key to the trie.
+	//
+	// Since 0.2.0
+	OpAppend OpType = iota
+
+	// OpSearch searches for Op.Key.
+	//
+	// Since 0.2.0
+	OpSearch
+
+	// OpSquash squashes the entire trie.
+	// After a full Squash, an OpAppend is allowed to fail if the key goes
+	// into a squashed branch.
+	//
+	// Since 0.2.0
+	OpSquash
+)
+
+// Op is a single operation applied by Check.
+//
+// Since 0.2.0
+type Op struct {
+	Type OpType
+	Key  []byte
+}
+
+// String implements fmt.Stringer.
+//
+// Since 0.2.0
+func (o Op) String() string {
+	switch o.Type {
+	case OpAppend:
+		return fmt.Sprintf("Append(%v)", o.Key)
+	case OpSearch:
+		return fmt.Sprintf("Search(%v)", o.Key)
+	case OpSquash:
+		return "Squash()"
+	}
+	return fmt.Sprintf("Op(%d)", o.Type)
+}
+
+// Check applies `ops` to a trie created with `squash` and to a Model, and
+// returns an error describing the first disagreement between them.
+//
+// The i-th op, if it is an OpAppend, appends value i.
+//
+// After every op, all keys are searched and the leaves are walked to verify
+// there are exactly the appended values in key order.
+// A squashed trie may return a false positive for an absent key, thus
+// searches for absent keys are only verified on an unsquashed trie.
+//
+// Since 0.2.0
+func Check(ops []Op, squash bool) error {
+
+	tr, err := trie.NewTrie(nil, nil, squash)
+	if err != nil {
+		return errors.Wrapf(err, "NewTrie")
+	}
+
+	m := &Model{}
+	squashed := squash
+
+	// frozen is set after a full Squash.
+	frozen := false
+
+	for i, op := range ops {
+
+		switch op.Type {
+		case OpAppend:
+			if frozen {
+				// Append could only succeed if it does not go into a
+				// squashed branch.
+				_, err := tr.Append(op.Key, i)
+				switch errors.Cause(err) {
+				case nil:
+					if wantErr := m.Append(op.Key, i); wantErr != nil {
+						return fmt.Errorf("%d-th op: %v: want error: %v, got: nil", i, op, wantErr)
+					}
+				case trie.ErrSquashed, trie.ErrKeyOutOfOrder, trie.ErrDuplicateKeys:
+				default:
+					return fmt.Errorf("%d-th op: %v: unexpected error: %v", i, op, err)
+				}
+				break
+			}
+
+			has := m.Has(op.Key)
+			wantErr := m.Append(op.Key, i)
+			_, err := tr.Append(op.Key, i)
+			err = errors.Cause(err)
+
+			// A trie reports an existent key that is not the last one either
+			// as out of order or as duplicate.
+			if err != wantErr && !(has && err == trie.ErrDuplicateKeys) {
+				return fmt.Errorf("%d-th op: %v: want error: %v, got: %v", i, op, wantErr, err)
+			}
+
+		case OpSearch:
+			if err := checkSearch(tr, m, op.Key, squashed); err != nil {
+				return fmt.Errorf("%d-th op: %v: %v", i, op, err)
+			}
+
+		case OpSquash:
+			tr.Squash()
+			squashed = true
+			frozen = true
+		}
+
+		if err := checkAll(tr, m, squashed); err != nil {
+			return fmt.Errorf("after %d-th op: %v: %v", i, op, err)
+		}
+	}
+
+	return nil
+}
+
+func checkAll(tr *trie.Node, m *Model, squashed bool) error {
+
+	for _, k := range m.Keys {
+		if err := checkSearch(tr, m, k, squashed); err != nil {
+			return err
+		}
+	}
+
+	vals := LeafValues(tr)
+	if !reflect.DeepEqual(emptyIfNil(m.Values), vals) {
+		return fmt.Errorf("walk: want values: %v, got: %v", m.Values, vals)
+	}
+	return nil
+}
+
+func checkSearch(tr *trie.Node, m *Model, key []byte, squashed bool) error {
+
+	if squashed && !m.Has(key) {
+		// Only verify it does not panic.
+		tr.Search(key)
+		return nil
+	}
+
+	wlt, weq, wgt := m.Search(key)
+	lt, eq, gt := tr.Search(key)
+
+	if wlt != lt || weq != eq || wgt != gt {
+		return fmt.Errorf("search %v: want: %v %v %v, got: %v %v %v",
+			key, wlt, weq, wgt, lt, eq, gt)
+	}
+	return nil
+}
+
+// LeafValues returns values of all leaves in a trie in key order.
+//
+// Since 0.2.0
+func LeafValues(n *trie.Node) []interface{} {
+
+	rst := []interface{}{}
+
+	var walk func(n *trie.Node)
+	walk = func(n *trie.Node) {
+		if len(n.Branches) == 0 {
+			if n.Value != nil {
+				rst = append(rst, n.Value)
+			}
+			return
+		}
+		for _, b := range n.Branches {
+			walk(n.Children[b])
+		}
+	}
+	walk(n)
+
+	return rst
+}
+
+// RandomOps generates a sequence of about `n` ops with keys built from
+// `alphabet`.
+//
+// Most OpAppend are in key order, with some duplicate and out of order keys.
+// It may end with an OpSquash followed by several OpSearch.
+//
+// Since 0.2.0
+func RandomOps(rnd *rand.Rand, n int, alphabet []byte) []Op {
+
+	randKey := func() []byte {
+		k := make([]byte, rnd.Intn(6))
+		for i := range k {
+			k[i] = alphabet[rnd.Intn(len(alphabet))]
+		}
+		return k
+	}
+
+	keys := make([][]byte, n)
+	for i := range keys {
+		keys[i] = randKey()
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i], keys[j]) < 0
+	})
+
+	ops := make([]Op, 0, n*2)
+	for i, k := range keys {
+		ops = append(ops, Op{OpAppend, k})
+
+		if rnd.Intn(10) == 0 {
+			ops = append(ops, Op{OpAppend, keys[rnd.Intn(i+1)]})
+		}
+		if rnd.Intn(3) == 0 {
+			ops = append(ops, Op{OpSearch, randKey()})
+		}
+	}
+
+	if rnd.Intn(2) == 0 {
+		ops = append(ops, Op{Type: OpSquash})
+		for i := 0; i < n/2; i++ {
+			ops = append(ops, Op{OpSearch, randKey()})
+		}
+	}
+
+	return ops
+}
+
+// OpsFromBytes decodes a byte stream into ops, for fuzzing.
+//
+// Every op is encoded with a header byte followed by key bytes: the lower 3
+// bits of the header is the op type: 0-5 for OpAppend, 6 for OpSearch and 7
+// for OpSquash; the rest bits are the key length.
+// Key bytes are reduced to 4 distinct values to make keys share prefixes.
+//
+// Since 0.2.0
+func OpsFromBytes(data []byte) []Op {
+
+	ops := []Op{}
+
+	for len(data) > 0 {
+		h := data[0]
+		data = data[1:]
+
+		var typ OpType
+		switch h & 7 {
+		case 6:
+			typ = OpSearch
+		case 7:
+			ops = append(ops, Op{Type: OpSquash})
+			continue
+		default:
+			typ = OpAppend
+		}
+
+		l := int(h>>3) % 6
+		if l > len(data) {
+			l = len(data)
+		}
+
+		key := make([]byte, l)
+		for i := range key {
+			key[i] = data[i] & 3
+		}
+		data = data[l:]
+
+		ops = append(ops, Op{typ, key})
+	}
+
+	return ops
+}
+
+// CheckRandom runs Check with `rounds` random op sequences of about `n` ops,
+// on both squashed and unsquashed tries.
+// The op sequence is logged on failure.
+//
+// Since 0.2.0
+func CheckRandom(t testing.TB, seed int64, rounds, n int) {
+
+	rnd := rand.New(rand.NewSource(seed))
+	alphabet := []byte{0, 1, 2, 'a', 'b', 255}
+
+	for i := 0; i < rounds; i++ {
+		ops := RandomOps(rnd, n, alphabet)
+		for _, squash := range []bool{false, true} {
+			if err := Check(ops, squash); err != nil {
+				t.Fatalf("seed: %d, round: %d, squash: %v: %v\nops: %v",
+					seed, i, squash, err, ops)
+			}
+		}
+	}
+}
+
+func emptyIfNil(vs []interface{}) []interface{} {
+	if vs == nil {
+		return []interface{}{}
+	}
+	return vs
+}
@@ -0,0 +1,44 @@
+package trietest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck(t *testing.T) {
+
+	ta := require.New(t)
+
+	ops := []Op{
+		{OpAppend, []byte{1, 2}},
+		{OpAppend, []byte{1, 2}},
+		{OpAppend, []byte{1}},
+		{OpAppend, []byte{1, 3, 4}},
+		{OpSearch, []byte{1, 3}},
+		{OpAppend, []byte{2}},
+		{OpSquash, nil},
+		{OpAppend, []byte{3}},
+		{OpSearch, []byte{1, 5, 4}},
+	}
+
+	ta.Nil(Check(ops, false))
+	ta.Nil(Check(ops, true))
+}
+
+func TestOpsFromBytes(t *testing.T) {
+
+	ta := require.New(t)
+
+	got := OpsFromBytes([]byte{8 + 6, 5, 7, 16, 1, 2, 40})
+	ta.Equal([]Op{
+		{OpSearch, []byte{1}},
+		{Type: OpSquash},
+		{OpAppend, []byte{1, 2}},
+		{OpAppend, []byte{}},
+	}, got)
+}
+
+func TestCheckRandom(t *testing.T) {
+	CheckRandom(t, 1, 50, 100)
+}
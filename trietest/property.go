@@ -0,0 +1,265 @@
+package trietest
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/openacid/trie"
+)
+
+// Searcher is anything that answers Search like trie.Node does.
+//
+// Since 0.2.0
+type Searcher interface {
+	Search(key []byte) (ltValue, eqValue, gtValue interface{})
+}
+
+// Builder builds a Searcher from ascendingly ordered unique keys and their
+// values.
+//
+// Since 0.2.0
+type Builder func(keys [][]byte, values []interface{}) (Searcher, error)
+
+// Dump returns all keys and values in a Searcher in iteration order.
+//
+// Since 0.2.0
+type Dump func(s Searcher) (keys [][]byte, values []interface{})
+
+// Generator generates ascendingly ordered unique keys and their values.
+//
+// Since 0.2.0
+type Generator func(rnd *rand.Rand) (keys [][]byte, values []interface{})
+
+// Property checks an invariant of the Searcher built by `b` from `keys` and
+// `values`. It returns an error describing the violation, or nil.
+//
+// Since 0.2.0
+type Property func(b Builder, keys [][]byte, values []interface{}) error
+
+// TrieBuilder returns a Builder that creates a trie.Node with NewTrie.
+//
+// Since 0.2.0
+func TrieBuilder(squash bool) Builder {
+	return func(keys [][]byte, values []interface{}) (Searcher, error) {
+		return trie.NewTrie(keys, values, squash)
+	}
+}
+
+// TrieDump is a Dump of a trie.Node with trie.Iter.
+// Keys are incomplete in a squashed trie.
+//
+// Since 0.2.0
+func TrieDump(s Searcher) ([][]byte, []interface{}) {
+
+	keys := [][]byte{}
+	values := []interface{}{}
+
+	it := s.(*trie.Node).NewIter()
+	for it.Next() {
+		keys = append(keys, append([]byte{}, it.Key()...))
+		values = append(values, it.Value())
+	}
+
+	return keys, values
+}
+
+// GenKeys returns a Generator of up to `maxN` keys of up to `maxLen` bytes
+// from `alphabet`. Values are distinct ints.
+//
+// Since 0.2.0
+func GenKeys(maxN, maxLen int, alphabet []byte) Generator {
+	return func(rnd *rand.Rand) ([][]byte, []interface{}) {
+
+		n := rnd.Intn(maxN + 1)
+		keys := make([][]byte, 0, n)
+		for i := 0; i < n; i++ {
+			k := make([]byte, rnd.Intn(maxLen+1))
+			for j := range k {
+				k[j] = alphabet[rnd.Intn(len(alphabet))]
+			}
+			keys = append(keys, k)
+		}
+
+		keys = SortUnique(keys)
+		values := make([]interface{}, len(keys))
+		for i := range values {
+			values[i] = i
+		}
+		return keys, values
+	}
+}
+
+// SortUnique sorts keys in place and removes duplicates.
+//
+// Since 0.2.0
+func SortUnique(keys [][]byte) [][]byte {
+
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i], keys[j]) < 0
+	})
+
+	rst := keys[:0]
+	for i, k := range keys {
+		if i > 0 && bytes.Equal(k, keys[i-1]) {
+			continue
+		}
+		rst = append(rst, k)
+	}
+	return rst
+}
+
+// SearchNeighbors checks Search returns the correct left sibling, matching and
+// right sibling value for every key.
+// If `exact` is true, it also checks keys not in the Searcher, which does not
+// hold on a squashed trie.
+//
+// Since 0.2.0
+func SearchNeighbors(exact bool) Property {
+	return func(b Builder, keys [][]byte, values []interface{}) error {
+
+		s, err := b(keys, values)
+		if err != nil {
+			return err
+		}
+
+		m := &Model{Keys: keys, Values: values}
+
+		probes := keys
+		if exact {
+			probes = Probes(keys)
+		}
+
+		for _, k := range probes {
+			wlt, weq, wgt := m.Search(k)
+			lt, eq, gt := s.Search(k)
+			if wlt != lt || weq != eq || wgt != gt {
+				return fmt.Errorf("search %v: want: %v %v %v, got: %v %v %v",
+					k, wlt, weq, wgt, lt, eq, gt)
+			}
+		}
+		return nil
+	}
+}
+
+// IterSorted checks keys returned by `dump` are ascendingly ordered, unique,
+// and are exactly the input keys with their values.
+//
+// Since 0.2.0
+func IterSorted(dump Dump) Property {
+	return func(b Builder, keys [][]byte, values []interface{}) error {
+
+		s, err := b(keys, values)
+		if err != nil {
+			return err
+		}
+
+		ks, vs := dump(s)
+		for i := 1; i < len(ks); i++ {
+			if bytes.Compare(ks[i-1], ks[i]) >= 0 {
+				return fmt.Errorf("iterated keys not ascending: %d-th: %v, %d-th: %v",
+					i-1, ks[i-1], i, ks[i])
+			}
+		}
+
+		return equalKVs(keys, values, ks, vs)
+	}
+}
+
+// Rebuild checks that building from what `dump` returns yields an identical
+// Searcher: the dumps are the same, and for a fmt.Stringer, so are the
+// strings.
+//
+// Since 0.2.0
+func Rebuild(dump Dump) Property {
+	return func(b Builder, keys [][]byte, values []interface{}) error {
+
+		s1, err := b(keys, values)
+		if err != nil {
+			return err
+		}
+		k1, v1 := dump(s1)
+
+		s2, err := b(k1, v1)
+		if err != nil {
+			return fmt.Errorf("rebuild: %v", err)
+		}
+		k2, v2 := dump(s2)
+
+		if err := equalKVs(k1, v1, k2, v2); err != nil {
+			return fmt.Errorf("rebuild: %v", err)
+		}
+
+		if st1, ok := s1.(fmt.Stringer); ok {
+			st2 := s2.(fmt.Stringer)
+			if st1.String() != st2.String() {
+				return fmt.Errorf("rebuild: want:\n%s\ngot:\n%s", st1, st2)
+			}
+		}
+		return nil
+	}
+}
+
+// Probes returns `keys` along with keys around them: every prefix, every key
+// with a byte appended, and every key with the last byte changed.
+//
+// Since 0.2.0
+func Probes(keys [][]byte) [][]byte {
+
+	rst := [][]byte{}
+	for _, k := range keys {
+		rst = append(rst, k)
+		for i := 0; i < len(k); i++ {
+			rst = append(rst, k[:i])
+		}
+		rst = append(rst,
+			append(append([]byte{}, k...), 0),
+			append(append([]byte{}, k...), 255),
+		)
+		if l := len(k); l > 0 {
+			for _, d := range []byte{1, 255} {
+				p := append([]byte{}, k...)
+				p[l-1] += d
+				rst = append(rst, p)
+			}
+		}
+	}
+	return rst
+}
+
+// CheckProperties runs every property against `rounds` key sets generated
+// by `g`.
+//
+// Since 0.2.0
+func CheckProperties(t testing.TB, seed int64, rounds int, g Generator, b Builder, props ...Property) {
+
+	rnd := rand.New(rand.NewSource(seed))
+
+	for i := 0; i < rounds; i++ {
+		keys, values := g(rnd)
+		for j, p := range props {
+			if err := p(b, keys, values); err != nil {
+				t.Fatalf("seed: %d, round: %d, %d-th property: %v\nkeys: %v",
+					seed, i, j, err, keys)
+			}
+		}
+	}
+}
+
+func equalKVs(wantKeys [][]byte, wantValues []interface{}, keys [][]byte, values []interface{}) error {
+
+	if len(wantKeys) == 0 && len(keys) == 0 {
+		return nil
+	}
+
+	if !reflect.DeepEqual(wantKeys, keys) {
+		return fmt.Errorf("want keys: %v, got: %v", wantKeys, keys)
+	}
+	if !reflect.DeepEqual(wantValues, values) {
+		return fmt.Errorf("want values: %v, got: %v", wantValues, values)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package trietest
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenKeys(t *testing.T) {
+
+	ta := require.New(t)
+
+	g := GenKeys(50, 4, []byte{1, 2, 3})
+	k1, v1 := g(rand.New(rand.NewSource(3)))
+	k2, v2 := g(rand.New(rand.NewSource(3)))
+
+	ta.Equal(k1, k2)
+	ta.Equal(v1, v2)
+	ta.Equal(k1, SortUnique(append([][]byte{}, k1...)))
+}
+
+func TestProperties(t *testing.T) {
+
+	g := GenKeys(100, 5, []byte{0, 1, 2, 'a', 'b', 255})
+
+	CheckProperties(t, 1, 100, g, TrieBuilder(false),
+		SearchNeighbors(true),
+		IterSorted(TrieDump),
+		Rebuild(TrieDump),
+	)
+
+	CheckProperties(t, 2, 100, g, TrieBuilder(true),
+		SearchNeighbors(false),
+	)
+}
+
+func TestProperties_violation(t *testing.T) {
+
+	ta := require.New(t)
+
+	// A Searcher that never finds anything.
+	b := func(keys [][]byte, values []interface{}) (Searcher, error) {
+		return &Model{}, nil
+	}
+
+	err := SearchNeighbors(false)(b, [][]byte{{1}}, []interface{}{1})
+	ta.NotNil(err)
+}
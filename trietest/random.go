@@ -0,0 +1,164 @@
+package trietest
+
+import (
+	"math/rand"
+
+	"github.com/openacid/trie"
+)
+
+// KeyLenDist draws a key length from `rnd`.
+//
+// Since 0.2.0
+type KeyLenDist func(rnd *rand.Rand) int
+
+// FixedLen returns a KeyLenDist that always returns `l`.
+//
+// Since 0.2.0
+func FixedLen(l int) KeyLenDist {
+	return func(rnd *rand.Rand) int {
+		return l
+	}
+}
+
+// UniformLen returns a KeyLenDist uniformly distributed in [min, max].
+//
+// Since 0.2.0
+func UniformLen(min, max int) KeyLenDist {
+	return func(rnd *rand.Rand) int {
+		return min + rnd.Intn(max-min+1)
+	}
+}
+
+// NormalLen returns a KeyLenDist normally distributed with `mean` and
+// `stddev`, clipped to be non-negative.
+//
+// Since 0.2.0
+func NormalLen(mean, stddev float64) KeyLenDist {
+	return func(rnd *rand.Rand) int {
+		l := int(rnd.NormFloat64()*stddev + mean + 0.5)
+		if l < 0 {
+			l = 0
+		}
+		return l
+	}
+}
+
+// Stats describes a RandomSet.
+//
+// Since 0.2.0
+type Stats struct {
+	KeyCnt int
+
+	MinKeyLen   int
+	MaxKeyLen   int
+	TotalKeyLen int
+
+	// PrefixCnt is the number of distinct non-empty prefixes of all keys,
+	// including the keys themselves.
+	// An unsquashed trie has PrefixCnt+1 inner nodes.
+	PrefixCnt int
+}
+
+// RandomSet is a reproducible set of keys and values.
+//
+// Since 0.2.0
+type RandomSet struct {
+
+	// Keys are ascendingly ordered and unique.
+	//
+	// Since 0.2.0
+	Keys [][]byte
+
+	// Values are the indexes of Keys, as int.
+	//
+	// Since 0.2.0
+	Values []interface{}
+
+	// Stats describes Keys.
+	//
+	// Since 0.2.0
+	Stats Stats
+}
+
+// Random generates up to `n` unique keys with bytes from `alphabet` and
+// lengths drawn from `keyLenDist`. The same arguments always produce the same
+// set.
+//
+// Fewer than `n` keys are generated if the key space is too small.
+//
+// Since 0.2.0
+func Random(seed int64, n int, keyLenDist KeyLenDist, alphabet []byte) *RandomSet {
+
+	rnd := rand.New(rand.NewSource(seed))
+
+	seen := make(map[string]bool, n)
+	keys := make([][]byte, 0, n)
+
+	for tries := 0; len(keys) < n && tries < n*10+100; tries++ {
+		k := make([]byte, keyLenDist(rnd))
+		for i := range k {
+			k[i] = alphabet[rnd.Intn(len(alphabet))]
+		}
+		if seen[string(k)] {
+			continue
+		}
+		seen[string(k)] = true
+		keys = append(keys, k)
+	}
+
+	keys = SortUnique(keys)
+
+	values := make([]interface{}, len(keys))
+	for i := range values {
+		values[i] = i
+	}
+
+	return &RandomSet{
+		Keys:   keys,
+		Values: values,
+		Stats:  keyStats(keys),
+	}
+}
+
+// Trie builds a trie of the set.
+//
+// Since 0.2.0
+func (s *RandomSet) Trie(squash bool) *trie.Node {
+	t, err := trie.NewTrie(s.Keys, s.Values, squash)
+	if err != nil {
+		// keys are sorted and unique.
+		panic(err)
+	}
+	return t
+}
+
+func keyStats(keys [][]byte) Stats {
+
+	st := Stats{KeyCnt: len(keys)}
+
+	var prev []byte
+	for i, k := range keys {
+		l := len(k)
+		if i == 0 || l < st.MinKeyLen {
+			st.MinKeyLen = l
+		}
+		if l > st.MaxKeyLen {
+			st.MaxKeyLen = l
+		}
+		st.TotalKeyLen += l
+
+		// Sorted keys: the new prefixes of k are those longer than the common
+		// prefix with the previous key.
+		st.PrefixCnt += l - commonPrefixLen(prev, k)
+		prev = k
+	}
+	return st
+}
+
+func commonPrefixLen(a, b []byte) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
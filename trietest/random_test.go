@@ -0,0 +1,48 @@
+package trietest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRandom(t *testing.T) {
+
+	ta := require.New(t)
+
+	abc := []byte("abc")
+
+	s1 := Random(7, 200, UniformLen(1, 6), abc)
+	s2 := Random(7, 200, UniformLen(1, 6), abc)
+	ta.Equal(s1, s2)
+	ta.Equal(200, s1.Stats.KeyCnt)
+	ta.Equal(s1.Keys, SortUnique(append([][]byte{}, s1.Keys...)))
+
+	tr := s1.Trie(false)
+	ta.Equal(s1.Stats.PrefixCnt+1, tr.InnerNodeCnt)
+
+	ks, vs := TrieDump(tr)
+	ta.Equal(s1.Keys, ks)
+	ta.Equal(s1.Values, vs)
+
+	s3 := Random(8, 200, UniformLen(1, 6), abc)
+	ta.NotEqual(s1.Keys, s3.Keys)
+}
+
+func TestRandom_stats(t *testing.T) {
+
+	ta := require.New(t)
+
+	s := Random(1, 100, FixedLen(2), []byte{1, 2})
+	ta.Equal(4, s.Stats.KeyCnt, "only 4 keys possible")
+	ta.Equal(2, s.Stats.MinKeyLen)
+	ta.Equal(2, s.Stats.MaxKeyLen)
+	ta.Equal(8, s.Stats.TotalKeyLen)
+	ta.Equal(6, s.Stats.PrefixCnt)
+
+	s = Random(1, 100, NormalLen(5, 2), []byte("xyz"))
+	ta.Equal(100, s.Stats.KeyCnt)
+	for _, k := range s.Keys {
+		ta.True(len(k) >= s.Stats.MinKeyLen && len(k) <= s.Stats.MaxKeyLen)
+	}
+}
@@ -0,0 +1,75 @@
+package trie
+
+import "time"
+
+// SetTTL is the same as Set, except that `key` expires after `ttl`.
+// Set of the key again makes it not expire.
+//
+// An expired key is not found by Search or Get, which do not modify the trie.
+// It is removed when Sweep visits it. Until then, it is still yielded by Iter,
+// counted by KeyCnt, and may be returned by Search as a neighbor.
+//
+// Since 0.2.0
+func (r *Node) SetTTL(key []byte, value interface{}, ttl time.Duration) (leaf *Node, err error) {
+
+	leaf, err = r.Set(key, value)
+	if err != nil {
+		return
+	}
+
+	r.expire(leaf, ttl)
+	return
+}
+
+// expire makes `leaf` expire after `ttl`.
+func (r *Node) expire(leaf *Node, ttl time.Duration) {
+	leaf.leaf().expiry = time.Now().Add(ttl).UnixNano()
+}
+
+// Sweep visits at most `budget` keys, continuing from where the last Sweep
+// stopped, and removes those expired by SetTTL. Once the last key is visited,
+// the next Sweep starts from the first key. It returns the number of keys
+// removed.
+//
+// It bounds the work of expiring keys, thus can be called periodically, e.g.,
+// by the goroutine writing the trie.
+//
+// Keys are found by the form Iter rebuilds them, thus it only removes keys
+// in a trie that does not squash.
+//
+// Since 0.2.0
+func (r *Node) Sweep(budget int) int {
+
+	if r.opt == nil || budget <= 0 {
+		return 0
+	}
+
+	now := time.Now().UnixNano()
+	var expired [][]byte
+	var last []byte
+	visited := 0
+
+	r.scanRange(r.opt.sweepFrom, nil, func(key []byte, leaf *Node) bool {
+		if leaf.expiry() != 0 && now >= leaf.expiry() {
+			expired = append(expired, copyBytes(key))
+		}
+		visited++
+		last = append(last[:0], key...)
+		return visited < budget
+	})
+
+	if visited < budget {
+		// Reached the end.
+		r.opt.sweepFrom = nil
+	} else {
+		// The next key after last.
+		r.opt.sweepFrom = append(last, 0)
+	}
+
+	return r.removeKeys(expired)
+}
+
+// expired returns whether `leaf` set with SetTTL has expired.
+func (r *Node) expired(leaf *Node) bool {
+	return leaf.expiry() != 0 && time.Now().UnixNano() >= leaf.expiry()
+}
@@ -0,0 +1,190 @@
+package trie
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNode_SetTTL(t *testing.T) {
+
+	ta := require.New(t)
+
+	for _, opts := range [][]Option{nil, {WithLazyRemove()}} {
+
+		tr, err := NewTrie(nil, nil, false, opts...)
+		ta.Nil(err)
+
+		_, err = tr.SetTTL([]byte("a"), 1, time.Hour)
+		ta.Nil(err)
+		_, err = tr.SetTTL([]byte("b"), 2, -time.Second)
+		ta.Nil(err)
+		_, err = tr.Set([]byte("c"), 3)
+		ta.Nil(err)
+
+		// An expired key is not found, and is not removed by reads.
+		lt, eq, gt := tr.Search([]byte("b"))
+		ta.Equal(1, lt)
+		ta.Nil(eq)
+		ta.Equal(3, gt)
+		_, found := tr.Get([]byte("b"))
+		ta.False(found)
+		ta.Equal(3, tr.KeyCnt())
+		ta.Equal(1, tr.Sweep(10))
+		ta.Equal(2, tr.KeyCnt())
+
+		_, eq, _ = tr.Search([]byte("a"))
+		ta.Equal(1, eq)
+
+		// Set without TTL makes a key not expire.
+		_, err = tr.SetTTL([]byte("a"), 4, -time.Second)
+		ta.Nil(err)
+		_, err = tr.Set([]byte("a"), 5)
+		ta.Nil(err)
+		_, eq, _ = tr.Search([]byte("a"))
+		ta.Equal(5, eq)
+	}
+}
+
+func TestNode_Sweep(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr, err := NewTrie(nil, nil, false)
+	ta.Nil(err)
+
+	ta.Equal(0, tr.Sweep(10))
+
+	keys := []string{"a", "b", "c", "d", "e", "f"}
+	for i, k := range keys {
+		ttl := time.Hour
+		if i%2 == 0 {
+			ttl = -time.Second
+		}
+		_, err = tr.SetTTL([]byte(k), i, ttl)
+		ta.Nil(err)
+	}
+
+	// "a", "b" are visited.
+	ta.Equal(1, tr.Sweep(2))
+	ta.Equal(5, tr.KeyCnt())
+
+	// "c", "d", "e", "f" are visited.
+	ta.Equal(2, tr.Sweep(10))
+	ta.Equal(3, tr.KeyCnt())
+
+	// Starts over.
+	_, err = tr.SetTTL([]byte("b"), 1, -time.Second)
+	ta.Nil(err)
+	ta.Equal(1, tr.Sweep(1))
+	ta.Equal(0, tr.Sweep(0))
+
+	got := []interface{}{}
+	for it := tr.NewIter(); it.Next(); {
+		got = append(got, it.Value())
+	}
+	ta.Equal([]interface{}{3, 5}, got)
+}
+
+func TestNode_SetTTL_readOnly(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr, err := NewTrie(nil, nil, false)
+	ta.Nil(err)
+	_, err = tr.SetTTL([]byte("a"), 1, -time.Second)
+	ta.Nil(err)
+	_, err = tr.Set([]byte("b"), 2)
+	ta.Nil(err)
+
+	// Remove fails on a read only trie, which does not make an expired key
+	// found.
+	tr.hashCons()
+	ta.True(tr.readOnly())
+
+	_, eq, gt := tr.Search([]byte("a"))
+	ta.Nil(eq)
+	ta.Equal(2, gt)
+	_, found := tr.Get([]byte("a"))
+	ta.False(found)
+	_, found = tr.SearchCandidate([]byte("a"))
+	ta.False(found)
+}
+
+func TestSyncTrie_expiredRead(t *testing.T) {
+
+	ta := require.New(t)
+
+	s, err := NewSyncTrie(nil, nil, false)
+	ta.Nil(err)
+
+	ops := []Op{}
+	for i := 0; i < 200; i++ {
+		ops = append(ops, Op{Type: OpSetTTL, Key: []byte{byte(i)}, Value: i, TTL: -time.Second})
+	}
+	ta.Nil(s.Apply(ops))
+	v := s.Load()
+
+	// Keys found are sent to the test goroutine, which asserts there is none.
+	found := make(chan int, 4)
+	for g := 0; g < 4; g++ {
+		go func() {
+			n := 0
+			for i := 0; i < 200; i++ {
+				if _, eq, _ := s.Search([]byte{byte(i)}); eq != nil {
+					n++
+				}
+			}
+			found <- n
+		}()
+	}
+	for g := 0; g < 4; g++ {
+		ta.Equal(0, <-found)
+	}
+
+	ta.Equal(200, v.KeyCnt())
+}
+
+func TestSyncTrie_TTL_versions(t *testing.T) {
+
+	ta := require.New(t)
+
+	s, err := NewSyncTrie(nil, nil, false, WithHistory(2))
+	ta.Nil(err)
+
+	ta.Nil(s.Apply([]Op{{Type: OpSetTTL, Key: []byte("a"), Value: 1, TTL: time.Hour}}))
+	ta.Nil(s.Set([]byte("a"), 2))
+	v := s.Load()
+
+	stop := make(chan bool)
+	done := make(chan bool)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				done <- true
+				return
+			default:
+			}
+			s.Search([]byte("a"))
+			s.Load().History([]byte("a"))
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		ta.Nil(s.Apply([]Op{{Type: OpSetTTL, Key: []byte("a"), Value: 3 + i, TTL: -time.Second}}))
+		tx := s.Begin()
+		tx.Remove([]byte("a"))
+		ta.Nil(tx.Commit())
+	}
+	close(stop)
+	<-done
+
+	// The version of before is not affected.
+	_, eq, _ := v.Search([]byte("a"))
+	ta.Equal(2, eq)
+	h := v.History([]byte("a"))
+	ta.Equal(1, len(h))
+	ta.Equal(1, h[0].Value)
+}
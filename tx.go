@@ -0,0 +1,99 @@
+package trie
+
+import "github.com/openacid/errors"
+
+// Tx is a batch of changes of a SyncTrie, staged by Set and Remove and
+// applied by Commit all at once.
+// A Tx is not safe to use concurrently.
+//
+// Since 0.2.0
+type Tx struct {
+	s    *SyncTrie
+	ops  []Op
+	done bool
+}
+
+// Begin starts a Tx on s. Nothing is applied until Commit.
+//
+// Since 0.2.0
+func (s *SyncTrie) Begin() *Tx {
+	return &Tx{s: s}
+}
+
+// Set stages a Node.Set of `key`.
+//
+// Since 0.2.0
+func (tx *Tx) Set(key []byte, value interface{}) {
+	tx.ops = append(tx.ops, Op{Type: OpSet, Key: copyBytes(key), Value: value})
+}
+
+// Remove stages a Node.Remove of `key`.
+//
+// Since 0.2.0
+func (tx *Tx) Remove(key []byte) {
+	tx.ops = append(tx.ops, Op{Type: OpRemove, Key: copyBytes(key)})
+}
+
+// Commit applies the staged changes with SyncTrie.Apply: readers see either
+// none or all of them.
+// It returns ErrTxDone if the Tx is already committed or rolled back.
+//
+// Since 0.2.0
+func (tx *Tx) Commit() error {
+
+	if tx.done {
+		return errors.Wrapf(ErrTxDone, "commit")
+	}
+	tx.done = true
+
+	return tx.s.Apply(tx.ops)
+}
+
+// Rollback discards the staged changes.
+// It returns ErrTxDone if the Tx is already committed or rolled back.
+//
+// Since 0.2.0
+func (tx *Tx) Rollback() error {
+
+	if tx.done {
+		return errors.Wrapf(ErrTxDone, "rollback")
+	}
+	tx.done = true
+	tx.ops = nil
+	return nil
+}
+
+// copyKeyPath returns a copy of r, in which every node on the path of `key`,
+// including squashed nodes and the leaf, is copied and the others are shared
+// with r. Set and Remove of `key` on the copy do not modify r.
+func (r *Node) copyKeyPath(key []byte) *Node {
+
+	root := r.copyNode()
+
+	node := root
+	for i := -1; ; {
+		i += int(node.Step)
+		if len(key) < i {
+			break
+		}
+
+		br := leafBranch
+		if len(key) > i {
+			br = int(key[i])
+		}
+
+		child := node.Children[br]
+		if child == nil {
+			break
+		}
+		cp := child.copyNode()
+		node.Children[br] = cp
+
+		if br == leafBranch {
+			break
+		}
+		node = cp
+	}
+
+	return root
+}
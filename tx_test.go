@@ -0,0 +1,117 @@
+package trie
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/openacid/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTx(t *testing.T) {
+
+	ta := require.New(t)
+
+	for _, opt := range []Option{WithLazyRemove(), WithHistory(2)} {
+
+		st, err := NewSyncTrie(nil, nil, false, opt)
+		ta.Nil(err)
+		for i, k := range []string{"a", "ab", "b"} {
+			ta.Nil(st.Append([]byte(k), i))
+		}
+		old := st.Load()
+
+		tx := st.Begin()
+		tx.Set([]byte("a"), 10)
+		tx.Remove([]byte("ab"))
+		tx.Set([]byte("c"), 30)
+
+		// Nothing is applied before Commit.
+		_, eq, _ := st.Search([]byte("c"))
+		ta.Nil(eq)
+
+		ta.Nil(tx.Commit())
+		ta.Equal(ErrTxDone, errors.Cause(tx.Commit()))
+		ta.Equal(ErrTxDone, errors.Cause(tx.Rollback()))
+
+		ks, vs := iterAll(st.Load().NewIter())
+		ta.Equal([]string{"a", "b", "c"}, ks)
+		ta.Equal([]interface{}{10, 2, 30}, vs)
+
+		// The version before Commit is not modified.
+		ks, vs = iterAll(old.NewIter())
+		ta.Equal([]string{"a", "ab", "b"}, ks)
+		ta.Equal([]interface{}{0, 1, 2}, vs)
+
+		tx = st.Begin()
+		tx.Set([]byte("d"), 40)
+		ta.Nil(tx.Rollback())
+		ta.Equal(ErrTxDone, errors.Cause(tx.Commit()))
+
+		_, eq, _ = st.Search([]byte("d"))
+		ta.Nil(eq)
+	}
+}
+
+func TestTx_error(t *testing.T) {
+
+	ta := require.New(t)
+
+	st, err := NewSyncTrie(
+		[][]byte{[]byte("abc"), []byte("abd")}, []int{1, 2}, true)
+	ta.Nil(err)
+	st.Load().Squash()
+
+	tx := st.Begin()
+	tx.Set([]byte("b"), 3)
+	tx.Set([]byte("abe"), 4)
+	ta.Equal(ErrSquashed, errors.Cause(tx.Commit()))
+
+	// No change is published.
+	_, eq, _ := st.Search([]byte("b"))
+	ta.Nil(eq)
+}
+
+func TestTx_atomic(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := [][]byte{[]byte("a"), []byte("b"), []byte("c")}
+
+	st, err := NewSyncTrie(keys, []int{0, 0, 0}, false)
+	ta.Nil(err)
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+
+			root := st.Load()
+			_, first, _ := root.Search(keys[0])
+			for _, k := range keys[1:] {
+				_, eq, _ := root.Search(k)
+				if eq != first {
+					t.Errorf("partial commit: %v and %v", first, eq)
+					return
+				}
+			}
+		}
+	}()
+
+	for i := 1; i <= 100; i++ {
+		tx := st.Begin()
+		for _, k := range keys {
+			tx.Set(k, i)
+		}
+		ta.Nil(tx.Commit())
+	}
+	close(stop)
+	wg.Wait()
+}
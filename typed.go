@@ -0,0 +1,12 @@
+//go:build go1.18
+// +build go1.18
+
+package trie
+
+// NewTrieG is the same as NewTrie except that values are of a slice of any
+// type, which are read without reflection.
+//
+// Since 0.2.0
+func NewTrieG[V any](keys [][]byte, values []V, squash bool, opts ...Option) (*Node, error) {
+	return newTrie(nil, keys, len(values), func(i int) interface{} { return values[i] }, squash, opts)
+}
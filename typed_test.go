@@ -0,0 +1,64 @@
+//go:build go1.18
+// +build go1.18
+
+package trie
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTrieG(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := [][]byte{[]byte("a"), []byte("ab"), []byte("b")}
+
+	type point struct{ x, y int }
+
+	for _, squash := range []bool{false, true} {
+		ints, err := NewTrieG(keys, []int{1, 2, 3}, squash)
+		ta.Nil(err)
+		want, err := NewTrie(keys, []int{1, 2, 3}, squash)
+		ta.Nil(err)
+		ta.Equal(want.String(), ints.String())
+		ta.Nil(ints.Validate())
+
+		points, err := NewTrieG(keys, []point{{1, 2}, {3, 4}, {5, 6}}, squash, WithCachedExtremes())
+		ta.Nil(err)
+		_, eq, gt := points.Search([]byte("ab"))
+		ta.Equal(point{3, 4}, eq)
+		ta.Equal(point{5, 6}, gt)
+	}
+
+	_, err := NewTrieG(keys, []string{"a"}, false)
+	ta.Equal(ErrKVLenNotMatch, err)
+
+	empty, err := NewTrieG[int](nil, nil, false)
+	ta.Nil(err)
+	ta.Equal(0, empty.KeyCnt())
+}
+
+func BenchmarkNewTrieG(b *testing.B) {
+
+	keys := make([][]byte, 1000)
+	values := make([]int, len(keys))
+	for i := range keys {
+		keys[i] = []byte(fmt.Sprintf("%06d", i))
+		values[i] = i
+	}
+
+	b.Run("NewTrie", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, _ = NewTrie(keys, values, true)
+		}
+	})
+
+	b.Run("NewTrieG", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, _ = NewTrieG(keys, values, true)
+		}
+	})
+}
@@ -0,0 +1,145 @@
+package trie
+
+import (
+	"sync"
+
+	"github.com/openacid/errors"
+)
+
+// ValueStore stores values outside of a trie, e.g., off-heap, on disk or in a
+// remote store. A trie created with WithValueStore holds only the handles
+// returned by Put as leaf values.
+//
+// Since 0.2.0
+type ValueStore interface {
+	// Put stores `v` and returns a handle to get it back.
+	//
+	// Since 0.2.0
+	Put(v interface{}) (handle interface{}, err error)
+
+	// Get returns the value of `handle`, or an error wrapping
+	// ErrValueNotFound if there is none.
+	//
+	// Since 0.2.0
+	Get(handle interface{}) (interface{}, error)
+}
+
+// WithValueStore makes Append and Set put values into `store`, and keep the
+// handles in leaves. Search returns handles; SearchValues or Resolve gets the
+// values.
+//
+// If an Append or Set fails after putting a value, the value is not removed
+// from `store`.
+//
+// Since 0.2.0
+func WithValueStore(store ValueStore) Option {
+	return func(o *options) {
+		o.valueStore = store
+	}
+}
+
+// Resolve returns the value of a handle found by Search in a trie created with
+// WithValueStore. Without a ValueStore, it returns `handle` as is.
+// A nil handle, i.e., no value found by Search, resolves to nil.
+//
+// If the value is a *Lazy, it is materialized.
+//
+// Since 0.2.0
+func (r *Node) Resolve(handle interface{}) (interface{}, error) {
+
+	v := handle
+	if v != nil && r.opt != nil && r.opt.valueStore != nil {
+		var err error
+		v, err = r.opt.valueStore.Get(handle)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if l, ok := v.(*Lazy); ok {
+		return l.Value()
+	}
+	return v, nil
+}
+
+// prepareValue converts a value to add into what a leaf holds: a thunk is
+// wrapped into a *Lazy with WithLazyValues, then it is put into the
+// ValueStore.
+func (r *Node) prepareValue(v interface{}) (interface{}, error) {
+
+	if r.opt == nil {
+		return v, nil
+	}
+
+	if r.opt.lazyValues {
+		if fn, ok := v.(func() (interface{}, error)); ok {
+			v = NewLazy(fn, r.opt.lazyCache)
+		}
+	}
+
+	if r.opt.valueStore != nil {
+		return r.opt.valueStore.Put(v)
+	}
+	return v, nil
+}
+
+// SearchValues is Search followed by Resolve of the three results.
+//
+// Since 0.2.0
+func (r *Node) SearchValues(key []byte) (ltValue, eqValue, gtValue interface{}, err error) {
+
+	lt, eq, gt := r.Search(key)
+
+	if ltValue, err = r.Resolve(lt); err != nil {
+		return
+	}
+	if eqValue, err = r.Resolve(eq); err != nil {
+		return
+	}
+	gtValue, err = r.Resolve(gt)
+	return
+}
+
+// MemValueStore is a ValueStore that keeps values in memory. Its handles are
+// int.
+// It is safe for concurrent use.
+//
+// Since 0.2.0
+type MemValueStore struct {
+	mu     sync.RWMutex
+	values []interface{}
+}
+
+// Put implements ValueStore.
+//
+// Since 0.2.0
+func (s *MemValueStore) Put(v interface{}) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.values = append(s.values, v)
+	return len(s.values) - 1, nil
+}
+
+// Get implements ValueStore.
+//
+// Since 0.2.0
+func (s *MemValueStore) Get(handle interface{}) (interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i, ok := handle.(int)
+	if !ok || i < 0 || i >= len(s.values) {
+		return nil, errors.Wrapf(ErrValueNotFound, "handle %v", handle)
+	}
+	return s.values[i], nil
+}
+
+// Len returns the number of values put.
+//
+// Since 0.2.0
+func (s *MemValueStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.values)
+}
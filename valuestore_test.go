@@ -0,0 +1,70 @@
+package trie
+
+import (
+	"testing"
+
+	"github.com/openacid/errors"
+	"github.com/stretchr/testify/require"
+)
+
+type failingStore struct{ MemValueStore }
+
+var errStoreFull = errors.New("store full")
+
+func (s *failingStore) Put(v interface{}) (interface{}, error) {
+	if s.Len() >= 2 {
+		return nil, errStoreFull
+	}
+	return s.MemValueStore.Put(v)
+}
+
+func TestWithValueStore(t *testing.T) {
+
+	ta := require.New(t)
+
+	store := &MemValueStore{}
+
+	keys := [][]byte{[]byte("a"), []byte("b"), []byte("c")}
+	tr, err := NewTrie(keys, []string{"A", "B", "C"}, true, WithValueStore(store))
+	ta.Nil(err)
+	ta.Equal(3, store.Len())
+
+	_, eq, _ := tr.Search([]byte("b"))
+	ta.Equal(1, eq, "leaves hold handles")
+
+	lt, eq, gt, err := tr.SearchValues([]byte("b"))
+	ta.Nil(err)
+	ta.Equal([]interface{}{"A", "B", "C"}, []interface{}{lt, eq, gt})
+
+	lt, eq, gt, err = tr.SearchValues([]byte("d"))
+	ta.Nil(err)
+	ta.Equal([]interface{}{"C", nil, nil}, []interface{}{lt, eq, gt})
+
+	_, err = tr.Append([]byte("d"), "D")
+	ta.Nil(err)
+	_, eq, _, err = tr.SearchValues([]byte("d"))
+	ta.Nil(err)
+	ta.Equal("D", eq)
+
+	_, err = tr.Resolve(100)
+	ta.Equal(ErrValueNotFound, errors.Cause(err))
+
+	plain := newStrTrie(ta, false, "x")
+	v, err := plain.Resolve("x")
+	ta.Nil(err)
+	ta.Equal("x", v)
+
+	// Errors of Put are returned.
+	_, err = NewTrie(keys, []string{"A", "B", "C"}, false, WithValueStore(&failingStore{}))
+	ta.Equal(errStoreFull, errors.Cause(err))
+
+	fs := &failingStore{}
+	tr, err = NewTrie(nil, nil, false, WithValueStore(fs))
+	ta.Nil(err)
+	_, err = tr.Set([]byte("a"), "A")
+	ta.Nil(err)
+	_, err = tr.Set([]byte("b"), "B")
+	ta.Nil(err)
+	_, err = tr.Set([]byte("c"), "C")
+	ta.Equal(errStoreFull, errors.Cause(err))
+}
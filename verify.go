@@ -0,0 +1,49 @@
+package trie
+
+import "bytes"
+
+// KeySource returns the whole key of a leaf, as normalized by
+// WithKeyNormalizer, e.g., read from where the keys are kept by the value of
+// the leaf. It is not called with a leaf removed by WithLazyRemove.
+//
+// Since 0.2.0
+type KeySource func(leaf *Node) []byte
+
+// SearchVerified is the same as Search except that it is exact on a squashed
+// trie, as if keys are stored WithStoreKeys, with keys of leaves from `src`
+// instead: a key not in the trie is not matched, and the neighbors of it are
+// the right ones. It calls `src` with one leaf of the subtree `key` leads to,
+// and again when it searches again for removed keys.
+//
+// Keys do not need to be kept in memory, only to be found by the leaf.
+//
+// Since 0.2.0
+func (r *Node) SearchVerified(key []byte, src KeySource) (ltValue, eqValue, gtValue interface{}) {
+	return r.search(r.normalize(key), src)
+}
+
+// GetVerified is the same as Get except that the key of the leaf found is
+// verified with `src`, and nothing is loaded by WithLoader.
+// It calls `src` at most once.
+//
+// Since 0.2.0
+func (r *Node) GetVerified(key []byte, src KeySource) (interface{}, bool) {
+
+	key = r.normalize(key)
+
+	if len(r.Branches) == 0 {
+		return nil, false
+	}
+
+	leaf := r.leafOf(key)
+	if leaf == nil || leaf.Value == removed {
+		return nil, false
+	}
+	if !bytes.Equal(src(leaf), key) {
+		return nil, false
+	}
+	if r.expired(leaf) {
+		return nil, false
+	}
+	return leaf.Value, true
+}
@@ -0,0 +1,102 @@
+package trie
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNode_SearchVerified(t *testing.T) {
+
+	ta := require.New(t)
+
+	for _, lazy := range []bool{false, true} {
+		for seed := int64(0); seed < 10; seed++ {
+
+			rnd := rand.New(rand.NewSource(seed))
+			randKey := func() string {
+				k := make([]byte, rnd.Intn(6))
+				for i := range k {
+					k[i] = "abc"[rnd.Intn(3)]
+				}
+				return string(k)
+			}
+
+			uniq := map[string]bool{}
+			for i := 0; i < 12; i++ {
+				uniq[randKey()] = true
+			}
+			keys := []string{}
+			for k := range uniq {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+
+			// Keys are kept out of the trie; values are their indexes.
+			store := make([][]byte, len(keys))
+			values := make([]int, len(keys))
+			index := map[string]int{}
+			for i, k := range keys {
+				store[i] = []byte(k)
+				values[i] = i
+				index[k] = i
+			}
+			calls := 0
+			src := func(leaf *Node) []byte {
+				calls++
+				return store[leaf.Value.(int)]
+			}
+
+			var opts []Option
+			if lazy {
+				opts = append(opts, WithLazyRemove())
+			}
+			tr, err := NewTrie(store, values, true, opts...)
+			ta.Nil(err)
+
+			msg := fmt.Sprintf("lazy=%v seed=%d keys=%q", lazy, seed, keys)
+
+			check := func(step string) {
+				for i := 0; i < 100; i++ {
+					q := randKey()
+
+					want := bruteSearch(keys, q)
+					for j, v := range want {
+						if v != nil {
+							want[j] = index[v.(string)]
+						}
+					}
+
+					calls = 0
+					lt, eq, gt := tr.SearchVerified([]byte(q), src)
+					ta.Equal(want, []interface{}{lt, eq, gt}, "%s %s: search %q", msg, step, q)
+					if !lazy {
+						ta.True(calls <= 1, "%s %s: %d calls", msg, step, calls)
+					}
+
+					_, found := tr.GetVerified([]byte(q), src)
+					ta.Equal(uniq[q], found, "%s %s: get %q", msg, step, q)
+				}
+			}
+			check("new")
+
+			if !lazy {
+				continue
+			}
+
+			for i := 0; i < 5; i++ {
+				q := randKey()
+				if uniq[q] {
+					ta.True(tr.Remove([]byte(q)))
+					j := sort.SearchStrings(keys, q)
+					keys = append(keys[:j], keys[j+1:]...)
+					delete(uniq, q)
+				}
+			}
+			check("remove")
+		}
+	}
+}
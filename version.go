@@ -0,0 +1,124 @@
+package trie
+
+import (
+	"fmt"
+
+	"github.com/openacid/errors"
+)
+
+// VersionConflictError is returned by SetIfVersion if the version of a key
+// is not the expected one.
+// Its Cause is ErrVersionConflict.
+//
+// Since 0.2.0
+type VersionConflictError struct {
+	Key      []byte
+	Expected uint64
+	Actual   uint64
+}
+
+// Error implements error.
+//
+// Since 0.2.0
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("%s: key %q: expected %d, actual %d",
+		ErrVersionConflict, e.Key, e.Expected, e.Actual)
+}
+
+// Cause returns ErrVersionConflict.
+//
+// Since 0.2.0
+func (e *VersionConflictError) Cause() error {
+	return ErrVersionConflict
+}
+
+// KeyVersion returns the version of `key`, and false if it is not in the
+// trie.
+//
+// Every change of the value of a key, by Append, Set or the methods built on
+// them, gives it a new version greater than any version in the trie before.
+// A key removed and added again does not get back an old version.
+//
+// Since 0.2.0
+func (r *Node) KeyVersion(key []byte) (version uint64, found bool) {
+
+	leaf := r.liveLeaf(r.normalize(key))
+	if leaf == nil {
+		return 0, false
+	}
+	return leaf.version, true
+}
+
+// SetIfVersion is the same as Set, if the version of `key` is
+// `expectedVersion`: 0 for a key not in the trie.
+// Otherwise it returns a *VersionConflictError.
+//
+// Since 0.2.0
+func (r *Node) SetIfVersion(key []byte, value interface{}, expectedVersion uint64) (leaf *Node, err error) {
+
+	if err = r.checkVersion(key, expectedVersion); err != nil {
+		return
+	}
+	return r.Set(key, value)
+}
+
+// checkVersion returns a *VersionConflictError if the version of `key` is
+// not `expected`.
+func (r *Node) checkVersion(key []byte, expected uint64) error {
+
+	actual, _ := r.KeyVersion(key)
+	if actual != expected {
+		return &VersionConflictError{Key: copyBytes(key), Expected: expected, Actual: actual}
+	}
+	return nil
+}
+
+// liveLeaf returns the leaf of normalized `key`, or nil if `key` is not in
+// the trie, removed or expired.
+func (r *Node) liveLeaf(key []byte) *Node {
+
+	leaf := r.leafOf(key)
+	if leaf == nil || leaf.Value == removed || r.expired(leaf) {
+		return nil
+	}
+	return leaf
+}
+
+// newVersion gives `leaf` a new version.
+func (r *Node) newVersion(leaf *Node) {
+	if r.opt == nil {
+		return
+	}
+	r.opt.lastVersion++
+	leaf.version = r.opt.lastVersion
+}
+
+// KeyVersion is the same as Node.KeyVersion on the current version.
+//
+// Since 0.2.0
+func (s *SyncTrie) KeyVersion(key []byte) (version uint64, found bool) {
+	return s.Load().KeyVersion(key)
+}
+
+// SetIfVersion is the same as Node.SetIfVersion on a new version.
+// The version check and the Set are atomic to other writers.
+//
+// Since 0.2.0
+func (s *SyncTrie) SetIfVersion(key []byte, value interface{}, expectedVersion uint64) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old := s.Load()
+	if err := old.checkVersion(key, expectedVersion); err != nil {
+		return err
+	}
+
+	root := old.copyKeyPath(old.normalize(key))
+	if _, err := root.Set(key, value); err != nil {
+		return errors.Wrapf(err, "set if version")
+	}
+
+	s.root.Store(root)
+	return nil
+}
@@ -0,0 +1,95 @@
+package trie
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/openacid/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNode_SetIfVersion(t *testing.T) {
+
+	ta := require.New(t)
+
+	tr, err := NewTrie([][]byte{[]byte("a"), []byte("b")}, []int{1, 2}, false, WithLazyRemove())
+	ta.Nil(err)
+
+	va, found := tr.KeyVersion([]byte("a"))
+	ta.True(found)
+	vb, found := tr.KeyVersion([]byte("b"))
+	ta.True(found)
+	ta.True(vb > va)
+
+	_, found = tr.KeyVersion([]byte("c"))
+	ta.False(found)
+
+	// Mismatch.
+	_, err = tr.SetIfVersion([]byte("a"), 10, vb)
+	ta.Equal(ErrVersionConflict, errors.Cause(err))
+	ce, ok := err.(*VersionConflictError)
+	ta.True(ok)
+	ta.Equal(&VersionConflictError{Key: []byte("a"), Expected: vb, Actual: va}, ce)
+
+	_, eq, _ := tr.Search([]byte("a"))
+	ta.Equal(1, eq)
+
+	// Match.
+	_, err = tr.SetIfVersion([]byte("a"), 10, va)
+	ta.Nil(err)
+	v, _ := tr.KeyVersion([]byte("a"))
+	ta.True(v > vb)
+	_, eq, _ = tr.Search([]byte("a"))
+	ta.Equal(10, eq)
+
+	// 0 is the version of an absent key.
+	_, err = tr.SetIfVersion([]byte("c"), 3, 1)
+	ta.Equal(ErrVersionConflict, errors.Cause(err))
+	_, err = tr.SetIfVersion([]byte("c"), 3, 0)
+	ta.Nil(err)
+
+	// A key added again gets a new version.
+	vc, _ := tr.KeyVersion([]byte("c"))
+	ta.True(tr.Remove([]byte("c")))
+	_, found = tr.KeyVersion([]byte("c"))
+	ta.False(found)
+	_, err = tr.Append([]byte("c"), 4)
+	ta.Nil(err)
+	v, _ = tr.KeyVersion([]byte("c"))
+	ta.True(v > vc)
+}
+
+func TestSyncTrie_SetIfVersion(t *testing.T) {
+
+	ta := require.New(t)
+
+	st, err := NewSyncTrie([][]byte{[]byte("n")}, []int{0}, false)
+	ta.Nil(err)
+
+	// Concurrent increments with retries do not lose updates.
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; {
+				root := st.Load()
+				ver, _ := root.KeyVersion([]byte("n"))
+				_, eq, _ := root.Search([]byte("n"))
+				err := st.SetIfVersion([]byte("n"), eq.(int)+1, ver)
+				if err == nil {
+					j++
+					continue
+				}
+				if errors.Cause(err) != ErrVersionConflict {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	_, eq, _ := st.Search([]byte("n"))
+	ta.Equal(400, eq)
+}
@@ -0,0 +1,126 @@
+package trie
+
+import (
+	"bytes"
+	"sort"
+)
+
+// Warmer is implemented by a ValueStore that keeps values in a cache, e.g.,
+// of values on disk. Warm fills the cache with it.
+//
+// Since 0.2.0
+type Warmer interface {
+	// Warm reads the value of `handle` into the cache, if it is not there.
+	//
+	// Since 0.2.0
+	Warm(handle interface{}) error
+}
+
+// Warm loads the values of all keys starting with any of `prefixes`, e.g.,
+// the hot ranges of a trie before it serves requests, so that the first
+// requests do not wait for cold reads. It returns the number of values
+// loaded and kept, and stops at the first error.
+//
+// A value of a ValueStore implementing Warmer is loaded with Warm, and a
+// *Lazy created with `cache` is materialized and keeps its value. Other
+// values are not read, and not counted: a value in memory, a value of a
+// ValueStore without a cache, e.g., MemValueStore, and a *Lazy without
+// `cache`, which would not keep the value.
+//
+// Prefixes are matched the same way as WithStripPrefix. An empty prefix
+// matches all keys, and a prefix covered by another one is skipped.
+//
+// Since 0.2.0
+func (r *Node) Warm(prefixes [][]byte) (int, error) {
+
+	var store ValueStore
+	if r.opt != nil {
+		store = r.opt.valueStore
+	}
+	warmer, _ := store.(Warmer)
+
+	loaded := 0
+	for _, p := range uncovered(prefixes) {
+
+		it := r.NewIter(WithStripPrefix(p))
+		for it.Next() {
+			v := it.Value()
+			if v == nil {
+				continue
+			}
+
+			if store != nil {
+				if warmer == nil {
+					continue
+				}
+				if err := warmer.Warm(v); err != nil {
+					return loaded, err
+				}
+				loaded++
+				continue
+			}
+
+			l, ok := v.(*Lazy)
+			if !ok || !l.cache {
+				continue
+			}
+			if _, err := l.Value(); err != nil {
+				return loaded, err
+			}
+			loaded++
+		}
+	}
+	return loaded, nil
+}
+
+// Warm reads every page of the nodes and values of all keys starting with
+// any of `prefixes` in the data of f, e.g., a memory-mapped file before it
+// serves requests, so that the first requests do not wait for disk reads.
+// The pages are kept as long as the system does not need the memory.
+// It returns the number of values read, and stops at the first malformed
+// node, with a *FixedLayoutError.
+//
+// Prefixes are matched the same way as Node.Warm.
+//
+// Since 0.2.0
+func (f *FixedReader) Warm(prefixes [][]byte) (int, error) {
+
+	loaded := 0
+	for _, p := range uncovered(prefixes) {
+		off, ok, err := f.prefixNode(p)
+		if err != nil {
+			return loaded, err
+		}
+		if !ok {
+			continue
+		}
+
+		err = f.walk(off, nil, func(key, value []byte) bool {
+			loaded++
+			return true
+		})
+		if err != nil {
+			return loaded, err
+		}
+	}
+	return loaded, nil
+}
+
+// uncovered returns `prefixes` sorted, without those covered by another one.
+func uncovered(prefixes [][]byte) [][]byte {
+
+	ps := make([][]byte, len(prefixes))
+	copy(ps, prefixes)
+	sort.Slice(ps, func(i, j int) bool {
+		return bytes.Compare(ps[i], ps[j]) < 0
+	})
+
+	rst := ps[:0]
+	for _, p := range ps {
+		if len(rst) > 0 && bytes.HasPrefix(p, rst[len(rst)-1]) {
+			continue
+		}
+		rst = append(rst, p)
+	}
+	return rst
+}
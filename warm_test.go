@@ -0,0 +1,166 @@
+package trie
+
+import (
+	"bytes"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/openacid/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// cachingStore is a countingStore with a cache, which Warm fills with Get.
+type cachingStore struct {
+	countingStore
+}
+
+func (s *cachingStore) Warm(handle interface{}) error {
+	_, err := s.Get(handle)
+	return err
+}
+
+func TestNode_Warm(t *testing.T) {
+
+	ta := require.New(t)
+
+	store := &cachingStore{}
+	tr := prefetchTestTrie(ta, 100, store)
+	atomic.StoreInt32(&store.gets, 0)
+
+	n, err := tr.Warm([][]byte{[]byte("001"), []byte("0015"), []byte("009"), []byte("01")})
+	ta.Nil(err)
+	ta.Equal(20, n, "0015 is covered by 001, 01 matches nothing")
+	ta.Equal(int32(20), atomic.LoadInt32(&store.gets))
+
+	n, err = tr.Warm([][]byte{nil})
+	ta.Nil(err)
+	ta.Equal(100, n)
+
+	n, err = tr.Warm(nil)
+	ta.Nil(err)
+	ta.Equal(0, n)
+
+	store.fail = 15
+	n, err = tr.Warm([][]byte{[]byte("001")})
+	ta.Equal(ErrValueNotFound, errors.Cause(err))
+	ta.Equal(5, n)
+
+	// Nothing is kept without a cache, or of values in memory.
+	noCache := &countingStore{}
+	tr = prefetchTestTrie(ta, 100, noCache)
+	atomic.StoreInt32(&noCache.gets, 0)
+	n, err = tr.Warm([][]byte{nil})
+	ta.Nil(err)
+	ta.Equal(0, n)
+	ta.Equal(int32(0), atomic.LoadInt32(&noCache.gets))
+
+	tr = newStrTrie(ta, false, "a", "b")
+	n, err = tr.Warm([][]byte{nil})
+	ta.Nil(err)
+	ta.Equal(0, n)
+}
+
+func TestFixedReader_Warm(t *testing.T) {
+
+	ta := require.New(t)
+
+	keys := [][]byte{}
+	values := []string{}
+	for i := 0; i < 100; i++ {
+		keys = append(keys, []byte(fmt.Sprintf("%04d", i)))
+		values = append(values, fmt.Sprintf("v%d", i))
+	}
+
+	for _, squash := range []bool{false, true} {
+		tr, err := NewTrie(keys, values, squash)
+		ta.Nil(err)
+		var buf bytes.Buffer
+		ta.Nil(tr.ExportFixed(&buf, strEnc))
+		fr, err := NewFixedReader(buf.Bytes())
+		ta.Nil(err)
+
+		prefixes := [][]byte{[]byte("001"), []byte("0015"), []byte("009")}
+		if !squash {
+			// Bytes removed by squashing are not checked.
+			prefixes = append(prefixes, []byte("01"))
+		}
+		n, err := fr.Warm(prefixes)
+		ta.Nil(err)
+		ta.Equal(20, n, "squash=%v", squash)
+
+		n, err = fr.Warm([][]byte{nil})
+		ta.Nil(err)
+		ta.Equal(100, n)
+
+		n, err = fr.Warm(nil)
+		ta.Nil(err)
+		ta.Equal(0, n)
+	}
+
+	// The root at 16 has branches "a" to 34 and "b" to 42. Values are at 50
+	// and 55.
+	tr, err := NewTrie([][]byte{[]byte("a"), []byte("b")}, []string{"A", "B"}, false)
+	ta.Nil(err)
+	var buf bytes.Buffer
+	ta.Nil(tr.ExportFixed(&buf, strEnc))
+	data := buf.Bytes()
+
+	fr, err := NewFixedReader(data[:len(data)-1])
+	ta.Nil(err)
+	n, err := fr.Warm([][]byte{nil})
+	ta.Equal(ErrInvalidFixedLayout, errors.Cause(err))
+	ta.Equal(1, n)
+
+	n, err = fr.Warm([][]byte{[]byte("a")})
+	ta.Nil(err)
+	ta.Equal(1, n)
+}
+
+func TestNode_Warm_lazy(t *testing.T) {
+
+	ta := require.New(t)
+
+	for _, cache := range []bool{false, true} {
+
+		var calls int32
+		keys := [][]byte{}
+		values := []interface{}{}
+		for i := 0; i < 10; i++ {
+			i := i
+			keys = append(keys, []byte(fmt.Sprintf("k%d", i)))
+			values = append(values, func() (interface{}, error) {
+				atomic.AddInt32(&calls, 1)
+				if i == 7 {
+					return nil, errors.New("load failure")
+				}
+				return i, nil
+			})
+		}
+
+		tr, err := NewTrie(keys, values, false, WithLazyValues(cache))
+		ta.Nil(err)
+
+		n, err := tr.Warm([][]byte{[]byte("k")})
+		if !cache {
+			ta.Nil(err)
+			ta.Equal(0, n)
+			ta.Equal(int32(0), calls, "not cached, not called")
+			continue
+		}
+
+		ta.Equal("load failure", errors.Cause(err).Error())
+		ta.Equal(7, n)
+
+		n, err = tr.Warm([][]byte{[]byte("k0"), []byte("k1")})
+		ta.Nil(err)
+		ta.Equal(2, n)
+
+		// Warmed values are kept.
+		calls = 0
+		_, eq, _, err := tr.SearchValues([]byte("k1"))
+		ta.Nil(err)
+		ta.Equal(1, eq)
+		ta.Equal(int32(0), calls)
+	}
+}